@@ -2,16 +2,141 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
+	"github.com/abhinandanwadwa/overbookr/internal/api/handlers"
 	"github.com/abhinandanwadwa/overbookr/internal/api/server"
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
+	"github.com/abhinandanwadwa/overbookr/internal/auth"
+	"github.com/abhinandanwadwa/overbookr/internal/payments"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
+	"github.com/abhinandanwadwa/overbookr/internal/tracing"
 	"github.com/abhinandanwadwa/overbookr/internal/workers"
+	"github.com/abhinandanwadwa/overbookr/migrations"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
 
+const (
+	defaultHoldExpiryInterval   = 30 * time.Second
+	defaultReconcileInterval    = 1 * time.Hour
+	defaultReminderInterval     = 15 * time.Minute
+	defaultReminderLeadTime     = 24 * time.Hour
+	defaultPaymentSweepInterval = 1 * time.Minute
+	// Bookings for paid events are abandoned if not paid for within this window.
+	defaultPaymentWindow = 15 * time.Minute
+	// Expired and converted holds are pruned this long after they expire.
+	defaultHoldRetention = 7 * 24 * time.Hour
+	// Login failure counters are pruned once their window has passed.
+	defaultLoginFailureWindow = 15 * time.Minute
+
+	// minWorkerInterval keeps a misconfigured ticker from hammering the DB.
+	minWorkerInterval = 1 * time.Second
+)
+
+// durationFromEnv reads a Go duration string (e.g. "30s", "5m") from key,
+// falling back to def when unset.
+func durationFromEnv(key string, def time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	if d < minWorkerInterval {
+		return 0, fmt.Errorf("%s: %s is below the minimum of %s", key, d, minWorkerInterval)
+	}
+	return d, nil
+}
+
+// retentionFromEnv reads a Go duration from key, falling back to def when
+// unset. "0" disables pruning.
+func retentionFromEnv(key string, def time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s: %s is negative", key, d)
+	}
+	return d, nil
+}
+
+// Pool defaults; pgx's own default MaxConns (max(4, NumCPU)) is too small for
+// the retry-heavy booking path.
+const (
+	defaultDBMaxConns        = 20
+	defaultDBMinConns        = 2
+	defaultDBMaxConnLifetime = 1 * time.Hour
+	defaultDBMaxConnIdleTime = 30 * time.Minute
+)
+
+// int32FromEnv reads a non-negative integer from key, falling back to def when unset.
+func int32FromEnv(key string, def int32) (int32, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s: %q is not a non-negative integer", key, raw)
+	}
+	return int32(n), nil
+}
+
+// boolFromEnv reads a boolean ("true", "false", "1", "0", ...) from key; unset
+// means false.
+func boolFromEnv(key string) (bool, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: want true or false", key, raw)
+	}
+	return v, nil
+}
+
+// poolConfigFromEnv builds the pgxpool config from the connection string and
+// DB_MAX_CONNS, DB_MIN_CONNS, DB_MAX_CONN_LIFETIME and DB_MAX_CONN_IDLE_TIME.
+func poolConfigFromEnv(uri string) (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(uri)
+	if err != nil {
+		return nil, fmt.Errorf("POSTGRESQL_URI: %w", err)
+	}
+
+	if poolCfg.MaxConns, err = int32FromEnv("DB_MAX_CONNS", defaultDBMaxConns); err != nil {
+		return nil, err
+	}
+	if poolCfg.MinConns, err = int32FromEnv("DB_MIN_CONNS", defaultDBMinConns); err != nil {
+		return nil, err
+	}
+	if poolCfg.MaxConns < 1 {
+		return nil, fmt.Errorf("DB_MAX_CONNS must be at least 1")
+	}
+	if poolCfg.MinConns > poolCfg.MaxConns {
+		return nil, fmt.Errorf("DB_MIN_CONNS (%d) exceeds DB_MAX_CONNS (%d)", poolCfg.MinConns, poolCfg.MaxConns)
+	}
+	if poolCfg.MaxConnLifetime, err = durationFromEnv("DB_MAX_CONN_LIFETIME", defaultDBMaxConnLifetime); err != nil {
+		return nil, err
+	}
+	if poolCfg.MaxConnIdleTime, err = durationFromEnv("DB_MAX_CONN_IDLE_TIME", defaultDBMaxConnIdleTime); err != nil {
+		return nil, err
+	}
+	return poolCfg, nil
+}
+
 func main() {
 	// Load context and envs
 	ctx, cancel := context.WithCancel(context.Background())
@@ -27,26 +152,151 @@ func main() {
 		log.Fatal("POSTGRESQL_URI is required")
 	}
 
+	// `server migrate ...` only touches the schema and exits.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(ctx, DB_URI, os.Args[2:]))
+	}
+
 	cfg := server.Config{
 		DB_URI: DB_URI,
 		PORT:   PORT,
 	}
 
+	holdExpiryInterval, err := durationFromEnv("HOLD_EXPIRY_INTERVAL", defaultHoldExpiryInterval)
+	if err != nil {
+		log.Fatalf("invalid worker config: %v", err)
+	}
+	reconcileInterval, err := durationFromEnv("RECONCILE_INTERVAL", defaultReconcileInterval)
+	if err != nil {
+		log.Fatalf("invalid worker config: %v", err)
+	}
+	reminderInterval, err := durationFromEnv("REMINDER_INTERVAL", defaultReminderInterval)
+	if err != nil {
+		log.Fatalf("invalid worker config: %v", err)
+	}
+	reminderLeadTime, err := durationFromEnv("REMINDER_LEAD_TIME", defaultReminderLeadTime)
+	if err != nil {
+		log.Fatalf("invalid worker config: %v", err)
+	}
+	holdRetention, err := retentionFromEnv("HOLD_RETENTION", defaultHoldRetention)
+	if err != nil {
+		log.Fatalf("invalid worker config: %v", err)
+	}
+	loginFailureWindow, err := durationFromEnv("LOGIN_FAILURE_WINDOW", defaultLoginFailureWindow)
+	if err != nil {
+		log.Fatalf("invalid worker config: %v", err)
+	}
+	paymentSweepInterval, err := durationFromEnv("PAYMENT_SWEEP_INTERVAL", defaultPaymentSweepInterval)
+	if err != nil {
+		log.Fatalf("invalid worker config: %v", err)
+	}
+	paymentWindow, err := durationFromEnv("PAYMENT_WINDOW", defaultPaymentWindow)
+	if err != nil {
+		log.Fatalf("invalid worker config: %v", err)
+	}
+	log.Printf("worker intervals: hold_expiry=%s reconcile=%s reminders=%s (lead time %s) payment_sweep=%s (window %s), hold retention %s",
+		holdExpiryInterval, reconcileInterval, reminderInterval, reminderLeadTime, paymentSweepInterval, paymentWindow, holdRetention)
+
+	if _, err := auth.LoadConfig(); err != nil {
+		log.Fatalf("invalid auth config: %v", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		log.Fatalf("invalid tracing config: %v", err)
+	}
+	defer func() {
+		sctx, scancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer scancel()
+		if err := shutdownTracing(sctx); err != nil {
+			log.Printf("tracing shutdown: %v", err)
+		}
+	}()
+	log.Printf("tracing: otlp export enabled=%t", tracing.Enabled())
+
+	mailer, err := mail.NewMailerFromEnv()
+	if err != nil {
+		log.Fatalf("invalid mail config: %v", err)
+	}
+	smsSender, err := mail.NewSMSSenderFromEnv()
+	if err != nil {
+		log.Fatalf("invalid sms config: %v", err)
+	}
+	paymentProvider, err := payments.NewProviderFromEnv()
+	if err != nil {
+		log.Fatalf("invalid payment config: %v", err)
+	}
+
+	poolCfg, err := poolConfigFromEnv(cfg.DB_URI)
+	if err != nil {
+		log.Fatalf("invalid database pool config: %v", err)
+	}
+	migrateOnStart, err := boolFromEnv("MIGRATE_ON_START")
+	if err != nil {
+		log.Fatalf("invalid migration config: %v", err)
+	}
+	if tracing.Enabled() {
+		poolCfg.ConnConfig.Tracer = tracing.PgxTracer{}
+	}
+	log.Printf("db pool: max_conns=%d min_conns=%d max_conn_lifetime=%s max_conn_idle_time=%s",
+		poolCfg.MaxConns, poolCfg.MinConns, poolCfg.MaxConnLifetime, poolCfg.MaxConnIdleTime)
+
 	// Create a connection pool for workers
-	pool, err := pgxpool.New(ctx, cfg.DB_URI)
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		log.Fatalf("unable to create pgx pool: %v", err)
 	}
 	defer pool.Close()
 
+	// MIGRATE_ON_START=true brings the schema up to date before anything
+	// touches it.
+	if migrateOnStart {
+		from, to, err := migrations.Up(pool)
+		if err != nil {
+			log.Fatalf("migrations failed: %v", err)
+		}
+		logMigrated(from, to)
+	}
+
+	// Seats stored in another form than SEAT_NO_NORMALIZATION produces
+	// can't be found by number; say so, but keep serving everything else.
+	if err := handlers.CheckSeatNumbers(ctx, pool); err != nil {
+		log.Printf("seat data: %v", err)
+	}
+
+	// Mail queue is created up front so workers can queue notices; it is
+	// started further down.
+	mailQueue := mail.NewQueue(pool, mailer, smsSender, mail.DefaultQueueOptions())
+
+	// Seat changes from handlers and workers fan out to SSE subscribers.
+	seatHub := realtime.NewHub()
+
 	// --- Workers setup ---
 	// Create worker instances bound to the same DB connection
-	holdExpiryWorker := workers.NewHoldExpiryWorker(pool)
-	reconcileWorker := workers.NewReconcileWorker(pool)
+	holdExpiryWorker := workers.NewHoldExpiryWorker(pool, mailQueue, seatHub)
+	reconcileWorker := workers.NewReconcileWorker(pool, holdRetention, loginFailureWindow)
+	reminderWorker := workers.NewReminderWorker(pool, mailQueue, reminderLeadTime)
+	paymentSweepWorker := workers.NewPaymentSweepWorker(pool, mailQueue, seatHub, paymentProvider, paymentWindow)
 
-	// 1) Start hold expiry loop (every 30s)
+	// --- Server setup ---
+	// Built before any loop starts, so an invalid setting stops startup cleanly.
+	srv, err := server.NewServer(cfg, server.AppDeps{
+		DB:                 pool,
+		Mailer:             mailer,
+		MailQueue:          mailQueue,
+		SeatHub:            seatHub,
+		HoldRetention:      holdRetention,
+		LoginFailureWindow: loginFailureWindow,
+		PaymentWindow:      paymentWindow,
+		Payments:           paymentProvider,
+	})
+	if err != nil {
+		log.Fatalf("invalid server config: %v", err)
+	}
+
+	// 1) Start hold expiry loop (default every 30s)
 	go func() {
-		ticker := time.NewTicker(30 * time.Second)
+		ticker := time.NewTicker(holdExpiryInterval)
 		defer ticker.Stop()
 		for {
 			select {
@@ -61,25 +311,79 @@ func main() {
 		}
 	}()
 
-	// 2) Start reconcile loop (every 1 hour)
+	// 2) Start reconcile loop (default every 1 hour)
 	go func() {
-		ticker := time.NewTicker(1 * time.Hour)
+		ticker := time.NewTicker(reconcileInterval)
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				if err := reconcileWorker.Reconcile(context.Background()); err != nil {
+				summary, err := reconcileWorker.Reconcile(context.Background())
+				if err != nil {
 					log.Printf("reconcile worker error: %v\n", err)
+					continue
+				}
+				if !summary.Skipped {
+					log.Printf("reconcile: events_fixed=%d seats_fixed=%d login_attempts_pruned=%d errors=%d", summary.EventsFixed, summary.SeatsFixed, summary.LoginAttemptsPruned, len(summary.Errors))
+				}
+			}
+		}
+	}()
+
+	// 3) Start event reminder loop (default every 15m)
+	go func() {
+		ticker := time.NewTicker(reminderInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sent, err := reminderWorker.SendReminders(ctx)
+				if err != nil {
+					log.Printf("reminder worker error: %v\n", err)
+				}
+				if sent > 0 {
+					log.Printf("reminders: queued=%d", sent)
 				}
 			}
 		}
 	}()
 
+	// 4) Start the unpaid booking sweep (default every 1m)
+	go func() {
+		ticker := time.NewTicker(paymentSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				abandoned, err := paymentSweepWorker.AbandonUnpaid(ctx)
+				if err != nil {
+					log.Printf("payment sweep worker error: %v\n", err)
+				}
+				if abandoned > 0 {
+					log.Printf("payment sweep: abandoned=%d", abandoned)
+				}
+			}
+		}
+	}()
+
+	// 5) Start the mail queue (confirmation emails, retries, outbox replay)
+	mailQueue.Start(ctx)
+
 	// --- Server start ---
-	srv := server.NewServer(cfg, pool)
-	if err := srv.Start(); err != nil {
+	err = srv.Start()
+
+	// Stop the workers and let the mail queue park unsent mail in the outbox
+	// before the pool goes away.
+	cancel()
+	mailQueue.Stop()
+
+	if err != nil {
 		log.Printf("server exited: %v", err)
 		os.Exit(1)
 	}
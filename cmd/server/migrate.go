@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/abhinandanwadwa/overbookr/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const migrateUsage = `usage: server migrate [command]
+
+commands:
+  up         apply all pending migrations (default)
+  version    print the applied schema version
+  force N    mark version N as applied and clear the dirty flag`
+
+// runMigrate implements the migrate subcommand and returns the exit code.
+func runMigrate(ctx context.Context, dbURI string, args []string) int {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	pool, err := pgxpool.New(ctx, dbURI)
+	if err != nil {
+		log.Printf("unable to create pgx pool: %v", err)
+		return 1
+	}
+	defer pool.Close()
+
+	switch cmd {
+	case "up":
+		from, to, err := migrations.Up(pool)
+		if err != nil {
+			log.Printf("migrate up: %v", err)
+			return 1
+		}
+		logMigrated(from, to)
+	case "version":
+		v, dirty, err := migrations.Version(pool)
+		if err != nil {
+			log.Printf("migrate version: %v", err)
+			return 1
+		}
+		fmt.Printf("version=%d dirty=%t\n", v, dirty)
+	case "force":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		v, err := strconv.Atoi(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid version %q\n", args[1])
+			return 2
+		}
+		if err := migrations.Force(pool, v); err != nil {
+			log.Printf("migrate force: %v", err)
+			return 1
+		}
+		log.Printf("migrations: forced version %d", v)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
+
+func logMigrated(from, to uint) {
+	if from == to {
+		log.Printf("migrations: schema up to date at version %d", to)
+		return
+	}
+	log.Printf("migrations: applied %d -> %d", from, to)
+}
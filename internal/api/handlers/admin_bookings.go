@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/api/middleware"
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type AdminCreateBookingRequest struct {
+	UserID  string   `json:"user_id" binding:"required,uuid"`
+	EventID string   `json:"event_id" binding:"required,uuid"`
+	SeatNos []string `json:"seat_nos" binding:"required,min=1,dive,required"`
+}
+
+// lockAvailableSeats locks the event's seats named in seatNos FOR UPDATE, in
+// id order as CreateHold does, and returns their ids. If any seat is missing
+// or not available it returns the response to send instead (404 listing the
+// missing seat numbers, or 409 listing the unavailable ones). A non-nil err is
+// a query failure, which may be retryable.
+func lockAvailableSeats(ctx context.Context, q *db.Queries, eventParam pgtype.UUID, seatNos []string) ([]pgtype.UUID, int, *APIError, error) {
+	seats, err := q.GetSeatsForEventForUpdate(ctx, db.GetSeatsForEventForUpdateParams{EventID: eventParam, Column2: seatNos})
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	if len(seats) != len(seatNos) {
+		found := make(map[string]struct{}, len(seats))
+		for _, s := range seats {
+			found[s.SeatNo] = struct{}{}
+		}
+		missing := make([]string, 0)
+		for _, s := range seatNos {
+			if _, ok := found[s]; !ok {
+				missing = append(missing, s)
+			}
+		}
+		body := apiError(CodeSeatNotFound, "some seats not found for this event", nil).with("missing", missing)
+		return nil, http.StatusNotFound, &body, nil
+	}
+
+	seatIDs := make([]pgtype.UUID, 0, len(seats))
+	unavailable := make([]UnavailableSeat, 0)
+	for _, s := range seats {
+		if s.Status != "available" {
+			unavailable = append(unavailable, UnavailableSeat{SeatNo: s.SeatNo, SeatID: s.ID.String(), Status: s.Status})
+			continue
+		}
+		seatIDs = append(seatIDs, s.ID)
+	}
+	if len(unavailable) > 0 {
+		// seat_no and status name the first one, for clients that predate the list.
+		body := apiError(CodeSeatUnavailable, "seat not available", nil).
+			with("seat_no", unavailable[0].SeatNo).
+			with("status", unavailable[0].Status).
+			with("unavailable_seats", unavailable)
+		return nil, http.StatusConflict, &body, nil
+	}
+	return seatIDs, 0, nil, nil
+}
+
+// POST /admin/bookings
+// Books seats for a customer in one transaction, skipping the client-side
+// hold step. Seats must be available; capacity is enforced as in CreateBooking.
+func (h *BookingsHandler) AdminCreateBooking(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req AdminCreateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request", err.Error())
+		return
+	}
+
+	userParam := pgtype.UUID{Bytes: uuid.MustParse(req.UserID), Valid: true}
+	eventParam := pgtype.UUID{Bytes: uuid.MustParse(req.EventID), Valid: true}
+
+	if _, err := h.db.GetUserByID(ctx, userParam); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, CodeUserNotFound, "user not found", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch user", err.Error())
+		return
+	}
+
+	seatNos := h.seatNoFormat.unique(req.SeatNos)
+
+	// Admin bookings don't come with a client key; generate one so the row
+	// looks like any other booking.
+	idempotencyParam := pgtype.Text{String: "admin:" + uuid.NewString(), Valid: true}
+
+	retrier := h.retryPolicy.start()
+	retry := func() bool {
+		if err := retrier.wait(ctx); err != nil {
+			c.JSON(retryFailedResponse(err))
+			return false
+		}
+		return true
+	}
+	for attempt := 0; attempt < h.retryPolicy.MaxAttempts; attempt++ {
+		tx, err := h.DB.Begin(ctx)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, CodeInternal, "failed to start transaction", err.Error())
+			return
+		}
+		q := db.New(tx)
+
+		seatIDs, status, body, err := lockAvailableSeats(ctx, q, eventParam, seatNos)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			if isSerializationFailure(err) {
+				if retry() {
+					continue
+				}
+				return
+			}
+			writeError(c, http.StatusInternalServerError, CodeInternal, "failed to query seats", err.Error())
+			return
+		}
+		if body != nil {
+			_ = tx.Rollback(ctx)
+			c.JSON(status, *body)
+			return
+		}
+		if err := recordSeatChanges(c, q, seatReasonAdminBooking); err != nil {
+			_ = tx.Rollback(ctx)
+			writeError(c, http.StatusInternalServerError, CodeInternal, "failed to record seat history", err.Error())
+			return
+		}
+
+		bookingRow, err := bookSeats(ctx, q, db.InsertBookingParams{
+			EventID:        eventParam,
+			UserID:         userParam,
+			Seats:          int32(len(seatIDs)),
+			SeatIds:        seatIDs,
+			Status:         "active",
+			IdempotencyKey: idempotencyParam,
+		})
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			if isSerializationFailure(err) {
+				if retry() {
+					continue
+				}
+				return
+			}
+			status, body := bookSeatsErrorResponse(err)
+			c.JSON(status, body)
+			return
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			_ = tx.Rollback(ctx)
+			if isSerializationFailure(err) {
+				if retry() {
+					continue
+				}
+				return
+			}
+			writeError(c, http.StatusInternalServerError, CodeInternal, "failed to commit transaction", err.Error())
+			return
+		}
+
+		resp, err := h.BuildBookingConfirmation(ctx, bookingRow.ID)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, CodeInternal, "failed to build confirmation", err.Error())
+			return
+		}
+		c.Set(middleware.AuditTargetKey, resp.ID)
+		c.JSON(http.StatusCreated, resp)
+
+		h.seatHub.Publish(bookingRow.EventID.Bytes, "booked", resp.SeatNumbers)
+		h.queueConfirmation(resp, userParam)
+		return
+	}
+
+	writeError(c, http.StatusServiceUnavailable, CodeBookingContention, "could not complete booking due to concurrent conflicts; please retry", nil)
+}
+
+type AdminBookingListItem struct {
+	ID        string    `json:"id"`
+	EventID   string    `json:"event_id"`
+	EventName string    `json:"event_name"`
+	UserID    *string   `json:"user_id"`
+	UserEmail *string   `json:"user_email"`
+	SeatsCnt  int32     `json:"seats_count"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GET /admin/bookings
+// Lists bookings across all users and events, newest first. Optional filters:
+// event_id, user_id, status, from, to (RFC3339 or YYYY-MM-DD); paged with limit/offset.
+func (h *BookingsHandler) AdminListBookings(c *gin.Context) {
+	var params db.ListBookingsParams
+
+	if v := c.Query("event_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid event_id", err.Error())
+			return
+		}
+		params.EventID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+	if v := c.Query("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid user_id", err.Error())
+			return
+		}
+		params.UserID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+	if v := c.Query("status"); v != "" {
+		switch v {
+		case "active", "pending_payment", "cancelled", "expired", "failed", "abandoned":
+			params.Status = pgtype.Text{String: v, Valid: true}
+		default:
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid status", "status must be one of active, pending_payment, cancelled, expired, failed, abandoned")
+			return
+		}
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := parseDateOrDatetime(v, time.Time{})
+		if err != nil {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid from param", err.Error())
+			return
+		}
+		params.CreatedFrom = pgtype.Timestamptz{Time: t, Valid: true}
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := parseRangeEnd(v, time.Time{})
+		if err != nil {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid to param", err.Error())
+			return
+		}
+		params.CreatedTo = pgtype.Timestamptz{Time: t, Valid: true}
+	}
+
+	page, perr := parsePage(c, h.pages)
+	if perr != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, perr.Message, perr.Details)
+		return
+	}
+	params.Limit = page.Limit
+	params.Offset = page.Offset
+
+	rows, err := h.db.ListBookings(c.Request.Context(), params)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch bookings", err.Error())
+		return
+	}
+
+	var total int64
+	out := make([]AdminBookingListItem, 0, len(rows))
+	for _, r := range rows {
+		total = r.Total
+		item := AdminBookingListItem{
+			ID:        r.ID.String(),
+			EventID:   r.EventID.String(),
+			EventName: r.EventName,
+			SeatsCnt:  r.Seats,
+			Status:    r.Status,
+			CreatedAt: r.CreatedAt.Time,
+			UpdatedAt: r.UpdatedAt.Time,
+		}
+		if r.UserID.Valid {
+			uid := r.UserID.String()
+			item.UserID = &uid
+		}
+		if r.UserEmail.Valid {
+			email := r.UserEmail.String
+			item.UserEmail = &email
+		}
+		out = append(out, item)
+	}
+	total, err = pageTotal(c, len(rows), page, total, func() (int64, error) {
+		return h.db.CountBookings(c.Request.Context(), db.CountBookingsParams{
+			EventID:     params.EventID,
+			UserID:      params.UserID,
+			Status:      params.Status,
+			CreatedFrom: params.CreatedFrom,
+			CreatedTo:   params.CreatedTo,
+		})
+	})
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to count bookings", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, listResponse(c, out, page, total))
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"time"
@@ -76,38 +75,14 @@ type EventUtilizationPoint struct {
 
 // GET /admin/analytics/total_bookings?from=&to=&top_n=
 func (h *AnalyticsHandler) GetTotalBookingsAnalytics(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
-	// Parse from/to; support ISO8601 datetime or date-only (YYYY-MM-DD)
-	now := time.Now().UTC()
-	var from, to time.Time
-	fromStr := c.Query("from")
-	toStr := c.Query("to")
-	if fromStr == "" && toStr == "" {
-		// default: last 30 days
-		to = now
-		from = now.AddDate(0, 0, -30)
-	} else {
-		var err error
-		from, err = parseDateOrDatetime(fromStr, now.AddDate(0, 0, -30))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from param", "details": err.Error()})
-			return
-		}
-		to, err = parseDateOrDatetime(toStr, now)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to param", "details": err.Error()})
-			return
-		}
-	}
-
-	// optional top N (default 10)
-	topN := 10
-	if v := c.Query("top_n"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			topN = n
-		}
+	// default: last 30 days
+	from, to, ok := analyticsRange(c, 30)
+	if !ok {
+		return
 	}
+	topN := analyticsTopN(c)
 
 	// prepare pgtype parameters (your sqlc likely expects pgtype.Timestamptz)
 	fromParam := pgtype.Timestamptz{Time: from, Valid: true}
@@ -202,6 +177,35 @@ func (h *AnalyticsHandler) GetTotalBookingsAnalytics(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// analyticsRange reads the from and to query params, ISO 8601 datetimes or
+// dates (YYYY-MM-DD), defaulting to the last defaultDays days. Both ends are
+// inclusive, and a date-only to covers that whole day. On a bad value it
+// writes the 400 and returns false.
+func analyticsRange(c *gin.Context, defaultDays int) (time.Time, time.Time, bool) {
+	now := time.Now().UTC()
+	from, err := parseDateOrDatetime(c.Query("from"), now.AddDate(0, 0, -defaultDays))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from param", "details": err.Error()})
+		return time.Time{}, time.Time{}, false
+	}
+	to, err := parseRangeEnd(c.Query("to"), now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to param", "details": err.Error()})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// analyticsTopN reads the optional top_n query param (default 10).
+func analyticsTopN(c *gin.Context) int {
+	if v := c.Query("top_n"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 10
+}
+
 // parseDateOrDatetime accepts ISO datetime or date-only (YYYY-MM-DD). If empty, returns defaultVal.
 func parseDateOrDatetime(s string, defaultVal time.Time) (time.Time, error) {
 	if s == "" {
@@ -218,3 +222,15 @@ func parseDateOrDatetime(s string, defaultVal time.Time) (time.Time, error) {
 	}
 	return time.Time{}, &time.ParseError{Layout: "RFC3339 or 2006-01-02", Value: s, LayoutElem: "", ValueElem: ""}
 }
+
+// parseRangeEnd parses the inclusive end of a range like parseDateOrDatetime,
+// except that a date-only value covers that whole day: it becomes the last
+// microsecond (Postgres' resolution) before the next day starts, so
+// "to=2024-05-31" includes bookings made on the 31st.
+func parseRangeEnd(s string, defaultVal time.Time) (time.Time, error) {
+	t, err := parseDateOrDatetime(s, defaultVal)
+	if err != nil || len(s) != len("2006-01-02") {
+		return t, err
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Microsecond), nil
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCode is a stable, machine-readable error identifier. Clients branch on
+// it; messages may change wording, codes may not.
+type ErrorCode string
+
+const (
+	CodeInvalidRequest         ErrorCode = "INVALID_REQUEST"
+	CodeUnauthenticated        ErrorCode = "UNAUTHENTICATED"
+	CodeForbidden              ErrorCode = "FORBIDDEN"
+	CodeEmailNotVerified       ErrorCode = "EMAIL_NOT_VERIFIED"
+	CodeInternal               ErrorCode = "INTERNAL_ERROR"
+	CodeEventNotFound          ErrorCode = "EVENT_NOT_FOUND"
+	CodeEventNotBookable       ErrorCode = "EVENT_NOT_BOOKABLE"
+	CodeCapacityExceeded       ErrorCode = "CAPACITY_EXCEEDED"
+	CodeSeatNotFound           ErrorCode = "SEAT_NOT_FOUND"
+	CodeSeatUnavailable        ErrorCode = "SEAT_UNAVAILABLE"
+	CodeTooManySeats           ErrorCode = "TOO_MANY_SEATS"
+	CodeHoldNotFound           ErrorCode = "HOLD_NOT_FOUND"
+	CodeHoldExpired            ErrorCode = "HOLD_EXPIRED"
+	CodeHoldNotActive          ErrorCode = "HOLD_NOT_ACTIVE"
+	CodeHoldMismatch           ErrorCode = "HOLD_MISMATCH"
+	CodeBookingNotFound        ErrorCode = "BOOKING_NOT_FOUND"
+	CodeBookingNotActive       ErrorCode = "BOOKING_NOT_ACTIVE"
+	CodeBookingNotPending      ErrorCode = "BOOKING_NOT_PENDING"
+	CodeCancellationClosed     ErrorCode = "CANCELLATION_CLOSED"
+	CodeUserNotFound           ErrorCode = "USER_NOT_FOUND"
+	CodeIdempotencyKeyRequired ErrorCode = "IDEMPOTENCY_KEY_REQUIRED"
+	CodeIdempotencyConflict    ErrorCode = "IDEMPOTENCY_CONFLICT"
+	CodeIdempotencyInProgress  ErrorCode = "IDEMPOTENCY_IN_PROGRESS"
+	CodeBookingContention      ErrorCode = "BOOKING_CONTENTION"
+	CodeRequestCancelled       ErrorCode = "REQUEST_CANCELLED"
+	CodeAlreadyWaitlisted      ErrorCode = "ALREADY_WAITLISTED"
+	CodeWaitlistFull           ErrorCode = "WAITLIST_FULL"
+	CodeWaitlistClosed         ErrorCode = "WAITLIST_CLOSED"
+	CodeSeatsAvailable         ErrorCode = "SEATS_AVAILABLE"
+	CodeRateLimited            ErrorCode = "RATE_LIMITED"
+	CodeNoAdjacentSeats        ErrorCode = "NO_ADJACENT_SEATS"
+	CodeInvalidSignature       ErrorCode = "INVALID_SIGNATURE"
+	CodeCancelAllBusy          ErrorCode = "CANCEL_ALL_BUSY"
+)
+
+// APIError is the error body of the booking, hold and waitlist endpoints:
+//
+//	{"code": "SEAT_UNAVAILABLE", "message": "...", "details": ..., "error": "..."}
+//
+// "error" repeats the message for clients written against the older
+// {"error", "details"} shape. Extra holds endpoint-specific fields such as
+// unavailable_seats, rendered next to the standard ones.
+type APIError struct {
+	Code    ErrorCode
+	Message string
+	Details any
+	Extra   gin.H
+}
+
+func apiError(code ErrorCode, message string, details any) APIError {
+	return APIError{Code: code, Message: message, Details: details}
+}
+
+// with returns a copy of e carrying an extra top-level field.
+func (e APIError) with(key string, value any) APIError {
+	extra := make(gin.H, len(e.Extra)+1)
+	for k, v := range e.Extra {
+		extra[k] = v
+	}
+	extra[key] = value
+	e.Extra = extra
+	return e
+}
+
+func (e APIError) Error() string { return string(e.Code) + ": " + e.Message }
+
+func (e APIError) MarshalJSON() ([]byte, error) {
+	body := make(map[string]any, len(e.Extra)+4)
+	for k, v := range e.Extra {
+		body[k] = v
+	}
+	body["code"] = e.Code
+	body["message"] = e.Message
+	body["error"] = e.Message
+	if e.Details != nil {
+		body["details"] = e.Details
+	}
+	return json.Marshal(body)
+}
+
+// writeError sends an APIError with the given status.
+func writeError(c *gin.Context, status int, code ErrorCode, message string, details any) {
+	c.JSON(status, apiError(code, message, details))
+}
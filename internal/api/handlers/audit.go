@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditHandler serves the audit log of privileged actions to admins.
+type AuditHandler struct {
+	db    *db.Queries
+	pages pagePolicy
+}
+
+func NewAuditHandler(dbconn *pgxpool.Pool) (*AuditHandler, error) {
+	pages, err := pagePolicyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &AuditHandler{db: db.New(dbconn), pages: pages}, nil
+}
+
+// AuditEntry is one recorded action in GET /admin/audit.
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	ActorID   *string   `json:"actor_id,omitempty"`
+	ActorRole *string   `json:"actor_role,omitempty"`
+	Action    string    `json:"action"`
+	Target    *string   `json:"target,omitempty"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int32     `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GET /admin/audit
+// Lists recorded privileged actions, newest first. Optional filters:
+// actor_id, action, from, to (RFC3339 or YYYY-MM-DD); paged with limit/offset.
+func (h *AuditHandler) ListAuditLog(c *gin.Context) {
+	var params db.ListAuditLogParams
+
+	if v := c.Query("actor_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid actor_id", "details": err.Error()})
+			return
+		}
+		params.ActorID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+	if v := c.Query("action"); v != "" {
+		params.Action = pgtype.Text{String: v, Valid: true}
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := parseDateOrDatetime(v, time.Time{})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from param", "details": err.Error()})
+			return
+		}
+		params.CreatedFrom = pgtype.Timestamptz{Time: t, Valid: true}
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := parseRangeEnd(v, time.Time{})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to param", "details": err.Error()})
+			return
+		}
+		params.CreatedTo = pgtype.Timestamptz{Time: t, Valid: true}
+	}
+
+	page, perr := parsePage(c, h.pages)
+	if perr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": perr.Message, "details": perr.Details})
+		return
+	}
+	params.Limit = page.Limit
+	params.Offset = page.Offset
+
+	rows, err := h.db.ListAuditLog(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch audit log", "details": err.Error()})
+		return
+	}
+
+	var total int64
+	out := make([]AuditEntry, 0, len(rows))
+	for _, r := range rows {
+		total = r.Total
+		entry := AuditEntry{
+			ID:        r.ID,
+			ActorRole: textPtr(r.ActorRole),
+			Action:    r.Action,
+			Target:    textPtr(r.Target),
+			Method:    r.Method,
+			Path:      r.Path,
+			Status:    r.Status,
+			CreatedAt: r.CreatedAt.Time,
+		}
+		if r.ActorID.Valid {
+			id := r.ActorID.String()
+			entry.ActorID = &id
+		}
+		out = append(out, entry)
+	}
+	total, err = pageTotal(c, len(rows), page, total, func() (int64, error) {
+		return h.db.CountAuditLog(c.Request.Context(), db.CountAuditLogParams{
+			ActorID:     params.ActorID,
+			Action:      params.Action,
+			CreatedFrom: params.CreatedFrom,
+			CreatedTo:   params.CreatedTo,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count audit log", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, listResponse(c, out, page, total))
+}
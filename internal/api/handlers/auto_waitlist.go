@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// AutoWaitlistResponse answers a booking that found the event sold out and,
+// because the request carried auto_waitlist, put the caller on its waitlist
+// instead. Reason is the code the booking would have failed with.
+type AutoWaitlistResponse struct {
+	EventID    string               `json:"event_id"`
+	Waitlisted bool                 `json:"waitlisted"`
+	Reason     ErrorCode            `json:"reason"`
+	Waitlist   JoinWaitlistResponse `json:"waitlist"`
+}
+
+// soldOutFailure reports whether a booking failed for lack of seats rather
+// than because of the request itself.
+func soldOutFailure(e APIError) bool {
+	return e.Code == CodeCapacityExceeded || e.Code == CodeSeatUnavailable
+}
+
+// respondBookingFailure sends a failed booking's response. With autoWaitlist
+// set and a sold-out failure, the user is enrolled on the event's waitlist
+// for seats and gets 202 with their entry; an existing waiting entry is
+// updated rather than duplicated. When the waitlist can't take them (closed,
+// full, or enough seats are in fact bookable) the original failure is sent,
+// with the waitlist's error code as auto_waitlist_error.
+func (h *BookingsHandler) respondBookingFailure(ctx context.Context, respond func(status int, body any), status int, body APIError, autoWaitlist bool, eventParam, userParam pgtype.UUID, seats int) {
+	if !autoWaitlist || !userParam.Valid || !soldOutFailure(body) {
+		respond(status, body)
+		return
+	}
+	entry, _, apiErr := enrollOnWaitlist(ctx, h.DB, eventParam, userParam, int32(seats), false)
+	if apiErr != nil {
+		respond(status, body.with("auto_waitlist_error", apiErr.Code))
+		return
+	}
+	respond(http.StatusAccepted, AutoWaitlistResponse{
+		EventID:    eventParam.String(),
+		Waitlisted: true,
+		Reason:     body.Code,
+		Waitlist:   entry,
+	})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// availabilityCacheControl lets shared caches absorb polling bursts while
+// keeping counts at most a few seconds stale.
+const availabilityCacheControl = "public, max-age=5, stale-while-revalidate=5"
+
+// Values of availability_status.
+const (
+	availabilityOpen    = "open"
+	availabilityLimited = "limited"
+	availabilitySoldOut = "sold_out"
+)
+
+const defaultLimitedPercent = 10
+
+// limitedPercentFromEnv reads AVAILABILITY_LIMITED_PERCENT: events with at
+// most this percentage of their capacity left are "limited". Unset means 10.
+func limitedPercentFromEnv() (int, error) {
+	raw := os.Getenv("AVAILABILITY_LIMITED_PERCENT")
+	if raw == "" {
+		return defaultLimitedPercent, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 || n > 100 {
+		return 0, fmt.Errorf("invalid AVAILABILITY_LIMITED_PERCENT %q: want 0-100", raw)
+	}
+	return n, nil
+}
+
+// availabilityStatus classifies an event for browsing. Held seats count as
+// still on sale: a hold either turns into a booking or expires and frees its
+// seat, so counting them keeps an event from flipping between sold_out and
+// open as holds come and go. capacity is the sellable capacity, overbooking
+// buffer included. GetAllEvents filters with the same rule.
+func availabilityStatus(capacity, booked, available, held int32, limitedPercent int) string {
+	left := min(available+held, capacity-booked)
+	switch {
+	case left <= 0:
+		return availabilitySoldOut
+	case int64(left)*100 <= int64(capacity)*int64(limitedPercent):
+		return availabilityLimited
+	default:
+		return availabilityOpen
+	}
+}
+
+// AvailabilityResponse is the numeric summary behind GET /events/:id/availability.
+// Available matches EventResponse.Purchasable: free seats capped by the
+// sellable capacity left.
+type AvailabilityResponse struct {
+	EventID          string `json:"event_id"`
+	Capacity         int32  `json:"capacity"`
+	SellableCapacity int32  `json:"sellable_capacity"`
+	Booked           int32  `json:"booked"`
+	Held             int32  `json:"held"`
+	Available        int32  `json:"available"`
+	Status           string `json:"availability_status"`
+}
+
+// GET /events/:id/availability
+// A cheap alternative to the seat list and the SSE stream for clients that
+// poll. Public and cacheable; archived events are reported as not found.
+func (h *EventsHandler) GetAvailability(c *gin.Context) {
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id", "details": err.Error()})
+		return
+	}
+
+	row, err := h.db.GetEventAvailability(c.Request.Context(), pgtype.UUID{Bytes: uid, Valid: true})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch availability", "details": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", availabilityCacheControl)
+	sellable := sellableCapacity(row.Capacity, row.OversellPercent)
+	c.JSON(http.StatusOK, AvailabilityResponse{
+		EventID:          uid.String(),
+		Capacity:         row.Capacity,
+		SellableCapacity: sellable,
+		Booked:           row.BookedCount,
+		Held:             row.Held,
+		Available:        purchasableSeats(row),
+		Status:           availabilityStatus(sellable, row.BookedCount, row.AvailableSeats, row.Held, h.limitedPercent),
+	})
+}
+
+// purchasableSeats is how many seats can be booked right now: free seats,
+// which leaves out held ones, capped by the sellable capacity left.
+func purchasableSeats(row db.GetEventAvailabilityRow) int32 {
+	return max(min(row.AvailableSeats, sellableCapacity(row.Capacity, row.OversellPercent)-row.BookedCount), 0)
+}
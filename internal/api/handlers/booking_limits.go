@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+const defaultMaxSeatsPerBooking = 10
+
+// maxSeatsMetadataKey lets an event's metadata override the seat limit, e.g.
+// {"max_seats_per_booking": 40} for group bookings.
+const maxSeatsMetadataKey = "max_seats_per_booking"
+
+// maxSeatsPerBookingFromEnv reads MAX_SEATS_PER_BOOKING, the most seats one
+// hold or booking may take (0 = no limit). Unset means the default.
+func maxSeatsPerBookingFromEnv() (int, error) {
+	raw := os.Getenv("MAX_SEATS_PER_BOOKING")
+	if raw == "" {
+		return defaultMaxSeatsPerBooking, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid MAX_SEATS_PER_BOOKING %q: want a non-negative integer", raw)
+	}
+	return n, nil
+}
+
+// seatLimitForEvent returns the seat limit for an event: a positive integer
+// max_seats_per_booking in its metadata, or def.
+func seatLimitForEvent(def int, metadata []byte) int {
+	if len(metadata) == 0 {
+		return def
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(metadata, &m); err != nil {
+		return def
+	}
+	raw, ok := m[maxSeatsMetadataKey]
+	if !ok {
+		return def
+	}
+	var n int
+	if err := json.Unmarshal(raw, &n); err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+// seatLimitExceeded reports whether taking n seats breaks limit. Admins are
+// exempt, as is a limit of 0.
+func seatLimitExceeded(n, limit int, role string) bool {
+	return role != "admin" && limit > 0 && n > limit
+}
+
+func seatLimitResponse(n, limit int) (int, APIError) {
+	return http.StatusBadRequest, apiError(CodeTooManySeats, "too many seats requested",
+		fmt.Sprintf("requested %d seats, at most %d allowed per booking", n, limit)).
+		with("max_seats", limit)
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/money"
+	"github.com/abhinandanwadwa/overbookr/internal/payments"
+	"github.com/abhinandanwadwa/overbookr/internal/workers"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PaymentDetails lets the client pay for a pending booking with the payment
+// provider.
+type PaymentDetails struct {
+	IntentID     string `json:"intent_id"`
+	ClientSecret string `json:"client_secret"`
+	AmountCents  int64  `json:"amount_cents"`
+	Currency     string `json:"currency"`
+}
+
+// bookingPrice prices seatNos at unitCents, the seat price their booking was
+// made at, in currency; an empty currency is the base currency. It returns
+// nil for free bookings.
+func (h *BookingsHandler) bookingPrice(seatNos []string, unitCents int64, currency string) *money.Bill {
+	return h.priceLocale.PerSeat(seatNos, unitCents, cmp.Or(currency, h.baseCurrency))
+}
+
+// initialBookingStatus is the status a user's new booking for event starts
+// in. Bookings for events that require payment hold their seats as
+// pending_payment until the payment is confirmed.
+func initialBookingStatus(event db.Event) string {
+	if event.RequiresPayment {
+		return "pending_payment"
+	}
+	return "active"
+}
+
+// paymentDueAt is when a booking made at createdAt is abandoned unless paid
+// for. It is nil for bookings that aren't awaiting payment.
+func (h *BookingsHandler) paymentDueAt(status string, createdAt time.Time) *time.Time {
+	if status != "pending_payment" {
+		return nil
+	}
+	due := createdAt.Add(h.paymentWindow)
+	return &due
+}
+
+// startPayment opens a payment intent for price, the price of a committed
+// pending_payment booking of the event, and links it to the booking. It
+// returns nil when no provider is configured, there is nothing to charge, or
+// the provider failed; the client can then ask POST /bookings/:id/payment
+// again, and otherwise the booking waits for an admin to confirm it or for
+// the sweep to abandon it.
+func (h *BookingsHandler) startPayment(ctx context.Context, bookingID, eventID pgtype.UUID, price *money.Bill) *PaymentDetails {
+	payment, err := h.paymentFor(ctx, h.db, bookingID, eventID, price, pgtype.Text{})
+	if err != nil {
+		log.Printf("failed to open payment for booking %s: %v", bookingID.String(), err)
+		return nil
+	}
+	return payment
+}
+
+// paymentFor returns the payment details of a pending_payment booking of the
+// event costing price: its intent intentID fetched from the provider, or a
+// new intent linked to the booking through q when it has none. It returns
+// nil when no provider is configured or there is nothing to charge.
+func (h *BookingsHandler) paymentFor(ctx context.Context, q *db.Queries, bookingID, eventID pgtype.UUID, price *money.Bill, intentID pgtype.Text) (*PaymentDetails, error) {
+	if h.payments == nil || price == nil || price.TotalCents <= 0 {
+		return nil, nil
+	}
+	amount, currency := price.TotalCents, price.Currency
+	var intent payments.Intent
+	var err error
+	if intentID.Valid {
+		if intent, err = h.payments.GetIntent(ctx, intentID.String); err != nil {
+			return nil, err
+		}
+	} else {
+		intent, err = h.payments.CreateIntent(ctx, amount, currency, map[string]string{
+			"booking_id": bookingID.String(),
+			"event_id":   eventID.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := q.SetBookingPaymentIntent(ctx, db.SetBookingPaymentIntentParams{
+			ID:              bookingID,
+			PaymentIntentID: pgtype.Text{String: intent.ID, Valid: true},
+		}); err != nil {
+			return nil, fmt.Errorf("link payment %s: %w", intent.ID, err)
+		}
+	}
+	return &PaymentDetails{
+		IntentID:     intent.ID,
+		ClientSecret: intent.ClientSecret,
+		AmountCents:  amount,
+		Currency:     currency,
+	}, nil
+}
+
+// loadPendingBooking parses :id and locks that booking on q's transaction.
+// It responds (returning false) when the id is invalid, the booking doesn't
+// exist, allowed rejects the caller or the booking isn't awaiting payment.
+func loadPendingBooking(c *gin.Context, q *db.Queries, allowed func(db.GetBookingForUpdateRow) bool) (db.GetBookingForUpdateRow, bool) {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid booking id", err.Error())
+		return db.GetBookingForUpdateRow{}, false
+	}
+	b, err := q.GetBookingForUpdate(c.Request.Context(), pgtype.UUID{Bytes: bookingID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, CodeBookingNotFound, "booking not found", nil)
+			return b, false
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch booking", err.Error())
+		return b, false
+	}
+	if !allowed(b) {
+		writeError(c, http.StatusForbidden, CodeForbidden, "forbidden: only booking owner or admin may change this booking", nil)
+		return b, false
+	}
+	if b.Status != "pending_payment" {
+		c.JSON(http.StatusConflict, apiError(CodeBookingNotPending, "booking is not awaiting payment", nil).with("status", b.Status))
+		return b, false
+	}
+	return b, true
+}
+
+// confirmPending makes the pending booking b, locked on tx, active, commits
+// and queues its confirmation.
+func (h *BookingsHandler) confirmPending(ctx context.Context, tx pgx.Tx, b db.GetBookingForUpdateRow) (CreateBookingResponse, error) {
+	if _, err := db.New(tx).ConfirmPendingBooking(ctx, b.ID); err != nil {
+		return CreateBookingResponse{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return CreateBookingResponse{}, err
+	}
+	resp, err := h.BuildBookingConfirmation(ctx, b.ID)
+	if err != nil {
+		return CreateBookingResponse{}, err
+	}
+	h.queueConfirmation(resp, b.UserID)
+	return resp, nil
+}
+
+// abandonPending abandons the pending booking b, locked on tx, recording
+// reason in the seat history, commits, cancels its payment intent and offers
+// the seats to the waitlist.
+func (h *BookingsHandler) abandonPending(c *gin.Context, tx pgx.Tx, b db.GetBookingForUpdateRow, reason string) error {
+	ctx := c.Request.Context()
+	q := db.New(tx)
+	if err := recordSeatChanges(c, q, reason); err != nil {
+		return err
+	}
+	releasedSeatNos, err := workers.ReleasePendingBooking(ctx, q, b)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	h.seatHub.Publish(b.EventID.Bytes, "available", releasedSeatNos)
+	workers.CancelPaymentIntent(ctx, h.payments, b.PaymentIntentID)
+	go EnqueuePromoteEvent(h.DB, h.mailQueue, h.seatHub, b.EventID.Bytes)
+	return nil
+}
+
+// POST /bookings/:id/confirm
+// Marks a pending_payment booking as paid, making it active, and sends the
+// booking confirmation. Admin only: for payments settled outside the
+// payment provider, so the booking's provider intent is cancelled.
+func (h *BookingsHandler) ConfirmBooking(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to start transaction", err.Error())
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	b, ok := loadPendingBooking(c, db.New(tx), func(db.GetBookingForUpdateRow) bool { return true })
+	if !ok {
+		return
+	}
+	resp, err := h.confirmPending(ctx, tx, b)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to confirm booking", err.Error())
+		return
+	}
+	workers.CancelPaymentIntent(ctx, h.payments, b.PaymentIntentID)
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /bookings/:id/payment
+// Returns the payment details of a pending_payment booking, opening its
+// payment intent first if the booking has none, e.g. because the provider
+// failed when the booking was made. Owner or admin.
+func (h *BookingsHandler) BookingPayment(c *gin.Context) {
+	if h.payments == nil {
+		writeError(c, http.StatusNotFound, CodeInvalidRequest, "payments are not enabled", nil)
+		return
+	}
+	ctx := c.Request.Context()
+	caller, _ := callerID(c)
+
+	// The booking stays locked until its intent is linked, so two requests
+	// never open two intents.
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to start transaction", err.Error())
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := db.New(tx)
+
+	b, ok := loadPendingBooking(c, q, func(b db.GetBookingForUpdateRow) bool {
+		return canViewBooking(c, b.UserID, caller)
+	})
+	if !ok {
+		return
+	}
+	seatNos, err := q.GetSeatNosByIds(ctx, b.SeatIds)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to get seat numbers", err.Error())
+		return
+	}
+	price := h.bookingPrice(seatNos, int64(b.UnitPriceCents), b.Currency.String)
+	payment, err := h.paymentFor(ctx, q, b.ID, b.EventID, price, b.PaymentIntentID)
+	if err != nil {
+		writeError(c, http.StatusBadGateway, CodeInternal, "failed to open payment", err.Error())
+		return
+	}
+	if payment == nil {
+		writeError(c, http.StatusConflict, CodeInvalidRequest, "booking has nothing to pay", nil)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to commit transaction", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, payment)
+}
+
+// POST /bookings/:id/abandon
+// Gives up a booking that is still awaiting payment: its seats become
+// available again and the waitlist is offered them. Owner or admin.
+func (h *BookingsHandler) AbandonBooking(c *gin.Context) {
+	ctx := c.Request.Context()
+	caller, _ := callerID(c)
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to start transaction", err.Error())
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	b, ok := loadPendingBooking(c, db.New(tx), func(b db.GetBookingForUpdateRow) bool {
+		return canViewBooking(c, b.UserID, caller)
+	})
+	if !ok {
+		return
+	}
+	if err := h.abandonPending(c, tx, b, seatReasonPaymentAbandoned); err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to abandon booking", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"id":     b.ID.String(),
+		"status": "abandoned",
+	})
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const defaultConfirmationResendCooldown = 5 * time.Minute
+
+// confirmationResendCooldownFromEnv reads CONFIRMATION_RESEND_COOLDOWN, the
+// minimum time between resends of one booking's confirmation (e.g. "10m").
+// Unset means 5m.
+func confirmationResendCooldownFromEnv() (time.Duration, error) {
+	return positiveDurationFromEnv("CONFIRMATION_RESEND_COOLDOWN", defaultConfirmationResendCooldown)
+}
+
+// POST /bookings/:id/resend-confirmation
+// Queues the confirmation email (and SMS) for an active booking again, for
+// users whose original never arrived. Access follows GetBookingByID: owner or
+// admin. Each booking can be resent once per CONFIRMATION_RESEND_COOLDOWN.
+func (h *BookingsHandler) ResendConfirmation(c *gin.Context) {
+	ctx := c.Request.Context()
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid booking id", err.Error())
+		return
+	}
+	bookingParam := pgtype.UUID{Bytes: bookingID, Valid: true}
+
+	b, err := h.db.GetBookingByID(ctx, bookingParam)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(c, http.StatusNotFound, CodeBookingNotFound, "booking not found", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch booking", err.Error())
+		return
+	}
+
+	uid, ok := callerID(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, CodeUnauthenticated, "unauthenticated", nil)
+		return
+	}
+	if !canViewBooking(c, b.UserID, uid) {
+		writeError(c, http.StatusForbidden, CodeForbidden, "forbidden: only booking owner or admin may resend its confirmation", nil)
+		return
+	}
+	if b.Status != "active" {
+		c.JSON(http.StatusConflict, apiError(CodeBookingNotActive, "booking is not active", nil).with("status", b.Status))
+		return
+	}
+
+	resp, err := h.BuildBookingConfirmation(ctx, b.ID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to build confirmation", err.Error())
+		return
+	}
+
+	claimed, err := h.db.ClaimConfirmationResend(ctx, db.ClaimConfirmationResendParams{
+		BookingID: bookingParam,
+		SentAt:    pgtype.Timestamptz{Time: time.Now().Add(-h.resendCooldown), Valid: true},
+	})
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to record resend", err.Error())
+		return
+	}
+	if claimed == 0 {
+		retryAfter := int(h.resendCooldown.Seconds())
+		if last, err := h.db.GetConfirmationResentAt(ctx, bookingParam); err == nil && last.Valid {
+			retryAfter = int(time.Until(last.Time.Add(h.resendCooldown)).Seconds()) + 1
+		} else if err != nil {
+			log.Printf("failed to read last resend of booking %s: %v", resp.ID, err)
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, apiError(CodeRateLimited, "confirmation was resent recently", "Try again later").with("retry_after", retryAfter))
+		return
+	}
+
+	h.queueConfirmation(resp, b.UserID)
+	c.JSON(http.StatusAccepted, gin.H{"id": resp.ID, "status": "queued"})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/api/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultBookingMaxRetries     = 3
+	defaultBookingInitialBackoff = 100 * time.Millisecond
+
+	maxBookingRetries        = 10
+	minBookingInitialBackoff = time.Millisecond
+	maxBookingInitialBackoff = 2 * time.Second
+
+	// maxBookingRetryTime caps how long one booking request may spend
+	// retrying, keeping it well inside the server's 10s WriteTimeout however
+	// long DB_TIMEOUT is.
+	maxBookingRetryTime = 8 * time.Second
+)
+
+// bookingRetryTime is how long a booking may spend retrying under a request
+// DB deadline of dbTimeout. The deadline cancels the request's context, so
+// retrying past it only turns a contention 503 into a timeout; a fifth of it
+// is left for the last attempt's transaction.
+func bookingRetryTime(dbTimeout time.Duration) time.Duration {
+	return min(dbTimeout-dbTimeout/5, maxBookingRetryTime)
+}
+
+// errBookingRetriesExhausted means a booking ran out of attempts or retry time.
+var errBookingRetriesExhausted = errors.New("booking retries exhausted")
+
+// bookingRetryPolicy controls how hard a booking fights serialization
+// failures on contended seats.
+type bookingRetryPolicy struct {
+	// MaxAttempts is the number of transaction attempts, including the first.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry; it doubles after
+	// each one.
+	InitialBackoff time.Duration
+	// MaxRetryTime bounds the whole request, retries included.
+	MaxRetryTime time.Duration
+}
+
+// bookingRetryPolicyFromEnv reads BOOKING_MAX_RETRIES (attempts, 1-10) and
+// BOOKING_INITIAL_BACKOFF (a Go duration, 1ms-2s). The retry time follows
+// DB_TIMEOUT; see bookingRetryTime.
+func bookingRetryPolicyFromEnv() (bookingRetryPolicy, error) {
+	p := bookingRetryPolicy{
+		MaxAttempts:    defaultBookingMaxRetries,
+		InitialBackoff: defaultBookingInitialBackoff,
+	}
+	dbTimeout, err := middleware.TimeoutFromEnv("DB_TIMEOUT", middleware.DefaultDBTimeout)
+	if err != nil {
+		return p, err
+	}
+	p.MaxRetryTime = bookingRetryTime(dbTimeout)
+	if raw := os.Getenv("BOOKING_MAX_RETRIES"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxBookingRetries {
+			return p, fmt.Errorf("invalid BOOKING_MAX_RETRIES %q: want 1-%d", raw, maxBookingRetries)
+		}
+		p.MaxAttempts = n
+	}
+	if raw := os.Getenv("BOOKING_INITIAL_BACKOFF"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < minBookingInitialBackoff || d > maxBookingInitialBackoff {
+			return p, fmt.Errorf("invalid BOOKING_INITIAL_BACKOFF %q: want %s-%s", raw, minBookingInitialBackoff, maxBookingInitialBackoff)
+		}
+		p.InitialBackoff = d
+	}
+	return p, nil
+}
+
+// bookingRetry tracks one request's attempts against its policy.
+type bookingRetry struct {
+	policy   bookingRetryPolicy
+	started  time.Time
+	attempts int
+	backoff  time.Duration
+}
+
+func (p bookingRetryPolicy) start() *bookingRetry {
+	return &bookingRetry{policy: p, started: time.Now(), backoff: p.InitialBackoff}
+}
+
+// wait is called after a failed attempt. It sleeps out the backoff and
+// returns nil when another attempt may be made, errBookingRetriesExhausted
+// when the attempts or the retry time are used up, or ctx's error if the
+// request is cancelled while waiting.
+func (r *bookingRetry) wait(ctx context.Context) error {
+	r.attempts++
+	if r.attempts >= r.policy.MaxAttempts {
+		return errBookingRetriesExhausted
+	}
+	// Budget for the worst-case jitter so the next attempt still fits.
+	if time.Since(r.started)+r.backoff*3/2 > r.policy.MaxRetryTime {
+		return errBookingRetriesExhausted
+	}
+	if err := waitBackoff(ctx, r.backoff); err != nil {
+		return err
+	}
+	r.backoff *= 2
+	return nil
+}
+
+// retryFailedResponse is the response for a booking that could not be retried.
+func retryFailedResponse(err error) (int, APIError) {
+	if errors.Is(err, errBookingRetriesExhausted) {
+		return http.StatusServiceUnavailable, apiError(CodeBookingContention, "could not complete booking due to concurrent conflicts; please retry", nil)
+	}
+	return http.StatusServiceUnavailable, apiError(CodeRequestCancelled, "request cancelled while retrying booking", err.Error())
+}
+
+type BookingRetryPolicyResponse struct {
+	MaxAttempts    int    `json:"max_attempts"`
+	InitialBackoff string `json:"initial_backoff"`
+	MaxRetryTime   string `json:"max_retry_time"`
+}
+
+// GET /admin/debug/booking-retry
+// Reports the retry policy bookings are running with, after env validation.
+func (h *BookingsHandler) GetRetryPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, BookingRetryPolicyResponse{
+		MaxAttempts:    h.retryPolicy.MaxAttempts,
+		InitialBackoff: h.retryPolicy.InitialBackoff.String(),
+		MaxRetryTime:   h.retryPolicy.MaxRetryTime.String(),
+	})
+}
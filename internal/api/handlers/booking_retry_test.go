@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// attemptsUntilGiveUp runs attempts the way the booking handlers do, every
+// one failing, and returns how many were made before the policy gave up.
+func attemptsUntilGiveUp(t *testing.T, p bookingRetryPolicy) (int, error) {
+	t.Helper()
+	r := p.start()
+	for attempt := 1; ; attempt++ {
+		if attempt > maxBookingRetries+1 {
+			t.Fatalf("still retrying after %d attempts", attempt-1)
+		}
+		if err := r.wait(context.Background()); err != nil {
+			return attempt, err
+		}
+	}
+}
+
+func TestBookingRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	for _, attempts := range []int{1, 2, 3, 5} {
+		p := bookingRetryPolicy{MaxAttempts: attempts, InitialBackoff: time.Millisecond, MaxRetryTime: time.Minute}
+		n, err := attemptsUntilGiveUp(t, p)
+		if !errors.Is(err, errBookingRetriesExhausted) {
+			t.Fatalf("MaxAttempts %d: err = %v, want errBookingRetriesExhausted", attempts, err)
+		}
+		if n != attempts {
+			t.Errorf("MaxAttempts %d: gave up after %d attempts", attempts, n)
+		}
+	}
+}
+
+func TestBookingRetryGivesUpWhenOutOfTime(t *testing.T) {
+	// 10ms, 20ms, 40ms... the third wait would run past 50ms.
+	p := bookingRetryPolicy{MaxAttempts: 10, InitialBackoff: 10 * time.Millisecond, MaxRetryTime: 50 * time.Millisecond}
+	start := time.Now()
+	n, err := attemptsUntilGiveUp(t, p)
+	if !errors.Is(err, errBookingRetriesExhausted) {
+		t.Fatalf("err = %v, want errBookingRetriesExhausted", err)
+	}
+	if n >= p.MaxAttempts {
+		t.Errorf("made all %d attempts; the retry time should have stopped it first", n)
+	}
+	if elapsed := time.Since(start); elapsed > p.MaxRetryTime {
+		t.Errorf("retried for %s, past MaxRetryTime %s", elapsed, p.MaxRetryTime)
+	}
+}
+
+func TestBookingRetryStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := bookingRetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxRetryTime: time.Minute}.start()
+	err := r.wait(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if status, body := retryFailedResponse(err); status != http.StatusServiceUnavailable || body.Code != CodeRequestCancelled {
+		t.Errorf("response = %d %s, want 503 %s", status, body.Code, CodeRequestCancelled)
+	}
+	if status, body := retryFailedResponse(errBookingRetriesExhausted); status != http.StatusServiceUnavailable || body.Code != CodeBookingContention {
+		t.Errorf("exhausted response = %d %s, want 503 %s", status, body.Code, CodeBookingContention)
+	}
+}
+
+func TestBookingRetryPolicyFromEnv(t *testing.T) {
+	tests := []struct {
+		retries, backoff string
+		wantAttempts     int
+		wantBackoff      time.Duration
+		wantErr          bool
+	}{
+		{"", "", defaultBookingMaxRetries, defaultBookingInitialBackoff, false},
+		{"5", "250ms", 5, 250 * time.Millisecond, false},
+		{"1", "1ms", 1, time.Millisecond, false},
+		{"10", "2s", 10, 2 * time.Second, false},
+		{"0", "", 0, 0, true},
+		{"11", "", 0, 0, true},
+		{"three", "", 0, 0, true},
+		{"", "0s", 0, 0, true},
+		{"", "3s", 0, 0, true},
+		{"", "fast", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("BOOKING_MAX_RETRIES", tt.retries)
+		t.Setenv("BOOKING_INITIAL_BACKOFF", tt.backoff)
+		got, err := bookingRetryPolicyFromEnv()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("retries %q backoff %q: no error", tt.retries, tt.backoff)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("retries %q backoff %q: %v", tt.retries, tt.backoff, err)
+			continue
+		}
+		if got.MaxAttempts != tt.wantAttempts || got.InitialBackoff != tt.wantBackoff {
+			t.Errorf("retries %q backoff %q: got %d/%s, want %d/%s",
+				tt.retries, tt.backoff, got.MaxAttempts, got.InitialBackoff, tt.wantAttempts, tt.wantBackoff)
+		}
+	}
+}
+
+func TestBookingRetryPolicyFollowsDBTimeout(t *testing.T) {
+	t.Setenv("DB_TIMEOUT", "1s")
+	p, err := bookingRetryPolicyFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MaxRetryTime != 800*time.Millisecond {
+		t.Errorf("MaxRetryTime = %s, want 800ms", p.MaxRetryTime)
+	}
+}
+
+func TestBookingRetryTimeFitsWriteTimeout(t *testing.T) {
+	tests := []struct {
+		dbTimeout, want time.Duration
+	}{
+		{5 * time.Second, 4 * time.Second},
+		{time.Second, 800 * time.Millisecond},
+		{30 * time.Second, maxBookingRetryTime},
+	}
+	for _, tt := range tests {
+		if got := bookingRetryTime(tt.dbTimeout); got != tt.want {
+			t.Errorf("bookingRetryTime(%s) = %s, want %s", tt.dbTimeout, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/api/middleware"
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
+	"github.com/abhinandanwadwa/overbookr/internal/tickets"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// GET /bookings/:id/ticket.pdf
+// Streams a printable ticket for an active booking, carrying the same signed
+// QR code as the confirmation email. Access follows GetBookingByID: owner or
+// admin.
+func (h *BookingsHandler) GetBookingTicketPDF(c *gin.Context) {
+	ctx := c.Request.Context()
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid booking id", err.Error())
+		return
+	}
+
+	b, err := h.db.GetBookingByID(ctx, pgtype.UUID{Bytes: bookingID, Valid: true})
+	if err != nil {
+		writeError(c, http.StatusNotFound, CodeBookingNotFound, "booking not found", err.Error())
+		return
+	}
+
+	uid, ok := callerID(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, CodeUnauthenticated, "unauthenticated", nil)
+		return
+	}
+	if !canViewBooking(c, b.UserID, uid) {
+		writeError(c, http.StatusForbidden, CodeForbidden, "forbidden: only booking owner or admin may view this booking", nil)
+		return
+	}
+	if b.Status != "active" {
+		c.JSON(http.StatusConflict, apiError(CodeBookingNotActive, "booking is not active", nil).with("status", b.Status))
+		return
+	}
+
+	event, err := h.db.GetEventByID(ctx, b.EventID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch event", err.Error())
+		return
+	}
+	seatNumbers, err := h.db.GetSeatNosByIds(ctx, b.SeatIds)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to get seat numbers", err.Error())
+		return
+	}
+
+	resp := mail.CreateBookingResponse{
+		ID:           b.ID.String(),
+		EventID:      b.EventID.String(),
+		UserID:       b.UserID.String(),
+		SeatNumbers:  seatNumbers,
+		CreatedAt:    b.CreatedAt.Time,
+		TokenVersion: b.TokenVersion,
+	}
+	// A ticket without its QR is still worth printing, so a signing failure
+	// only drops the code.
+	qrPNG, err := mail.TicketQR(resp, time.Now())
+	if err != nil {
+		log.Printf("failed to build ticket QR for booking %s, rendering without it: %v", resp.ID, err)
+		qrPNG = nil
+	}
+
+	pdf, err := mail.BuildTicketPDF(resp, event, qrPNG)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to generate ticket", err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="ticket-%s.pdf"`, resp.ID))
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// RegenerateTicketResponse carries the booking's new ticket. TicketToken is
+// what the QR code encodes; TicketURL serves the printable ticket.
+type RegenerateTicketResponse struct {
+	BookingID    string `json:"booking_id"`
+	TokenVersion int32  `json:"token_version"`
+	TicketToken  string `json:"ticket_token"`
+	TicketURL    string `json:"ticket_url"`
+}
+
+// POST /bookings/:id/regenerate-ticket
+// Revokes every ticket QR issued for an active booking, e.g. after a resale
+// or a leaked code, by bumping its token version, and emails the holder a
+// fresh confirmation with the new code. Access follows GetBookingByID: owner
+// or admin.
+func (h *BookingsHandler) RegenerateTicket(c *gin.Context) {
+	ctx := c.Request.Context()
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid booking id", err.Error())
+		return
+	}
+	bookingParam := pgtype.UUID{Bytes: bookingID, Valid: true}
+
+	b, err := h.db.GetBookingByID(ctx, bookingParam)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(c, http.StatusNotFound, CodeBookingNotFound, "booking not found", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch booking", err.Error())
+		return
+	}
+
+	uid, ok := callerID(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, CodeUnauthenticated, "unauthenticated", nil)
+		return
+	}
+	if !canViewBooking(c, b.UserID, uid) {
+		writeError(c, http.StatusForbidden, CodeForbidden, "forbidden: only booking owner or admin may regenerate its ticket", nil)
+		return
+	}
+	if b.Status != "active" {
+		c.JSON(http.StatusConflict, apiError(CodeBookingNotActive, "booking is not active", nil).with("status", b.Status))
+		return
+	}
+
+	version, err := h.db.BumpBookingTokenVersion(ctx, bookingParam)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			// Cancelled between the read and the bump.
+			writeError(c, http.StatusConflict, CodeBookingNotActive, "booking is not active", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to revoke ticket", err.Error())
+		return
+	}
+
+	// Old codes are revoked from here on, even if signing the new one fails.
+	token, err := tickets.Sign(b.ID.String(), b.EventID.String(), b.UserID.String(), version, time.Now())
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to sign ticket", err.Error())
+		return
+	}
+
+	resp, err := h.BuildBookingConfirmation(ctx, b.ID)
+	if err != nil {
+		log.Printf("failed to build confirmation for regenerated ticket of booking %s: %v", b.ID.String(), err)
+	} else {
+		h.queueConfirmation(resp, b.UserID)
+	}
+
+	c.JSON(http.StatusOK, RegenerateTicketResponse{
+		BookingID:    b.ID.String(),
+		TokenVersion: version,
+		TicketToken:  token,
+		TicketURL:    fmt.Sprintf("%s/bookings/%s/ticket.pdf", middleware.APIVersionPrefix, b.ID.String()),
+	})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// bookingCutoffFromEnv reads BOOKING_CUTOFF, the Go duration before an event's
+// start_time at which new holds and bookings are refused (e.g. "15m"). A
+// negative value keeps sales open that long after the start. Unset means
+// sales close at start_time.
+func bookingCutoffFromEnv() (time.Duration, error) {
+	raw := os.Getenv("BOOKING_CUTOFF")
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid BOOKING_CUTOFF %q: %w", raw, err)
+	}
+	return d, nil
+}
+
+// bookableUntil is the last moment seats for an event starting at start can be
+// held or booked. It is nil when the event has no start time.
+func bookableUntil(start pgtype.Timestamptz, cutoff time.Duration) *time.Time {
+	if !start.Valid {
+		return nil
+	}
+	d := start.Time.Add(-cutoff)
+	return &d
+}
+
+// salesClosed reports whether the booking window for an event starting at
+// start has passed.
+func salesClosed(start pgtype.Timestamptz, cutoff time.Duration) bool {
+	until := bookableUntil(start, cutoff)
+	return until != nil && time.Now().After(*until)
+}
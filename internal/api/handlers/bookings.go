@@ -2,171 +2,364 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"math"
+	"math/rand/v2"
 	"net/http"
-	"os"
 	"time"
 
 	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
 	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/idempotency"
+	"github.com/abhinandanwadwa/overbookr/internal/money"
+	"github.com/abhinandanwadwa/overbookr/internal/payments"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
-	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type BookingsHandler struct {
-	db *db.Queries
-	DB *pgxpool.Pool
+	db          *db.Queries
+	DB          *pgxpool.Pool
+	idempotency *idempotency.Store
+	mailQueue   *mail.Queue
+	// cancelCutoff is how long before start_time users stop being able to cancel.
+	cancelCutoff time.Duration
+	// bookingCutoff is how long before start_time new bookings are refused.
+	bookingCutoff time.Duration
+	// requireVerifiedEmail refuses bookings from users who haven't confirmed their email.
+	requireVerifiedEmail bool
+	seatHub              *realtime.Hub
+	// retryPolicy bounds retries of bookings that hit serialization failures.
+	retryPolicy bookingRetryPolicy
+	// maxSeats caps seats per booking unless the event overrides it; 0 = no cap.
+	maxSeats     int
+	seatNoFormat seatNoFormat
+	// resendCooldown spaces out confirmation resends for one booking.
+	resendCooldown time.Duration
+	// paymentWindow is how long a booking for a paid event may await payment.
+	paymentWindow time.Duration
+	// payments opens, cancels and refunds the payment intents of pending
+	// bookings; nil when disabled.
+	payments payments.Provider
+	// baseCurrency prices events without a currency; priceLocale formats
+	// booking prices.
+	baseCurrency string
+	priceLocale  money.Locale
+	// pages is the page size policy of my bookings and the admin list.
+	pages pagePolicy
 }
 
 type CreateBookingRequest struct {
 	EventID   string `json:"event_id" binding:"required,uuid"`
 	HoldToken string `json:"hold_token" binding:"required"`
+	// AutoWaitlist joins the event's waitlist (202) instead of failing with
+	// 409 when the event is sold out.
+	AutoWaitlist bool `json:"auto_waitlist"`
 }
 
 type CreateBookingResponse struct {
 	ID          string    `json:"id"`
 	EventID     string    `json:"event_id"`
 	SeatNumbers []string  `json:"seat_numbers"`
+	Status      string    `json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
+	// PaymentDueAt is set while the booking awaits payment; it is abandoned
+	// if not confirmed by then.
+	PaymentDueAt *time.Time `json:"payment_due_at,omitempty"`
+	// Price itemizes the booking for events with a price.
+	Price *money.Bill `json:"price,omitempty"`
+	// Payment is set on new pending bookings when a payment provider is
+	// configured.
+	Payment *PaymentDetails `json:"payment,omitempty"`
 }
 
 type BookingResponse struct {
-	ID          string    `json:"id"`
-	EventID     string    `json:"event_id"`
-	SeatsCnt    int32     `json:"seats_count"`
-	SeatNumbers []string  `json:"seat_numbers"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string     `json:"id"`
+	EventID     string     `json:"event_id"`
+	SeatsCnt    int32      `json:"seats_count"`
+	SeatNumbers []string   `json:"seat_numbers"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty"`
+	// CancellationDeadline is when the owner loses the ability to cancel.
+	CancellationDeadline *time.Time `json:"cancellation_deadline,omitempty"`
+	// HoldToken is the hold the booking was made from and PaymentIntentID
+	// the provider's payment for it. Shown to admins only.
+	HoldToken       *string `json:"hold_token,omitempty"`
+	PaymentIntentID *string `json:"payment_intent_id,omitempty"`
 }
 
-const (
-	createBookingMaxRetries = 3
-	initialBackoff          = 100 * time.Millisecond
-)
-
-func NewBookingsHandler(dbconn *pgxpool.Pool) *BookingsHandler {
-	return &BookingsHandler{
-		db: db.New(dbconn),
-		DB: dbconn,
+// NewBookingsHandler reads the booking settings from the environment and
+// fails on any that are invalid.
+func NewBookingsHandler(dbconn *pgxpool.Pool, mailQueue *mail.Queue, seatHub *realtime.Hub, paymentWindow time.Duration, paymentProvider payments.Provider) (*BookingsHandler, error) {
+	h := &BookingsHandler{
+		db:            db.New(dbconn),
+		DB:            dbconn,
+		idempotency:   idempotency.NewStore(dbconn),
+		mailQueue:     mailQueue,
+		seatHub:       seatHub,
+		paymentWindow: paymentWindow,
+		payments:      paymentProvider,
+	}
+	var err error
+	if h.requireVerifiedEmail, err = requireEmailVerificationFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.cancelCutoff, err = cancellationCutoffFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.bookingCutoff, err = bookingCutoffFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.retryPolicy, err = bookingRetryPolicyFromEnv(); err != nil {
+		return nil, err
 	}
+	if h.maxSeats, err = maxSeatsPerBookingFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.seatNoFormat, err = seatNoFormatFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.resendCooldown, err = confirmationResendCooldownFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.baseCurrency, err = money.BaseCurrencyFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.priceLocale, err = money.LocaleFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.pages, err = pagePolicyFromEnv(); err != nil {
+		return nil, err
+	}
+	return h, nil
 }
 
-func SimpleValidateHold(ctx context.Context, q *db.Queries, token string, eventID uuid.UUID, userParam pgtype.UUID, userRole string) (int, string, bool) {
+// SimpleValidateHold checks that token names an active, unexpired hold for
+// eventID that the caller may book from. On failure it returns the status and
+// error to respond with.
+func SimpleValidateHold(ctx context.Context, q *db.Queries, token string, eventID uuid.UUID, userParam pgtype.UUID, userRole string) (int, APIError, bool) {
 	hold, err := q.GetSeatHoldForUpdateByToken(ctx, token)
 	if err != nil {
-		return http.StatusNotFound, "hold token not found", false
+		return http.StatusNotFound, apiError(CodeHoldNotFound, "hold token not found", nil), false
 	}
 
 	if hold.Status != "active" {
-		return http.StatusConflict, "hold not active", false
+		return http.StatusConflict, apiError(CodeHoldNotActive, "hold not active", hold.Status), false
 	}
 
 	if hold.ExpiresAt.Valid && hold.ExpiresAt.Time.Before(time.Now()) {
-		return http.StatusConflict, "hold expired", false
+		return http.StatusConflict, apiError(CodeHoldExpired, "hold expired", nil), false
 	}
 
 	if hold.EventID.Valid && hold.EventID.Bytes != eventID {
-		return http.StatusConflict, "hold belongs to a different event", false
+		return http.StatusConflict, apiError(CodeHoldMismatch, "hold belongs to a different event", nil), false
 	}
 
 	if hold.UserID.Valid {
 		if !userParam.Valid || hold.UserID.Bytes != userParam.Bytes {
-			return http.StatusForbidden, "hold token owned by another user", false
+			return http.StatusForbidden, apiError(CodeForbidden, "hold token owned by another user", nil), false
 		}
 	} else {
 		if userRole == "admin" {
-			return 0, "", true
+			return 0, APIError{}, true
 		}
-		return http.StatusForbidden, "hold token not claimable by this user", false
+		return http.StatusForbidden, apiError(CodeForbidden, "hold token not claimable by this user", nil), false
 	}
 
-	return 0, "", true
+	return 0, APIError{}, true
 }
 
-func sendConfirmationMail(resp CreateBookingResponse, userId pgtype.UUID, bookingsHandler *BookingsHandler) {
-	log.Println("Preparing to send confirmation email for booking ID:", resp.ID)
-	mailer := mail.NewMailer(
-		"smtp.gmail.com",
-		587,
-		os.Getenv("GMAIL_USER"),
-		os.Getenv("GMAIL_PASS"),
-	)
+// errCapacityExceeded is returned by bookSeats when the event has no room left.
+var errCapacityExceeded = errors.New("not enough capacity to book the requested seats")
+
+// errSeatAlreadyBooked is returned by bookSeats when the database refuses a
+// seat that already belongs to another active booking.
+var errSeatAlreadyBooked = errors.New("a requested seat already belongs to an active booking")
+
+// bookingStepError records which step of bookSeats failed, for the error response.
+type bookingStepError struct {
+	step string
+	err  error
+}
+
+func (e *bookingStepError) Error() string { return e.step + ": " + e.err.Error() }
+func (e *bookingStepError) Unwrap() error { return e.err }
+
+// bookSeats inserts the booking, marks its seats booked and bumps the event's
+// booked_count, all on q's transaction. Callers must already hold row locks on
+// the seats and have checked they can be booked.
+func bookSeats(ctx context.Context, q *db.Queries, arg db.InsertBookingParams) (db.InsertBookingRow, error) {
+	var row db.InsertBookingRow
 
-	user, err := bookingsHandler.db.GetUserByID(context.Background(), userId)
+	// Lock the event and check the remaining sellable capacity up front, so a
+	// drifted booked_count can't let more seats through than the event may sell.
+	ev, err := q.GetEventCapacityForUpdate(ctx, arg.EventID)
 	if err != nil {
-		log.Println("failed to get user for sending confirmation email:", err)
+		return row, &bookingStepError{step: "failed to lock event", err: err}
+	}
+	if remaining := sellableCapacity(ev.Capacity, ev.OversellPercent) - ev.BookedCount; arg.Seats > remaining {
+		return row, fmt.Errorf("%w: requested %d, remaining %d", errCapacityExceeded, arg.Seats, max(remaining, 0))
 	}
 
-	event, err := bookingsHandler.db.GetEventByID(context.Background(), pgtype.UUID{Bytes: uuid.MustParse(resp.EventID), Valid: true})
+	row, err = q.InsertBooking(ctx, arg)
 	if err != nil {
-		log.Println("failed to get event for sending confirmation email:", err)
+		if isDoubleBooking(err) {
+			return row, errSeatAlreadyBooked
+		}
+		return row, &bookingStepError{step: "failed to create booking", err: err}
 	}
-
-	newResp := mail.CreateBookingResponse{
-		ID:          resp.ID,
-		EventID:     resp.EventID,
-		SeatNumbers: resp.SeatNumbers,
-		CreatedAt:   resp.CreatedAt,
+	if err := q.UpdateSeatsToBooked(ctx, db.UpdateSeatsToBookedParams{BookingID: row.ID, Column2: arg.SeatIds}); err != nil {
+		return row, &bookingStepError{step: "failed to update seats", err: err}
 	}
-	mail.SendConfirmationMail(mailer, newResp, event, user.Email, true)
+	n, err := q.UpdateEventBookedCount(ctx, db.UpdateEventBookedCountParams{BookedCount: arg.Seats, ID: arg.EventID})
+	if err != nil {
+		if isCapacityViolation(err) {
+			return row, errCapacityExceeded
+		}
+		return row, &bookingStepError{step: "failed to update event booked_count", err: err}
+	}
+	if n == 0 {
+		return row, errCapacityExceeded
+	}
+	return row, nil
 }
 
-func (h *BookingsHandler) CreateBooking(c *gin.Context) {
-	idempotencyKey := c.GetHeader("Idempotency-Key")
-	if idempotencyKey == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key header required"})
-		return
+// bookSeatsErrorResponse maps a non-retryable bookSeats error to a response.
+func bookSeatsErrorResponse(err error) (int, APIError) {
+	if errors.Is(err, errCapacityExceeded) {
+		return http.StatusConflict, apiError(CodeCapacityExceeded, "event capacity exceeded", err.Error())
 	}
+	if errors.Is(err, errSeatAlreadyBooked) {
+		return http.StatusConflict, apiError(CodeSeatUnavailable, "some seats no longer available", err.Error())
+	}
+	var stepErr *bookingStepError
+	if errors.As(err, &stepErr) {
+		return http.StatusInternalServerError, apiError(CodeInternal, stepErr.step, stepErr.err.Error())
+	}
+	return http.StatusInternalServerError, apiError(CodeInternal, "failed to create booking", err.Error())
+}
 
-	var req CreateBookingRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request", "details": err.Error()})
-		return
+// isSerializationFailure reports whether err is a serialization failure or
+// deadlock, i.e. the transaction can simply be retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "40001" || pgErr.Code == "40P01")
+}
+
+// isCapacityViolation reports whether err is the events_booked_count_within_capacity
+// CHECK constraint rejecting an update.
+func isCapacityViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23514" && pgErr.ConstraintName == "events_booked_count_within_capacity"
+}
+
+// isDoubleBooking reports whether err is ux_booking_seats_active_seat refusing
+// a seat that is already in another active booking.
+func isDoubleBooking(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "ux_booking_seats_active_seat"
+}
+
+// UnavailableSeat is a seat from a hold that can no longer be booked with it.
+type UnavailableSeat struct {
+	// SeatNo is empty if the seat no longer exists; SeatID identifies it then.
+	SeatNo string `json:"seat_no,omitempty"`
+	SeatID string `json:"seat_id"`
+	// Status is the seat's current status ("available", "held", "booked"),
+	// or "removed" if the seat is gone.
+	Status string `json:"status"`
+	// HeldByOther is set when the seat is held under a different hold token.
+	HeldByOther bool `json:"held_by_other,omitempty"`
+}
+
+// unavailableHeldSeats diffs the hold's seat ids against the locked rows and
+// lists every seat that is missing, not held, or held under another token.
+func unavailableHeldSeats(seatIDs []pgtype.UUID, seats []db.GetSeatsForBookingByIDsRow, holdToken string) []UnavailableSeat {
+	found := make(map[[16]byte]db.GetSeatsForBookingByIDsRow, len(seats))
+	for _, s := range seats {
+		found[s.ID.Bytes] = s
 	}
 
-	eid, err := uuid.Parse(req.EventID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event_id", "details": err.Error()})
-		return
+	unavailable := make([]UnavailableSeat, 0)
+	for _, id := range seatIDs {
+		s, ok := found[id.Bytes]
+		switch {
+		case !ok:
+			unavailable = append(unavailable, UnavailableSeat{SeatID: id.String(), Status: "removed"})
+		case s.Status != "held":
+			unavailable = append(unavailable, UnavailableSeat{SeatNo: s.SeatNo, SeatID: id.String(), Status: s.Status})
+		case !s.HoldToken.Valid || s.HoldToken.String != holdToken:
+			unavailable = append(unavailable, UnavailableSeat{SeatNo: s.SeatNo, SeatID: id.String(), Status: s.Status, HeldByOther: true})
+		}
 	}
+	return unavailable
+}
 
-	ctx := context.Background()
-	eventParam := pgtype.UUID{Bytes: eid, Valid: true}
-	idempotencyParam := pgtype.Text{String: idempotencyKey, Valid: true}
+// waitBackoff sleeps for d plus up to 50% random jitter, so retries from a burst
+// of conflicting requests spread out. It returns ctx's error if the request is
+// cancelled first.
+func waitBackoff(ctx context.Context, d time.Duration) error {
+	jitter := time.Duration(rand.Int64N(int64(d)/2 + 1))
+	t := time.NewTimer(d + jitter)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
 
-	existing, err := h.db.GetBookingByEventAndIdempotency(ctx, db.GetBookingByEventAndIdempotencyParams{
-		EventID:        eventParam,
-		IdempotencyKey: idempotencyParam,
-	})
-	if err == nil && existing.ID.Bytes != uuid.Nil {
-		seatNumbers, serr := h.db.GetSeatNosByIds(ctx, existing.SeatIds)
-		if serr != nil {
-			c.JSON(http.StatusConflict, gin.H{
-				"error":      "booking already exists for this idempotency key",
-				"details":    "please use a new idempotency key if you want to create a new booking",
-				"booking_id": existing.ID.String(),
-			})
-			return
-		}
-		c.JSON(http.StatusConflict, gin.H{
-			"error":        "booking already exists for this idempotency key",
-			"details":      "please use a new idempotency key if you want to create a new booking",
-			"booking_id":   existing.ID.String(),
-			"seat_numbers": seatNumbers,
-		})
-		return
+// BuildBookingConfirmation loads a committed booking as the confirmation
+// returned to the client and queued by email. Every path that confirms a
+// booking, including resends, goes through it.
+func (h *BookingsHandler) BuildBookingConfirmation(ctx context.Context, bookingID pgtype.UUID) (CreateBookingResponse, error) {
+	conf, err := mail.BuildBookingConfirmation(ctx, h.db, bookingID)
+	if err != nil {
+		return CreateBookingResponse{}, err
 	}
+	resp := CreateBookingResponse{
+		ID:           conf.ID,
+		EventID:      conf.EventID,
+		SeatNumbers:  conf.SeatNumbers,
+		Status:       conf.Status,
+		CreatedAt:    conf.CreatedAt,
+		PaymentDueAt: h.paymentDueAt(conf.Status, conf.CreatedAt),
+		Price:        h.bookingPrice(conf.SeatNumbers, conf.UnitPriceCents, conf.Currency),
+	}
+	return resp, nil
+}
 
-	if err != nil && err != pgx.ErrNoRows {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "pre-check failed", "details": err.Error()})
+// queueConfirmation hands the confirmation email (and SMS) for a new booking to
+// the mail queue. Bookings awaiting payment get theirs once confirmed.
+func (h *BookingsHandler) queueConfirmation(resp CreateBookingResponse, userID pgtype.UUID) {
+	if resp.Status == "pending_payment" {
 		return
 	}
+	if err := h.mailQueue.Notify(mail.KindBookingConfirmation, mail.ConfirmationJob{
+		BookingID:   resp.ID,
+		EventID:     resp.EventID,
+		UserID:      userID.String(),
+		SeatNumbers: resp.SeatNumbers,
+		CreatedAt:   resp.CreatedAt,
+	}); err != nil {
+		log.Printf("failed to queue confirmation for booking %s: %v", resp.ID, err)
+	}
+}
 
+// bookingUser reads the caller's id and role as set by AuthMiddleware. The
+// role defaults to "user".
+func bookingUser(c *gin.Context) (pgtype.UUID, string) {
 	var userIDParam pgtype.UUID
 	if uidVal, ok := c.Get("user_id"); ok {
 		switch v := uidVal.(type) {
@@ -179,52 +372,184 @@ func (h *BookingsHandler) CreateBooking(c *gin.Context) {
 		}
 	}
 
-	var currentUserRole string
+	currentUserRole := "user"
 	if rv, ok := c.Get("user_role"); ok {
 		switch r := rv.(type) {
 		case string:
 			currentUserRole = r
 		case []byte:
 			currentUserRole = string(r)
+		}
+	}
+	return userIDParam, currentUserRole
+}
+
+// beginIdempotent claims idempotencyKey for the request body bound with
+// ShouldBindBodyWith. If the key was already used it replays or rejects the
+// request itself and returns false; replays carry the Idempotent-Replay header.
+// Otherwise it returns a respond func that records every response against the
+// key.
+func (h *BookingsHandler) beginIdempotent(c *gin.Context, idempotencyKey string, userIDParam pgtype.UUID) (func(status int, body any), bool) {
+	ctx := c.Request.Context()
+
+	// Keys are scoped per user so one caller can never replay another's response.
+	rawBody, _ := c.Get(gin.BodyBytesKey)
+	bodyBytes, _ := rawBody.([]byte)
+	scope := "bookings:" + userIDParam.String()
+	stored, commit, err := h.idempotency.Ensure(ctx, idempotencyKey, scope, idempotency.Fingerprint(bodyBytes))
+	if err != nil {
+		switch {
+		case errors.Is(err, idempotency.ErrFingerprintMismatch):
+			writeError(c, http.StatusConflict, CodeIdempotencyConflict, "idempotency key reused with a different request",
+				"please use a new idempotency key if you want to create a new booking")
+		case errors.Is(err, idempotency.ErrInProgress):
+			writeError(c, http.StatusConflict, CodeIdempotencyInProgress, "a request with this idempotency key is still being processed",
+				"retry shortly to receive the original response")
 		default:
-			currentUserRole = "user"
+			writeError(c, http.StatusInternalServerError, CodeInternal, "pre-check failed", err.Error())
 		}
-	} else {
-		currentUserRole = "user"
+		return nil, false
+	}
+	if stored != nil {
+		c.Header(idempotentReplayHeader, "true")
+		c.Data(stored.StatusCode, "application/json; charset=utf-8", stored.Body)
+		return nil, false
+	}
+
+	// From here on every response is recorded against the idempotency key.
+	return func(status int, body any) {
+		c.JSON(status, body)
+		// The stored response must be recorded even if the client has gone away.
+		if err := commit(context.WithoutCancel(ctx), status, body); err != nil {
+			log.Printf("failed to record idempotent response for key %s: %v", idempotencyKey, err)
+		}
+	}, true
+}
+
+// checkEventBookable loads the event and responds (returning false) unless it
+// exists, is not archived, and is still open for sales.
+func (h *BookingsHandler) checkEventBookable(ctx context.Context, eventParam pgtype.UUID, respond func(status int, body any)) (db.Event, bool) {
+	event, err := h.db.GetEventByID(ctx, eventParam)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			respond(http.StatusNotFound, apiError(CodeEventNotFound, "event not found", nil))
+			return event, false
+		}
+		respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to fetch event", err.Error()))
+		return event, false
+	}
+	if event.DeletedAt.Valid {
+		respond(http.StatusNotFound, apiError(CodeEventNotFound, "event not found", nil))
+		return event, false
+	}
+	if salesClosed(event.StartTime, h.bookingCutoff) {
+		respond(http.StatusConflict, apiError(CodeEventNotBookable, "event is no longer bookable", nil).
+			with("bookable_until", bookableUntil(event.StartTime, h.bookingCutoff)))
+		return event, false
+	}
+	return event, true
+}
+
+func (h *BookingsHandler) CreateBooking(c *gin.Context) {
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey == "" {
+		writeError(c, http.StatusBadRequest, CodeIdempotencyKeyRequired, "Idempotency-Key header required", nil)
+		return
 	}
 
-	if status, msg, ok := SimpleValidateHold(ctx, h.db, req.HoldToken, eid, userIDParam, currentUserRole); !ok {
-		c.JSON(status, gin.H{"error": msg})
+	var req CreateBookingRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request", err.Error())
+		return
+	}
+
+	eid, err := uuid.Parse(req.EventID)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid event_id", err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	eventParam := pgtype.UUID{Bytes: eid, Valid: true}
+	idempotencyParam := pgtype.Text{String: idempotencyKey, Valid: true}
+	userIDParam, currentUserRole := bookingUser(c)
+
+	if h.requireVerifiedEmail && !ensureEmailVerified(c, h.db) {
+		return
+	}
+
+	respond, ok := h.beginIdempotent(c, idempotencyKey, userIDParam)
+	if !ok {
+		return
+	}
+	// The hold is converted by now if this key already booked it.
+	if h.replayExistingBooking(c, eventParam, idempotencyKey, userIDParam, respond, func(b db.Booking) (bool, error) {
+		holdSeats, err := h.db.GetSeatHoldSeatIDs(ctx, req.HoldToken)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return err == nil && sameSeatIDs(holdSeats, b.SeatIds), err
+	}) {
+		return
+	}
+
+	if status, apiErr, ok := SimpleValidateHold(ctx, h.db, req.HoldToken, eid, userIDParam, currentUserRole); !ok {
+		respond(status, apiErr)
+		return
+	}
+
+	event, ok := h.checkEventBookable(ctx, eventParam, respond)
+	if !ok {
 		return
 	}
 
 	var seatIDs []pgtype.UUID
 	rows, err := h.DB.Query(ctx, `SELECT id FROM seats WHERE hold_token = $1 AND event_id = $2 ORDER BY id`, req.HoldToken, eid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get seats from hold", "details": err.Error()})
+		respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to get seats from hold", err.Error()))
 		return
 	}
-	defer rows.Close()
-
 	for rows.Next() {
 		var seatID pgtype.UUID
 		if err := rows.Scan(&seatID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to scan seat ID", "details": err.Error()})
+			rows.Close()
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to scan seat ID", err.Error()))
 			return
 		}
 		seatIDs = append(seatIDs, seatID)
 	}
+	// Release the pooled connection before the retry loop opens its own transactions.
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to read seats from hold", err.Error()))
+		return
+	}
 
 	if len(seatIDs) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no seats found for the provided hold token"})
+		respond(http.StatusBadRequest, apiError(CodeHoldNotFound, "no seats found for the provided hold token", nil))
+		return
+	}
+	// Checked again here for holds made before the limit was lowered.
+	if limit := seatLimitForEvent(h.maxSeats, event.Metadata); seatLimitExceeded(len(seatIDs), limit, currentUserRole) {
+		respond(seatLimitResponse(len(seatIDs), limit))
 		return
 	}
 
-	backoff := initialBackoff
-	for attempt := 0; attempt < createBookingMaxRetries; attempt++ {
+	retrier := h.retryPolicy.start()
+	// retry waits out the backoff before the next attempt. It reports false (and
+	// has already responded) if the retries are used up or the request was
+	// cancelled meanwhile.
+	retry := func() bool {
+		if err := retrier.wait(ctx); err != nil {
+			respond(retryFailedResponse(err))
+			return false
+		}
+		return true
+	}
+	for attempt := 0; attempt < h.retryPolicy.MaxAttempts; attempt++ {
 		tx, err := h.DB.Begin(ctx)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start transaction", "details": err.Error()})
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to start transaction", err.Error()))
 			return
 		}
 
@@ -238,160 +563,109 @@ func (h *BookingsHandler) CreateBooking(c *gin.Context) {
 
 		q := db.New(tx)
 
-		if status, msg, ok := SimpleValidateHold(ctx, q, req.HoldToken, eid, userIDParam, currentUserRole); !ok {
+		if status, apiErr, ok := SimpleValidateHold(ctx, q, req.HoldToken, eid, userIDParam, currentUserRole); !ok {
 			rollbackIfNeeded()
-			c.JSON(status, gin.H{"error": msg})
+			respond(status, apiErr)
 			return
 		}
 
 		seats, err := q.GetSeatsForBookingByIDs(ctx, seatIDs)
 		if err != nil {
 			rollbackIfNeeded()
-			if pgErr, ok := err.(*pgconn.PgError); ok {
-				if pgErr.Code == "40001" || pgErr.Code == "40P01" {
-					time.Sleep(backoff)
-					backoff *= 2
+			if isSerializationFailure(err) {
+				if retry() {
 					continue
 				}
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to query seats", "details": err.Error()})
-			return
-		}
-
-		if len(seats) != len(seatIDs) {
-			rollbackIfNeeded()
-			c.JSON(http.StatusConflict, gin.H{"error": "some seats no longer available"})
-			return
-		}
-
-		for _, s := range seats {
-			if s.Status != "held" {
-				rollbackIfNeeded()
-				c.JSON(http.StatusConflict, gin.H{
-					"error":  "seat is not held",
-					"status": s.Status,
-				})
-				return
-			}
-			if !s.HoldToken.Valid || s.HoldToken.String != req.HoldToken {
-				rollbackIfNeeded()
-				c.JSON(http.StatusConflict, gin.H{
-					"error": "seat held by different hold token",
-				})
 				return
 			}
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to query seats", err.Error()))
+			return
 		}
 
-		seatsCount := int32(len(seatIDs))
-		status := "active"
-
-		bookingRow, err := q.InsertBooking(ctx,
-			db.InsertBookingParams{
-				EventID:        eventParam,
-				UserID:         userIDParam,
-				Seats:          seatsCount,
-				SeatIds:        seatIDs,
-				Status:         status,
-				IdempotencyKey: idempotencyParam,
-			},
-		)
-		if err != nil {
+		if unavailable := unavailableHeldSeats(seatIDs, seats, req.HoldToken); len(unavailable) > 0 {
 			rollbackIfNeeded()
-			if pgErr, ok := err.(*pgconn.PgError); ok {
-				if pgErr.Code == "40001" || pgErr.Code == "40P01" {
-					time.Sleep(backoff)
-					backoff *= 2
-					continue
-				}
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create booking", "details": err.Error()})
+			h.respondBookingFailure(ctx, respond, http.StatusConflict, apiError(CodeSeatUnavailable, "some seats no longer available", nil).
+				with("unavailable_seats", unavailable), req.AutoWaitlist, eventParam, userIDParam, len(seatIDs))
 			return
 		}
 
-		if err := q.UpdateSeatsToBooked(ctx, db.UpdateSeatsToBookedParams{BookingID: bookingRow.ID, Column2: seatIDs}); err != nil {
+		if err := recordSeatChanges(c, q, seatReasonBooking); err != nil {
 			rollbackIfNeeded()
-			if pgErr, ok := err.(*pgconn.PgError); ok {
-				if pgErr.Code == "40001" || pgErr.Code == "40P01" {
-					time.Sleep(backoff)
-					backoff *= 2
-					continue
-				}
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update seats", "details": err.Error()})
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to record seat history", err.Error()))
 			return
 		}
 
-		rowsAffected, err := q.UpdateEventBookedCount(ctx, db.UpdateEventBookedCountParams{BookedCount: seatsCount, ID: eventParam})
+		bookingRow, err := bookSeats(ctx, q, db.InsertBookingParams{
+			EventID:        eventParam,
+			UserID:         userIDParam,
+			Seats:          int32(len(seatIDs)),
+			SeatIds:        seatIDs,
+			Status:         initialBookingStatus(event),
+			IdempotencyKey: idempotencyParam,
+			HoldToken:      pgtype.Text{String: req.HoldToken, Valid: true},
+		})
 		if err != nil {
 			rollbackIfNeeded()
-			if pgErr, ok := err.(*pgconn.PgError); ok {
-				if pgErr.Code == "40001" || pgErr.Code == "40P01" {
-					time.Sleep(backoff)
-					backoff *= 2
+			if isSerializationFailure(err) {
+				if retry() {
 					continue
 				}
+				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update event booked_count", "details": err.Error()})
-			return
-		}
-		if rowsAffected == 0 {
-			rollbackIfNeeded()
-			c.JSON(http.StatusConflict, gin.H{"error": "event capacity exceeded", "details": "not enough capacity to book the requested seats"})
+			status, body := bookSeatsErrorResponse(err)
+			h.respondBookingFailure(ctx, respond, status, body, req.AutoWaitlist, eventParam, userIDParam, len(seatIDs))
 			return
 		}
 
 		if err := q.ConvertSeatHoldToConverted(ctx, req.HoldToken); err != nil {
 			rollbackIfNeeded()
-			if pgErr, ok := err.(*pgconn.PgError); ok {
-				if pgErr.Code == "40001" || pgErr.Code == "40P01" {
-					time.Sleep(backoff)
-					backoff *= 2
+			if isSerializationFailure(err) {
+				if retry() {
 					continue
 				}
+				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update seat_hold status", "details": err.Error()})
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to update seat_hold status", err.Error()))
 			return
 		}
 
 		if err := tx.Commit(ctx); err != nil {
 			_ = tx.Rollback(ctx)
-			if pgErr, ok := err.(*pgconn.PgError); ok {
-				if pgErr.Code == "40001" || pgErr.Code == "40P01" {
-					time.Sleep(backoff)
-					backoff *= 2
+			if isSerializationFailure(err) {
+				if retry() {
 					continue
 				}
+				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit transaction", "details": err.Error()})
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to commit transaction", err.Error()))
 			return
 		}
 
-		seatNumbers, serr := h.db.GetSeatNosByIds(ctx, bookingRow.SeatIds)
+		resp, serr := h.BuildBookingConfirmation(ctx, bookingRow.ID)
 		if serr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get seat numbers", "details": serr.Error()})
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to build confirmation", serr.Error()))
 			return
 		}
-
-		resp := CreateBookingResponse{
-			ID:          bookingRow.ID.String(),
-			EventID:     bookingRow.EventID.String(),
-			SeatNumbers: seatNumbers,
-			CreatedAt:   bookingRow.CreatedAt.Time,
+		if resp.Status == "pending_payment" {
+			resp.Payment = h.startPayment(ctx, bookingRow.ID, bookingRow.EventID, resp.Price)
 		}
-		c.JSON(http.StatusCreated, resp)
+		respond(http.StatusCreated, resp)
 
-		// Send mail for the confirmed booking
-		log.Println("Sending confirmation email for booking ID:", resp.ID)
-		go sendConfirmationMail(resp, userIDParam, h)
+		h.seatHub.Publish(bookingRow.EventID.Bytes, "booked", resp.SeatNumbers)
+		h.queueConfirmation(resp, userIDParam)
 
 		return
 	}
 
-	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "could not complete booking due to concurrent conflicts; please retry"})
+	respond(http.StatusServiceUnavailable, apiError(CodeBookingContention, "could not complete booking due to concurrent conflicts; please retry", nil))
 }
 
+// GET /bookings
+// The caller's bookings, newest first. Every booking unless the client pages
+// with limit/offset or asks for the envelope: the endpoint returned them all
+// before paging existed.
 func (h *BookingsHandler) GetMyBookings(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	var uid uuid.UUID
 	if v, ok := c.Get("user_id"); ok {
@@ -402,34 +676,53 @@ func (h *BookingsHandler) GetMyBookings(c *gin.Context) {
 			if parsed, err := uuid.Parse(t); err == nil {
 				uid = parsed
 			} else {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id in context"})
+				writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid user id in context", nil)
 				return
 			}
 		default:
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id in context"})
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid user id in context", nil)
 			return
 		}
 	} else {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		writeError(c, http.StatusUnauthorized, CodeUnauthenticated, "unauthenticated", nil)
 		return
 	}
 
+	page := Page{Limit: math.MaxInt32}
+	if wantsPage(c) {
+		var perr *pageError
+		if page, perr = parsePage(c, h.pages); perr != nil {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, perr.Message, perr.Details)
+			return
+		}
+	}
+
 	userParam := pgtype.UUID{Bytes: uid, Valid: true}
-	bookings, err := h.db.GetBookingsByUser(ctx, userParam)
+	rows, err := h.db.GetBookingsByUser(ctx, db.GetBookingsByUserParams{
+		UserID: userParam,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch bookings", "details": err.Error()})
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch bookings", err.Error())
 		return
 	}
 
-	out := make([]BookingResponse, 0, len(bookings))
-	for _, b := range bookings {
+	// bookings for the same event share a start time; look each event up once
+	eventStarts := make(map[pgtype.UUID]pgtype.Timestamptz)
+
+	var total int64
+	out := make([]BookingResponse, 0, len(rows))
+	for _, r := range rows {
+		b := r.Booking
+		total = r.Total
 		seatNumbers, err := h.db.GetSeatNosByIds(ctx, b.SeatIds)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get seat numbers", "details": err.Error()})
+			writeError(c, http.StatusInternalServerError, CodeInternal, "failed to get seat numbers", err.Error())
 			return
 		}
 
-		out = append(out, BookingResponse{
+		resp := BookingResponse{
 			ID:          b.ID.String(),
 			EventID:     b.EventID.String(),
 			SeatsCnt:    b.Seats,
@@ -437,24 +730,43 @@ func (h *BookingsHandler) GetMyBookings(c *gin.Context) {
 			Status:      b.Status,
 			CreatedAt:   b.CreatedAt.Time,
 			UpdatedAt:   b.UpdatedAt.Time,
-		})
+			CheckedInAt: checkedInAt(b),
+		}
+		if h.cancelCutoff > 0 {
+			start, ok := eventStarts[b.EventID]
+			if !ok {
+				if ev, err := h.db.GetEventByID(ctx, b.EventID); err == nil {
+					start = ev.StartTime
+				}
+				eventStarts[b.EventID] = start
+			}
+			resp.CancellationDeadline = h.cancellationDeadline(start)
+		}
+		out = append(out, resp)
+	}
+	total, err = pageTotal(c, len(rows), page, total, func() (int64, error) {
+		return h.db.CountBookingsByUser(ctx, userParam)
+	})
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to count bookings", err.Error())
+		return
 	}
 
-	c.JSON(http.StatusOK, out)
+	c.JSON(http.StatusOK, listResponse(c, out, page, total))
 }
 
 func (h *BookingsHandler) GetBookingByID(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	bookingIDStr := c.Param("id")
 	bookingID, err := uuid.Parse(bookingIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid booking id", "details": err.Error()})
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid booking id", err.Error())
 		return
 	}
 
 	b, err := h.db.GetBookingByID(ctx, pgtype.UUID{Bytes: bookingID, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "booking not found", "details": err.Error()})
+		writeError(c, http.StatusNotFound, CodeBookingNotFound, "booking not found", err.Error())
 		return
 	}
 
@@ -467,26 +779,26 @@ func (h *BookingsHandler) GetBookingByID(c *gin.Context) {
 			if parsed, err := uuid.Parse(t); err == nil {
 				uid = parsed
 			} else {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id in context"})
+				writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid user id in context", nil)
 				return
 			}
 		default:
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id in context"})
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid user id in context", nil)
 			return
 		}
 	} else {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		writeError(c, http.StatusUnauthorized, CodeUnauthenticated, "unauthenticated", nil)
 		return
 	}
 
-	if !b.UserID.Valid || b.UserID.Bytes != uid {
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: only booking owner may view this booking"})
+	if !canViewBooking(c, b.UserID, uid) {
+		writeError(c, http.StatusForbidden, CodeForbidden, "forbidden: only booking owner or admin may view this booking", nil)
 		return
 	}
 
 	seatNumbers, err := h.db.GetSeatNosByIds(ctx, b.SeatIds)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get seat numbers", "details": err.Error()})
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to get seat numbers", err.Error())
 		return
 	}
 
@@ -498,6 +810,41 @@ func (h *BookingsHandler) GetBookingByID(c *gin.Context) {
 		Status:      b.Status,
 		CreatedAt:   b.CreatedAt.Time,
 		UpdatedAt:   b.UpdatedAt.Time,
+		CheckedInAt: checkedInAt(b),
+	}
+	if isAdmin(c) {
+		resp.HoldToken = textPtr(b.HoldToken)
+		resp.PaymentIntentID = textPtr(b.PaymentIntentID)
+	}
+	if h.cancelCutoff > 0 {
+		if ev, err := h.db.GetEventByID(ctx, b.EventID); err == nil {
+			resp.CancellationDeadline = h.cancellationDeadline(ev.StartTime)
+		}
 	}
 	c.JSON(http.StatusOK, resp)
 }
+
+// checkedInAt returns the booking's check-in time, or nil if it hasn't been used.
+func checkedInAt(b db.Booking) *time.Time {
+	if !b.CheckedInAt.Valid {
+		return nil
+	}
+	t := b.CheckedInAt.Time
+	return &t
+}
+
+// isAdmin reports whether the authenticated caller has the admin role.
+func isAdmin(c *gin.Context) bool {
+	role, _ := c.Get("user_role")
+	r, ok := role.(string)
+	return ok && r == "admin"
+}
+
+// canViewBooking is the access rule for booking-detail endpoints: the owner
+// or any admin.
+func canViewBooking(c *gin.Context, owner pgtype.UUID, caller uuid.UUID) bool {
+	if isAdmin(c) {
+		return true
+	}
+	return owner.Valid && owner.Bytes == caller
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// callerContext is a request context as AuthMiddleware leaves it.
+func callerContext(id uuid.UUID, role string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("user_id", id.String())
+	c.Set("user_role", role)
+	return c
+}
+
+func TestCanViewBooking(t *testing.T) {
+	owner := uuid.New()
+	other := uuid.New()
+	booking := pgtype.UUID{Bytes: owner, Valid: true}
+
+	tests := []struct {
+		name   string
+		caller uuid.UUID
+		role   string
+		owner  pgtype.UUID
+		want   bool
+	}{
+		{"owner", owner, "user", booking, true},
+		{"admin", other, "admin", booking, true},
+		{"third party", other, "user", booking, false},
+		{"organizer is not admin", other, "organizer", booking, false},
+		{"admin on ownerless booking", other, "admin", pgtype.UUID{}, true},
+		{"user on ownerless booking", uuid.Nil, "user", pgtype.UUID{}, false},
+	}
+	for _, tt := range tests {
+		c := callerContext(tt.caller, tt.role)
+		caller, ok := callerID(c)
+		if !ok {
+			t.Fatalf("%s: callerID found no caller", tt.name)
+		}
+		if got := canViewBooking(c, tt.owner, caller); got != tt.want {
+			t.Errorf("%s: canViewBooking = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
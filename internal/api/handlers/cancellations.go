@@ -3,9 +3,14 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"time"
 
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
 	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
 	"github.com/abhinandanwadwa/overbookr/internal/workers"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -14,10 +19,43 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func EnqueuePromoteEvent(conn *pgxpool.Pool, eventID uuid.UUID) {
-	promoterWorker := workers.NewWaitlistWorker(conn)
+// cancellationCutoffFromEnv reads CANCELLATION_CUTOFF, the Go duration before an
+// event's start_time after which users can no longer cancel (e.g. "24h").
+// Unset means no cutoff.
+func cancellationCutoffFromEnv() (time.Duration, error) {
+	raw := os.Getenv("CANCELLATION_CUTOFF")
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid CANCELLATION_CUTOFF %q: want a non-negative duration", raw)
+	}
+	return d, nil
+}
+
+// cancellationDeadline is the last moment a user may cancel a booking for an
+// event starting at start. It is nil when no cutoff is configured or the event
+// has no start time.
+func (h *BookingsHandler) cancellationDeadline(start pgtype.Timestamptz) *time.Time {
+	if h.cancelCutoff <= 0 || !start.Valid {
+		return nil
+	}
+	d := start.Time.Add(-h.cancelCutoff)
+	return &d
+}
 
-	if err := promoterWorker.ProcessWaitlistForEvent(context.Background(), eventID); err != nil {
+// promoteTimeout bounds the post-cancellation waitlist run, which outlives the request.
+const promoteTimeout = 30 * time.Second
+
+func EnqueuePromoteEvent(conn *pgxpool.Pool, mailQueue *mail.Queue, seatHub *realtime.Hub, eventID uuid.UUID) {
+	promoterWorker := workers.NewWaitlistWorker(conn, mailQueue, seatHub)
+
+	// Runs after the response is sent, so it can't use the request context.
+	ctx, cancel := context.WithTimeout(context.Background(), promoteTimeout)
+	defer cancel()
+
+	if err := promoterWorker.ProcessWaitlistForEvent(ctx, eventID); err != nil {
 		fmt.Printf("waitlist promotion failed for event %s: %v\n", eventID, err)
 	}
 }
@@ -25,11 +63,11 @@ func EnqueuePromoteEvent(conn *pgxpool.Pool, eventID uuid.UUID) {
 // CancelBookingHandler cancels a booking (owner or admin).
 // Routes: DELETE /bookings/:id  OR  POST /bookings/:id/cancel
 func (h *BookingsHandler) CancelBooking(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	bookingIDStr := c.Param("id")
 	bookingID, err := uuid.Parse(bookingIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid booking id", "details": err.Error()})
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid booking id", err.Error())
 		return
 	}
 
@@ -55,7 +93,7 @@ func (h *BookingsHandler) CancelBooking(c *gin.Context) {
 	// Begin transaction
 	tx, err := h.DB.Begin(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start transaction", "details": err.Error()})
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to start transaction", err.Error())
 		return
 	}
 	// ensure rollback if we exit before commit
@@ -68,10 +106,10 @@ func (h *BookingsHandler) CancelBooking(c *gin.Context) {
 	if err != nil {
 		// if not found
 		if err == pgx.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "booking not found"})
+			writeError(c, http.StatusNotFound, CodeBookingNotFound, "booking not found", nil)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch booking", "details": err.Error()})
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch booking", err.Error())
 		return
 	}
 
@@ -83,16 +121,31 @@ func (h *BookingsHandler) CancelBooking(c *gin.Context) {
 		}
 	}
 	if !(isOwner || currentUserRole == "admin") {
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: only booking owner or admin can cancel"})
+		writeError(c, http.StatusForbidden, CodeForbidden, "forbidden: only booking owner or admin can cancel", nil)
 		return
 	}
 
 	// Only cancel if booking is 'active'
 	if bookingRow.Status != "active" {
-		c.JSON(http.StatusConflict, gin.H{"error": "booking cannot be cancelled", "status": bookingRow.Status})
+		c.JSON(http.StatusConflict, apiError(CodeBookingNotActive, "booking cannot be cancelled", nil).with("status", bookingRow.Status))
 		return
 	}
 
+	// Enforce the cancellation window; admins may cancel regardless.
+	if currentUserRole != "admin" && h.cancelCutoff > 0 {
+		event, err := q.GetEventByID(ctx, bookingRow.EventID)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch event", err.Error())
+			return
+		}
+		if deadline := h.cancellationDeadline(event.StartTime); deadline != nil && time.Now().After(*deadline) {
+			c.JSON(http.StatusConflict, apiError(CodeCancellationClosed, "cancellation window has closed",
+				fmt.Sprintf("bookings can't be cancelled within %s of the event start", h.cancelCutoff)).
+				with("cancellation_deadline", deadline))
+			return
+		}
+	}
+
 	// collect seat_ids from bookingRow.SeatIds (pgtype.UUID array)
 	seatIDs := make([]pgtype.UUID, 0, len(bookingRow.SeatIds))
 	seatIDs = append(seatIDs, bookingRow.SeatIds...)
@@ -102,50 +155,112 @@ func (h *BookingsHandler) CancelBooking(c *gin.Context) {
 	if nSeats == 0 {
 		// nothing to do but still mark booking cancelled
 		if err := q.UpdateBookingToCancelled(ctx, pgtype.UUID{Bytes: bookingID, Valid: true}); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel booking", "details": err.Error()})
+			writeError(c, http.StatusInternalServerError, CodeInternal, "failed to cancel booking", err.Error())
 			return
 		}
 		// commit
 		if err := tx.Commit(ctx); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit transaction", "details": err.Error()})
+			writeError(c, http.StatusInternalServerError, CodeInternal, "failed to commit transaction", err.Error())
 			return
 		}
 		// enqueue promotion job after commit
-		go EnqueuePromoteEvent(h.DB, bookingRow.EventID.Bytes)
+		go EnqueuePromoteEvent(h.DB, h.mailQueue, h.seatHub, bookingRow.EventID.Bytes)
 		c.JSON(http.StatusOK, gin.H{"id": bookingID.String(), "status": "cancelled"})
 		return
 	}
 
+	if err := recordSeatChanges(c, q, seatReasonCancellation); err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to record seat history", err.Error())
+		return
+	}
+
 	// 2) Update booking.status -> 'cancelled'
 	if err := q.UpdateBookingToCancelled(ctx, pgtype.UUID{Bytes: bookingID, Valid: true}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel booking", "details": err.Error()})
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to cancel booking", err.Error())
 		return
 	}
 
 	// 3) Update seats -> available
 	if err := q.UpdateSeatsToAvailableByIds(ctx, seatIDs); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update seats", "details": err.Error()})
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to update seats", err.Error())
 		return
 	}
+	// Only needed for the live seat stream, so a failure isn't fatal.
+	releasedSeatNos, err := q.GetSeatNosByIds(ctx, seatIDs)
+	if err != nil {
+		log.Printf("cancel booking %s: failed to load seat numbers: %v", bookingID, err)
+	}
 
 	// 4) Update events.booked_count = booked_count - nSeats
 	// Pass negative delta
 	if err := q.UpdateEventBookedCountByDelta(ctx, db.UpdateEventBookedCountByDeltaParams{BookedCount: -nSeats, ID: bookingRow.EventID}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update event count", "details": err.Error()})
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to update event count", err.Error())
 		return
 	}
 
 	// Commit transaction
 	if err := tx.Commit(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit", "details": err.Error()})
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to commit", err.Error())
 		return
 	}
 
+	h.seatHub.Publish(bookingRow.EventID.Bytes, "available", releasedSeatNos)
+
 	// After commit, enqueue promote job to process waitlist
-	go EnqueuePromoteEvent(h.DB, bookingRow.EventID.Bytes)
+	go EnqueuePromoteEvent(h.DB, h.mailQueue, h.seatHub, bookingRow.EventID.Bytes)
 
 	c.JSON(http.StatusOK, gin.H{
 		"id":     bookingID.String(),
 		"status": "cancelled",
 	})
 }
+
+// POST /events/:id/bookings/cancel-all
+// For an event that has been called off: cancels every active and
+// pending_payment booking and ends every active hold, frees the seats and
+// emails the holders. Admin only. Repeating it is harmless; it reports
+// nothing left to cancel. Seats are not offered to the waitlist, so archive
+// the event afterwards with DELETE /events/:id. A large event takes a while,
+// so the route runs without DB_TIMEOUT and the server's write timeout, and
+// keeps going if the client disconnects.
+func (h *BookingsHandler) CancelAllEventBookings(c *gin.Context) {
+	eid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid event id", err.Error())
+		return
+	}
+	ctx := c.Request.Context()
+	eventParam := pgtype.UUID{Bytes: eid, Valid: true}
+
+	if _, err := h.db.GetEventByID(ctx, eventParam); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(c, http.StatusNotFound, CodeEventNotFound, "event not found", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch event", err.Error())
+		return
+	}
+
+	var actor string
+	if id, ok := callerID(c); ok {
+		actor = id.String()
+	}
+	// Without this the server's WriteTimeout would cut the response off while
+	// the batches carry on, so refuse to start rather than lose the summary.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to lift the write timeout for cancel-all", err.Error())
+		return
+	}
+	summary, err := workers.CancelAllBookings(context.WithoutCancel(ctx), h.DB, h.mailQueue, h.seatHub, h.payments, eventParam, actor)
+	if err != nil {
+		// Batches already committed stay cancelled; running it again finishes the rest.
+		c.JSON(http.StatusInternalServerError, apiError(CodeInternal, "failed to cancel bookings", err.Error()).
+			with("cancelled", summary.Cancelled).with("seats_freed", summary.SeatsFreed))
+		return
+	}
+	if summary.Skipped {
+		writeError(c, http.StatusConflict, CodeCancelAllBusy, "reconcile or another cancel-all is running; retry shortly", nil)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type DirectBookingRequest struct {
+	EventID string   `json:"event_id" binding:"required,uuid"`
+	SeatNos []string `json:"seat_nos" binding:"required,min=1,dive,required"`
+	// AutoWaitlist joins the event's waitlist (202) instead of failing with
+	// 409 when the event is sold out.
+	AutoWaitlist bool `json:"auto_waitlist"`
+}
+
+// POST /bookings/direct
+// CreateDirectBooking books available seats in one step, without a hold: it
+// locks the seats, checks they are available and books them in a single
+// transaction. Idempotency, the booking cutoff, capacity checks, serialization
+// retries and auto_waitlist work as in CreateBooking.
+func (h *BookingsHandler) CreateDirectBooking(c *gin.Context) {
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey == "" {
+		writeError(c, http.StatusBadRequest, CodeIdempotencyKeyRequired, "Idempotency-Key header required", nil)
+		return
+	}
+
+	var req DirectBookingRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request", err.Error())
+		return
+	}
+
+	eid, err := uuid.Parse(req.EventID)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid event_id", err.Error())
+		return
+	}
+	seatNos := h.seatNoFormat.unique(req.SeatNos)
+	if len(seatNos) == 0 {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "no valid seat numbers provided", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	eventParam := pgtype.UUID{Bytes: eid, Valid: true}
+	idempotencyParam := pgtype.Text{String: idempotencyKey, Valid: true}
+	userIDParam, currentUserRole := bookingUser(c)
+
+	if h.requireVerifiedEmail && !ensureEmailVerified(c, h.db) {
+		return
+	}
+
+	respond, ok := h.beginIdempotent(c, idempotencyKey, userIDParam)
+	if !ok {
+		return
+	}
+	if h.replayExistingBooking(c, eventParam, idempotencyKey, userIDParam, respond, func(b db.Booking) (bool, error) {
+		booked, err := h.db.GetSeatNosByIds(ctx, b.SeatIds)
+		return err == nil && sameSeatNos(booked, seatNos), err
+	}) {
+		return
+	}
+
+	event, ok := h.checkEventBookable(ctx, eventParam, respond)
+	if !ok {
+		return
+	}
+	if limit := seatLimitForEvent(h.maxSeats, event.Metadata); seatLimitExceeded(len(seatNos), limit, currentUserRole) {
+		respond(seatLimitResponse(len(seatNos), limit))
+		return
+	}
+
+	retrier := h.retryPolicy.start()
+	retry := func() bool {
+		if err := retrier.wait(ctx); err != nil {
+			respond(retryFailedResponse(err))
+			return false
+		}
+		return true
+	}
+	for attempt := 0; attempt < h.retryPolicy.MaxAttempts; attempt++ {
+		tx, err := h.DB.Begin(ctx)
+		if err != nil {
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to start transaction", err.Error()))
+			return
+		}
+		q := db.New(tx)
+
+		seatIDs, status, body, err := lockAvailableSeats(ctx, q, eventParam, seatNos)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			if isSerializationFailure(err) {
+				if retry() {
+					continue
+				}
+				return
+			}
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to query seats", err.Error()))
+			return
+		}
+		if body != nil {
+			_ = tx.Rollback(ctx)
+			h.respondBookingFailure(ctx, respond, status, *body, req.AutoWaitlist, eventParam, userIDParam, len(seatNos))
+			return
+		}
+		if err := recordSeatChanges(c, q, seatReasonDirectBooking); err != nil {
+			_ = tx.Rollback(ctx)
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to record seat history", err.Error()))
+			return
+		}
+
+		bookingRow, err := bookSeats(ctx, q, db.InsertBookingParams{
+			EventID:        eventParam,
+			UserID:         userIDParam,
+			Seats:          int32(len(seatIDs)),
+			SeatIds:        seatIDs,
+			Status:         initialBookingStatus(event),
+			IdempotencyKey: idempotencyParam,
+		})
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			if isSerializationFailure(err) {
+				if retry() {
+					continue
+				}
+				return
+			}
+			status, body := bookSeatsErrorResponse(err)
+			h.respondBookingFailure(ctx, respond, status, body, req.AutoWaitlist, eventParam, userIDParam, len(seatNos))
+			return
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			_ = tx.Rollback(ctx)
+			if isSerializationFailure(err) {
+				if retry() {
+					continue
+				}
+				return
+			}
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to commit transaction", err.Error()))
+			return
+		}
+
+		resp, err := h.BuildBookingConfirmation(ctx, bookingRow.ID)
+		if err != nil {
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to build confirmation", err.Error()))
+			return
+		}
+		if resp.Status == "pending_payment" {
+			resp.Payment = h.startPayment(ctx, bookingRow.ID, bookingRow.EventID, resp.Price)
+		}
+		respond(http.StatusCreated, resp)
+
+		h.seatHub.Publish(bookingRow.EventID.Bytes, "booked", resp.SeatNumbers)
+		h.queueConfirmation(resp, userIDParam)
+		return
+	}
+
+	respond(http.StatusServiceUnavailable, apiError(CodeBookingContention, "could not complete booking due to concurrent conflicts; please retry", nil))
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// maxEventImportRows bounds one import so the transaction stays short.
+const maxEventImportRows = 500
+
+// EventImportResult is the outcome for one row of an import. Index is the
+// row's position in the JSON array or, for CSV, among the data rows (the
+// header is not counted).
+type EventImportResult struct {
+	Index   int    `json:"index"`
+	Status  int    `json:"status"`
+	ID      string `json:"id,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+type EventImportResponse struct {
+	Created int                 `json:"created"`
+	Failed  int                 `json:"failed"`
+	Results []EventImportResult `json:"results"`
+}
+
+// POST /events/import
+// Creates many events at once from a JSON array of CreateEventRequest objects,
+// or from CSV (a multipart "file" upload or a text/csv body) with a header row
+// naming the columns. Every row gets CreateEvent's validation; valid rows are
+// inserted in one transaction, each under its own savepoint so one failing
+// insert doesn't sink the rest. Responds 201 when every row was created and
+// 207 with per-row results otherwise.
+func (h *EventsHandler) ImportEvents(c *gin.Context) {
+	rows, err := readEventImport(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import", "details": err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import", "details": "no events provided"})
+		return
+	}
+	if len(rows) > maxEventImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid import",
+			"details": fmt.Sprintf("at most %d events per import, got %d", maxEventImportRows, len(rows)),
+		})
+		return
+	}
+
+	resp := EventImportResponse{Results: make([]EventImportResult, len(rows))}
+	valid := make([]int, 0, len(rows))
+	seatNos := make([][]string, len(rows))
+	for i, row := range rows {
+		resp.Results[i] = EventImportResult{Index: i}
+		if row.err != nil {
+			resp.Results[i].Status = http.StatusBadRequest
+			resp.Results[i].Error = "Invalid input"
+			resp.Results[i].Details = row.err.Error()
+			continue
+		}
+		if err := binding.Validator.ValidateStruct(&row.req); err != nil {
+			resp.Results[i].Status = http.StatusBadRequest
+			resp.Results[i].Error = "Invalid input"
+			resp.Results[i].Details = err.Error()
+			continue
+		}
+		seats, label, err := h.validateNewEvent(row.req)
+		if err != nil {
+			resp.Results[i].Status = http.StatusBadRequest
+			resp.Results[i].Error = label
+			resp.Results[i].Details = err.Error()
+			continue
+		}
+		seatNos[i] = seats
+		valid = append(valid, i)
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start transaction", "details": err.Error()})
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, i := range valid {
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start savepoint", "details": err.Error()})
+			return
+		}
+		event, _, err := insertEvent(ctx, db.New(sp), newEventParams(c, rows[i].req), seatNos[i], rows[i].req.Seats.section())
+		if err != nil {
+			_ = sp.Rollback(ctx)
+			resp.Results[i].Status = http.StatusUnprocessableEntity
+			resp.Results[i].Error = "Failed to create event"
+			resp.Results[i].Details = err.Error()
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to release savepoint", "details": err.Error()})
+			return
+		}
+		resp.Results[i].Status = http.StatusCreated
+		resp.Results[i].ID = event.ID.String()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit transaction", "details": err.Error()})
+		return
+	}
+
+	for _, r := range resp.Results {
+		if r.Status == http.StatusCreated {
+			resp.Created++
+		} else {
+			resp.Failed++
+		}
+	}
+	status := http.StatusCreated
+	if resp.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, resp)
+}
+
+// importRow is one parsed row; err is set when the row itself could not be
+// decoded, which is reported per row rather than failing the import.
+type importRow struct {
+	req CreateEventRequest
+	err error
+}
+
+// readEventImport decodes the request body as CSV or as a JSON array,
+// depending on how it was sent.
+func readEventImport(c *gin.Context) ([]importRow, error) {
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("multipart upload needs a CSV in the %q field: %w", "file", err)
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readEventCSV(f)
+	}
+	if c.ContentType() == "text/csv" {
+		return readEventCSV(c.Request.Body)
+	}
+
+	var items []json.RawMessage
+	if err := c.ShouldBindJSON(&items); err != nil {
+		return nil, fmt.Errorf("body must be a JSON array of events: %w", err)
+	}
+	rows := make([]importRow, len(items))
+	for i, item := range items {
+		dec := json.NewDecoder(bytes.NewReader(item))
+		rows[i].err = dec.Decode(&rows[i].req)
+	}
+	return rows, nil
+}
+
+// eventImportColumns are the CSV columns understood by the import; name,
+// venue, start_time and capacity are required. start_time is RFC 3339 and
+// metadata, when present, is a JSON object.
+var eventImportColumns = []string{"name", "venue", "start_time", "capacity", "description", "image_url", "metadata", "sender_name", "reply_to"}
+
+func readEventCSV(r io.Reader) ([]importRow, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		col[name] = i
+	}
+	for _, required := range eventImportColumns[:4] {
+		if _, ok := col[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing the %q column", required)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read CSV: %w", err)
+		}
+		field := func(name string) string {
+			if i, ok := col[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		rows = append(rows, parseEventCSVRow(field))
+	}
+}
+
+func parseEventCSVRow(field func(string) string) importRow {
+	req := CreateEventRequest{
+		Name:        field("name"),
+		Venue:       field("venue"),
+		Description: field("description"),
+		ImageURL:    field("image_url"),
+		SenderName:  field("sender_name"),
+		ReplyTo:     field("reply_to"),
+	}
+	if raw := field("start_time"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return importRow{err: fmt.Errorf("start_time must be RFC 3339, got %q", raw)}
+		}
+		req.StartTime = t
+	}
+	if raw := field("capacity"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return importRow{err: fmt.Errorf("capacity must be an integer, got %q", raw)}
+		}
+		req.Capacity = int32(n)
+	}
+	if raw := field("metadata"); raw != "" {
+		req.Metadata = json.RawMessage(raw)
+	}
+	return importRow{req: req}
+}
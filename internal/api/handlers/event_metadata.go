@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// defaultEventMetadataMaxBytes caps event metadata unless
+// EVENT_METADATA_MAX_BYTES says otherwise.
+const defaultEventMetadataMaxBytes = 16 << 10
+
+// metadataValidator checks event metadata before it is stored: it must be a
+// JSON object no larger than maxBytes, the keys the app reads must have the
+// right types, and it must match schema when one is configured.
+type metadataValidator struct {
+	maxBytes int
+	schema   *jsonschema.Schema
+}
+
+// metadataValidatorFromEnv reads EVENT_METADATA_MAX_BYTES and
+// EVENT_METADATA_SCHEMA_FILE, the path of an optional JSON Schema the
+// metadata must satisfy.
+func metadataValidatorFromEnv() (metadataValidator, error) {
+	v := metadataValidator{maxBytes: defaultEventMetadataMaxBytes}
+	if raw := os.Getenv("EVENT_METADATA_MAX_BYTES"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return v, fmt.Errorf("invalid EVENT_METADATA_MAX_BYTES %q: want a positive integer", raw)
+		}
+		v.maxBytes = n
+	}
+	if path := os.Getenv("EVENT_METADATA_SCHEMA_FILE"); path != "" {
+		schema, err := jsonschema.NewCompiler().Compile(path)
+		if err != nil {
+			return v, fmt.Errorf("EVENT_METADATA_SCHEMA_FILE: %w", err)
+		}
+		v.schema = schema
+	}
+	return v, nil
+}
+
+// validate returns a client-facing error if raw is not acceptable metadata.
+// Absent or null metadata is always accepted.
+func (v metadataValidator) validate(raw []byte) error {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	if len(trimmed) > v.maxBytes {
+		return fmt.Errorf("metadata must be at most %d bytes, got %d", v.maxBytes, len(trimmed))
+	}
+
+	var fields map[string]json.RawMessage
+	if trimmed[0] != '{' || json.Unmarshal(trimmed, &fields) != nil {
+		return errors.New("metadata must be a JSON object")
+	}
+	if rawMax, ok := fields[maxSeatsMetadataKey]; ok {
+		var n int
+		if err := json.Unmarshal(rawMax, &n); err != nil || n <= 0 {
+			return fmt.Errorf("metadata.%s must be a positive integer", maxSeatsMetadataKey)
+		}
+	}
+
+	if v.schema != nil {
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(trimmed))
+		if err != nil {
+			return fmt.Errorf("metadata is not valid JSON: %w", err)
+		}
+		if err := v.schema.Validate(doc); err != nil {
+			return fmt.Errorf("metadata does not match the event metadata schema: %w", err)
+		}
+	}
+	return nil
+}
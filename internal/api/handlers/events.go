@@ -3,11 +3,19 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/abhinandanwadwa/overbookr/internal/api/middleware"
 	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/money"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -15,56 +23,557 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// startTimeGrace absorbs clock skew between clients and the server when
+// checking that an event starts in the future.
+const startTimeGrace = 1 * time.Minute
+
+// defaultMaxEventCapacity caps event capacity unless EVENT_MAX_CAPACITY says otherwise.
+const defaultMaxEventCapacity = 100000
+
+// maxImageURLLength bounds image_url; longer values are almost always data
+// URIs or tracking junk rather than a real image link.
+const maxImageURLLength = 2048
+
+// maxSenderNameLength bounds sender_name, the display name on booking emails.
+const maxSenderNameLength = 100
+
 type EventsHandler struct {
 	db *db.Queries
 	DB *pgxpool.Pool
+	// minLeadTime is how far in the future a new event must start.
+	minLeadTime time.Duration
+	maxCapacity int32
+	// bookingCutoff feeds bookable_until; see bookingCutoffFromEnv.
+	bookingCutoff time.Duration
+	// seatHub feeds the live seat stream.
+	seatHub      *realtime.Hub
+	seatNoFormat seatNoFormat
+	metadata     metadataValidator
+	// baseCurrency prices events without a currency; priceLocale formats
+	// prices in responses.
+	baseCurrency string
+	priceLocale  money.Locale
+	// limitedPercent is the capacity share left at which an event becomes
+	// "limited".
+	limitedPercent int
+	// pages is the events listing's page size policy.
+	pages pagePolicy
+}
+
+// eventMinLeadTimeFromEnv reads EVENT_MIN_LEAD_TIME, the Go duration a new
+// event's start_time must be ahead of now (e.g. "30m"). Unset means the event
+// only has to start in the future.
+func eventMinLeadTimeFromEnv() (time.Duration, error) {
+	raw := os.Getenv("EVENT_MIN_LEAD_TIME")
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid EVENT_MIN_LEAD_TIME %q: want a non-negative duration", raw)
+	}
+	return d, nil
+}
+
+// eventMaxCapacityFromEnv reads EVENT_MAX_CAPACITY, falling back to
+// defaultMaxEventCapacity when unset.
+func eventMaxCapacityFromEnv() (int32, error) {
+	raw := os.Getenv("EVENT_MAX_CAPACITY")
+	if raw == "" {
+		return defaultMaxEventCapacity, nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid EVENT_MAX_CAPACITY %q: want a positive integer", raw)
+	}
+	return int32(n), nil
+}
+
+// validateCapacity checks that capacity is positive and within the configured maximum.
+func (h *EventsHandler) validateCapacity(capacity int32) error {
+	if capacity <= 0 {
+		return fmt.Errorf("capacity must be a positive integer, got %d", capacity)
+	}
+	if capacity > h.maxCapacity {
+		return fmt.Errorf("capacity must not exceed %d, got %d", h.maxCapacity, capacity)
+	}
+	return nil
+}
+
+// overbookingBuffer is how many seats beyond capacity oversellPercent lets an
+// event sell. It rounds down, as events_booked_count_within_capacity does.
+func overbookingBuffer(capacity, oversellPercent int32) int32 {
+	return int32(int64(capacity) * int64(oversellPercent) / 100)
+}
+
+// sellableCapacity is how many seats an event may have booked: its capacity
+// plus the overbooking buffer.
+func sellableCapacity(capacity, oversellPercent int32) int32 {
+	return capacity + overbookingBuffer(capacity, oversellPercent)
+}
+
+// validateNewStartTime checks start against the configured lead time, allowing
+// startTimeGrace for clock skew.
+func (h *EventsHandler) validateNewStartTime(start time.Time) error {
+	earliest := time.Now().Add(h.minLeadTime - startTimeGrace)
+	if start.Before(earliest) {
+		if h.minLeadTime > 0 {
+			return fmt.Errorf("start_time must be at least %s in the future", h.minLeadTime)
+		}
+		return fmt.Errorf("start_time must be in the future")
+	}
+	return nil
+}
+
+// validateImageURL checks that an event image is an absolute http(s) URL.
+// Empty is allowed and means no image.
+func validateImageURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	if len(raw) > maxImageURLLength {
+		return fmt.Errorf("image_url must be at most %d characters", maxImageURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("image_url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// validateSenderName checks the display name used on an event's booking
+// emails. Empty is allowed and means the global default.
+func validateSenderName(name string) error {
+	if len(name) > maxSenderNameLength {
+		return fmt.Errorf("sender_name must be at most %d characters", maxSenderNameLength)
+	}
+	if strings.ContainsAny(name, "\r\n") {
+		return fmt.Errorf("sender_name must not contain line breaks")
+	}
+	return nil
+}
+
+// validateReplyTo checks that an event's reply-to is a bare address such as
+// "box-office@example.com". Empty is allowed and means no Reply-To.
+func validateReplyTo(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	addr, err := netmail.ParseAddress(raw)
+	if err != nil || addr.Name != "" || addr.Address != raw {
+		return fmt.Errorf("reply_to must be a plain email address")
+	}
+	return nil
 }
 
 type CreateEventRequest struct {
-	Name      string          `json:"name" binding:"required"`
-	Venue     string          `json:"venue" binding:"required"`
-	StartTime time.Time       `json:"start_time" binding:"required"`
-	Capacity  int32           `json:"capacity" binding:"required"`
-	Metadata  json.RawMessage `json:"metadata"`
+	Name        string          `json:"name" binding:"required"`
+	Venue       string          `json:"venue" binding:"required"`
+	StartTime   time.Time       `json:"start_time" binding:"required"`
+	Capacity    int32           `json:"capacity" binding:"required"`
+	Description string          `json:"description" binding:"max=10000"`
+	ImageURL    string          `json:"image_url"`
+	Metadata    json.RawMessage `json:"metadata"`
+	// SenderName and ReplyTo override the From display name and Reply-To of
+	// this event's booking emails.
+	SenderName string `json:"sender_name"`
+	ReplyTo    string `json:"reply_to"`
+	// RequiresPayment makes bookings wait in pending_payment until payment
+	// is confirmed.
+	RequiresPayment bool `json:"requires_payment"`
+	// PriceCents is the price of one seat in the smallest unit of Currency,
+	// an ISO 4217 code that defaults to BASE_CURRENCY.
+	PriceCents int32  `json:"price_cents" binding:"min=0"`
+	Currency   string `json:"currency"`
+	// OversellPercent lets bookings exceed capacity by that share of it, to
+	// make up for expected no-shows.
+	OversellPercent int32 `json:"oversell_percent" binding:"min=0,max=100"`
+	// Seats optionally creates the event's seats in the same transaction;
+	// POST /events/:id/seats can add more later.
+	Seats *SeatLayout `json:"seats"`
 }
 
-type CreateEventResponse struct {
-	ID        string          `json:"id"`
-	Name      string          `json:"name"`
-	Venue     string          `json:"venue"`
-	StartTime time.Time       `json:"start_time"`
-	Capacity  int32           `json:"capacity"`
-	Metadata  json.RawMessage `json:"metadata"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+// validateNewEvent runs the checks CreateEvent applies beyond request binding
+// and returns the seat numbers to create with the event. On failure it returns
+// the error label for the response along with the cause.
+func (h *EventsHandler) validateNewEvent(req CreateEventRequest) ([]string, string, error) {
+	if err := h.validateCapacity(req.Capacity); err != nil {
+		return nil, "invalid capacity", err
+	}
+	if err := h.validateNewStartTime(req.StartTime); err != nil {
+		return nil, "invalid start_time", err
+	}
+	if err := h.metadata.validate(req.Metadata); err != nil {
+		return nil, "invalid metadata", err
+	}
+	if err := validateImageURL(req.ImageURL); err != nil {
+		return nil, "invalid image_url", err
+	}
+	if err := validateSenderName(req.SenderName); err != nil {
+		return nil, "invalid sender_name", err
+	}
+	if err := validateReplyTo(req.ReplyTo); err != nil {
+		return nil, "invalid reply_to", err
+	}
+	if _, err := currencyParam(req.Currency); err != nil {
+		return nil, "invalid currency", err
+	}
+	if req.Seats == nil {
+		return nil, "", nil
+	}
+	seatNos, err := req.Seats.seatNos(h.seatNoFormat)
+	if err != nil {
+		return nil, "invalid seats", err
+	}
+	if sellable := sellableCapacity(req.Capacity, req.OversellPercent); len(seatNos) > int(sellable) {
+		return nil, "invalid seats", fmt.Errorf("layout has %d seats but sellable capacity is %d", len(seatNos), sellable)
+	}
+	return seatNos, "", nil
 }
 
-type UpdateEventRequest struct {
-	Name      *string          `json:"name"`
-	Venue     *string          `json:"venue"`
-	StartTime *time.Time       `json:"start_time"`
-	Capacity  *int32           `json:"capacity"`
-	Metadata  *json.RawMessage `json:"metadata"`
+// insertEvent creates the event and then its seats in section with q,
+// returning the number of seats created. Run it in a transaction so a seat
+// failure also undoes the event.
+func insertEvent(ctx context.Context, q *db.Queries, params db.AddEventParams, seatNos []string, section string) (db.AddEventRow, int, error) {
+	event, err := q.AddEvent(ctx, params)
+	if err != nil {
+		return db.AddEventRow{}, 0, err
+	}
+	if len(seatNos) == 0 {
+		return event, 0, nil
+	}
+	seats, err := q.BulkInsertSeats(ctx, db.BulkInsertSeatsParams{EventID: event.ID, Column2: seatNos, Section: section})
+	if err != nil {
+		return db.AddEventRow{}, 0, fmt.Errorf("create seats: %w", err)
+	}
+	return event, len(seats), nil
 }
 
-type EventResponse struct {
+// newEventParams maps a validated request onto AddEvent. The creator owns the
+// event, which is what lets organizers manage it later.
+func newEventParams(c *gin.Context, req CreateEventRequest) db.AddEventParams {
+	params := db.AddEventParams{
+		Name:            req.Name,
+		Venue:           pgtype.Text{String: req.Venue, Valid: true},
+		StartTime:       pgtype.Timestamptz{Time: req.StartTime, Valid: true},
+		Capacity:        req.Capacity,
+		Metadata:        req.Metadata,
+		Description:     optionalText(req.Description),
+		ImageUrl:        optionalText(req.ImageURL),
+		SenderName:      optionalText(req.SenderName),
+		ReplyTo:         optionalText(req.ReplyTo),
+		RequiresPayment: req.RequiresPayment,
+		PriceCents:      req.PriceCents,
+		OversellPercent: req.OversellPercent,
+	}
+	// validateNewEvent has checked the currency.
+	params.Currency, _ = currencyParam(req.Currency)
+	if uid, ok := callerID(c); ok {
+		params.OwnerID = pgtype.UUID{Bytes: uid, Valid: true}
+	}
+	return params
+}
+
+type CreateEventResponse struct {
 	ID          string          `json:"id"`
 	Name        string          `json:"name"`
-	Venue       *string         `json:"venue"`
-	StartTime   *time.Time      `json:"start_time"`
+	Venue       string          `json:"venue"`
+	StartTime   time.Time       `json:"start_time"`
 	Capacity    int32           `json:"capacity"`
-	BookedCount int32           `json:"booked_count"`
-	Available   int32           `json:"available"`
+	Description *string         `json:"description,omitempty"`
+	ImageURL    *string         `json:"image_url,omitempty"`
+	SenderName  *string         `json:"sender_name,omitempty"`
+	ReplyTo     *string         `json:"reply_to,omitempty"`
 	Metadata    json.RawMessage `json:"metadata"`
 	CreatedAt   time.Time       `json:"created_at"`
 	UpdatedAt   time.Time       `json:"updated_at"`
+	Version     int32           `json:"version"`
+	OwnerID     *string         `json:"owner_id,omitempty"`
+	// SeatCount is how many seats were created from the request's layout.
+	SeatCount       int   `json:"seat_count"`
+	RequiresPayment bool  `json:"requires_payment"`
+	PriceCents      int32 `json:"price_cents"`
+	// Currency is the event's currency or BASE_CURRENCY; Price is PriceCents
+	// formatted in it, omitted when the event is free.
+	Currency string `json:"currency"`
+	Price    string `json:"price,omitempty"`
+	// SellableCapacity is Capacity plus OverbookingBuffer, the seats
+	// OversellPercent allows beyond it.
+	OversellPercent   int32 `json:"oversell_percent"`
+	OverbookingBuffer int32 `json:"overbooking_buffer"`
+	SellableCapacity  int32 `json:"sellable_capacity"`
+}
+
+type UpdateEventRequest struct {
+	Name      *string    `json:"name"`
+	Venue     *string    `json:"venue"`
+	StartTime *time.Time `json:"start_time"`
+	Capacity  *int32     `json:"capacity"`
+	// Description, ImageURL, SenderName and ReplyTo are cleared by sending
+	// an empty string.
+	Description *string          `json:"description" binding:"omitempty,max=10000"`
+	ImageURL    *string          `json:"image_url"`
+	SenderName  *string          `json:"sender_name"`
+	ReplyTo     *string          `json:"reply_to"`
+	Metadata    *json.RawMessage `json:"metadata"`
+	// RequiresPayment only affects bookings made after the change.
+	RequiresPayment *bool  `json:"requires_payment"`
+	PriceCents      *int32 `json:"price_cents" binding:"omitempty,min=0"`
+	// Currency is reset to BASE_CURRENCY by sending an empty string.
+	Currency        *string `json:"currency"`
+	OversellPercent *int32  `json:"oversell_percent" binding:"omitempty,min=0,max=100"`
+	// Version is the event version the client last read; the update is
+	// rejected if someone else has changed the event since.
+	Version *int32 `json:"version" binding:"required"`
+}
+
+type EventResponse struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Venue       *string    `json:"venue"`
+	StartTime   *time.Time `json:"start_time"`
+	Capacity    int32      `json:"capacity"`
+	BookedCount int32      `json:"booked_count"`
+	Available   int32      `json:"available"`
+	Description *string    `json:"description,omitempty"`
+	ImageURL    *string    `json:"image_url,omitempty"`
+	SenderName  *string    `json:"sender_name,omitempty"`
+	ReplyTo     *string    `json:"reply_to,omitempty"`
+	// SellableCapacity is Capacity plus OverbookingBuffer, the seats
+	// OversellPercent allows beyond it; Available counts against it.
+	OversellPercent   int32 `json:"oversell_percent"`
+	OverbookingBuffer int32 `json:"overbooking_buffer"`
+	SellableCapacity  int32 `json:"sellable_capacity"`
+	// HeldCount counts seats mid-checkout; Purchasable is the available seats
+	// capped by the capacity left, i.e. what can actually be booked now.
+	HeldCount   int32 `json:"held_count"`
+	Purchasable int32 `json:"purchasable"`
+	// Sections breaks the seats down by section; events that don't use
+	// sections have just the default one.
+	Sections []SectionAvailability `json:"sections"`
+	// AvailabilityStatus is open, limited or sold_out; see availabilityStatus.
+	AvailabilityStatus string          `json:"availability_status"`
+	Metadata           json.RawMessage `json:"metadata"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	// BookableUntil is when holds and bookings stop being accepted.
+	BookableUntil *time.Time `json:"bookable_until,omitempty"`
+	// DeletedAt is set on archived events, which only admins can see.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version must be echoed back on PATCH /events/:id.
+	Version int32   `json:"version"`
+	OwnerID *string `json:"owner_id,omitempty"`
+	// WaitlistSize counts waiting entries. WaitlistCap is omitted when the
+	// waitlist is unlimited.
+	WaitlistOpen bool   `json:"waitlist_open"`
+	WaitlistCap  *int32 `json:"waitlist_cap,omitempty"`
+	WaitlistSize int32  `json:"waitlist_size"`
+	// RequiresPayment means new bookings start out pending_payment.
+	// PriceCents is charged per seat, in Currency; Price is it formatted.
+	RequiresPayment bool   `json:"requires_payment"`
+	PriceCents      int32  `json:"price_cents"`
+	Currency        string `json:"currency"`
+	Price           string `json:"price,omitempty"`
+}
+
+// canManageEvent reports whether the caller may change an event: admins may
+// change any event, organizers only the ones they own.
+func canManageEvent(c *gin.Context, owner pgtype.UUID) bool {
+	if isAdmin(c) {
+		return true
+	}
+	role, _ := c.Get("user_role")
+	if r, ok := role.(string); !ok || r != "organizer" {
+		return false
+	}
+	caller, ok := callerID(c)
+	return ok && owner.Valid && owner.Bytes == caller
+}
+
+// callerID returns the authenticated user's id, if any.
+func callerID(c *gin.Context) (uuid.UUID, bool) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		return uuid.Nil, false
+	}
+	switch t := v.(type) {
+	case uuid.UUID:
+		return t, true
+	case string:
+		if parsed, err := uuid.Parse(t); err == nil {
+			return parsed, true
+		}
+	}
+	return uuid.Nil, false
+}
+
+// ownerID renders an event's owner for responses.
+func ownerID(e db.Event) *string {
+	if !e.OwnerID.Valid {
+		return nil
+	}
+	id := e.OwnerID.String()
+	return &id
 }
 
-func NewEventsHandler(dbconn *pgxpool.Pool) *EventsHandler {
-	return &EventsHandler{
-		db: db.New(dbconn),
-		DB: dbconn,
+// staleVersionResponse reports an update made against an outdated event version.
+func staleVersionResponse(c *gin.Context, current, given int32) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":           "event was modified by someone else",
+		"details":         "reload the event and retry with its current version",
+		"current_version": current,
+		"given_version":   given,
+	})
+}
+
+// deletedAt returns when e was archived, or nil for a live event.
+func deletedAt(e db.Event) *time.Time {
+	if !e.DeletedAt.Valid {
+		return nil
+	}
+	t := e.DeletedAt.Time
+	return &t
+}
+
+// applySeatCounts fills HeldCount, Purchasable, Sections and
+// AvailabilityStatus from the event's seat counts. SellableCapacity must
+// already be set.
+func (h *EventsHandler) applySeatCounts(resp *EventResponse, counts eventSeatCounts) {
+	resp.HeldCount = counts.byStatus["held"]
+	purchasable := min(counts.byStatus["available"], resp.SellableCapacity-resp.BookedCount)
+	resp.Purchasable = max(purchasable, 0)
+	resp.Sections = counts.sections
+	if resp.Sections == nil {
+		resp.Sections = []SectionAvailability{}
+	}
+	resp.AvailabilityStatus = availabilityStatus(resp.SellableCapacity, resp.BookedCount, counts.byStatus["available"], counts.byStatus["held"], h.limitedPercent)
+}
+
+// waitlistSizes returns the number of waiting entries keyed by event id.
+func (h *EventsHandler) waitlistSizes(ctx context.Context, eventIDs []pgtype.UUID) (map[[16]byte]int32, error) {
+	rows, err := h.db.GetWaitingCountsByEvent(ctx, eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[[16]byte]int32, len(rows))
+	for _, r := range rows {
+		sizes[r.EventID.Bytes] = r.Waiting
 	}
+	return sizes, nil
+}
+
+// int4Ptr renders a nullable integer column for responses.
+func int4Ptr(v pgtype.Int4) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
+}
+
+// seatCountsByEvent returns seat counts, in total and per section, keyed by
+// event id.
+func (h *EventsHandler) seatCountsByEvent(ctx context.Context, eventIDs []pgtype.UUID) (map[[16]byte]eventSeatCounts, error) {
+	rows, err := h.db.GetSeatStatusCountsByEvent(ctx, eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[[16]byte]eventSeatCounts, len(eventIDs))
+	for _, r := range rows {
+		ec, ok := counts[r.EventID.Bytes]
+		if !ok {
+			ec.byStatus = make(map[string]int32)
+		}
+		ec.byStatus[r.Status] += r.Cnt
+		// Rows come ordered by event and section.
+		if n := len(ec.sections); n == 0 || ec.sections[n-1].Section != r.Section {
+			ec.sections = append(ec.sections, SectionAvailability{Section: r.Section})
+		}
+		sec := &ec.sections[len(ec.sections)-1]
+		sec.Total += r.Cnt
+		switch r.Status {
+		case "available":
+			sec.Available = r.Cnt
+		case "held":
+			sec.Held = r.Cnt
+		case "booked":
+			sec.Booked = r.Cnt
+		}
+		counts[r.EventID.Bytes] = ec
+	}
+	return counts, nil
+}
+
+// NewEventsHandler reads the event settings from the environment and fails
+// on any that are invalid.
+func NewEventsHandler(dbconn *pgxpool.Pool, seatHub *realtime.Hub) (*EventsHandler, error) {
+	h := &EventsHandler{
+		db:      db.New(dbconn),
+		DB:      dbconn,
+		seatHub: seatHub,
+	}
+	var err error
+	if h.minLeadTime, err = eventMinLeadTimeFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.maxCapacity, err = eventMaxCapacityFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.bookingCutoff, err = bookingCutoffFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.seatNoFormat, err = seatNoFormatFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.metadata, err = metadataValidatorFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.baseCurrency, err = money.BaseCurrencyFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.priceLocale, err = money.LocaleFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.limitedPercent, err = limitedPercentFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.pages, err = pagePolicyFromEnv(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// currencyParam validates a requested currency code. An empty code means the
+// base currency and is stored as NULL.
+func currencyParam(raw string) (pgtype.Text, error) {
+	if strings.TrimSpace(raw) == "" {
+		return pgtype.Text{}, nil
+	}
+	code, err := money.NormalizeCurrency(raw)
+	if err != nil {
+		return pgtype.Text{}, err
+	}
+	return pgtype.Text{String: code, Valid: true}, nil
+}
+
+// currency is the currency an event with currency cur is priced in.
+func (h *EventsHandler) currency(cur pgtype.Text) string {
+	return currencyOr(cur, h.baseCurrency)
+}
+
+// price formats an event's seat price, or returns "" for free events.
+func (h *EventsHandler) price(cents int32, cur pgtype.Text) string {
+	if cents == 0 {
+		return ""
+	}
+	return h.priceLocale.Format(int64(cents), h.currency(cur))
+}
+
+// currencyOr returns cur, or base when the event has no currency of its own.
+func currencyOr(cur pgtype.Text, base string) string {
+	if cur.Valid {
+		return cur.String
+	}
+	return base
 }
 
 func (h *EventsHandler) CreateEvent(c *gin.Context) {
@@ -77,19 +586,30 @@ func (h *EventsHandler) CreateEvent(c *gin.Context) {
 		return
 	}
 
-	venue := pgtype.Text{String: req.Venue, Valid: true}
-	startTime := pgtype.Timestamptz{Time: req.StartTime, Valid: true}
+	seatNos, label, err := h.validateNewEvent(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   label,
+			"details": err.Error(),
+		})
+		return
+	}
 
-	params := db.AddEventParams{
-		Name:      req.Name,
-		Venue:     venue,
-		StartTime: startTime,
-		Capacity:  req.Capacity,
-		Metadata:  req.Metadata,
+	// The event and its seats are created together or not at all.
+	ctx := c.Request.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to start transaction",
+			"details": err.Error(),
+		})
+		return
 	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
-	// Call the database
-	event, err := h.db.AddEvent(context.Background(), params)
+	event, seatCount, err := insertEvent(ctx, db.New(tx), newEventParams(c, req), seatNos, req.Seats.section())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to create event",
@@ -97,71 +617,109 @@ func (h *EventsHandler) CreateEvent(c *gin.Context) {
 		})
 		return
 	}
+	if err := tx.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to commit transaction",
+			"details": err.Error(),
+		})
+		return
+	}
 
 	// Convert to response format
 	response := CreateEventResponse{
-		ID:        event.ID.String(),
-		Name:      event.Name,
-		Venue:     venue.String,
-		StartTime: startTime.Time,
-		Capacity:  event.Capacity,
-		Metadata:  event.Metadata,
-		CreatedAt: event.CreatedAt.Time,
-		UpdatedAt: event.UpdatedAt.Time,
+		ID:              event.ID.String(),
+		Name:            event.Name,
+		Venue:           event.Venue.String,
+		StartTime:       event.StartTime.Time,
+		Capacity:        event.Capacity,
+		Description:     textPtr(event.Description),
+		ImageURL:        textPtr(event.ImageUrl),
+		SenderName:      textPtr(event.SenderName),
+		ReplyTo:         textPtr(event.ReplyTo),
+		Metadata:        event.Metadata,
+		CreatedAt:       event.CreatedAt.Time,
+		UpdatedAt:       event.UpdatedAt.Time,
+		Version:         event.Version,
+		SeatCount:       seatCount,
+		RequiresPayment: event.RequiresPayment,
+		PriceCents:      event.PriceCents,
+		Currency:        h.currency(event.Currency),
+		Price:           h.price(event.PriceCents, event.Currency),
+
+		OversellPercent:   event.OversellPercent,
+		OverbookingBuffer: overbookingBuffer(event.Capacity, event.OversellPercent),
+		SellableCapacity:  sellableCapacity(event.Capacity, event.OversellPercent),
+	}
+	if event.OwnerID.Valid {
+		owner := event.OwnerID.String()
+		response.OwnerID = &owner
 	}
 
+	c.Set(middleware.AuditTargetKey, response.ID)
 	c.JSON(http.StatusCreated, response)
 }
 
 func (h *EventsHandler) GetEvents(c *gin.Context) {
-	// Defaults
-	const (
-		defaultLimit  = 20
-		defaultOffset = 0
-		maxLimit      = 100
-	)
-
-	// Parse query params
-	limitStr := c.DefaultQuery("limit", strconv.Itoa(defaultLimit))
-	offsetStr := c.DefaultQuery("offset", strconv.Itoa(defaultOffset))
 	q := c.DefaultQuery("q", "")
 
-	limit64, err := strconv.ParseInt(limitStr, 10, 32)
-	if err != nil || limit64 <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid 'limit' query parameter",
-			"details": "limit must be a positive integer",
-		})
+	includeDeleted := c.Query("include_deleted") == "true"
+	if includeDeleted && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "include_deleted is only available to admins"})
 		return
 	}
-	offset64, err := strconv.ParseInt(offsetStr, 10, 32)
-	if err != nil || offset64 < 0 {
+	availability := c.Query("availability_status")
+	switch availability {
+	case "", availabilityOpen, availabilityLimited, availabilitySoldOut:
+	default:
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid 'offset' query parameter",
-			"details": "offset must be a non-negative integer",
+			"error":   "Invalid 'availability_status' query parameter",
+			"details": "availability_status must be one of open, limited, sold_out",
 		})
 		return
 	}
 
-	// Enforce max limit
-	if limit64 > maxLimit {
-		limit64 = maxLimit
+	// DEFAULT_PAGE_SIZE and MAX_PAGE_SIZE; see pagePolicyFromEnv.
+	page, perr := parsePage(c, h.pages)
+	if perr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": perr.Message, "details": perr.Details})
+		return
 	}
 
 	// Call the sqlc-generated method
-	ctx := context.Background()
-	events, err := h.db.GetAllEvents(ctx, db.GetAllEventsParams{
-		Limit:   int32(limit64),
-		Offset:  int32(offset64),
+	ctx := c.Request.Context()
+	rows, err := h.db.GetAllEvents(ctx, db.GetAllEventsParams{
+		Limit:   page.Limit,
+		Offset:  page.Offset,
 		Column3: q,
+		Column4: includeDeleted,
+		Column5: availability,
+		Column6: int32(h.limitedPercent),
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch events", "details": err.Error()})
 		return
 	}
 
-	var response []EventResponse
-	for _, event := range events {
+	var total int64
+	eventIDs := make([]pgtype.UUID, 0, len(rows))
+	for _, r := range rows {
+		eventIDs = append(eventIDs, r.Event.ID)
+		total = r.Total
+	}
+	seatCounts, err := h.seatCountsByEvent(ctx, eventIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch seat counts", "details": err.Error()})
+		return
+	}
+	waitlist, err := h.waitlistSizes(ctx, eventIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch waitlist sizes", "details": err.Error()})
+		return
+	}
+
+	response := make([]EventResponse, 0, len(rows))
+	for _, r := range rows {
+		event := r.Event
 		venue := (*string)(nil)
 		if event.Venue.Valid {
 			venue = &event.Venue.String
@@ -171,21 +729,55 @@ func (h *EventsHandler) GetEvents(c *gin.Context) {
 			startTime = &event.StartTime.Time
 		}
 
-		response = append(response, EventResponse{
+		item := EventResponse{
 			ID:          event.ID.String(),
 			Name:        event.Name,
 			Venue:       venue,
 			StartTime:   startTime,
 			Capacity:    event.Capacity,
 			BookedCount: event.BookedCount,
-			Available:   event.Capacity - event.BookedCount,
+			Available:   sellableCapacity(event.Capacity, event.OversellPercent) - event.BookedCount,
+			Description: textPtr(event.Description),
+			ImageURL:    textPtr(event.ImageUrl),
+			SenderName:  textPtr(event.SenderName),
+			ReplyTo:     textPtr(event.ReplyTo),
 			Metadata:    event.Metadata,
 			CreatedAt:   event.CreatedAt.Time,
 			UpdatedAt:   event.UpdatedAt.Time,
+
+			BookableUntil:   bookableUntil(event.StartTime, h.bookingCutoff),
+			DeletedAt:       deletedAt(event),
+			Version:         event.Version,
+			OwnerID:         ownerID(event),
+			WaitlistOpen:    event.WaitlistOpen,
+			WaitlistCap:     int4Ptr(event.WaitlistCap),
+			RequiresPayment: event.RequiresPayment,
+			PriceCents:      event.PriceCents,
+			Currency:        h.currency(event.Currency),
+			Price:           h.price(event.PriceCents, event.Currency),
+
+			OversellPercent:   event.OversellPercent,
+			OverbookingBuffer: overbookingBuffer(event.Capacity, event.OversellPercent),
+			SellableCapacity:  sellableCapacity(event.Capacity, event.OversellPercent),
+		}
+		h.applySeatCounts(&item, seatCounts[event.ID.Bytes])
+		item.WaitlistSize = waitlist[event.ID.Bytes]
+		response = append(response, item)
+	}
+	total, err = pageTotal(c, len(rows), page, total, func() (int64, error) {
+		return h.db.CountEvents(ctx, db.CountEventsParams{
+			Column1: q,
+			Column2: includeDeleted,
+			Column3: availability,
+			Column4: int32(h.limitedPercent),
 		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count events", "details": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, listResponse(c, response, page, total))
 }
 
 func (h *EventsHandler) GetEventByID(c *gin.Context) {
@@ -200,7 +792,8 @@ func (h *EventsHandler) GetEventByID(c *gin.Context) {
 	}
 
 	// Validate UUID
-	event, err := h.db.GetEventByID(context.Background(), pgtype.UUID{Bytes: uid, Valid: true})
+	ctx := c.Request.Context()
+	event, err := h.db.GetEventByID(ctx, pgtype.UUID{Bytes: uid, Valid: true})
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -215,6 +808,14 @@ func (h *EventsHandler) GetEventByID(c *gin.Context) {
 		return
 	}
 
+	// Archived events are hidden from everyone but admins.
+	if event.DeletedAt.Valid && !isAdmin(c) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Event not found",
+		})
+		return
+	}
+
 	// Convert to response format
 	response := EventResponse{
 		ID:          event.ID.String(),
@@ -223,10 +824,29 @@ func (h *EventsHandler) GetEventByID(c *gin.Context) {
 		StartTime:   (*time.Time)(nil),
 		Capacity:    event.Capacity,
 		BookedCount: event.BookedCount,
-		Available:   event.Capacity - event.BookedCount,
+		Available:   sellableCapacity(event.Capacity, event.OversellPercent) - event.BookedCount,
+		Description: textPtr(event.Description),
+		ImageURL:    textPtr(event.ImageUrl),
+		SenderName:  textPtr(event.SenderName),
+		ReplyTo:     textPtr(event.ReplyTo),
 		Metadata:    event.Metadata,
 		CreatedAt:   event.CreatedAt.Time,
 		UpdatedAt:   event.UpdatedAt.Time,
+
+		BookableUntil:   bookableUntil(event.StartTime, h.bookingCutoff),
+		DeletedAt:       deletedAt(event),
+		Version:         event.Version,
+		OwnerID:         ownerID(event),
+		WaitlistOpen:    event.WaitlistOpen,
+		WaitlistCap:     int4Ptr(event.WaitlistCap),
+		RequiresPayment: event.RequiresPayment,
+		PriceCents:      event.PriceCents,
+		Currency:        h.currency(event.Currency),
+		Price:           h.price(event.PriceCents, event.Currency),
+
+		OversellPercent:   event.OversellPercent,
+		OverbookingBuffer: overbookingBuffer(event.Capacity, event.OversellPercent),
+		SellableCapacity:  sellableCapacity(event.Capacity, event.OversellPercent),
 	}
 	if event.Venue.Valid {
 		response.Venue = &event.Venue.String
@@ -235,6 +855,26 @@ func (h *EventsHandler) GetEventByID(c *gin.Context) {
 		response.StartTime = &event.StartTime.Time
 	}
 
+	seatCounts, err := h.seatCountsByEvent(ctx, []pgtype.UUID{event.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch seat counts",
+			"details": err.Error(),
+		})
+		return
+	}
+	h.applySeatCounts(&response, seatCounts[event.ID.Bytes])
+
+	waitlist, err := h.waitlistSizes(ctx, []pgtype.UUID{event.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch waitlist size",
+			"details": err.Error(),
+		})
+		return
+	}
+	response.WaitlistSize = waitlist[event.ID.Bytes]
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -252,7 +892,7 @@ func (h *EventsHandler) UpdateEvent(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	existing, err := h.db.GetEventByID(ctx, pgtype.UUID{Bytes: eid, Valid: true})
 	if err != nil {
@@ -264,6 +904,19 @@ func (h *EventsHandler) UpdateEvent(c *gin.Context) {
 		return
 	}
 
+	if !canManageEvent(c, existing.OwnerID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you can only manage events you own"})
+		return
+	}
+	if existing.DeletedAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{"error": "event is archived", "details": "restore the event before editing it"})
+		return
+	}
+	if existing.Version != *req.Version {
+		staleVersionResponse(c, existing.Version, *req.Version)
+		return
+	}
+
 	// Name: UpdateEventParams.Name is string (non-nullable)
 	finalName := existing.Name
 	if req.Name != nil {
@@ -282,6 +935,14 @@ func (h *EventsHandler) UpdateEvent(c *gin.Context) {
 	// StartTime: UpdateEventParams.StartTime is pgtype.Timestamptz
 	var finalStart pgtype.Timestamptz
 	if req.StartTime != nil {
+		// Moving an event with live bookings into the past would strand its ticket holders.
+		if existing.BookedCount > 0 && req.StartTime.Before(time.Now().Add(-startTimeGrace)) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":        "start_time cannot be moved into the past for an event with active bookings",
+				"booked_count": existing.BookedCount,
+			})
+			return
+		}
 		finalStart = pgtype.Timestamptz{Time: *req.StartTime, Valid: true}
 	} else {
 		finalStart = existing.StartTime
@@ -301,32 +962,106 @@ func (h *EventsHandler) UpdateEvent(c *gin.Context) {
 		finalMeta = existing.Metadata
 	}
 
+	if req.Metadata != nil {
+		if err := h.metadata.validate(*req.Metadata); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid metadata", "details": err.Error()})
+			return
+		}
+	}
+
+	// Description and ImageURL: nil keeps the current value, "" clears it
+	finalDescription := existing.Description
+	if req.Description != nil {
+		finalDescription = optionalText(*req.Description)
+	}
+	finalImageURL := existing.ImageUrl
+	if req.ImageURL != nil {
+		if err := validateImageURL(*req.ImageURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid image_url", "details": err.Error()})
+			return
+		}
+		finalImageURL = optionalText(*req.ImageURL)
+	}
+	finalSenderName := existing.SenderName
+	if req.SenderName != nil {
+		if err := validateSenderName(*req.SenderName); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sender_name", "details": err.Error()})
+			return
+		}
+		finalSenderName = optionalText(*req.SenderName)
+	}
+	finalReplyTo := existing.ReplyTo
+	if req.ReplyTo != nil {
+		if err := validateReplyTo(*req.ReplyTo); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reply_to", "details": err.Error()})
+			return
+		}
+		finalReplyTo = optionalText(*req.ReplyTo)
+	}
+	finalRequiresPayment := existing.RequiresPayment
+	if req.RequiresPayment != nil {
+		finalRequiresPayment = *req.RequiresPayment
+	}
+	finalPriceCents := existing.PriceCents
+	if req.PriceCents != nil {
+		finalPriceCents = *req.PriceCents
+	}
+	finalCurrency := existing.Currency
+	if req.Currency != nil {
+		cur, err := currencyParam(*req.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid currency", "details": err.Error()})
+			return
+		}
+		finalCurrency = cur
+	}
+	finalOversell := existing.OversellPercent
+	if req.OversellPercent != nil {
+		finalOversell = *req.OversellPercent
+	}
+
 	// 2. Precheck capacity
-	if req.Capacity != nil && *req.Capacity < existing.BookedCount {
+	if req.Capacity != nil {
+		if err := h.validateCapacity(*req.Capacity); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid capacity", "details": err.Error()})
+			return
+		}
+	}
+	if sellable := sellableCapacity(finalCapacity, finalOversell); (req.Capacity != nil || req.OversellPercent != nil) && sellable < existing.BookedCount {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "capacity cannot be less than booked_count",
-			"current": existing.BookedCount,
-			"given":   *req.Capacity,
+			"error":            "sellable capacity cannot be less than booked_count",
+			"current":          existing.BookedCount,
+			"given":            sellable,
+			"capacity":         finalCapacity,
+			"oversell_percent": finalOversell,
 		})
 		return
 	}
 
 	// Build params in the exact generated types
 	params := db.UpdateEventParams{
-		ID:        pgtype.UUID{Bytes: eid, Valid: true},
-		Name:      finalName,
-		Venue:     finalVenue,
-		StartTime: finalStart,
-		Capacity:  finalCapacity,
-		Metadata:  finalMeta,
+		ID:              pgtype.UUID{Bytes: eid, Valid: true},
+		Name:            finalName,
+		Venue:           finalVenue,
+		StartTime:       finalStart,
+		Capacity:        finalCapacity,
+		Metadata:        finalMeta,
+		Version:         *req.Version,
+		Description:     finalDescription,
+		ImageUrl:        finalImageURL,
+		SenderName:      finalSenderName,
+		ReplyTo:         finalReplyTo,
+		RequiresPayment: finalRequiresPayment,
+		PriceCents:      finalPriceCents,
+		Currency:        finalCurrency,
+		OversellPercent: finalOversell,
 	}
 
 	// Call UpdateEvent
 	updated, err := h.db.UpdateEvent(ctx, params)
 	if err != nil {
-		// Distinguish "no rows updated" (capacity too small or missing event) vs other errors.
+		// Distinguish "no rows updated" (stale version or missing event) vs other errors.
 		if err == pgx.ErrNoRows {
-			// If event exists but capacity prevented update, return 409 with detail.
 			ev, gerr := h.db.GetEventByID(ctx, pgtype.UUID{Bytes: eid, Valid: true})
 			if gerr != nil {
 				// if event really doesn't exist
@@ -337,11 +1072,17 @@ func (h *EventsHandler) UpdateEvent(c *gin.Context) {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify event", "details": gerr.Error()})
 				return
 			}
-			// event exists -> probably capacity constraint
+			// event exists -> someone updated it after our read
+			staleVersionResponse(c, ev.Version, *req.Version)
+			return
+		}
+		// bookings raced the capacity precheck; the CHECK constraint caught it
+		if isCapacityViolation(err) {
+			ev, _ := h.db.GetEventByID(ctx, pgtype.UUID{Bytes: eid, Valid: true})
 			c.JSON(http.StatusConflict, gin.H{
 				"error":        "capacity too small",
 				"booked_count": ev.BookedCount,
-				"message":      "new capacity must be >= current booked_count",
+				"message":      "new sellable capacity must be >= current booked_count",
 			})
 			return
 		}
@@ -367,14 +1108,53 @@ func (h *EventsHandler) UpdateEvent(c *gin.Context) {
 		StartTime:   startPtr,
 		Capacity:    updated.Capacity,
 		BookedCount: updated.BookedCount,
+		Available:   sellableCapacity(updated.Capacity, updated.OversellPercent) - updated.BookedCount,
+		Description: textPtr(updated.Description),
+		ImageURL:    textPtr(updated.ImageUrl),
+		SenderName:  textPtr(updated.SenderName),
+		ReplyTo:     textPtr(updated.ReplyTo),
 		Metadata:    updated.Metadata,
 		CreatedAt:   updated.CreatedAt.Time,
 		UpdatedAt:   updated.UpdatedAt.Time,
+
+		BookableUntil:   bookableUntil(updated.StartTime, h.bookingCutoff),
+		DeletedAt:       deletedAt(updated),
+		Version:         updated.Version,
+		OwnerID:         ownerID(updated),
+		WaitlistOpen:    updated.WaitlistOpen,
+		WaitlistCap:     int4Ptr(updated.WaitlistCap),
+		RequiresPayment: updated.RequiresPayment,
+		PriceCents:      updated.PriceCents,
+		Currency:        h.currency(updated.Currency),
+		Price:           h.price(updated.PriceCents, updated.Currency),
+
+		OversellPercent:   updated.OversellPercent,
+		OverbookingBuffer: overbookingBuffer(updated.Capacity, updated.OversellPercent),
+		SellableCapacity:  sellableCapacity(updated.Capacity, updated.OversellPercent),
+	}
+
+	// PATCH returns the same shape as GET /events/:id.
+	seatCounts, err := h.seatCountsByEvent(ctx, []pgtype.UUID{updated.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch seat counts", "details": err.Error()})
+		return
+	}
+	h.applySeatCounts(&resp, seatCounts[updated.ID.Bytes])
+	waitlist, err := h.waitlistSizes(ctx, []pgtype.UUID{updated.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch waitlist size", "details": err.Error()})
+		return
 	}
+	resp.WaitlistSize = waitlist[updated.ID.Bytes]
 
 	c.JSON(http.StatusOK, resp)
 }
 
+// DELETE /events/:id
+// Archives the event (soft delete) so its bookings and analytics history are
+// kept. Refused with 409 while the event has active bookings or unexpired
+// holds; with ?force=true those bookings are cancelled, holds expired, seats
+// released and waiting waitlist entries cancelled in the same transaction.
 func (h *EventsHandler) DeleteEvent(c *gin.Context) {
 	idStr := c.Param("id")
 	eid, err := uuid.Parse(idStr)
@@ -382,22 +1162,208 @@ func (h *EventsHandler) DeleteEvent(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id", "details": err.Error()})
 		return
 	}
+	force := c.Query("force") == "true"
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
+	eventParam := pgtype.UUID{Bytes: eid, Valid: true}
 
-	// call sqlc-generated DeleteEvent (expects pgtype.UUID)
-	row, err := h.db.DeleteEvent(ctx, pgtype.UUID{Bytes: eid, Valid: true})
+	if status, body, ok := h.authorizeEventChange(c, eventParam); !ok {
+		c.JSON(status, body)
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
 	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start transaction", "details": err.Error()})
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	q := db.New(tx)
+
+	// Lock the event so no booking can land between the count and the archive.
+	if _, err := q.GetEventCapacityForUpdate(ctx, eventParam); err != nil {
 		if err == pgx.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to lock event", "details": err.Error()})
+		return
+	}
+
+	deps, err := q.CountEventDependents(ctx, eventParam)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to inspect event", "details": err.Error()})
+		return
+	}
+
+	if !force && (deps.ActiveBookings > 0 || deps.ActiveHolds > 0) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "event has active bookings or holds",
+			"details": "cancel the event's bookings first (POST /events/:id/bookings/cancel-all notifies their holders), or retry with force=true to cancel them along with the event",
+			"summary": gin.H{
+				"active_bookings":  deps.ActiveBookings,
+				"active_holds":     deps.ActiveHolds,
+				"seats":            deps.Seats,
+				"waitlist_entries": deps.WaitlistEntries,
+			},
+		})
+		return
+	}
+
+	var cancelled gin.H
+	if force {
+		if err := recordSeatChanges(c, q, seatReasonEventDeleted); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record seat history", "details": err.Error()})
+			return
+		}
+		cancelled, err = cancelEventDependents(ctx, q, eventParam)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel event bookings", "details": err.Error()})
+			return
+		}
+	}
+
+	row, err := q.SoftDeleteEvent(ctx, eventParam)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "event not found", "details": "event is already archived"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete event", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":      row.String(),
-		"deleted": true,
-	})
+	if err := tx.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit transaction", "details": err.Error()})
+		return
+	}
+
+	resp := gin.H{
+		"id":         row.ID.String(),
+		"deleted":    true,
+		"deleted_at": row.DeletedAt.Time,
+	}
+	if cancelled != nil {
+		resp["cancelled"] = cancelled
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// cancelEventDependents cancels everything still live on an event being
+// force-archived and reports what it touched.
+func cancelEventDependents(ctx context.Context, q *db.Queries, eventID pgtype.UUID) (gin.H, error) {
+	bookings, err := q.CancelActiveBookingsByEvent(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("cancel bookings: %w", err)
+	}
+	if bookings.Seats > 0 {
+		if err := q.UpdateEventBookedCountByDelta(ctx, db.UpdateEventBookedCountByDeltaParams{BookedCount: -bookings.Seats, ID: eventID}); err != nil {
+			return nil, fmt.Errorf("update booked_count: %w", err)
+		}
+	}
+	holds, err := q.ExpireActiveHoldsByEvent(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("expire holds: %w", err)
+	}
+	seats, err := q.ReleaseSeatsByEvent(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("release seats: %w", err)
+	}
+	waitlist, err := q.CancelWaitlistByEvent(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("cancel waitlist: %w", err)
+	}
+	return gin.H{
+		"bookings":         bookings.Bookings,
+		"holds":            holds,
+		"seats_released":   seats,
+		"waitlist_entries": waitlist,
+	}, nil
+}
+
+// POST /events/:id/restore
+// Brings an archived event back. Bookings cancelled by a forced delete stay cancelled.
+func (h *EventsHandler) RestoreEvent(c *gin.Context) {
+	eid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id", "details": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	eventParam := pgtype.UUID{Bytes: eid, Valid: true}
+
+	if status, body, ok := h.authorizeEventChange(c, eventParam); !ok {
+		c.JSON(status, body)
+		return
+	}
+
+	event, err := h.db.RestoreEvent(ctx, eventParam)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			if _, gerr := h.db.GetEventByID(ctx, eventParam); gerr == nil {
+				c.JSON(http.StatusConflict, gin.H{"error": "event is not archived"})
+				return
+			}
+			c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore event", "details": err.Error()})
+		return
+	}
+
+	resp := EventResponse{
+		ID:          event.ID.String(),
+		Name:        event.Name,
+		Capacity:    event.Capacity,
+		BookedCount: event.BookedCount,
+		Available:   sellableCapacity(event.Capacity, event.OversellPercent) - event.BookedCount,
+		Description: textPtr(event.Description),
+		ImageURL:    textPtr(event.ImageUrl),
+		SenderName:  textPtr(event.SenderName),
+		ReplyTo:     textPtr(event.ReplyTo),
+		Metadata:    event.Metadata,
+		CreatedAt:   event.CreatedAt.Time,
+		UpdatedAt:   event.UpdatedAt.Time,
+
+		BookableUntil:   bookableUntil(event.StartTime, h.bookingCutoff),
+		Version:         event.Version,
+		OwnerID:         ownerID(event),
+		WaitlistOpen:    event.WaitlistOpen,
+		WaitlistCap:     int4Ptr(event.WaitlistCap),
+		RequiresPayment: event.RequiresPayment,
+		PriceCents:      event.PriceCents,
+		Currency:        h.currency(event.Currency),
+		Price:           h.price(event.PriceCents, event.Currency),
+
+		OversellPercent:   event.OversellPercent,
+		OverbookingBuffer: overbookingBuffer(event.Capacity, event.OversellPercent),
+		SellableCapacity:  sellableCapacity(event.Capacity, event.OversellPercent),
+	}
+	if event.Venue.Valid {
+		resp.Venue = &event.Venue.String
+	}
+	if event.StartTime.Valid {
+		resp.StartTime = &event.StartTime.Time
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// authorizeEventChange loads the event and checks canManageEvent, returning the
+// error response to send when the caller may not change it.
+func (h *EventsHandler) authorizeEventChange(c *gin.Context, eventID pgtype.UUID) (int, gin.H, bool) {
+	event, err := h.db.GetEventByID(c.Request.Context(), eventID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return http.StatusNotFound, gin.H{"error": "event not found"}, false
+		}
+		return http.StatusInternalServerError, gin.H{"error": "failed to fetch event", "details": err.Error()}, false
+	}
+	if !canManageEvent(c, event.OwnerID) {
+		return http.StatusForbidden, gin.H{"error": "you can only manage events you own"}, false
+	}
+	return 0, nil, true
 }
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// readyPingTimeout keeps a hung database from stalling load balancer probes.
+const readyPingTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	DB *pgxpool.Pool
+}
+
+type PoolStats struct {
+	TotalConns    int32 `json:"total_conns"`
+	AcquiredConns int32 `json:"acquired_conns"`
+	IdleConns     int32 `json:"idle_conns"`
+	MaxConns      int32 `json:"max_conns"`
+}
+
+type ReadinessResponse struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+	Database  string    `json:"database"`
+	Error     string    `json:"error,omitempty"`
+	Pool      PoolStats `json:"pool"`
+}
+
+func NewHealthHandler(dbconn *pgxpool.Pool) *HealthHandler {
+	return &HealthHandler{DB: dbconn}
+}
+
+// GET /readyz
+// Readiness probe: 200 when the database answers a ping, 503 otherwise.
+// /healthz stays a plain liveness check.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readyPingTimeout)
+	defer cancel()
+
+	stat := h.DB.Stat()
+	resp := ReadinessResponse{
+		Status:    "ok",
+		Timestamp: time.Now().UTC(),
+		Database:  "up",
+		Pool: PoolStats{
+			TotalConns:    stat.TotalConns(),
+			AcquiredConns: stat.AcquiredConns(),
+			IdleConns:     stat.IdleConns(),
+			MaxConns:      stat.MaxConns(),
+		},
+	}
+
+	if err := h.DB.Ping(ctx); err != nil {
+		resp.Status = "unavailable"
+		resp.Database = "down"
+		resp.Error = err.Error()
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type TransferHoldRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type TransferHoldResponse struct {
+	HoldToken string    `json:"hold_token"`
+	EventID   string    `json:"event_id"`
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// POST /holds/:token/transfer
+// Hands an active hold to another registered user (current owner only), e.g.
+// a group organizer passing the cart to whoever pays. The recipient books it
+// with the same hold token before it expires; the expiry is not extended.
+func (h *HoldsHandler) TransferHold(c *gin.Context) {
+	ctx := c.Request.Context()
+	token := c.Param("token")
+
+	uid, ok := callerID(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, CodeUnauthenticated, "unauthenticated", nil)
+		return
+	}
+
+	var req TransferHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request", err.Error())
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to start transaction", err.Error())
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := db.New(tx)
+
+	hold, err := q.GetSeatHoldForUpdateByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, CodeHoldNotFound, "hold token not found", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch hold", err.Error())
+		return
+	}
+	if !hold.UserID.Valid || hold.UserID.Bytes != uid {
+		writeError(c, http.StatusForbidden, CodeForbidden, "forbidden: only the hold owner can transfer it", nil)
+		return
+	}
+	if hold.Status != "active" {
+		writeError(c, http.StatusConflict, CodeHoldNotActive, "hold not active", hold.Status)
+		return
+	}
+	if !hold.ExpiresAt.Time.After(time.Now()) {
+		writeError(c, http.StatusConflict, CodeHoldExpired, "hold expired", nil)
+		return
+	}
+
+	target, err := q.GetUserByEmail(ctx, strings.TrimSpace(req.Email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, CodeUserNotFound, "target user not found", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to look up target user", err.Error())
+		return
+	}
+	if target.ID.Bytes == uid {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "cannot transfer a hold to yourself", nil)
+		return
+	}
+
+	n, err := q.UpdateHoldOwner(ctx, db.UpdateHoldOwnerParams{
+		HoldToken: token,
+		UserID:    target.ID,
+	})
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to transfer hold", err.Error())
+		return
+	}
+	if n == 0 {
+		// Expired between the check above and the update.
+		writeError(c, http.StatusConflict, CodeHoldExpired, "hold expired", nil)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to commit", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, TransferHoldResponse{
+		HoldToken: hold.HoldToken,
+		EventID:   hold.EventID.String(),
+		UserID:    target.ID.String(),
+		Email:     target.Email,
+		ExpiresAt: hold.ExpiresAt.Time,
+	})
+}
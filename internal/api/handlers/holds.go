@@ -2,72 +2,174 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type HoldsHandler struct {
 	DB *pgxpool.Pool
+	// bookingCutoff is how long before start_time new holds are refused.
+	bookingCutoff time.Duration
+	// requireVerifiedEmail refuses holds from users who haven't confirmed their email.
+	requireVerifiedEmail bool
+	seatHub              *realtime.Hub
+	// maxSeats caps seats per hold unless the event overrides it; 0 = no cap.
+	maxSeats     int
+	seatNoFormat seatNoFormat
 }
 
+// CreateHoldRequest names the seats to hold, or with Quantity asks the server
+// to pick that many free seats ("best available"). Exactly one of SeatNos and
+// Quantity must be given.
 type CreateHoldRequest struct {
-	EventID string   `json:"event_id" binding:"required,uuid"`
-	SeatNos []string `json:"seat_nos" binding:"required,min=1"`
+	EventID  string   `json:"event_id" binding:"required,uuid"`
+	SeatNos  []string `json:"seat_nos"`
+	Quantity int      `json:"quantity" binding:"omitempty,min=1"`
+	// PreferTogether asks for Quantity adjacent seats in one row. If no such
+	// block is free the hold fails, unless AllowSplit accepts the first free
+	// seats instead.
+	PreferTogether bool `json:"prefer_together"`
+	AllowSplit     bool `json:"allow_split"`
+	// Section limits a quantity hold to seats of that section; empty means
+	// any section.
+	Section string `json:"section"`
 }
 
 type CreateHoldResponse struct {
 	HoldToken string    `json:"hold_token"`
 	ExpiresAt time.Time `json:"expires_at"`
+	// ServerTime is when the response was written and TTLSeconds how long the
+	// hold had left then. Clients count down TTLSeconds from receiving the
+	// response rather than comparing ExpiresAt with their own clock.
+	ServerTime time.Time `json:"server_time"`
+	TTLSeconds int       `json:"ttl_seconds"`
+	// SeatNumbers are the held seats; with quantity they are the ones picked.
+	SeatNumbers []string `json:"seat_numbers"`
+	// Contiguous reports, for quantity holds, whether the picked seats sit
+	// side by side in one row, so the UI can warn a split group.
+	Contiguous *bool `json:"contiguous,omitempty"`
+	// Existing is set when the caller already held exactly these seats and
+	// that hold was returned instead of creating a new one.
+	Existing bool `json:"existing,omitempty"`
 }
 
 const defaultHoldTTLSeconds = 300
 
-func NewHoldsHandler(dbconn *pgxpool.Pool) *HoldsHandler {
-	return &HoldsHandler{
-		DB: dbconn,
+// secondsUntil is how many whole seconds are left until expiresAt at now,
+// never negative.
+func secondsUntil(expiresAt, now time.Time) int {
+	if !expiresAt.After(now) {
+		return 0
 	}
+	return int(expiresAt.Sub(now).Seconds())
+}
+
+func NewHoldsHandler(dbconn *pgxpool.Pool, seatHub *realtime.Hub) (*HoldsHandler, error) {
+	h := &HoldsHandler{
+		DB:      dbconn,
+		seatHub: seatHub,
+	}
+	var err error
+	if h.requireVerifiedEmail, err = requireEmailVerificationFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.bookingCutoff, err = bookingCutoffFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.maxSeats, err = maxSeatsPerBookingFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.seatNoFormat, err = seatNoFormatFromEnv(); err != nil {
+		return nil, err
+	}
+	return h, nil
 }
 
 func (h *HoldsHandler) CreateHold(c *gin.Context) {
 	var req CreateHoldRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request", "details": err.Error()})
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request", err.Error())
 		return
 	}
 
 	eid, err := uuid.Parse(req.EventID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id", "details": err.Error()})
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid event id", err.Error())
 		return
 	}
 
-	seatMap := make(map[string]struct{}, len(req.SeatNos))
-	seatNos := make([]string, 0, len(req.SeatNos))
-	for _, s := range req.SeatNos {
-		if s == "" {
-			continue
-		}
-		if _, ok := seatMap[s]; !ok {
-			seatMap[s] = struct{}{}
-			seatNos = append(seatNos, s)
+	var seatNos []string
+	switch {
+	case req.Quantity > 0 && len(req.SeatNos) > 0:
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "give either seat_nos or quantity, not both", nil)
+		return
+	case req.Quantity > maxSeatsPerRequest:
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid quantity", fmt.Sprintf("at most %d seats allowed", maxSeatsPerRequest))
+		return
+	case req.Quantity == 0 && req.Section != "":
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "section only applies to quantity holds", nil)
+		return
+	case req.Quantity == 0:
+		seatNos = h.seatNoFormat.unique(req.SeatNos)
+		if len(seatNos) == 0 {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "no valid seat numbers provided", nil)
+			return
 		}
 	}
-	if len(seatNos) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no valid seat numbers provided"})
+	section, err := sectionFilter(req.Section)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid section", err.Error())
 		return
 	}
+	count := len(seatNos)
+	if req.Quantity > 0 {
+		count = req.Quantity
+	}
 
-	ctx := context.Background()
+	if h.requireVerifiedEmail && !ensureEmailVerified(c, db.New(h.DB)) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	eventParam := pgtype.UUID{Bytes: eid, Valid: true}
+
+	event, err := db.New(h.DB).GetEventByID(ctx, eventParam)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(c, http.StatusNotFound, CodeEventNotFound, "event not found", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch event", err.Error())
+		return
+	}
+	if event.DeletedAt.Valid {
+		writeError(c, http.StatusNotFound, CodeEventNotFound, "event not found", nil)
+		return
+	}
+	if salesClosed(event.StartTime, h.bookingCutoff) {
+		c.JSON(http.StatusConflict, apiError(CodeEventNotBookable, "event is no longer bookable", nil).
+			with("bookable_until", bookableUntil(event.StartTime, h.bookingCutoff)))
+		return
+	}
+	userIDParam, role := bookingUser(c)
+	if limit := seatLimitForEvent(h.maxSeats, event.Metadata); seatLimitExceeded(count, limit, role) {
+		c.JSON(seatLimitResponse(count, limit))
+		return
+	}
 
 	tx, err := h.DB.Begin(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start transaction", "details": err.Error()})
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to start transaction", err.Error())
 		return
 	}
 	defer func() {
@@ -75,14 +177,97 @@ func (h *HoldsHandler) CreateHold(c *gin.Context) {
 	}()
 
 	q := db.New(tx)
-	eventParam := pgtype.UUID{Bytes: eid, Valid: true}
 
-	seats, err := q.GetSeatsForEventForUpdate(ctx, db.GetSeatsForEventForUpdateParams{EventID: eventParam, Column2: seatNos})
+	var ids []pgtype.UUID
+	var contiguous *bool
+	if req.Quantity > 0 {
+		picked, together, err := bestAvailableSeats(ctx, q, eventParam, section, req.Quantity, req.PreferTogether, req.AllowSplit)
+		if err != nil {
+			switch {
+			case errors.Is(err, errNotEnoughSeats) && section != "":
+				c.JSON(http.StatusConflict, apiError(CodeSeatUnavailable, "not enough seats available in section", nil).with("requested", req.Quantity).with("section", section))
+			case errors.Is(err, errNotEnoughSeats):
+				c.JSON(http.StatusConflict, apiError(CodeSeatUnavailable, "not enough seats available", nil).with("requested", req.Quantity))
+			case errors.Is(err, errNoAdjacentSeats):
+				c.JSON(http.StatusConflict, apiError(CodeNoAdjacentSeats, "no block of adjacent seats available", "Retry with allow_split to accept seats that are not side by side").with("requested", req.Quantity))
+			default:
+				writeError(c, http.StatusInternalServerError, CodeInternal, "failed to get seats", err.Error())
+			}
+			return
+		}
+		contiguous = &together
+		for _, s := range picked {
+			ids = append(ids, s.ID)
+			seatNos = append(seatNos, s.SeatNo)
+		}
+	} else {
+		var ok bool
+		if ids, ok = lockRequestedSeats(c, q, eventParam, seatNos, userIDParam); !ok {
+			return
+		}
+	}
+
+	token := uuid.NewString()
+	expiresAt := time.Now().Add(time.Duration(defaultHoldTTLSeconds) * time.Second)
+
+	holdExpiresParam := pgtype.Timestamptz{Time: expiresAt, Valid: true}
+	holdTokenParam := pgtype.Text{String: token, Valid: true}
+
+	if err := recordSeatChanges(c, q, seatReasonHold); err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to record seat history", err.Error())
+		return
+	}
+	if err := q.UpdateSeatsToHeld(ctx, db.UpdateSeatsToHeldParams{
+		HoldExpiresAt: holdExpiresParam,
+		HoldToken:     holdTokenParam,
+		Column3:       ids,
+	}); err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to update seats to held", err.Error())
+		return
+	}
+
+	holdRow, err := q.InsertSeatHold(ctx, db.InsertSeatHoldParams{
+		HoldToken: token,
+		EventID:   eventParam,
+		UserID:    userIDParam,
+		SeatIds:   ids,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get seats", "details": err.Error()})
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to create seat_hold", err.Error())
 		return
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to commit", err.Error())
+		return
+	}
+	h.seatHub.Publish(eid, "held", seatNos)
+
+	now := time.Now()
+	resp := CreateHoldResponse{
+		HoldToken:   holdRow.HoldToken,
+		ExpiresAt:   holdRow.ExpiresAt.Time,
+		ServerTime:  now,
+		TTLSeconds:  secondsUntil(holdRow.ExpiresAt.Time, now),
+		SeatNumbers: seatNos,
+		Contiguous:  contiguous,
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// lockRequestedSeats locks the named seats of the event and returns their ids
+// when all of them exist and are free. Otherwise it writes the response,
+// which for a repeated request is the caller's existing hold, and returns
+// false.
+func lockRequestedSeats(c *gin.Context, q *db.Queries, eventParam pgtype.UUID, seatNos []string, userIDParam pgtype.UUID) ([]pgtype.UUID, bool) {
+	ctx := c.Request.Context()
+	seats, err := q.GetSeatsForEventForUpdate(ctx, db.GetSeatsForEventForUpdateParams{EventID: eventParam, Column2: seatNos})
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to get seats", err.Error())
+		return nil, false
+	}
+
 	if len(seats) != len(seatNos) {
 		found := map[string]struct{}{}
 		for _, s := range seats {
@@ -94,14 +279,28 @@ func (h *HoldsHandler) CreateHold(c *gin.Context) {
 				missing = append(missing, s)
 			}
 		}
-		c.JSON(http.StatusNotFound, gin.H{"error": "some seats not found", "details": missing})
-		return
+		writeError(c, http.StatusNotFound, CodeSeatNotFound, "some seats not found", missing)
+		return nil, false
 	}
 
 	for _, s := range seats {
 		if s.Status != "available" {
-			c.JSON(http.StatusConflict, gin.H{"error": "one or more seats are not available", "seat_no": s.SeatNo, "status": s.Status})
-			return
+			// A double-submitted hold finds its seats held by the first one;
+			// hand that hold back rather than reporting a conflict.
+			if hold, ok := ownActiveHold(ctx, q, seats, userIDParam); ok {
+				now := time.Now()
+				c.JSON(http.StatusOK, CreateHoldResponse{
+					HoldToken:   hold.HoldToken,
+					ExpiresAt:   hold.ExpiresAt.Time,
+					ServerTime:  now,
+					TTLSeconds:  secondsUntil(hold.ExpiresAt.Time, now),
+					SeatNumbers: seatNos,
+					Existing:    true,
+				})
+				return nil, false
+			}
+			c.JSON(http.StatusConflict, apiError(CodeSeatUnavailable, "one or more seats are not available", nil).with("seat_no", s.SeatNo).with("status", s.Status))
+			return nil, false
 		}
 	}
 
@@ -109,54 +308,89 @@ func (h *HoldsHandler) CreateHold(c *gin.Context) {
 	for _, s := range seats {
 		ids = append(ids, s.ID)
 	}
+	return ids, true
+}
 
-	token := uuid.NewString()
-	expiresAt := time.Now().Add(time.Duration(defaultHoldTTLSeconds) * time.Second)
-
-	holdExpiresParam := pgtype.Timestamptz{Time: expiresAt, Valid: true}
-	holdTokenParam := pgtype.Text{String: token, Valid: true}
+type HoldResponse struct {
+	HoldToken        string    `json:"hold_token"`
+	EventID          string    `json:"event_id"`
+	SeatNumbers      []string  `json:"seat_numbers"`
+	Status           string    `json:"status"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	SecondsRemaining int       `json:"seconds_remaining"`
+	CreatedAt        time.Time `json:"created_at"`
+	// ServerTime is the clock SecondsRemaining was measured against, and
+	// TTLSeconds repeats it under the name CreateHoldResponse uses.
+	ServerTime time.Time `json:"server_time"`
+	TTLSeconds int       `json:"ttl_seconds"`
+}
 
-	if err := q.UpdateSeatsToHeld(ctx, db.UpdateSeatsToHeldParams{
-		HoldExpiresAt: holdExpiresParam,
-		HoldToken:     holdTokenParam,
-		Column3:       ids,
-	}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update seats to held", "details": err.Error()})
+// GET /holds
+// Lists the caller's active holds, soonest to expire first. With
+// ?include_inactive=true expired and converted holds are included too.
+func (h *HoldsHandler) GetMyHolds(c *gin.Context) {
+	uid, ok := callerID(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, CodeUnauthenticated, "unauthenticated", nil)
 		return
 	}
+	includeInactive := c.Query("include_inactive") == "true"
 
-	var userIDParam pgtype.UUID
-	if uidVal, ok := c.Get("user_id"); ok {
-		switch v := uidVal.(type) {
-		case uuid.UUID:
-			userIDParam = pgtype.UUID{Bytes: v, Valid: true}
-		case string:
-			if parsed, perr := uuid.Parse(v); perr == nil {
-				userIDParam = pgtype.UUID{Bytes: parsed, Valid: true}
-			}
-		}
-	}
-
-	holdRow, err := q.InsertSeatHold(ctx, db.InsertSeatHoldParams{
-		HoldToken: token,
-		EventID:   eventParam,
-		UserID:    userIDParam,
-		SeatIds:   ids,
-		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	holds, err := db.New(h.DB).GetActiveHoldsByUser(c.Request.Context(), db.GetActiveHoldsByUserParams{
+		UserID:  pgtype.UUID{Bytes: uid, Valid: true},
+		Column2: includeInactive,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create seat_hold", "details": err.Error()})
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch holds", err.Error())
 		return
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit", "details": err.Error()})
-		return
+	now := time.Now()
+	out := make([]HoldResponse, 0, len(holds))
+	for _, hold := range holds {
+		remaining := secondsUntil(hold.ExpiresAt.Time, now)
+		status := hold.Status
+		if status == "active" && remaining <= 0 {
+			// Past its expiry but not yet swept by the expiry worker.
+			status = "expired"
+		}
+		if status != "active" {
+			remaining = 0
+		}
+		out = append(out, HoldResponse{
+			HoldToken:        hold.HoldToken,
+			EventID:          hold.EventID.String(),
+			SeatNumbers:      hold.SeatNos,
+			Status:           status,
+			ExpiresAt:        hold.ExpiresAt.Time,
+			SecondsRemaining: remaining,
+			CreatedAt:        hold.CreatedAt.Time,
+			ServerTime:       now,
+			TTLSeconds:       remaining,
+		})
 	}
 
-	resp := CreateHoldResponse{
-		HoldToken: holdRow.HoldToken,
-		ExpiresAt: holdRow.ExpiresAt.Time,
+	c.JSON(http.StatusOK, out)
+}
+
+// ownActiveHold returns the hold covering seats when they are all held by a
+// single unexpired hold that belongs to userID and holds nothing else.
+func ownActiveHold(ctx context.Context, q *db.Queries, seats []db.GetSeatsForEventForUpdateRow, userID pgtype.UUID) (db.GetActiveSeatHoldByTokenRow, bool) {
+	if !userID.Valid || len(seats) == 0 {
+		return db.GetActiveSeatHoldByTokenRow{}, false
 	}
-	c.JSON(http.StatusCreated, resp)
+	token := seats[0].HoldToken
+	for _, s := range seats {
+		if s.Status != "held" || !s.HoldToken.Valid || s.HoldToken != token {
+			return db.GetActiveSeatHoldByTokenRow{}, false
+		}
+	}
+	hold, err := q.GetActiveSeatHoldByToken(ctx, token.String)
+	if err != nil {
+		return db.GetActiveSeatHoldByTokenRow{}, false
+	}
+	if !hold.UserID.Valid || hold.UserID.Bytes != userID.Bytes || len(hold.SeatIds) != len(seats) {
+		return db.GetActiveSeatHoldByTokenRow{}, false
+	}
+	return hold, true
 }
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// idempotentReplayHeader marks a response replayed for a repeated
+// Idempotency-Key rather than produced by this request. Status and body match
+// the original.
+const idempotentReplayHeader = "Idempotent-Replay"
+
+// replayExistingBooking handles a key whose stored response is gone, because
+// it expired or was released after a server error, while the booking it
+// created still exists. If the event already has a booking under
+// idempotencyKey, the booking is replayed as the original 201 when it belongs
+// to the caller and sameSeats accepts it, with its payment details while it
+// awaits payment. Otherwise the key counts as reused with a different
+// request. It reports whether it responded.
+func (h *BookingsHandler) replayExistingBooking(c *gin.Context, eventParam pgtype.UUID, idempotencyKey string, userIDParam pgtype.UUID, respond func(status int, body any), sameSeats func(db.Booking) (bool, error)) bool {
+	ctx := c.Request.Context()
+	existing, err := h.db.GetBookingByEventAndIdempotency(ctx, db.GetBookingByEventAndIdempotencyParams{
+		EventID:        eventParam,
+		IdempotencyKey: pgtype.Text{String: idempotencyKey, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to look up idempotency key", err.Error()))
+		return true
+	}
+
+	same := existing.UserID.Valid && userIDParam.Valid && existing.UserID.Bytes == userIDParam.Bytes
+	if same {
+		if same, err = sameSeats(existing); err != nil {
+			respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to compare with the original booking", err.Error()))
+			return true
+		}
+	}
+	if !same {
+		respond(http.StatusConflict, apiError(CodeIdempotencyConflict, "idempotency key reused with a different request",
+			"please use a new idempotency key if you want to create a new booking"))
+		return true
+	}
+
+	resp, err := h.BuildBookingConfirmation(ctx, existing.ID)
+	if err != nil {
+		respond(http.StatusInternalServerError, apiError(CodeInternal, "failed to build confirmation", err.Error()))
+		return true
+	}
+	if resp.Status == "pending_payment" {
+		if resp.Payment, err = h.paymentFor(ctx, h.db, existing.ID, existing.EventID, resp.Price, existing.PaymentIntentID); err != nil {
+			log.Printf("failed to load payment for booking %s: %v", existing.ID.String(), err)
+		}
+	}
+	c.Header(idempotentReplayHeader, "true")
+	respond(http.StatusCreated, resp)
+	return true
+}
+
+// sameSeatIDs reports whether a and b hold the same seats, in any order.
+func sameSeatIDs(a, b []pgtype.UUID) bool {
+	key := func(ids []pgtype.UUID) []string {
+		out := make([]string, len(ids))
+		for i, id := range ids {
+			out[i] = id.String()
+		}
+		slices.Sort(out)
+		return out
+	}
+	return slices.Equal(key(a), key(b))
+}
+
+// sameSeatNos reports whether a and b name the same seats, in any order.
+func sameSeatNos(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
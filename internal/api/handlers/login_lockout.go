@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultLoginMaxFailures   = 5
+	defaultLoginFailureWindow = 15 * time.Minute
+	defaultLoginLockout       = 15 * time.Minute
+)
+
+// loginLockout locks an email out of Login after maxFailures wrong passwords
+// within window. It is keyed on the account being attacked, not the client.
+type loginLockout struct {
+	maxFailures int32
+	window      time.Duration
+	duration    time.Duration
+}
+
+// loginLockoutFromEnv reads LOGIN_MAX_FAILURES (0 disables the lockout),
+// LOGIN_FAILURE_WINDOW and LOGIN_LOCKOUT_DURATION.
+func loginLockoutFromEnv() (loginLockout, error) {
+	l := loginLockout{
+		maxFailures: defaultLoginMaxFailures,
+		window:      defaultLoginFailureWindow,
+		duration:    defaultLoginLockout,
+	}
+	if raw := os.Getenv("LOGIN_MAX_FAILURES"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || n < 0 {
+			return l, fmt.Errorf("invalid LOGIN_MAX_FAILURES %q: want a non-negative integer", raw)
+		}
+		l.maxFailures = int32(n)
+	}
+	var err error
+	if l.window, err = positiveDurationFromEnv("LOGIN_FAILURE_WINDOW", l.window); err != nil {
+		return l, err
+	}
+	if l.duration, err = positiveDurationFromEnv("LOGIN_LOCKOUT_DURATION", l.duration); err != nil {
+		return l, err
+	}
+	return l, nil
+}
+
+// positiveDurationFromEnv reads a positive Go duration from key, falling
+// back to def when it is unset.
+func positiveDurationFromEnv(key string, def time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: want a positive duration such as \"15m\"", key, raw)
+	}
+	return d, nil
+}
+
+func (l loginLockout) enabled() bool {
+	return l.maxFailures > 0
+}
+
+// loginKey normalises an email so "A@x.com" and "a@x.com " share a counter.
+func loginKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// lockedUntil returns when the lockout on email ends, if one is active. Lookup
+// errors are logged and treated as not locked so a DB hiccup doesn't block
+// every login.
+func (l loginLockout) lockedUntil(ctx context.Context, q *db.Queries, email string) (time.Time, bool) {
+	if !l.enabled() {
+		return time.Time{}, false
+	}
+	until, err := q.GetLoginLockedUntil(ctx, loginKey(email))
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			log.Printf("login lockout: lookup failed: %v", err)
+		}
+		return time.Time{}, false
+	}
+	if !until.Valid || !until.Time.After(time.Now()) {
+		return time.Time{}, false
+	}
+	return until.Time, true
+}
+
+// recordFailure counts a failed login and starts a lockout once the limit is hit.
+func (l loginLockout) recordFailure(ctx context.Context, q *db.Queries, email string) {
+	if !l.enabled() {
+		return
+	}
+	key := loginKey(email)
+	count, err := q.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+		Email:           key,
+		WindowStartedAt: pgtype.Timestamptz{Time: time.Now().Add(-l.window), Valid: true},
+	})
+	if err != nil {
+		log.Printf("login lockout: failed to record failure: %v", err)
+		return
+	}
+	if count < l.maxFailures {
+		return
+	}
+	if err := q.LockLogin(ctx, db.LockLoginParams{
+		Email:       key,
+		LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(l.duration), Valid: true},
+	}); err != nil {
+		log.Printf("login lockout: failed to lock: %v", err)
+	}
+}
+
+// reset forgets earlier failures after a successful login.
+func (l loginLockout) reset(ctx context.Context, q *db.Queries, email string) {
+	if !l.enabled() {
+		return
+	}
+	if err := q.ClearLoginAttempts(ctx, loginKey(email)); err != nil {
+		log.Printf("login lockout: failed to clear attempts: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// MailHandler exposes mail queue health to admins.
+type MailHandler struct {
+	queue *mail.Queue
+}
+
+// NewMailHandler creates handler
+func NewMailHandler(queue *mail.Queue) *MailHandler {
+	return &MailHandler{queue: queue}
+}
+
+// GET /admin/mail/stats
+// Reports queued, sent and retried counts plus the pending/dead outbox backlog.
+func (h *MailHandler) GetMailStats(c *gin.Context) {
+	stats, err := h.queue.Stats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load mail stats", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/abhinandanwadwa/overbookr/internal/api/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceHandler lets admins pause and resume writes.
+type MaintenanceHandler struct {
+	mode *middleware.Maintenance
+}
+
+func NewMaintenanceHandler(mode *middleware.Maintenance) *MaintenanceHandler {
+	return &MaintenanceHandler{mode: mode}
+}
+
+type SetMaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+	// Message is shown to clients in the 503's details.
+	Message string `json:"message"`
+}
+
+// GET /admin/maintenance
+func (h *MaintenanceHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, h.mode.Status())
+}
+
+// PUT /admin/maintenance
+// Switches maintenance mode on or off on this instance. While on, affected
+// writes get 503 with Retry-After; reads and this endpoint keep working.
+func (h *MaintenanceHandler) SetMaintenance(c *gin.Context) {
+	var req SetMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request", "details": err.Error()})
+		return
+	}
+	if len(req.Message) > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request", "details": "message must be at most 500 characters"})
+		return
+	}
+	h.mode.Set(*req.Enabled, strings.TrimSpace(req.Message))
+	c.JSON(http.StatusOK, h.mode.Status())
+}
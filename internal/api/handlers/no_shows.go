@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// NoShowReport backs oversell_percent decisions: how many booked seats went
+// unused at past events, and how far upcoming events are booked beyond
+// their seats.
+type NoShowReport struct {
+	Range    TimeRange             `json:"range"`
+	Totals   NoShowTotals          `json:"totals"`
+	Events   []EventNoShows        `json:"events"`
+	Exposure []OverbookingExposure `json:"exposure"`
+}
+
+// NoShowTotals sums every past event in range that used check-in.
+// NoShowRate is NoShowSeats over SeatsBooked, 0 without data.
+type NoShowTotals struct {
+	Events         int64   `json:"events"`
+	SeatsBooked    int64   `json:"seats_booked"`
+	SeatsCheckedIn int64   `json:"seats_checked_in"`
+	NoShowSeats    int64   `json:"no_show_seats"`
+	NoShowRate     float64 `json:"no_show_rate"`
+}
+
+type EventNoShows struct {
+	EventID         string    `json:"event_id"`
+	Name            string    `json:"name"`
+	StartTime       time.Time `json:"start_time"`
+	Capacity        int32     `json:"capacity"`
+	OversellPercent int32     `json:"oversell_percent"`
+	SeatsBooked     int64     `json:"seats_booked"`
+	SeatsCheckedIn  int64     `json:"seats_checked_in"`
+	NoShowSeats     int64     `json:"no_show_seats"`
+	NoShowRate      float64   `json:"no_show_rate"`
+}
+
+// OverbookingExposure is an upcoming event that oversells. OverbookedSeats
+// are booked seats without a physical seat should everyone turn up.
+// ExpectedAttendance applies the historical no-show rate to BookedCount and
+// ExpectedOverflow is how many of those would still find no seat; both are
+// omitted when there is no check-in history in range.
+type OverbookingExposure struct {
+	EventID            string    `json:"event_id"`
+	Name               string    `json:"name"`
+	StartTime          time.Time `json:"start_time"`
+	Capacity           int32     `json:"capacity"`
+	OversellPercent    int32     `json:"oversell_percent"`
+	SellableCapacity   int32     `json:"sellable_capacity"`
+	BookedCount        int32     `json:"booked_count"`
+	OverbookedSeats    int32     `json:"overbooked_seats"`
+	ExpectedAttendance *int64    `json:"expected_attendance,omitempty"`
+	ExpectedOverflow   *int64    `json:"expected_overflow,omitempty"`
+}
+
+// noShowRate is the share of booked seats nobody checked in for, rounded
+// to four places.
+func noShowRate(booked, checkedIn int64) float64 {
+	if booked <= 0 {
+		return 0
+	}
+	return math.Round(float64(booked-checkedIn)/float64(booked)*10000) / 10000
+}
+
+// GET /analytics/no_shows?from=&to=&top_n=
+// from and to bound the start time of the past events the no-show rates are
+// taken from (default: the last 180 days). Exposure lists upcoming events
+// that oversell. top_n caps both lists.
+func (h *AnalyticsHandler) GetNoShowReport(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	from, to, ok := analyticsRange(c, 180)
+	if !ok {
+		return
+	}
+	topN := analyticsTopN(c)
+	fromParam := pgtype.Timestamptz{Time: from, Valid: true}
+	toParam := pgtype.Timestamptz{Time: to, Valid: true}
+
+	totalsRow, err := h.db.GetNoShowTotalsBetween(ctx, db.GetNoShowTotalsBetweenParams{StartTime: fromParam, StartTime_2: toParam})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch no-show totals", "details": err.Error()})
+		return
+	}
+	totals := NoShowTotals{
+		Events:         totalsRow.Events,
+		SeatsBooked:    totalsRow.SeatsBooked,
+		SeatsCheckedIn: totalsRow.SeatsCheckedIn,
+		NoShowSeats:    totalsRow.SeatsBooked - totalsRow.SeatsCheckedIn,
+		NoShowRate:     noShowRate(totalsRow.SeatsBooked, totalsRow.SeatsCheckedIn),
+	}
+
+	eventRows, err := h.db.GetNoShowsByEventBetween(ctx, db.GetNoShowsByEventBetweenParams{StartTime: fromParam, StartTime_2: toParam, Limit: int32(topN)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch no-shows", "details": err.Error()})
+		return
+	}
+	events := make([]EventNoShows, 0, len(eventRows))
+	for _, r := range eventRows {
+		events = append(events, EventNoShows{
+			EventID:         r.EventID.String(),
+			Name:            r.Name,
+			StartTime:       r.StartTime.Time,
+			Capacity:        r.Capacity,
+			OversellPercent: r.OversellPercent,
+			SeatsBooked:     r.SeatsBooked,
+			SeatsCheckedIn:  r.SeatsCheckedIn,
+			NoShowSeats:     r.SeatsBooked - r.SeatsCheckedIn,
+			NoShowRate:      noShowRate(r.SeatsBooked, r.SeatsCheckedIn),
+		})
+	}
+
+	exposureRows, err := h.db.GetOverbookingExposure(ctx, int32(topN))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch overbooking exposure", "details": err.Error()})
+		return
+	}
+	exposure := make([]OverbookingExposure, 0, len(exposureRows))
+	for _, r := range exposureRows {
+		item := OverbookingExposure{
+			EventID:          r.EventID.String(),
+			Name:             r.Name,
+			StartTime:        r.StartTime.Time,
+			Capacity:         r.Capacity,
+			OversellPercent:  r.OversellPercent,
+			SellableCapacity: sellableCapacity(r.Capacity, r.OversellPercent),
+			BookedCount:      r.BookedCount,
+			OverbookedSeats:  r.OverbookedSeats,
+		}
+		if totals.SeatsBooked > 0 {
+			attendance := int64(math.Round(float64(r.BookedCount) * (1 - totals.NoShowRate)))
+			overflow := max(attendance-int64(r.Capacity), 0)
+			item.ExpectedAttendance, item.ExpectedOverflow = &attendance, &overflow
+		}
+		exposure = append(exposure, item)
+	}
+
+	c.JSON(http.StatusOK, NoShowReport{
+		Range:    TimeRange{From: from, To: to},
+		Totals:   totals,
+		Events:   events,
+		Exposure: exposure,
+	})
+}
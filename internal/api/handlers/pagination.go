@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	defaultMaxPage  = 100
+)
+
+// pagePolicy is a listing's page size when the client doesn't ask for one,
+// and the most it may ask for.
+type pagePolicy struct {
+	Default int32
+	Max     int32
+}
+
+// Page is a validated limit/offset pair.
+type Page struct {
+	Limit  int32
+	Offset int32
+}
+
+// ListEnvelope wraps one page of a listing with the paging metadata clients
+// need to render "page 3 of 12". Total counts every match, not just Data.
+type ListEnvelope struct {
+	Data   any   `json:"data"`
+	Total  int64 `json:"total"`
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+// listEnvelopeMediaType opts into ListEnvelope through the Accept header.
+const listEnvelopeMediaType = "application/vnd.overbookr.list+json"
+
+// pageError is a rejected limit or offset, for the caller to report in its
+// own error format.
+type pageError struct {
+	Message string
+	Details string
+}
+
+// pagePolicyFromEnv reads DEFAULT_PAGE_SIZE (default 20) and MAX_PAGE_SIZE
+// (default 100), the policy of the general listings: events, my bookings
+// and the admin lists. Listings meant for bulk reads, such as seats, keep
+// their own larger policy.
+func pagePolicyFromEnv() (pagePolicy, error) {
+	var p pagePolicy
+	var err error
+	if p.Default, err = pageSizeFromEnv("DEFAULT_PAGE_SIZE", defaultPageSize); err != nil {
+		return p, err
+	}
+	if p.Max, err = pageSizeFromEnv("MAX_PAGE_SIZE", defaultMaxPage); err != nil {
+		return p, err
+	}
+	if p.Default > p.Max {
+		return p, fmt.Errorf("DEFAULT_PAGE_SIZE (%d) exceeds MAX_PAGE_SIZE (%d)", p.Default, p.Max)
+	}
+	return p, nil
+}
+
+func pageSizeFromEnv(key string, def int32) (int32, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: want a positive integer", key, raw)
+	}
+	return int32(n), nil
+}
+
+// parsePage reads the limit and offset query parameters. A missing limit
+// means p.Default and one above p.Max is lowered to it.
+func parsePage(c *gin.Context, p pagePolicy) (Page, *pageError) {
+	limit := int64(p.Default)
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || n <= 0 {
+			return Page{}, &pageError{Message: "invalid 'limit' query parameter", Details: "limit must be a positive integer"}
+		}
+		limit = min(n, int64(p.Max))
+	}
+	var offset int64
+	if raw := c.Query("offset"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || n < 0 {
+			return Page{}, &pageError{Message: "invalid 'offset' query parameter", Details: "offset must be a non-negative integer"}
+		}
+		offset = n
+	}
+	return Page{Limit: int32(limit), Offset: int32(offset)}, nil
+}
+
+// wantsPage reports whether the client asked for paging at all, with a limit,
+// an offset or the envelope. Listings that returned everything before paging
+// was added keep doing so otherwise.
+func wantsPage(c *gin.Context) bool {
+	return c.Query("limit") != "" || c.Query("offset") != "" || wantsEnvelope(c)
+}
+
+// wantsEnvelope reports whether the client asked for a ListEnvelope, with
+// ?envelope=true or an Accept of listEnvelopeMediaType. Listings return a
+// bare array otherwise, so existing clients keep working.
+func wantsEnvelope(c *gin.Context) bool {
+	if v, err := strconv.ParseBool(c.Query("envelope")); err == nil {
+		return v
+	}
+	return strings.Contains(c.GetHeader("Accept"), listEnvelopeMediaType)
+}
+
+// pageTotal is the total for the envelope of a page of n rows. total comes
+// from the listing query's COUNT(*) OVER () column, which a page past the end
+// has no row to carry, so count, the listing's own count query, is run then.
+// Without the envelope the total isn't needed.
+func pageTotal(c *gin.Context, n int, page Page, total int64, count func() (int64, error)) (int64, error) {
+	if n > 0 || page.Offset == 0 || !wantsEnvelope(c) {
+		return total, nil
+	}
+	return count()
+}
+
+// listResponse returns items as they are, or wrapped in a ListEnvelope when
+// the client asked for one.
+func listResponse(c *gin.Context, items any, page Page, total int64) any {
+	if !wantsEnvelope(c) {
+		return items
+	}
+	return ListEnvelope{Data: items, Total: total, Limit: page.Limit, Offset: page.Offset}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/payments"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// POST /payments/webhook
+// Called by the payment provider. A successful payment makes its
+// pending_payment booking active; a failed or cancelled one abandons it. A
+// successful payment for a booking that no longer awaits it (abandoned,
+// cancelled, or confirmed by hand) is refunded; if the refund fails the
+// webhook answers 502 so the provider retries it. Other notifications that
+// don't apply, e.g. a repeat for a booking already paid, are acknowledged
+// with 200 and ignored, so the provider stops retrying them.
+func (h *BookingsHandler) PaymentWebhook(c *gin.Context) {
+	if h.payments == nil {
+		writeError(c, http.StatusNotFound, CodeInvalidRequest, "payments are not enabled", nil)
+		return
+	}
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "failed to read body", err.Error())
+		return
+	}
+	ev, err := h.payments.ParseWebhook(payload, c.Request.Header)
+	if err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			writeError(c, http.StatusBadRequest, CodeInvalidSignature, "invalid webhook signature", nil)
+			return
+		}
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid webhook", err.Error())
+		return
+	}
+	ignored := gin.H{"status": "ignored", "type": ev.Type}
+	if ev.Outcome == payments.OutcomeOther {
+		c.JSON(http.StatusOK, ignored)
+		return
+	}
+
+	ctx := c.Request.Context()
+	bookingID, err := h.db.GetBookingIDByPaymentIntent(ctx, pgtype.Text{String: ev.IntentID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusOK, ignored)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to look up payment", err.Error())
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to start transaction", err.Error())
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	b, err := db.New(tx).GetBookingForUpdate(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusOK, ignored)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch booking", err.Error())
+		return
+	}
+	if b.Status != "pending_payment" {
+		if ev.Outcome != payments.OutcomeSucceeded || b.PaidAt.Valid {
+			c.JSON(http.StatusOK, ignored)
+			return
+		}
+		if err := h.payments.Refund(ctx, ev.IntentID); err != nil {
+			writeError(c, http.StatusBadGateway, CodeInternal, "failed to refund payment", err.Error())
+			return
+		}
+		log.Printf("refunded payment %s for booking %s, which is %s", ev.IntentID, b.ID.String(), b.Status)
+		c.JSON(http.StatusOK, gin.H{"id": b.ID.String(), "status": b.Status, "refunded": true})
+		return
+	}
+
+	if ev.Outcome == payments.OutcomeSucceeded {
+		if err := db.New(tx).MarkBookingPaid(ctx, b.ID); err != nil {
+			writeError(c, http.StatusInternalServerError, CodeInternal, "failed to confirm booking", err.Error())
+			return
+		}
+		if _, err := h.confirmPending(ctx, tx, b); err != nil {
+			writeError(c, http.StatusInternalServerError, CodeInternal, "failed to confirm booking", err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": b.ID.String(), "status": "active"})
+		return
+	}
+	if err := h.abandonPending(c, tx, b, seatReasonPaymentFailed); err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to abandon booking", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": b.ID.String(), "status": "abandoned"})
+}
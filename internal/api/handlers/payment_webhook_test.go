@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abhinandanwadwa/overbookr/internal/payments"
+	"github.com/gin-gonic/gin"
+)
+
+const testPaymentSecret = "fake-webhook-secret"
+
+// postWebhook sends body to PaymentWebhook with a Fake-Signature made with
+// secret. The cases here are all answered before the database is touched.
+func postWebhook(h *BookingsHandler, body, secret string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.POST("/payments/webhook", h.PaymentWebhook)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	req := httptest.NewRequest(http.MethodPost, "/payments/webhook", strings.NewReader(body))
+	req.Header.Set("Fake-Signature", hex.EncodeToString(mac.Sum(nil)))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestPaymentWebhookBeforeLookup(t *testing.T) {
+	fake := &BookingsHandler{payments: &payments.Fake{WebhookSecret: testPaymentSecret}}
+	succeeded := `{"type":"payment_intent.succeeded","data":{"object":{"id":"pi_fake_1"}}}`
+
+	tests := []struct {
+		name     string
+		h        *BookingsHandler
+		body     string
+		secret   string
+		want     int
+		wantBody string
+	}{
+		{"payments disabled", &BookingsHandler{}, succeeded, testPaymentSecret, http.StatusNotFound, "payments are not enabled"},
+		{"bad signature", fake, succeeded, "guessed", http.StatusBadRequest, string(CodeInvalidSignature)},
+		{"malformed body", fake, `{"type":`, testPaymentSecret, http.StatusBadRequest, "invalid webhook"},
+		{"no intent id", fake, `{"type":"payment_intent.succeeded","data":{"object":{}}}`, testPaymentSecret, http.StatusBadRequest, "invalid webhook"},
+		{"unrelated event", fake, `{"type":"charge.refunded","data":{"object":{"id":"ch_1"}}}`, testPaymentSecret, http.StatusOK, `"ignored"`},
+	}
+	for _, tt := range tests {
+		w := postWebhook(tt.h, tt.body, tt.secret)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body)
+			continue
+		}
+		if !strings.Contains(w.Body.String(), tt.wantBody) {
+			t.Errorf("%s: body = %s, want it to mention %s", tt.name, w.Body, tt.wantBody)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/workers"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReconcileHandler exposes the reconcile worker to admins.
+type ReconcileHandler struct {
+	worker *workers.ReconcileWorker
+}
+
+// NewReconcileHandler creates handler
+func NewReconcileHandler(dbconn *pgxpool.Pool, holdRetention, loginWindow time.Duration) *ReconcileHandler {
+	return &ReconcileHandler{
+		worker: workers.NewReconcileWorker(dbconn, holdRetention, loginWindow),
+	}
+}
+
+// GET /admin/reconcile/preview
+// Reports what a reconcile pass would fix without writing anything.
+func (h *ReconcileHandler) PreviewReconcile(c *gin.Context) {
+	summary, err := h.worker.Preview(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to preview reconcile", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// POST /admin/reconcile
+// Runs a reconcile pass synchronously. The pass shares the worker's advisory lock,
+// so it returns 409 instead of colliding with a periodic run in progress.
+func (h *ReconcileHandler) RunReconcile(c *gin.Context) {
+	summary, err := h.worker.Reconcile(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "reconcile failed", "details": err.Error()})
+		return
+	}
+	if summary.Skipped {
+		c.JSON(http.StatusConflict, gin.H{"error": "reconcile already running", "details": "another instance holds the reconcile lock; retry shortly"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
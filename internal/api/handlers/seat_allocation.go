@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// togetherScanLimit bounds how many free seats a prefer_together hold looks
+// through for a block of adjacent seats. They are read without locks; only
+// the block found is locked. A block that only exists further back than this
+// is not found.
+const togetherScanLimit = 500
+
+var (
+	// errNotEnoughSeats means the event has fewer free seats than were asked for.
+	errNotEnoughSeats = errors.New("not enough seats available")
+	// errNoAdjacentSeats means enough seats are free, but not side by side,
+	// and the caller did not allow splitting the group.
+	errNoAdjacentSeats = errors.New("no block of adjacent seats available")
+)
+
+// bestAvailableSeats locks and returns n free seats of the event, the first
+// ones in seat order, and whether they sit side by side in one row. A
+// non-empty section limits the seats to that section. With together set it
+// looks for n adjacent seats first; when there are none it falls back to the
+// first n only if allowSplit is set. Seats other holds have locked are passed
+// over. Run it in the transaction that holds the seats.
+func bestAvailableSeats(ctx context.Context, q *db.Queries, eventID pgtype.UUID, section string, n int, together, allowSplit bool) ([]db.GetAvailableSeatsForEventForUpdateRow, bool, error) {
+	if together {
+		block, err := lockAdjacentBlock(ctx, q, eventID, section, n)
+		if err != nil {
+			return nil, false, err
+		}
+		if block != nil {
+			return block, true, nil
+		}
+		if !allowSplit {
+			return nil, false, errNoAdjacentSeats
+		}
+	}
+	picked, err := q.GetAvailableSeatsForEventForUpdate(ctx, db.GetAvailableSeatsForEventForUpdateParams{
+		EventID: eventID,
+		Limit:   int32(n),
+		Column3: section,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(picked) < n {
+		return nil, false, errNotEnoughSeats
+	}
+	return picked, adjacentBlock(picked, n) != nil, nil
+}
+
+// lockAdjacentBlock looks through the first togetherScanLimit free seats for
+// n adjacent ones and locks them. It returns nil, and no error, when there is
+// no such block or another hold took part of it first. When fewer than n
+// seats are free at all it returns errNotEnoughSeats.
+func lockAdjacentBlock(ctx context.Context, q *db.Queries, eventID pgtype.UUID, section string, n int) ([]db.GetAvailableSeatsForEventForUpdateRow, error) {
+	rows, err := q.GetAvailableSeatsForEvent(ctx, db.GetAvailableSeatsForEventParams{
+		EventID: eventID,
+		Limit:   int32(max(n, togetherScanLimit)),
+		Column3: section,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < n {
+		return nil, errNotEnoughSeats
+	}
+	free := make([]db.GetAvailableSeatsForEventForUpdateRow, len(rows))
+	for i, r := range rows {
+		free[i] = db.GetAvailableSeatsForEventForUpdateRow(r)
+	}
+	block := adjacentBlock(free, n)
+	if block == nil {
+		return nil, nil
+	}
+	ids := make([]pgtype.UUID, len(block))
+	for i, s := range block {
+		ids[i] = s.ID
+	}
+	locked, err := q.LockAvailableSeatsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(locked) < n {
+		return nil, nil
+	}
+	return block, nil
+}
+
+// adjacentBlock finds the first n seats in free, which is in seat order, that
+// share a section and row and have consecutive positions, e.g. C4 C5 C6.
+// Seats without a row and position never form a block. It returns nil if
+// there is no such run.
+func adjacentBlock(free []db.GetAvailableSeatsForEventForUpdateRow, n int) []db.GetAvailableSeatsForEventForUpdateRow {
+	start := 0
+	for i, s := range free {
+		if !s.RowLabel.Valid || !s.SeatCol.Valid {
+			start = i + 1
+			continue
+		}
+		if i > start {
+			prev := free[i-1]
+			if prev.Section != s.Section || prev.RowLabel.String != s.RowLabel.String || prev.SeatCol.Int32+1 != s.SeatCol.Int32 {
+				start = i
+			}
+		}
+		if i-start+1 == n {
+			return free[start : i+1]
+		}
+	}
+	return nil
+}
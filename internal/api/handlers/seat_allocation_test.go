@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// seatGrid builds free seats, in seat order, from specs like "A:B3" (section
+// A, row B, column 3). A seat number without a row letter, like ":12", is a
+// seat with no structured position.
+func seatGrid(specs ...string) []db.GetAvailableSeatsForEventForUpdateRow {
+	seats := make([]db.GetAvailableSeatsForEventForUpdateRow, len(specs))
+	for i, spec := range specs {
+		section, seatNo, _ := strings.Cut(spec, ":")
+		s := db.GetAvailableSeatsForEventForUpdateRow{SeatNo: seatNo, Section: section}
+		if row := strings.TrimRight(seatNo, "0123456789"); row != "" {
+			col, _ := strconv.Atoi(seatNo[len(row):])
+			s.RowLabel = pgtype.Text{String: row, Valid: true}
+			s.SeatCol = pgtype.Int4{Int32: int32(col), Valid: true}
+		}
+		seats[i] = s
+	}
+	return seats
+}
+
+func seatNos(seats []db.GetAvailableSeatsForEventForUpdateRow) string {
+	nos := make([]string, len(seats))
+	for i, s := range seats {
+		nos[i] = s.SeatNo
+	}
+	return strings.Join(nos, " ")
+}
+
+func TestAdjacentBlock(t *testing.T) {
+	tests := []struct {
+		name string
+		free []string
+		n    int
+		want string // "" means no block
+	}{
+		{"first seats", []string{"A:A1", "A:A2", "A:A3", "A:A4"}, 3, "A1 A2 A3"},
+		{"skips a gap", []string{"A:A1", "A:A3", "A:A4", "A:A5"}, 3, "A3 A4 A5"},
+		{"does not cross rows", []string{"A:A9", "A:A10", "A:B1", "A:B2"}, 3, ""},
+		{"next row", []string{"A:A9", "A:A10", "A:B1", "A:B2", "A:B3"}, 3, "B1 B2 B3"},
+		{"does not cross sections", []string{"A:C4", "B:C5", "B:C6"}, 3, ""},
+		{"gaps everywhere", []string{"A:A1", "A:A3", "A:A5", "A:B2", "A:B4"}, 2, ""},
+		{"single seat", []string{"A:A7"}, 1, "A7"},
+		{"no row data", []string{":1", ":2", ":3"}, 2, ""},
+		{"after unstructured seats", []string{":1", "A:D2", "A:D3"}, 2, "D2 D3"},
+		{"unstructured seat breaks a run", []string{"A:D1", ":7", "A:D2", "A:D3"}, 3, ""},
+		{"too few seats", []string{"A:A1", "A:A2"}, 3, ""},
+	}
+	for _, tt := range tests {
+		got := seatNos(adjacentBlock(seatGrid(tt.free...), tt.n))
+		if got != tt.want {
+			t.Errorf("%s: adjacentBlock = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Reasons recorded in the seat history for changes made by the handlers.
+const (
+	seatReasonHold             = "hold"
+	seatReasonBooking          = "booking"
+	seatReasonDirectBooking    = "direct_booking"
+	seatReasonAdminBooking     = "admin_booking"
+	seatReasonCancellation     = "cancellation"
+	seatReasonEventDeleted     = "event_deleted"
+	seatReasonPaymentAbandoned = "payment_abandoned"
+	seatReasonPaymentFailed    = "payment_failed"
+)
+
+// SeatEventItem is one status change in GET /seats/:id/history. OldStatus is
+// omitted for the seat's creation.
+type SeatEventItem struct {
+	ID            int64     `json:"id"`
+	OldStatus     *string   `json:"old_status,omitempty"`
+	NewStatus     string    `json:"new_status"`
+	BookingID     *string   `json:"booking_id,omitempty"`
+	HoldToken     *string   `json:"hold_token,omitempty"`
+	ActorID       *string   `json:"actor_id,omitempty"`
+	Reason        *string   `json:"reason,omitempty"`
+	CorrelationID *string   `json:"correlation_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type SeatHistoryResponse struct {
+	SeatID  string          `json:"seat_id"`
+	EventID string          `json:"event_id"`
+	SeatNo  string          `json:"seat_no"`
+	Status  string          `json:"status"`
+	History []SeatEventItem `json:"history"`
+}
+
+// recordSeatChanges tags the seat status changes made on q's transaction with
+// the caller and reason, for the seat history. Call it before the first
+// change.
+func recordSeatChanges(c *gin.Context, q *db.Queries, reason string) error {
+	ctx := c.Request.Context()
+	var actor string
+	if id, ok := callerID(c); ok {
+		actor = id.String()
+	}
+	return q.SetSeatAuditContext(ctx, db.SetSeatAuditContextParams{
+		ActorID:       actor,
+		Reason:        reason,
+		CorrelationID: tracing.CorrelationID(ctx),
+	})
+}
+
+// seatHistoryPages is the page size policy of a seat's history.
+var seatHistoryPages = pagePolicy{Default: 100, Max: 500}
+
+// GET /seats/:id/history
+// Every status change of one seat, oldest first, with who made it and why.
+// Admin only; paged with limit/offset.
+func (h *EventsHandler) GetSeatHistory(c *gin.Context) {
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid seat id", "details": err.Error()})
+		return
+	}
+	page, perr := parsePage(c, seatHistoryPages)
+	if perr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": perr.Message, "details": perr.Details})
+		return
+	}
+
+	ctx := c.Request.Context()
+	seatID := pgtype.UUID{Bytes: uid, Valid: true}
+	seat, err := h.db.GetSeatByID(ctx, seatID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "seat not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch seat", "details": err.Error()})
+		return
+	}
+
+	rows, err := h.db.GetSeatEventsBySeat(ctx, db.GetSeatEventsBySeatParams{
+		SeatID: seatID,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch seat history", "details": err.Error()})
+		return
+	}
+
+	resp := SeatHistoryResponse{
+		SeatID:  seat.ID.String(),
+		EventID: seat.EventID.String(),
+		SeatNo:  seat.SeatNo,
+		Status:  seat.Status,
+		History: make([]SeatEventItem, 0, len(rows)),
+	}
+	for _, r := range rows {
+		resp.History = append(resp.History, seatEventItem(r))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func seatEventItem(r db.SeatEvent) SeatEventItem {
+	item := SeatEventItem{
+		ID:            r.ID,
+		OldStatus:     textPtr(r.OldStatus),
+		NewStatus:     r.NewStatus,
+		HoldToken:     textPtr(r.HoldToken),
+		Reason:        textPtr(r.Reason),
+		CorrelationID: textPtr(r.CorrelationID),
+		CreatedAt:     r.CreatedAt.Time,
+	}
+	if r.BookingID.Valid {
+		id := r.BookingID.String()
+		item.BookingID = &id
+	}
+	if r.ActorID.Valid {
+		id := r.ActorID.String()
+		item.ActorID = &id
+	}
+	return item
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// maxSeatsPerRequest caps how many seats one request may create.
+const maxSeatsPerRequest = 2000
+
+// SeatLayout describes the seats to create along with a new event: either a
+// grid of Rows x Cols, named A1, A2, ... B1, ... (row 27 is AA), or an
+// explicit list of seat numbers. Exactly one form must be given. The seats
+// go into Section, or the default section when it is empty.
+type SeatLayout struct {
+	Rows    int      `json:"rows"`
+	Cols    int      `json:"cols"`
+	SeatNos []string `json:"seat_nos"`
+	Section string   `json:"section"`
+}
+
+// seatNos expands the layout into normalized, de-duplicated seat numbers.
+func (l SeatLayout) seatNos(f seatNoFormat) ([]string, error) {
+	if _, err := normalizeSection(l.Section); err != nil {
+		return nil, err
+	}
+	grid := l.Rows != 0 || l.Cols != 0
+	switch {
+	case grid && len(l.SeatNos) > 0:
+		return nil, errors.New("give either rows and cols or seat_nos, not both")
+	case grid:
+		if l.Rows <= 0 || l.Cols <= 0 {
+			return nil, errors.New("rows and cols must both be positive")
+		}
+		if l.Rows > maxSeatsPerRequest || l.Cols > maxSeatsPerRequest || l.Rows*l.Cols > maxSeatsPerRequest {
+			return nil, fmt.Errorf("layout has %d seats, at most %d allowed", l.Rows*l.Cols, maxSeatsPerRequest)
+		}
+		out := make([]string, 0, l.Rows*l.Cols)
+		for r := 0; r < l.Rows; r++ {
+			label := rowLabel(r)
+			for col := 1; col <= l.Cols; col++ {
+				out = append(out, label+strconv.Itoa(col))
+			}
+		}
+		return f.unique(out), nil
+	default:
+		if len(l.SeatNos) > maxSeatsPerRequest {
+			return nil, fmt.Errorf("at most %d seats allowed, got %d", maxSeatsPerRequest, len(l.SeatNos))
+		}
+		out := f.unique(l.SeatNos)
+		if len(out) == 0 {
+			return nil, errors.New("no valid seat numbers provided")
+		}
+		return out, nil
+	}
+}
+
+// section is the section the layout's seats go into. Call it after seatNos
+// has validated the layout.
+func (l *SeatLayout) section() string {
+	if l == nil {
+		return defaultSection
+	}
+	s, _ := normalizeSection(l.Section)
+	return s
+}
+
+// rowLabel names the zero-based row i like a spreadsheet column: A..Z, AA, AB, ...
+func rowLabel(i int) string {
+	label := ""
+	for i >= 0 {
+		label = string(rune('A'+i%26)) + label
+		i = i/26 - 1
+	}
+	return label
+}
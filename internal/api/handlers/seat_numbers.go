@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seatNoFormat says how seat numbers are normalized before they are stored or
+// looked up, so "a1 " and "A1" name the same seat.
+type seatNoFormat string
+
+const (
+	// seatNoUpper trims whitespace and uppercases (the default).
+	seatNoUpper seatNoFormat = "upper"
+	// seatNoTrim only trims whitespace; case is significant.
+	seatNoTrim seatNoFormat = "trim"
+	// seatNoExact stores seat numbers exactly as sent.
+	seatNoExact seatNoFormat = "none"
+)
+
+// seatNoFormatFromEnv reads SEAT_NO_NORMALIZATION (upper, trim or none).
+// Unset means upper.
+func seatNoFormatFromEnv() (seatNoFormat, error) {
+	raw := strings.ToLower(strings.TrimSpace(os.Getenv("SEAT_NO_NORMALIZATION")))
+	switch f := seatNoFormat(raw); f {
+	case "":
+		return seatNoUpper, nil
+	case seatNoUpper, seatNoTrim, seatNoExact:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid SEAT_NO_NORMALIZATION %q: want upper, trim or none", raw)
+	}
+}
+
+func (f seatNoFormat) normalize(s string) string {
+	switch f {
+	case seatNoExact:
+		return s
+	case seatNoTrim:
+		return strings.TrimSpace(s)
+	default:
+		return strings.ToUpper(strings.TrimSpace(s))
+	}
+}
+
+// unique normalizes seat numbers and drops blanks and duplicates, keeping the
+// caller's order.
+func (f seatNoFormat) unique(in []string) []string {
+	seen := make(map[string]struct{}, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		s = f.normalize(s)
+		if _, dup := seen[s]; dup || strings.TrimSpace(s) == "" {
+			continue
+		}
+		seen[s] = struct{}{}
+		out = append(out, s)
+	}
+	return out
+}
+
+// CheckSeatNumbers returns an error when stored seat numbers are not in the
+// form SEAT_NO_NORMALIZATION gives them, e.g. seats created before it was
+// set. Lookups normalize what they are sent, so such a seat can't be held or
+// booked by number until it is renamed; the rest of the event is unaffected,
+// so callers report it rather than stop.
+func CheckSeatNumbers(ctx context.Context, pool *pgxpool.Pool) error {
+	f, err := seatNoFormatFromEnv()
+	if err != nil || f == seatNoExact {
+		return err
+	}
+	rows, err := db.New(pool).ListSeatNoNormalizationCandidates(ctx)
+	if err != nil {
+		return fmt.Errorf("check seat numbers: %w", err)
+	}
+	total, examples := f.unnormalized(rows)
+	if total == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d seat numbers are not normalized for SEAT_NO_NORMALIZATION=%s and can't be held or booked by number, e.g. %s; "+
+		"rename them (see README) or set SEAT_NO_NORMALIZATION to match",
+		total, f, strings.Join(examples, ", "))
+}
+
+// unnormalized counts the seats whose number normalize would change and
+// describes up to five of them. The database only narrows the candidates:
+// Postgres's upper() need not agree with strings.ToUpper beyond ASCII.
+func (f seatNoFormat) unnormalized(rows []db.ListSeatNoNormalizationCandidatesRow) (int, []string) {
+	var total int
+	var examples []string
+	for _, r := range rows {
+		if f.normalize(r.SeatNo) == r.SeatNo {
+			continue
+		}
+		total++
+		if len(examples) < 5 {
+			examples = append(examples, fmt.Sprintf("%q (event %s)", r.SeatNo, r.EventID.String()))
+		}
+	}
+	return total, examples
+}
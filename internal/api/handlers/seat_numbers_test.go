@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+)
+
+func TestSeatNoNormalize(t *testing.T) {
+	tests := []struct {
+		format seatNoFormat
+		in     string
+		want   string
+	}{
+		{seatNoUpper, "a1", "A1"},
+		{seatNoUpper, "  b12\t", "B12"},
+		{seatNoUpper, "Vip-3\n", "VIP-3"},
+		{seatNoTrim, " a1 ", "a1"},
+		{seatNoTrim, "Vip-3", "Vip-3"},
+		{seatNoExact, " a1 ", " a1 "},
+		{seatNoExact, "A1", "A1"},
+	}
+	for _, tt := range tests {
+		if got := tt.format.normalize(tt.in); got != tt.want {
+			t.Errorf("%s.normalize(%q) = %q, want %q", tt.format, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSeatNoUnique(t *testing.T) {
+	in := []string{"a1", " A1", "A1 ", "b2", "", "   ", "B2", "a10"}
+	tests := []struct {
+		format seatNoFormat
+		want   []string
+	}{
+		{seatNoUpper, []string{"A1", "B2", "A10"}},
+		{seatNoTrim, []string{"a1", "A1", "b2", "B2", "a10"}},
+		{seatNoExact, []string{"a1", " A1", "A1 ", "b2", "B2", "a10"}},
+	}
+	for _, tt := range tests {
+		if got := tt.format.unique(in); !slices.Equal(got, tt.want) {
+			t.Errorf("%s.unique = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestSeatNoFormatFromEnv(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    seatNoFormat
+		wantErr bool
+	}{
+		{"", seatNoUpper, false},
+		{"upper", seatNoUpper, false},
+		{" Trim ", seatNoTrim, false},
+		{"NONE", seatNoExact, false},
+		{"lower", "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("SEAT_NO_NORMALIZATION", tt.env)
+		got, err := seatNoFormatFromEnv()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("SEAT_NO_NORMALIZATION=%q: got %q, %v", tt.env, got, err)
+		}
+	}
+}
+
+func TestSeatNoUnnormalized(t *testing.T) {
+	// Candidates as the database picks them: a superset of what normalizing
+	// actually changes.
+	var rows []db.ListSeatNoNormalizationCandidatesRow
+	for _, s := range []string{"a1", " A1", "É1", "é1", "ÄRE-3", "B2\t"} {
+		rows = append(rows, db.ListSeatNoNormalizationCandidatesRow{SeatNo: s})
+	}
+	tests := []struct {
+		format seatNoFormat
+		want   int
+	}{
+		{seatNoUpper, 4}, // a1, " A1", é1, "B2\t"
+		{seatNoTrim, 2},  // " A1", "B2\t"
+	}
+	for _, tt := range tests {
+		got, examples := tt.format.unnormalized(rows)
+		if got != tt.want || len(examples) != tt.want {
+			t.Errorf("%s.unnormalized = %d (%q), want %d", tt.format, got, examples, tt.want)
+		}
+	}
+}
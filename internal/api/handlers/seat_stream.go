@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// seatStreamHeartbeat keeps idle connections from being cut by proxies.
+const seatStreamHeartbeat = 15 * time.Second
+
+// GET /events/:id/seats/stream
+// StreamSeats pushes seat status changes for an event as server-sent events.
+// Delivery is best-effort: a client that reconnects (or is dropped for
+// falling behind) should refetch GET /events/:id/seats and apply deltas on top.
+func (h *EventsHandler) StreamSeats(c *gin.Context) {
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id", "details": err.Error()})
+		return
+	}
+
+	event, err := h.db.GetEventByID(c.Request.Context(), pgtype.UUID{Bytes: uid, Valid: true})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch event", "details": err.Error()})
+		return
+	}
+	if event.DeletedAt.Valid {
+		c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+		return
+	}
+	if h.seatHub == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "seat stream is not available"})
+		return
+	}
+
+	// The server's WriteTimeout would otherwise end the stream.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("seat stream: could not clear write deadline: %v", err)
+	}
+
+	sub := h.seatHub.Subscribe(uid)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.SSEvent("ready", gin.H{"event_id": uid.String()})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(seatStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case u, ok := <-sub.C:
+			if !ok {
+				// Dropped by the hub for falling behind; the client resyncs.
+				return
+			}
+			c.SSEvent("seats", u)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
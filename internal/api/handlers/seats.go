@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"context"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -14,22 +14,39 @@ import (
 
 type SeatResponse struct {
 	SeatNo    string    `json:"seat_no"`
+	Section   string    `json:"section"`
 	Status    string    `json:"status"`
 	BookingID *string   `json:"booking_id,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// SeatSection is one section of the seat map returned by
+// GET /events/:id/seats?group_by=section.
+type SeatSection struct {
+	Section string         `json:"section"`
+	Seats   []SeatResponse `json:"seats"`
+}
+
+// BulkCreateSeatsRequest adds seats to Section, the default section when
+// empty. Seat numbers that already exist keep their section.
 type BulkCreateSeatsRequest struct {
 	SeatNos []string `json:"seat_nos" binding:"required,min=1"`
+	Section string   `json:"section"`
 }
 
-func NewSeatsHandler(dbconn *pgx.Conn) *EventsHandler {
-	return &EventsHandler{
-		db: db.New(dbconn),
-	}
+// BulkCreateSeatsResponse splits the submitted seat numbers, after
+// normalization, into seats this request created and ones that already existed.
+type BulkCreateSeatsResponse struct {
+	Created  []SeatResponse `json:"created"`
+	Existing []string       `json:"existing"`
 }
 
+// GET /events/:id/seats
+// Seat handlers live on EventsHandler so they share its pool.
+// ?status=available returns only bookable seats, paginated with limit/offset.
+// ?section= narrows the seats to one section. ?group_by=section returns the
+// full seat map as a list of sections instead of a flat list of seats.
 func (h *EventsHandler) GetSeats(c *gin.Context) {
 	id := c.Param("id")
 	uid, err := uuid.Parse(id)
@@ -37,8 +54,32 @@ func (h *EventsHandler) GetSeats(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id", "details": err.Error()})
 		return
 	}
+	section, err := sectionFilter(c.Query("section"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'section' query parameter", "details": err.Error()})
+		return
+	}
+	groupBy := c.Query("group_by")
+	if groupBy != "" && groupBy != "section" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'group_by' query parameter", "details": "only 'section' is supported"})
+		return
+	}
 
-	seats, err := h.db.GetSeatsByEvent(context.Background(), pgtype.UUID{Bytes: uid, Valid: true})
+	switch status := c.Query("status"); status {
+	case "":
+	case "available":
+		if groupBy != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'group_by' query parameter", "details": "group_by cannot be combined with status"})
+			return
+		}
+		h.getAvailableSeats(c, uid, section)
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'status' query parameter", "details": "only 'available' is supported"})
+		return
+	}
+
+	seats, err := h.db.GetSeatsByEvent(c.Request.Context(), pgtype.UUID{Bytes: uid, Valid: true})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch seats", "details": err.Error()})
 		return
@@ -46,6 +87,9 @@ func (h *EventsHandler) GetSeats(c *gin.Context) {
 
 	resp := make([]SeatResponse, 0, len(seats))
 	for _, s := range seats {
+		if section != "" && s.Section != section {
+			continue
+		}
 		var bid *string
 		if s.BookingID.Valid {
 			bs := s.BookingID.String()
@@ -54,6 +98,7 @@ func (h *EventsHandler) GetSeats(c *gin.Context) {
 
 		resp = append(resp, SeatResponse{
 			SeatNo:    s.SeatNo,
+			Section:   s.Section,
 			Status:    s.Status,
 			BookingID: bid,
 			CreatedAt: s.CreatedAt.Time,
@@ -61,9 +106,28 @@ func (h *EventsHandler) GetSeats(c *gin.Context) {
 		})
 	}
 
+	if groupBy == "section" {
+		c.JSON(http.StatusOK, groupSeatsBySection(resp))
+		return
+	}
 	c.JSON(http.StatusOK, resp)
 }
 
+// groupSeatsBySection splits seats, which are ordered by section, into
+// one entry per section.
+func groupSeatsBySection(seats []SeatResponse) []SeatSection {
+	out := []SeatSection{}
+	for _, s := range seats {
+		if n := len(out); n == 0 || out[n-1].Section != s.Section {
+			out = append(out, SeatSection{Section: s.Section})
+		}
+		last := &out[len(out)-1]
+		last.Seats = append(last.Seats, s)
+	}
+	return out
+}
+
+// POST /events/:id/seats
 func (h *EventsHandler) BulkCreateSeats(c *gin.Context) {
 	id := c.Param("id")
 	uid, err := uuid.Parse(id)
@@ -79,33 +143,163 @@ func (h *EventsHandler) BulkCreateSeats(c *gin.Context) {
 	}
 
 	// simple guard: don't allow huge batches
-	if len(req.SeatNos) > 2000 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "too many seats in a single request", "details": "max 2000"})
+	if len(req.SeatNos) > maxSeatsPerRequest {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many seats in a single request", "details": fmt.Sprintf("max %d", maxSeatsPerRequest)})
+		return
+	}
+	seatNos := h.seatNoFormat.unique(req.SeatNos)
+	if len(seatNos) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no valid seat numbers provided"})
+		return
+	}
+	section, err := normalizeSection(req.Section)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid section", "details": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	eventID := pgtype.UUID{Bytes: uid, Valid: true}
+
+	// seat inventory must not outgrow what the event may sell
+	event, err := h.db.GetEventByID(ctx, eventID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch event", "details": err.Error()})
+		return
+	}
+	if !canManageEvent(c, event.OwnerID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you can only manage events you own"})
+		return
+	}
+	if event.DeletedAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{"error": "event is archived", "details": "restore the event before adding seats"})
+		return
+	}
+	existing, err := h.db.CountSeatsByEvent(ctx, eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count seats", "details": err.Error()})
 		return
 	}
+	// Seat numbers that already exist are skipped by the insert, so count only new ones.
+	sellable := sellableCapacity(event.Capacity, event.OversellPercent)
+	if remaining := int64(sellable) - existing; int64(len(seatNos)) > remaining {
+		var current []string
+		if rows, err := h.db.GetSeatsByEvent(ctx, eventID); err == nil {
+			current = make([]string, 0, len(rows))
+			for _, r := range rows {
+				current = append(current, r.SeatNo)
+			}
+		}
+		if newSeats := countNewSeatNos(seatNos, current); int64(newSeats) > remaining {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":               "seat count exceeds event capacity",
+				"capacity":            event.Capacity,
+				"sellable_capacity":   sellable,
+				"existing_seats":      existing,
+				"requested_new_seats": newSeats,
+			})
+			return
+		}
+	}
 
-	inserted, err := h.db.BulkInsertSeats(context.Background(), db.BulkInsertSeatsParams{EventID: pgtype.UUID{Bytes: uid, Valid: true}, Column2: req.SeatNos})
+	// Seats that already exist are skipped by the insert and not returned.
+	inserted, err := h.db.BulkInsertSeats(ctx, db.BulkInsertSeatsParams{EventID: eventID, Column2: seatNos, Section: section})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create seats", "details": err.Error()})
 		return
 	}
 
-	// Return list of created (or existing) seats
-	exResp := make([]SeatResponse, 0, len(inserted))
+	resp := BulkCreateSeatsResponse{
+		Created:  make([]SeatResponse, 0, len(inserted)),
+		Existing: make([]string, 0, len(seatNos)-len(inserted)),
+	}
+	newSeatNos := make([]string, 0, len(inserted))
+	created := make(map[string]struct{}, len(inserted))
 	for _, s := range inserted {
+		newSeatNos = append(newSeatNos, s.SeatNo)
+		created[s.SeatNo] = struct{}{}
 		var bid *string
 		if s.BookingID.Valid {
 			bs := s.BookingID.String()
 			bid = &bs
 		}
-		exResp = append(exResp, SeatResponse{
+		resp.Created = append(resp.Created, SeatResponse{
 			SeatNo:    s.SeatNo,
+			Section:   s.Section,
 			Status:    s.Status,
 			BookingID: bid,
 			CreatedAt: s.CreatedAt.Time,
 			UpdatedAt: s.UpdatedAt.Time,
 		})
 	}
+	for _, s := range seatNos {
+		if _, ok := created[s]; !ok {
+			resp.Existing = append(resp.Existing, s)
+		}
+	}
 
-	c.JSON(http.StatusCreated, exResp)
+	h.seatHub.Publish(eventID.Bytes, "available", newSeatNos)
+
+	status := http.StatusCreated
+	if len(resp.Created) == 0 {
+		status = http.StatusOK
+	}
+	c.JSON(status, resp)
+}
+
+// countNewSeatNos counts distinct entries of seatNos that are not in existing.
+func countNewSeatNos(seatNos, existing []string) int {
+	seen := make(map[string]struct{}, len(existing)+len(seatNos))
+	for _, s := range existing {
+		seen[s] = struct{}{}
+	}
+	n := 0
+	for _, s := range seatNos {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		n++
+	}
+	return n
+}
+
+// seatPages is larger than the general policy since seat maps are read in
+// bulk.
+var seatPages = pagePolicy{Default: 100, Max: 1000}
+
+func (h *EventsHandler) getAvailableSeats(c *gin.Context, eventID uuid.UUID, section string) {
+	page, perr := parsePage(c, seatPages)
+	if perr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": perr.Message, "details": perr.Details})
+		return
+	}
+
+	seats, err := h.db.GetAvailableSeatsByEvent(c.Request.Context(), db.GetAvailableSeatsByEventParams{
+		EventID: pgtype.UUID{Bytes: eventID, Valid: true},
+		Limit:   page.Limit,
+		Offset:  page.Offset,
+		Column4: section,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch seats", "details": err.Error()})
+		return
+	}
+
+	resp := make([]SeatResponse, 0, len(seats))
+	for _, s := range seats {
+		resp = append(resp, SeatResponse{
+			SeatNo:    s.SeatNo,
+			Section:   s.Section,
+			Status:    s.Status,
+			CreatedAt: s.CreatedAt.Time,
+			UpdatedAt: s.UpdatedAt.Time,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
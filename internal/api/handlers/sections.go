@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultSection holds the seats of events that don't use sections, and any
+// seats created without one.
+const defaultSection = "general"
+
+// maxSectionLen matches the seats.section check constraint.
+const maxSectionLen = 50
+
+// SectionAvailability counts one section's seats by status. Total includes
+// blocked seats.
+type SectionAvailability struct {
+	Section   string `json:"section"`
+	Total     int32  `json:"total"`
+	Available int32  `json:"available"`
+	Held      int32  `json:"held"`
+	Booked    int32  `json:"booked"`
+}
+
+// eventSeatCounts is an event's seats counted by status, in total and per
+// section in section order.
+type eventSeatCounts struct {
+	byStatus map[string]int32
+	sections []SectionAvailability
+}
+
+// normalizeSection trims a requested section name; empty means
+// defaultSection.
+func normalizeSection(raw string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return defaultSection, nil
+	}
+	if len(s) > maxSectionLen {
+		return "", fmt.Errorf("section must be at most %d characters", maxSectionLen)
+	}
+	return s, nil
+}
+
+// sectionFilter trims an optional section used to narrow a query; empty
+// means every section.
+func sectionFilter(raw string) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return "", nil
+	}
+	return normalizeSection(raw)
+}
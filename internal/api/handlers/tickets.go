@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/tickets"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TicketsHandler struct {
+	db *db.Queries
+	DB *pgxpool.Pool
+}
+
+type VerifyTicketRequest struct {
+	Token string `json:"token" binding:"required"`
+	// CheckIn marks the booking as used once the ticket validates.
+	CheckIn bool `json:"check_in"`
+}
+
+type TicketHolder struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type VerifyTicketResponse struct {
+	BookingID   string        `json:"booking_id"`
+	EventID     string        `json:"event_id"`
+	Status      string        `json:"status"`
+	SeatNumbers []string      `json:"seat_numbers"`
+	Holder      *TicketHolder `json:"holder,omitempty"`
+	IssuedAt    time.Time     `json:"issued_at"`
+	CheckedInAt *time.Time    `json:"checked_in_at,omitempty"`
+	// AlreadyCheckedIn is true when the booking had been checked in before this scan.
+	AlreadyCheckedIn bool `json:"already_checked_in"`
+}
+
+type CheckInResponse struct {
+	BookingID   string    `json:"booking_id"`
+	CheckedInAt time.Time `json:"checked_in_at"`
+}
+
+var errBookingNotActive = errors.New("booking is not active")
+
+func NewTicketsHandler(dbconn *pgxpool.Pool) *TicketsHandler {
+	return &TicketsHandler{
+		db: db.New(dbconn),
+		DB: dbconn,
+	}
+}
+
+// POST /tickets/verify
+// Validates the signed token from a ticket QR code and returns the booking it
+// belongs to. Only active bookings pass. With "check_in": true the booking is
+// also marked as used; re-scanning a used ticket reports the original check-in
+// time instead of failing.
+func (h *TicketsHandler) VerifyTicket(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req VerifyTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request", "details": err.Error()})
+		return
+	}
+
+	claims, err := tickets.Verify(req.Token)
+	if err != nil {
+		if errors.Is(err, tickets.ErrNoSecret) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Server misconfiguration: ticket secret not set", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket", "details": err.Error()})
+		return
+	}
+
+	bookingID, err := uuid.Parse(claims.BookingID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket", "details": "malformed booking id"})
+		return
+	}
+
+	b, err := h.db.GetBookingByID(ctx, pgtype.UUID{Bytes: bookingID, Valid: true})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "booking not found", "details": err.Error()})
+		return
+	}
+	if b.EventID.String() != claims.EventID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket", "details": "ticket does not match booking event"})
+		return
+	}
+	// Legacy tokens have no holder; a transfer bumps the token version, which
+	// still revokes them below.
+	if claims.UserID != "" && b.UserID.String() != claims.UserID {
+		c.JSON(http.StatusConflict, gin.H{"error": "ticket not valid", "details": "booking has been transferred to another holder"})
+		return
+	}
+	if b.TokenVersion != claims.Version {
+		c.JSON(http.StatusConflict, gin.H{"error": "ticket not valid", "details": "ticket has been regenerated; this code was revoked"})
+		return
+	}
+	if b.Status != "active" {
+		c.JSON(http.StatusConflict, gin.H{"error": "ticket not valid", "details": "booking is " + b.Status})
+		return
+	}
+
+	seatNumbers, err := h.db.GetSeatNosByIds(ctx, b.SeatIds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get seat numbers", "details": err.Error()})
+		return
+	}
+
+	resp := VerifyTicketResponse{
+		BookingID:   b.ID.String(),
+		EventID:     b.EventID.String(),
+		Status:      b.Status,
+		SeatNumbers: seatNumbers,
+	}
+	if claims.IssuedAt != nil {
+		resp.IssuedAt = claims.IssuedAt.Time
+	}
+	if b.CheckedInAt.Valid {
+		t := b.CheckedInAt.Time
+		resp.CheckedInAt = &t
+		resp.AlreadyCheckedIn = true
+	} else if req.CheckIn {
+		at, already, err := h.checkIn(ctx, b)
+		if err != nil {
+			if errors.Is(err, errBookingNotActive) {
+				c.JSON(http.StatusConflict, gin.H{"error": "ticket not valid", "details": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check in", "details": err.Error()})
+			return
+		}
+		resp.CheckedInAt = &at
+		resp.AlreadyCheckedIn = already
+	}
+	if b.UserID.Valid {
+		if u, err := h.db.GetUserByID(ctx, b.UserID); err == nil {
+			resp.Holder = &TicketHolder{ID: u.ID.String(), Name: u.Name, Email: u.Email}
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /bookings/:id/checkin
+// Marks an active booking as used at the gate. Returns 409 if it was already
+// checked in, along with the original check-in time.
+func (h *TicketsHandler) CheckInBooking(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid booking id", "details": err.Error()})
+		return
+	}
+
+	b, err := h.db.GetBookingByID(ctx, pgtype.UUID{Bytes: bookingID, Valid: true})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "booking not found", "details": err.Error()})
+		return
+	}
+	if b.Status != "active" {
+		c.JSON(http.StatusConflict, gin.H{"error": "booking not active", "details": "booking is " + b.Status})
+		return
+	}
+
+	at, already, err := h.checkIn(ctx, b)
+	if err != nil {
+		if errors.Is(err, errBookingNotActive) {
+			c.JSON(http.StatusConflict, gin.H{"error": "booking not active", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check in", "details": err.Error()})
+		return
+	}
+	if already {
+		c.JSON(http.StatusConflict, gin.H{"error": "booking already checked in", "checked_in_at": at})
+		return
+	}
+
+	c.JSON(http.StatusOK, CheckInResponse{BookingID: b.ID.String(), CheckedInAt: at})
+}
+
+// checkIn marks b as checked in. It returns the check-in time and whether the
+// booking had already been checked in (e.g. by a concurrent scan).
+func (h *TicketsHandler) checkIn(ctx context.Context, b db.Booking) (time.Time, bool, error) {
+	if b.CheckedInAt.Valid {
+		return b.CheckedInAt.Time, true, nil
+	}
+
+	at, err := h.db.MarkBookingCheckedIn(ctx, b.ID)
+	if err == nil {
+		return at.Time, false, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, err
+	}
+
+	// No row matched: another scan won the race, or the booking was cancelled meanwhile.
+	cur, err := h.db.GetBookingByID(ctx, b.ID)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if cur.CheckedInAt.Valid {
+		return cur.CheckedInAt.Time, true, nil
+	}
+	return time.Time{}, false, errBookingNotActive
+}
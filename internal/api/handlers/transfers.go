@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type TransferBookingRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type TransferBookingResponse struct {
+	ID      string `json:"id"`
+	EventID string `json:"event_id"`
+	UserID  string `json:"user_id"`
+	Email   string `json:"email"`
+	Status  string `json:"status"`
+}
+
+// POST /bookings/:id/transfer
+// Hands an active booking to another registered user (owner only). Both parties
+// are emailed; the new holder gets a fresh ticket QR and the old one stops verifying.
+func (h *BookingsHandler) TransferBooking(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid booking id", err.Error())
+		return
+	}
+
+	var currentUserID uuid.UUID
+	if v, ok := c.Get("user_id"); ok {
+		switch t := v.(type) {
+		case uuid.UUID:
+			currentUserID = t
+		case string:
+			if parsed, perr := uuid.Parse(t); perr == nil {
+				currentUserID = parsed
+			}
+		}
+	}
+	if currentUserID == uuid.Nil {
+		writeError(c, http.StatusUnauthorized, CodeUnauthenticated, "unauthenticated", nil)
+		return
+	}
+
+	var req TransferBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request", err.Error())
+		return
+	}
+
+	target, err := h.db.GetUserByEmail(ctx, strings.TrimSpace(req.Email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, CodeUserNotFound, "target user not found", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to look up target user", err.Error())
+		return
+	}
+	if target.ID.Bytes == currentUserID {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "cannot transfer a booking to yourself", nil)
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to start transaction", err.Error())
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	q := db.New(tx)
+
+	bookingRow, err := q.GetBookingForUpdate(ctx, pgtype.UUID{Bytes: bookingID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, CodeBookingNotFound, "booking not found", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to fetch booking", err.Error())
+		return
+	}
+
+	if !bookingRow.UserID.Valid || bookingRow.UserID.Bytes != currentUserID {
+		writeError(c, http.StatusForbidden, CodeForbidden, "forbidden: only the booking owner can transfer it", nil)
+		return
+	}
+	if bookingRow.Status != "active" {
+		c.JSON(http.StatusConflict, apiError(CodeBookingNotActive, "booking cannot be transferred", nil).with("status", bookingRow.Status))
+		return
+	}
+
+	if _, err := q.UpdateBookingOwner(ctx, db.UpdateBookingOwnerParams{ID: bookingRow.ID, UserID: target.ID}); err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to transfer booking", err.Error())
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to commit", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, TransferBookingResponse{
+		ID:      bookingRow.ID.String(),
+		EventID: bookingRow.EventID.String(),
+		UserID:  target.ID.String(),
+		Email:   target.Email,
+		Status:  bookingRow.Status,
+	})
+
+	// New holder gets a regular confirmation with a QR bound to them.
+	confirmation, err := h.BuildBookingConfirmation(ctx, bookingRow.ID)
+	if err != nil {
+		log.Printf("failed to build confirmation for transferred booking %s: %v", bookingRow.ID.String(), err)
+		confirmation = CreateBookingResponse{
+			ID:        bookingRow.ID.String(),
+			EventID:   bookingRow.EventID.String(),
+			CreatedAt: bookingRow.CreatedAt.Time,
+		}
+	}
+	h.queueConfirmation(confirmation, target.ID)
+
+	if err := h.mailQueue.Notify(mail.KindBookingTransferred, mail.TransferNoticeJob{
+		BookingID:  bookingRow.ID.String(),
+		EventID:    bookingRow.EventID.String(),
+		FromUserID: bookingRow.UserID.String(),
+		ToEmail:    target.Email,
+	}); err != nil {
+		log.Printf("failed to queue transfer notice for booking %s: %v", bookingRow.ID.String(), err)
+	}
+}
@@ -1,37 +1,73 @@
 package handlers
 
 import (
-	"context"
+	"log"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 
+	"github.com/abhinandanwadwa/overbookr/internal/api/middleware"
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
+	"github.com/abhinandanwadwa/overbookr/internal/auth"
 	"github.com/abhinandanwadwa/overbookr/internal/db"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UsersHandler struct {
-	db *db.Queries
+	db        *db.Queries
+	mailQueue *mail.Queue
+	// verificationTTL and inviteTTL are how long email verification and
+	// invite links stay valid.
+	verificationTTL time.Duration
+	inviteTTL       time.Duration
+	lockout         loginLockout
+	tokens          auth.Config
 }
 
+// RegisterUserRequest is the public sign-up payload. Role is accepted for
+// backwards compatibility but ignored: self-registered accounts are always
+// plain users. Elevated accounts are created through POST /admin/users.
 type RegisterUserRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role" binding:"required,oneof=admin user"`
+	Role     string `json:"role" binding:"omitempty,oneof=admin user organizer gate"`
+	// Phone is optional, in E.164 form (e.g. +14155550123); it enables SMS notifications.
+	Phone string `json:"phone" binding:"omitempty,e164"`
+}
+
+type AdminCreateUserRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	Role     string `json:"role" binding:"required,oneof=admin user organizer gate"`
+	Phone    string `json:"phone" binding:"omitempty,e164"`
+}
+
+type UserResponse struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Email     string  `json:"email"`
+	Role      string  `json:"role"`
+	Phone     *string `json:"phone,omitempty"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
 }
 
 type CreateUserResponse struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Role      string `json:"role"`
-	Token     string `json:"token"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Email         string  `json:"email"`
+	Role          string  `json:"role"`
+	Phone         *string `json:"phone,omitempty"`
+	EmailVerified bool    `json:"email_verified"`
+	Token         string  `json:"token"`
+	ExpiresAt     string  `json:"expires_at"`
+	CreatedAt     string  `json:"created_at"`
+	UpdatedAt     string  `json:"updated_at"`
 }
 
 type LoginRequest struct {
@@ -40,31 +76,45 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Role      string `json:"role"`
-	Token     string `json:"token"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Email         string  `json:"email"`
+	Role          string  `json:"role"`
+	Phone         *string `json:"phone,omitempty"`
+	EmailVerified bool    `json:"email_verified"`
+	Token         string  `json:"token"`
+	ExpiresAt     string  `json:"expires_at"`
+	CreatedAt     string  `json:"created_at"`
+	UpdatedAt     string  `json:"updated_at"`
 }
 
-func NewUsersHandler(dbconn *pgxpool.Pool) *UsersHandler {
-	return &UsersHandler{
-		db: db.New(dbconn),
+func NewUsersHandler(dbconn *pgxpool.Pool, mailQueue *mail.Queue) (*UsersHandler, error) {
+	h := &UsersHandler{
+		db:        db.New(dbconn),
+		mailQueue: mailQueue,
+		tokens:    auth.ConfigFromEnv(),
+	}
+	var err error
+	if h.verificationTTL, err = emailVerificationTTLFromEnv(); err != nil {
+		return nil, err
+	}
+	if h.inviteTTL, err = positiveDurationFromEnv("USER_INVITE_TTL", defaultUserInviteTTL); err != nil {
+		return nil, err
 	}
+	if h.lockout, err = loginLockoutFromEnv(); err != nil {
+		return nil, err
+	}
+	return h, nil
 }
 
 func (h *UsersHandler) Register(c *gin.Context) {
 	// check JWT secret early (fail fast)
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		// c.JSON(http.StatusInternalServerError, gin.H{
-		// 	"error":   "Server misconfiguration: JWT secret not set",
-		// 	"details": "Set JWT_SECRET environment variable",
-		// })
-		// return
-		secret = "secret"
+	if !h.tokens.CanSign() {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Server misconfiguration: JWT signing key not set",
+			"details": "Set JWT_SECRET, or the key files for JWT_ALG=RS256",
+		})
+		return
 	}
 
 	var req RegisterUserRequest
@@ -76,82 +126,130 @@ func (h *UsersHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// use GetUserByEmail to check existence first
-	if existing, err := h.db.GetUserByEmail(context.Background(), req.Email); err == nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error":   "User already exists",
-			"details": "A user with this email already exists",
-			"user_id": existing.ID.String(),
-		})
+	// Self-registration never grants an elevated role, whatever was asked for.
+	user, ok := h.createUser(c, db.CreateUserParams{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
+		Role:     "user",
+		Phone:    optionalText(req.Phone),
+	})
+	if !ok {
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	// The account exists either way; a failed send only delays verification.
+	if _, err := h.sendVerificationEmail(c.Request.Context(), user.ID, 0); err != nil {
+		log.Printf("failed to send verification email to user %s: %v", user.ID.String(), err)
+	}
+
+	signedToken, expiresAt, err := h.tokens.Mint(user.ID.String(), user.Role, time.Now())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to hash password",
+			"error":   "Failed to generate token",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	params := db.CreateUserParams{
-		Name:     req.Name,
-		Email:    req.Email,
-		Password: string(hashedPassword),
-		Role:     req.Role,
+	response := CreateUserResponse{
+		ID:        user.ID.String(),
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		Phone:     textPtr(user.Phone),
+		Token:     signedToken,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+		CreatedAt: user.CreatedAt.Time.String(),
+		UpdatedAt: user.UpdatedAt.Time.String(),
 	}
 
-	user, err := h.db.CreateUser(context.Background(), params)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create user",
+	c.JSON(http.StatusCreated, response)
+}
+
+// POST /admin/users
+// AdminCreateUser lets an admin create an account with any role.
+func (h *UsersHandler) AdminCreateUser(c *gin.Context) {
+	var req AdminCreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	expiration := time.Now().Add(72 * time.Hour)
-
-	claims := jwt.MapClaims{
-		"sub":  user.ID.String(),
-		"role": user.Role,
-		"iat":  time.Now().Unix(),
-		"exp":  expiration.Unix(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(secret))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to generate token",
-			"details": err.Error(),
-		})
+	// Admin-created accounts are trusted and skip email verification.
+	user, ok := h.createUser(c, db.CreateUserParams{
+		Name:          req.Name,
+		Email:         req.Email,
+		Password:      req.Password,
+		Role:          req.Role,
+		EmailVerified: true,
+		Phone:         optionalText(req.Phone),
+	})
+	if !ok {
 		return
 	}
 
-	response := CreateUserResponse{
+	c.Set(middleware.AuditTargetKey, user.ID.String())
+	c.JSON(http.StatusCreated, UserResponse{
 		ID:        user.ID.String(),
 		Name:      user.Name,
 		Email:     user.Email,
 		Role:      user.Role,
-		Token:     signedToken,
+		Phone:     textPtr(user.Phone),
 		CreatedAt: user.CreatedAt.Time.String(),
 		UpdatedAt: user.UpdatedAt.Time.String(),
+	})
+}
+
+// createUser hashes params.Password (given in plain text) and inserts the
+// account. On failure it has already written the error response.
+func (h *UsersHandler) createUser(c *gin.Context, params db.CreateUserParams) (db.CreateUserRow, bool) {
+	// use GetUserByEmail to check existence first
+	if existing, err := h.db.GetUserByEmail(c.Request.Context(), params.Email); err == nil {
+		details := "A user with this email already exists"
+		if existing.Password == "" {
+			details = "This email has a pending invite; accept it from the invite email"
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "User already exists",
+			"details": details,
+			"user_id": existing.ID.String(),
+		})
+		return db.CreateUserRow{}, false
 	}
 
-	c.JSON(http.StatusCreated, response)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(params.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to hash password",
+			"details": err.Error(),
+		})
+		return db.CreateUserRow{}, false
+	}
+
+	params.Password = string(hashedPassword)
+
+	user, err := h.db.CreateUser(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create user",
+			"details": err.Error(),
+		})
+		return db.CreateUserRow{}, false
+	}
+	return user, true
 }
 
 func (h *UsersHandler) Login(c *gin.Context) {
 	// check JWT secret early (fail fast)
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		// c.JSON(http.StatusInternalServerError, gin.H{
-		// 	"error":   "Server misconfiguration: JWT secret not set",
-		// 	"details": "Set JWT_SECRET environment variable",
-		// })
-		// return
-		secret = "secret"
+	if !h.tokens.CanSign() {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Server misconfiguration: JWT signing key not set",
+			"details": "Set JWT_SECRET, or the key files for JWT_ALG=RS256",
+		})
 		return
 	}
 
@@ -164,9 +262,24 @@ func (h *UsersHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := h.db.GetUserByEmail(context.Background(), req.Email)
+	ctx := c.Request.Context()
+
+	// A locked account is refused even with the right password.
+	if until, locked := h.lockout.lockedUntil(ctx, h.db, req.Email); locked {
+		retryAfter := int(time.Until(until).Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many failed login attempts",
+			"details":     "Try again later",
+			"retry_after": retryAfter,
+		})
+		return
+	}
+
+	user, err := h.db.GetUserByEmail(ctx, req.Email)
 	if err != nil {
 		// do not reveal whether email exists; return generic unauthorized
+		h.lockout.recordFailure(ctx, h.db, req.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid credentials",
 		})
@@ -174,22 +287,15 @@ func (h *UsersHandler) Login(c *gin.Context) {
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		h.lockout.recordFailure(ctx, h.db, req.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid credentials",
 		})
 		return
 	}
+	h.lockout.reset(ctx, h.db, req.Email)
 
-	expiration := time.Now().Add(72 * time.Hour)
-
-	claims := jwt.MapClaims{
-		"sub":  user.ID.String(),
-		"role": user.Role,
-		"iat":  time.Now().Unix(),
-		"exp":  expiration.Unix(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(secret))
+	signedToken, expiresAt, err := h.tokens.Mint(user.ID.String(), user.Role, time.Now())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to generate token",
@@ -199,14 +305,29 @@ func (h *UsersHandler) Login(c *gin.Context) {
 	}
 
 	resp := LoginResponse{
-		ID:        user.ID.String(),
-		Name:      user.Name,
-		Email:     user.Email,
-		Role:      user.Role,
-		Token:     signedToken,
-		CreatedAt: user.CreatedAt.Time.String(),
-		UpdatedAt: user.UpdatedAt.Time.String(),
+		ID:            user.ID.String(),
+		Name:          user.Name,
+		Email:         user.Email,
+		Role:          user.Role,
+		Phone:         textPtr(user.Phone),
+		EmailVerified: user.EmailVerified,
+		Token:         signedToken,
+		ExpiresAt:     expiresAt.Format(time.RFC3339),
+		CreatedAt:     user.CreatedAt.Time.String(),
+		UpdatedAt:     user.UpdatedAt.Time.String(),
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// optionalText maps an empty string to NULL.
+func optionalText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+func textPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
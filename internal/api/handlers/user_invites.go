@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
+	"github.com/abhinandanwadwa/overbookr/internal/auth"
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const defaultUserInviteTTL = 7 * 24 * time.Hour
+
+// Per-email outcomes of POST /admin/users/invite.
+const (
+	InviteStatusInvited           = "invited"
+	InviteStatusReinvited         = "reinvited"
+	InviteStatusAlreadyRegistered = "already_registered"
+	InviteStatusFailed            = "failed"
+)
+
+type InviteUsersRequest struct {
+	Emails []string `json:"emails" binding:"required,min=1,max=500,dive,email"`
+	// Role of the accounts created; defaults to user.
+	Role string `json:"role" binding:"omitempty,oneof=admin user organizer gate"`
+}
+
+type InviteResult struct {
+	Email   string `json:"email"`
+	Status  string `json:"status"`
+	UserID  string `json:"user_id,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+type InviteUsersResponse struct {
+	Invited   int            `json:"invited"`
+	Skipped   int            `json:"skipped"`
+	Failed    int            `json:"failed"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	Results   []InviteResult `json:"results"`
+}
+
+type AcceptInviteRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+	// Name replaces the placeholder taken from the email address.
+	Name string `json:"name"`
+}
+
+// POST /admin/users/invite
+// Pre-registers each email as an account without a password and emails it a
+// one-time link to POST /users/accept-invite, valid for USER_INVITE_TTL.
+// Emails that already belong to an active account are skipped; ones with a
+// pending invite get a fresh link, which replaces their earlier ones, and the
+// requested role. Duplicates in the list are sent once.
+func (h *UsersHandler) InviteUsers(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req InviteUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	role := req.Role
+	if role == "" {
+		role = "user"
+	}
+	var invitedBy pgtype.UUID
+	if uid, ok := callerID(c); ok {
+		invitedBy = pgtype.UUID{Bytes: uid, Valid: true}
+	}
+	expiresAt := time.Now().Add(h.inviteTTL)
+
+	resp := InviteUsersResponse{ExpiresAt: expiresAt, Results: make([]InviteResult, 0, len(req.Emails))}
+	seen := make(map[string]bool, len(req.Emails))
+	for _, raw := range req.Emails {
+		email := strings.TrimSpace(raw)
+		if seen[email] {
+			continue
+		}
+		seen[email] = true
+
+		result := h.inviteUser(ctx, email, role, invitedBy, expiresAt)
+		switch result.Status {
+		case InviteStatusInvited, InviteStatusReinvited:
+			resp.Invited++
+		case InviteStatusAlreadyRegistered:
+			resp.Skipped++
+		default:
+			resp.Failed++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// inviteUser creates email's placeholder account, or reuses a pending one
+// with its earlier invites expired and its role set to role, and queues a new
+// invite. The invite token is generated when the mail is sent.
+func (h *UsersHandler) inviteUser(ctx context.Context, email, role string, invitedBy pgtype.UUID, expiresAt time.Time) InviteResult {
+	result := InviteResult{Email: email, Status: InviteStatusInvited}
+
+	var userID pgtype.UUID
+	existing, err := h.db.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil && existing.Password != "":
+		result.Status, result.UserID = InviteStatusAlreadyRegistered, existing.ID.String()
+		return result
+	case err == nil:
+		userID = existing.ID
+		result.Status = InviteStatusReinvited
+		// The new invite replaces the old ones, with the role asked for now.
+		if err := h.db.ExpireUserInvites(ctx, userID); err != nil {
+			result.Status, result.Details = InviteStatusFailed, "failed to expire earlier invites: "+err.Error()
+			return result
+		}
+		if existing.Role != role {
+			n, err := h.db.UpdateInvitedUserRole(ctx, db.UpdateInvitedUserRoleParams{ID: userID, Role: role})
+			if err != nil {
+				result.Status, result.Details = InviteStatusFailed, "failed to update role: "+err.Error()
+				return result
+			}
+			if n == 0 {
+				// Accepted between the lookup and the update.
+				result.Status = InviteStatusAlreadyRegistered
+				return result
+			}
+		}
+	case errors.Is(err, pgx.ErrNoRows):
+		name, _, _ := strings.Cut(email, "@")
+		user, err := h.db.CreateInvitedUser(ctx, db.CreateInvitedUserParams{Name: name, Email: email, Role: role})
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Registered between the lookup and the insert.
+			result.Status = InviteStatusAlreadyRegistered
+			return result
+		}
+		if err != nil {
+			result.Status, result.Details = InviteStatusFailed, "failed to create user: "+err.Error()
+			return result
+		}
+		userID = user.ID
+	default:
+		result.Status, result.Details = InviteStatusFailed, "failed to look up user: "+err.Error()
+		return result
+	}
+	result.UserID = userID.String()
+
+	job := mail.InviteJob{UserID: userID.String(), ExpiresAt: expiresAt}
+	if invitedBy.Valid {
+		job.InvitedBy = invitedBy.String()
+	}
+	if err := h.mailQueue.Notify(mail.KindUserInvite, job); err != nil {
+		result.Status, result.Details = InviteStatusFailed, "failed to queue invite email: "+err.Error()
+	}
+	return result
+}
+
+// POST /users/accept-invite
+// Sets the password of an invited account, which also confirms its email,
+// and logs the user in.
+func (h *UsersHandler) AcceptInvite(c *gin.Context) {
+	if !h.tokens.CanSign() {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Server misconfiguration: JWT signing key not set",
+			"details": "Set JWT_SECRET, or the key files for JWT_ALG=RS256",
+		})
+		return
+	}
+
+	var req AcceptInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password", "details": err.Error()})
+		return
+	}
+
+	user, err := h.db.AcceptUserInvite(c.Request.Context(), db.AcceptUserInviteParams{
+		TokenHash: auth.HashLinkToken(req.Token),
+		Password:  string(hashedPassword),
+		Column3:   strings.TrimSpace(req.Name),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired invite token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to accept invite", "details": err.Error()})
+		return
+	}
+
+	signedToken, expiresAt, err := h.tokens.Mint(user.ID.String(), user.Role, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, CreateUserResponse{
+		ID:            user.ID.String(),
+		Name:          user.Name,
+		Email:         user.Email,
+		Role:          user.Role,
+		Phone:         textPtr(user.Phone),
+		EmailVerified: true,
+		Token:         signedToken,
+		ExpiresAt:     expiresAt.Format(time.RFC3339),
+		CreatedAt:     user.CreatedAt.Time.String(),
+		UpdatedAt:     user.UpdatedAt.Time.String(),
+	})
+}
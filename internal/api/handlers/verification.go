@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
+	"github.com/abhinandanwadwa/overbookr/internal/auth"
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const defaultEmailVerificationTTL = 24 * time.Hour
+
+// requireEmailVerificationFromEnv reads REQUIRE_EMAIL_VERIFICATION. When
+// true, users must confirm their email before they can hold or book seats.
+// Unset means false.
+func requireEmailVerificationFromEnv() (bool, error) {
+	raw := os.Getenv("REQUIRE_EMAIL_VERIFICATION")
+	if raw == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid REQUIRE_EMAIL_VERIFICATION %q: want true or false", raw)
+	}
+	return v, nil
+}
+
+// emailVerificationTTLFromEnv reads EMAIL_VERIFICATION_TTL, how long a
+// verification link stays valid (e.g. "48h"). Unset means 24h.
+func emailVerificationTTLFromEnv() (time.Duration, error) {
+	return positiveDurationFromEnv("EMAIL_VERIFICATION_TTL", defaultEmailVerificationTTL)
+}
+
+// verificationResendCooldown is the minimum time between verification emails
+// requested through POST /users/resend-verification.
+const verificationResendCooldown = time.Minute
+
+// sendVerificationEmail queues a verification link for userID unless one was
+// requested less than cooldown ago, and reports whether it did. The request is
+// recorded as it is queued: the token is only generated when the mail goes
+// out, and replaces any earlier link.
+func (h *UsersHandler) sendVerificationEmail(ctx context.Context, userID pgtype.UUID, cooldown time.Duration) (bool, error) {
+	claimed, err := h.db.ClaimEmailVerificationRequest(ctx, db.ClaimEmailVerificationRequestParams{
+		UserID: userID,
+		SentAt: pgtype.Timestamptz{Time: time.Now().Add(-cooldown), Valid: true},
+	})
+	if err != nil || claimed == 0 {
+		return false, err
+	}
+	return true, h.mailQueue.Notify(mail.KindEmailVerification, mail.VerificationJob{
+		UserID:    userID.String(),
+		ExpiresAt: time.Now().Add(h.verificationTTL),
+	})
+}
+
+// GET /users/verify?token=
+func (h *UsersHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	userID, err := h.db.VerifyEmailToken(c.Request.Context(), auth.HashLinkToken(token))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": userID.String(), "email_verified": true})
+}
+
+// POST /users/resend-verification
+// Emails the caller a new verification link, which replaces the earlier
+// ones. Limited to one per minute, counting the one queued at sign-up.
+func (h *UsersHandler) ResendVerification(c *gin.Context) {
+	ctx := c.Request.Context()
+	uid, ok := callerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := pgtype.UUID{Bytes: uid, Valid: true}
+
+	verified, err := h.db.IsUserEmailVerified(ctx, userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check email verification", "details": err.Error()})
+		return
+	}
+	if verified {
+		c.JSON(http.StatusConflict, gin.H{"error": "email already verified"})
+		return
+	}
+
+	sent, err := h.sendVerificationEmail(ctx, userID, verificationResendCooldown)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue verification email", "details": err.Error()})
+		return
+	}
+	if !sent {
+		retryAfter := int(verificationResendCooldown.Seconds())
+		if last, err := h.db.GetEmailVerificationRequestedAt(ctx, userID); err == nil && last.Valid {
+			retryAfter = int(time.Until(last.Time.Add(verificationResendCooldown)).Seconds()) + 1
+		} else if err != nil {
+			log.Printf("failed to read last verification request of user %s: %v", userID.String(), err)
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "verification email was sent recently", "retry_after": retryAfter})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"id": userID.String(), "status": "queued"})
+}
+
+// ensureEmailVerified writes a 403 and returns false when the caller has not
+// confirmed their email yet.
+func ensureEmailVerified(c *gin.Context, q *db.Queries) bool {
+	uid, ok := callerID(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, CodeUnauthenticated, "unauthorized", nil)
+		return false
+	}
+	verified, err := q.IsUserEmailVerified(c.Request.Context(), pgtype.UUID{Bytes: uid, Valid: true})
+	if err != nil && err != pgx.ErrNoRows {
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to check email verification", err.Error())
+		return false
+	}
+	if !verified {
+		writeError(c, http.StatusForbidden, CodeEmailNotVerified, "email not verified",
+			"Confirm your email address using the link we sent before booking")
+		return false
+	}
+	return true
+}
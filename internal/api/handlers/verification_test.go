@@ -0,0 +1,27 @@
+package handlers
+
+import "testing"
+
+func TestRequireEmailVerificationFromEnv(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    bool
+		wantErr bool
+	}{
+		{"", false, false},
+		{"true", true, false},
+		{"TRUE", true, false},
+		{"1", true, false},
+		{"false", false, false},
+		{"0", false, false},
+		{"yes", false, true},
+		{"ture", false, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("REQUIRE_EMAIL_VERIFICATION", tt.env)
+		got, err := requireEmailVerificationFromEnv()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("REQUIRE_EMAIL_VERIFICATION=%q: got %v, %v", tt.env, got, err)
+		}
+	}
+}
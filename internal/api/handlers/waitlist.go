@@ -2,42 +2,50 @@ package handlers
 
 import (
 	"context"
-	"errors"
 	"log"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/abhinandanwadwa/overbookr/internal/db"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Request/Response types (unchanged)
 type JoinWaitlistRequest struct {
 	RequestedSeats int32 `json:"requested_seats" binding:"required,min=1"`
+	// Force queues the caller even though enough seats are available, e.g.
+	// to wait for better seats.
+	Force bool `json:"force"`
 }
 
 type JoinWaitlistResponse struct {
-	ID       string    `json:"id"`
-	Position int64     `json:"position"`
-	Created  time.Time `json:"created_at"`
+	ID             string    `json:"id"`
+	Position       int64     `json:"position"`
+	RequestedSeats int32     `json:"requested_seats"`
+	Created        time.Time `json:"created_at"`
+	// Updated is set when an existing waiting entry was changed instead of
+	// a new one created.
+	Updated bool `json:"updated,omitempty"`
 }
 
 // POST /events/:id/waitlist
+// Joins the waitlist (202), or updates requested_seats when the caller is
+// already waiting (200).
 func (h *EventsHandler) JoinWaitlist(c *gin.Context) {
 	var req JoinWaitlistRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request", "details": err.Error()})
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request", err.Error())
 		return
 	}
 
 	eventIDStr := c.Param("id")
 	eventID, err := uuid.Parse(eventIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id", "details": err.Error()})
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid event id", err.Error())
 		return
 	}
 
@@ -51,67 +59,161 @@ func (h *EventsHandler) JoinWaitlist(c *gin.Context) {
 			if parsed, perr := uuid.Parse(t); perr == nil {
 				uid = parsed
 			} else {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id in context"})
+				writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid user id in context", nil)
 				return
 			}
 		default:
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id in context"})
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid user id in context", nil)
 			return
 		}
 	} else {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		writeError(c, http.StatusUnauthorized, CodeUnauthenticated, "unauthenticated", nil)
 		return
 	}
 
-	ctx := context.Background()
-	q := h.db
+	resp, status, apiErr := enrollOnWaitlist(c.Request.Context(), h.DB,
+		pgtype.UUID{Bytes: eventID, Valid: true}, pgtype.UUID{Bytes: uid, Valid: true}, req.RequestedSeats, req.Force)
+	if apiErr != nil {
+		c.JSON(status, *apiErr)
+		return
+	}
+	c.JSON(status, resp)
+}
 
-	eventParam := pgtype.UUID{Bytes: eventID, Valid: true}
-	userParam := pgtype.UUID{Bytes: uid, Valid: true}
+// enrollOnWaitlist puts the user on the event's waitlist for requestedSeats,
+// or updates their waiting entry, in its own transaction. Unless force is
+// set it refuses while enough seats can be booked. It returns 202 for a new
+// entry and 200 for an updated one; on failure the APIError is set and the
+// status goes with it.
+func enrollOnWaitlist(ctx context.Context, pool *pgxpool.Pool, eventParam, userParam pgtype.UUID, requestedSeats int32, force bool) (JoinWaitlistResponse, int, *APIError) {
+	fail := func(status int, e APIError) (JoinWaitlistResponse, int, *APIError) {
+		return JoinWaitlistResponse{}, status, &e
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fail(http.StatusInternalServerError, apiError(CodeInternal, "failed to start transaction", err.Error()))
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := db.New(tx)
 
-	row, err := q.InsertWaitlist(ctx, db.InsertWaitlistParams{
+	// Holding the event row lock until commit serializes joins, so the cap
+	// check and the insert can't interleave with another join.
+	settings, err := q.GetEventWaitlistSettingsForUpdate(ctx, eventParam)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fail(http.StatusNotFound, apiError(CodeEventNotFound, "event not found", nil))
+		}
+		return fail(http.StatusInternalServerError, apiError(CodeInternal, "failed to fetch event", err.Error()))
+	}
+	if settings.DeletedAt.Valid {
+		return fail(http.StatusNotFound, apiError(CodeEventNotFound, "event not found", nil))
+	}
+	if !settings.WaitlistOpen {
+		return fail(http.StatusConflict, apiError(CodeWaitlistClosed, "waitlist closed", nil))
+	}
+	if !force {
+		avail, err := q.GetEventAvailability(ctx, eventParam)
+		if err != nil {
+			return fail(http.StatusInternalServerError, apiError(CodeInternal, "failed to check availability", err.Error()))
+		}
+		if available := purchasableSeats(avail); available >= requestedSeats {
+			return fail(http.StatusBadRequest, apiError(CodeSeatsAvailable, "seats are available",
+				"book directly by holding seats with POST /holds, or send force=true to join the waitlist anyway").
+				with("available", available))
+		}
+	}
+	if settings.WaitlistCap.Valid {
+		// The caller's own entry doesn't count, so changing its seat count
+		// is never blocked by the cap.
+		waiting, err := q.CountOtherWaitingByEvent(ctx, db.CountOtherWaitingByEventParams{EventID: eventParam, UserID: userParam})
+		if err != nil {
+			return fail(http.StatusInternalServerError, apiError(CodeInternal, "failed to count waitlist", err.Error()))
+		}
+		if waiting >= settings.WaitlistCap.Int32 {
+			return fail(http.StatusConflict, apiError(CodeWaitlistFull, "waitlist full", nil).with("waitlist_cap", settings.WaitlistCap.Int32))
+		}
+	}
+
+	row, err := q.UpsertWaitlistEntry(ctx, db.UpsertWaitlistEntryParams{
 		EventID:        eventParam,
 		UserID:         userParam,
-		RequestedSeats: req.RequestedSeats,
+		RequestedSeats: requestedSeats,
 	})
 	if err != nil {
-		// Try to detect Postgres unique-violation reliably
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == "23505" {
-				// Duplicate entry -> the user already in waitlist for this event
-				c.JSON(http.StatusConflict, gin.H{
-					"error":   "already joined waitlist",
-					"details": pgErr.Detail,
-				})
-				return
-			}
-			// other pg errors -> forward as 500 with some details
-			log.Printf("JoinWaitlist: pg error: code=%s message=%s detail=%s", pgErr.Code, pgErr.Message, pgErr.Detail)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to join waitlist", "details": pgErr.Message})
-			return
+		if err == pgx.ErrNoRows {
+			// The caller's entry exists but was already promoted or cancelled.
+			return fail(http.StatusConflict, apiError(CodeAlreadyWaitlisted, "waitlist entry can no longer be changed",
+				"your entry for this event has already been promoted or cancelled"))
 		}
+		log.Printf("JoinWaitlist: unexpected db error: %T %v", err, err)
+		return fail(http.StatusInternalServerError, apiError(CodeInternal, "failed to join waitlist", err.Error()))
+	}
 
-		// Fallback: string match (defensive)
-		errStr := err.Error()
-		if strings.Contains(errStr, "23505") || strings.Contains(strings.ToLower(errStr), "duplicate key") || strings.Contains(strings.ToLower(errStr), "unique constraint") {
-			c.JSON(http.StatusConflict, gin.H{
-				"error":   "already joined waitlist",
-				"details": errStr,
-			})
-			return
-		}
+	if err := tx.Commit(ctx); err != nil {
+		return fail(http.StatusInternalServerError, apiError(CodeInternal, "failed to commit", err.Error()))
+	}
 
-		// Unknown error
-		log.Printf("JoinWaitlist: unexpected db error: %T %v", err, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to join waitlist", "details": errStr})
+	resp := JoinWaitlistResponse{
+		ID:             row.ID.String(),
+		Position:       row.Position,
+		RequestedSeats: row.RequestedSeats,
+		Created:        row.CreatedAt.Time,
+		Updated:        !row.Inserted,
+	}
+	if !row.Inserted {
+		return resp, http.StatusOK, nil
+	}
+	return resp, http.StatusAccepted, nil
+}
+
+type WaitlistSettingsRequest struct {
+	Open *bool `json:"open" binding:"required"`
+	// Cap limits waiting entries; omit or send null for no limit.
+	Cap *int32 `json:"cap" binding:"omitempty,min=0"`
+}
+
+type WaitlistSettingsResponse struct {
+	EventID string `json:"event_id"`
+	Open    bool   `json:"open"`
+	Cap     *int32 `json:"cap"`
+}
+
+// PUT /admin/events/:id/waitlist
+// Opens or closes an event's waitlist and sets its cap. Entries already
+// waiting are kept when the waitlist is closed or the cap is lowered.
+func (h *EventsHandler) SetWaitlistSettings(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid event id", err.Error())
+		return
+	}
+	var req WaitlistSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request", err.Error())
 		return
 	}
 
-	resp := JoinWaitlistResponse{
-		ID:       row.ID.String(),
-		Position: row.Position,
-		Created:  row.CreatedAt.Time,
+	params := db.SetEventWaitlistSettingsParams{
+		ID:           pgtype.UUID{Bytes: eventID, Valid: true},
+		WaitlistOpen: *req.Open,
+	}
+	if req.Cap != nil {
+		params.WaitlistCap = pgtype.Int4{Int32: *req.Cap, Valid: true}
+	}
+	row, err := h.db.SetEventWaitlistSettings(c.Request.Context(), params)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(c, http.StatusNotFound, CodeEventNotFound, "event not found", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, CodeInternal, "failed to update waitlist", err.Error())
+		return
 	}
-	c.JSON(http.StatusAccepted, resp)
+
+	c.JSON(http.StatusOK, WaitlistSettingsResponse{
+		EventID: row.ID.String(),
+		Open:    row.WaitlistOpen,
+		Cap:     int4Ptr(row.WaitlistCap),
+	})
 }
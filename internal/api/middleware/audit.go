@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditTargetKey is the context key under which a handler names what an
+// audited action touched when the route has no :id to go by, e.g. the id of
+// an event it just created.
+const AuditTargetKey = "audit_target"
+
+// auditWriteTimeout bounds the audit insert, which runs after the response
+// has been written and so is not covered by the request's own deadline.
+const auditWriteTimeout = 2 * time.Second
+
+// AuditLog records privileged actions in the audit_log table.
+type AuditLog struct {
+	q *db.Queries
+}
+
+func NewAuditLog(pool *pgxpool.Pool) *AuditLog {
+	return &AuditLog{q: db.New(pool)}
+}
+
+// Record logs who performed action, on what, once the handler has run.
+// Install it after AuthMiddleware and the role check, so only callers
+// allowed to act are recorded. Requests that fail (status 400 and up) are
+// not recorded. The entry is written after the response, so a failed write
+// can't undo or delay the action; it is only logged.
+func (a *AuditLog) Record(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusBadRequest {
+			return
+		}
+
+		entry := db.InsertAuditLogParams{
+			Action: action,
+			Method: c.Request.Method,
+			Path:   c.Request.URL.Path,
+			Status: int32(status),
+		}
+		if v, ok := c.Get("user_id"); ok {
+			if s, ok := v.(string); ok {
+				if id, err := uuid.Parse(s); err == nil {
+					entry.ActorID = pgtype.UUID{Bytes: id, Valid: true}
+				}
+			}
+		}
+		if v, ok := c.Get("user_role"); ok {
+			if s, ok := v.(string); ok && s != "" {
+				entry.ActorRole = pgtype.Text{String: s, Valid: true}
+			}
+		}
+		target := c.Param("id")
+		if v, ok := c.Get(AuditTargetKey); ok {
+			if s, ok := v.(string); ok {
+				target = s
+			}
+		}
+		if target != "" {
+			entry.Target = pgtype.Text{String: target, Valid: true}
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), auditWriteTimeout)
+		defer cancel()
+		if err := a.q.InsertAuditLog(ctx, entry); err != nil {
+			log.Printf("audit: failed to record %s by %s on %q: %v", action, entry.ActorID.String(), target, err)
+		}
+	}
+}
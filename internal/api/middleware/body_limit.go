@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultMaxBodyBytes bounds ordinary request bodies.
+	DefaultMaxBodyBytes int64 = 1 << 20
+	// DefaultMaxBulkBodyBytes is for the bulk endpoints (event import, seat
+	// creation), which legitimately take large payloads.
+	DefaultMaxBulkBodyBytes int64 = 10 << 20
+)
+
+// BytesFromEnv reads a positive byte count from key, falling back to def
+// when it is unset.
+func BytesFromEnv(key string, def int64) (int64, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: want a positive number of bytes", key, raw)
+	}
+	return n, nil
+}
+
+// BodyLimit caps request bodies at def bytes, or at limits[p] for requests
+// whose route pattern (e.g. "/events/:id/seats", with or without the /v1
+// prefix) is p. A body that declares a larger Content-Length is refused with
+// 413 before the handler runs. One that only turns out larger while being
+// read fails the read, and the handler's resulting 4xx goes out as 413.
+func BodyLimit(def int64, limits map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := def
+		if l, ok := limits[unversioned(c.FullPath())]; ok {
+			limit = l
+		}
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, bodyTooLarge(limit))
+			return
+		}
+
+		body := &limitedBody{ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, limit)}
+		c.Request.Body = body
+		c.Writer = &bodyLimitWriter{ResponseWriter: c.Writer, body: body}
+		c.Next()
+	}
+}
+
+func bodyTooLarge(limit int64) gin.H {
+	return gin.H{
+		"error":   "request body too large",
+		"details": fmt.Sprintf("the body of this request may be at most %d bytes", limit),
+	}
+}
+
+// limitedBody remembers whether reading ran into the size limit.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// bodyLimitWriter reports client errors as 413 once the body has hit the
+// limit, since the handler failed on the truncated body rather than on what
+// the client meant to send.
+type bodyLimitWriter struct {
+	gin.ResponseWriter
+	body *limitedBody
+}
+
+func (w *bodyLimitWriter) WriteHeader(code int) {
+	if code >= http.StatusBadRequest && code < http.StatusInternalServerError && w.body.exceeded {
+		code = http.StatusRequestEntityTooLarge
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. for handlers
+// that clear the write deadline.
+func (w *bodyLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
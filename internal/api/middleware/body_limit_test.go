@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// bodyLimitRouter serves POST /small (limit 16 bytes) and POST /bulk (64
+// bytes), recording whether a handler ran.
+func bodyLimitRouter(reached *bool) *gin.Engine {
+	r := gin.New()
+	r.Use(BodyLimit(16, map[string]int64{"/bulk": 64}))
+	handler := func(c *gin.Context) {
+		*reached = true
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+	r.POST("/small", handler)
+	r.POST("/bulk", handler)
+	return r
+}
+
+func TestBodyLimitRejectsDeclaredOversizedBody(t *testing.T) {
+	var reached bool
+	r := bodyLimitRouter(&reached)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/small", strings.NewReader(strings.Repeat("x", 17))))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", w.Code)
+	}
+	if reached {
+		t.Fatal("handler ran for a body over the limit")
+	}
+}
+
+func TestBodyLimitReportsUndeclaredOversizedBodyAs413(t *testing.T) {
+	var reached bool
+	r := bodyLimitRouter(&reached)
+
+	req := httptest.NewRequest(http.MethodPost, "/small", strings.NewReader(strings.Repeat("x", 100)))
+	req.ContentLength = -1 // chunked: the size is only found out while reading
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", w.Code)
+	}
+}
+
+func TestBodyLimitPerRouteOverride(t *testing.T) {
+	tests := []struct {
+		path string
+		size int
+		want int
+	}{
+		{"/small", 16, http.StatusNoContent},
+		{"/bulk", 64, http.StatusNoContent},
+		{"/bulk", 65, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		var reached bool
+		r := bodyLimitRouter(&reached)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(strings.Repeat("x", tt.size))))
+		if w.Code != tt.want {
+			t.Errorf("%s with %d bytes: status = %d, want %d", tt.path, tt.size, w.Code, tt.want)
+		}
+	}
+}
+
+// Handlers that run past the server's WriteTimeout clear the write deadline
+// through http.ResponseController, which has to reach the connection through
+// every writer the middleware wraps around it.
+func TestWrappedWritersKeepTheWriteDeadlineReachable(t *testing.T) {
+	r := gin.New()
+	r.Use(DBTimeout(time.Minute), BodyLimit(16, nil))
+	r.POST("/long", func(c *gin.Context) {
+		if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	for _, body := range []string{"", "{}"} {
+		resp, err := http.Post(srv.URL+"/long", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST with body %q: %v", body, err)
+		}
+		msg, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("POST with body %q: status = %d (%s), want 204", body, resp.StatusCode, msg)
+		}
+	}
+}
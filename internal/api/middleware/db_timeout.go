@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultDBTimeout bounds ordinary API requests.
+	DefaultDBTimeout = 5 * time.Second
+	// DefaultAnalyticsDBTimeout is for the analytics endpoints, whose
+	// aggregate queries can legitimately take longer.
+	DefaultAnalyticsDBTimeout = 30 * time.Second
+)
+
+// TimeoutFromEnv reads a Go duration (e.g. "5s") from key, falling back to def
+// when it is unset.
+func TimeoutFromEnv(key string, def time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: want a positive duration such as \"5s\"", key, raw)
+	}
+	return d, nil
+}
+
+// DBTimeout puts a deadline of d on the request context, so context-aware DB
+// calls give up once it passes. Server errors written after the deadline
+// are turned into 503s. Requests whose path starts with one of skip, or whose
+// route pattern (e.g. "/events/:id/seats/stream") equals one, are left alone
+// so a group can install its own, longer, timeout or stream indefinitely;
+// the /v1 prefix is ignored when matching.
+func DBTimeout(d time.Duration, skip ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, p := range skip {
+			if matchesRoute(c, p) {
+				c.Next()
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer = &timeoutWriter{ResponseWriter: c.Writer, ctx: ctx}
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "request timed out",
+				"details": "the database did not respond in time; please retry",
+			})
+		}
+	}
+}
+
+// timeoutWriter reports 5xx responses as 503 once the request deadline has
+// passed, since the failure was the timeout rather than the handler.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx context.Context
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if code >= http.StatusInternalServerError && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		code = http.StatusServiceUnavailable
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. for handlers
+// that clear the write deadline.
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultMaintenanceRetryAfter is the Retry-After sent with maintenance
+	// 503s unless MAINTENANCE_RETRY_AFTER says otherwise.
+	DefaultMaintenanceRetryAfter = 60 * time.Second
+
+	// MaintenanceAdminPath is never blocked, so maintenance can always be
+	// switched off again.
+	MaintenanceAdminPath = "/admin/maintenance"
+
+	// PaymentWebhookPath is never blocked either: the payment sweep keeps
+	// running, so a refused success webhook would see the booking abandoned
+	// after the customer has paid.
+	PaymentWebhookPath = "/payments/webhook"
+)
+
+// defaultMaintenanceExempt keeps login working so an admin whose token has
+// expired can still reach MaintenanceAdminPath.
+var defaultMaintenanceExempt = []string{"/users/login"}
+
+// MaintenanceStatus is the current maintenance state, as served by
+// GET /admin/maintenance.
+type MaintenanceStatus struct {
+	Enabled    bool       `json:"enabled"`
+	Message    string     `json:"message,omitempty"`
+	Since      *time.Time `json:"since,omitempty"`
+	RetryAfter string     `json:"retry_after"`
+	Paths      []string   `json:"paths"`
+	Exempt     []string   `json:"exempt"`
+}
+
+// Maintenance pauses writes: while enabled, POST, PUT, PATCH and DELETE
+// requests get 503 with a Retry-After header and reads carry on as normal.
+// Paths limits it to requests whose path starts with, or whose route pattern
+// equals, one of them, ignoring the /v1 prefix (empty means every write);
+// Exempt is matched the same way and always wins, and MaintenanceAdminPath and
+// PaymentWebhookPath are always exempt. The state lives in this process only,
+// so with several instances each one has to be switched.
+type Maintenance struct {
+	retryAfter time.Duration
+	paths      []string
+	exempt     []string
+
+	mu      sync.RWMutex
+	enabled bool
+	message string
+	since   time.Time
+}
+
+// NewMaintenance returns a switched-off Maintenance.
+func NewMaintenance(retryAfter time.Duration, paths, exempt []string) *Maintenance {
+	return &Maintenance{
+		retryAfter: retryAfter,
+		paths:      paths,
+		exempt:     append(append([]string{}, exempt...), MaintenanceAdminPath, PaymentWebhookPath),
+	}
+}
+
+// MaintenanceFromEnv reads MAINTENANCE_MODE (start switched on),
+// MAINTENANCE_MESSAGE, MAINTENANCE_RETRY_AFTER (a Go duration, default 60s),
+// and MAINTENANCE_PATHS and MAINTENANCE_EXEMPT, comma-separated path prefixes
+// or route patterns. MAINTENANCE_EXEMPT defaults to /users/login.
+func MaintenanceFromEnv() (*Maintenance, error) {
+	exempt := defaultMaintenanceExempt
+	if raw, ok := os.LookupEnv("MAINTENANCE_EXEMPT"); ok {
+		exempt = splitPaths(raw)
+	}
+	retryAfter, err := TimeoutFromEnv("MAINTENANCE_RETRY_AFTER", DefaultMaintenanceRetryAfter)
+	if err != nil {
+		return nil, err
+	}
+	m := NewMaintenance(retryAfter, splitPaths(os.Getenv("MAINTENANCE_PATHS")), exempt)
+	if raw := os.Getenv("MAINTENANCE_MODE"); raw != "" {
+		on, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MAINTENANCE_MODE %q: want true or false", raw)
+		}
+		if on {
+			m.Set(true, os.Getenv("MAINTENANCE_MESSAGE"))
+			log.Printf("maintenance mode on at startup")
+		}
+	}
+	return m, nil
+}
+
+func splitPaths(raw string) []string {
+	var out []string
+	for _, p := range strings.Split(raw, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Set switches maintenance on or off. Switching on when already on only
+// updates the message.
+func (m *Maintenance) Set(enabled bool, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if enabled && !m.enabled {
+		m.since = time.Now()
+	}
+	if !enabled {
+		m.since = time.Time{}
+		message = ""
+	}
+	m.enabled = enabled
+	m.message = message
+}
+
+// Status reports the current state and configuration.
+func (m *Maintenance) Status() MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	st := MaintenanceStatus{
+		Enabled:    m.enabled,
+		Message:    m.message,
+		RetryAfter: m.retryAfter.String(),
+		Paths:      append([]string{}, m.paths...),
+		Exempt:     append([]string{}, m.exempt...),
+	}
+	if m.enabled {
+		since := m.since
+		st.Since = &since
+	}
+	return st
+}
+
+// Handler blocks affected writes while maintenance is on.
+func (m *Maintenance) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m.mu.RLock()
+		enabled, message := m.enabled, m.message
+		m.mu.RUnlock()
+
+		if !enabled || !isWrite(c.Request.Method) || !m.affects(c) {
+			c.Next()
+			return
+		}
+
+		if message == "" {
+			message = "writes are paused for maintenance; please retry later"
+		}
+		secs := int((m.retryAfter + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.Itoa(secs))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "service under maintenance",
+			"details": message,
+		})
+	}
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (m *Maintenance) affects(c *gin.Context) bool {
+	if matchesAny(c, m.exempt) {
+		return false
+	}
+	return len(m.paths) == 0 || matchesAny(c, m.paths)
+}
+
+func matchesAny(c *gin.Context, paths []string) bool {
+	for _, p := range paths {
+		if matchesRoute(c, p) {
+			return true
+		}
+	}
+	return false
+}
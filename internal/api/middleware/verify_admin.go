@@ -2,13 +2,20 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AdminMiddleware requires AuthMiddleware to have run earlier (so user_role is set).
-// It rejects requests where the user's role is not "admin".
-func AdminMiddleware() gin.HandlerFunc {
+// RequireRole requires AuthMiddleware to have run earlier (so user_role is set).
+// It rejects requests whose role is not one of roles.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]struct{}, len(roles))
+	for _, r := range roles {
+		allowed[r] = struct{}{}
+	}
+	forbidden := "Forbidden: " + strings.Join(roles, " or ") + " only"
+
 	return func(c *gin.Context) {
 		val, exists := c.Get("user_role")
 		if !exists {
@@ -16,10 +23,15 @@ func AdminMiddleware() gin.HandlerFunc {
 			return
 		}
 		role, ok := val.(string)
-		if !ok || role != "admin" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: admin only"})
+		if _, permitted := allowed[role]; !ok || !permitted {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": forbidden})
 			return
 		}
 		c.Next()
 	}
 }
+
+// AdminMiddleware rejects requests where the user's role is not "admin".
+func AdminMiddleware() gin.HandlerFunc {
+	return RequireRole("admin")
+}
@@ -1,40 +1,40 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"os"
 	"strings"
 
+	"github.com/abhinandanwadwa/overbookr/internal/auth"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware validates a JWT from the Authorization header (Bearer token).
-// On success it sets "user_id" and "user_role" in the gin.Context.
+// AuthMiddleware validates a JWT from the Authorization header (Bearer token),
+// including its expiry, issuer and audience. On success it sets "user_id" and
+// "user_role" in the gin.Context.
 func AuthMiddleware() gin.HandlerFunc {
-	secret := os.Getenv("JWT_SECRET")
+	tokens := auth.ConfigFromEnv()
 	return func(c *gin.Context) {
-		if secret == "" {
+		if !tokens.CanVerify() {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"error":   "Server misconfiguration: JWT secret not set",
-				"details": "Set JWT_SECRET environment variable",
+				"error":   "Server misconfiguration: JWT signing key not set",
+				"details": "Set JWT_SECRET, or the key files for JWT_ALG=RS256",
 			})
 			return
 		}
 
-		auth := c.GetHeader("Authorization")
-		if auth == "" {
+		header := c.GetHeader("Authorization")
+		if header == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized to perform this action"})
 			return
 		}
 
 		var tokenString string
 		// Accept both "Bearer TOKEN" and "Bearer: TOKEN"
-		if strings.HasPrefix(auth, "Bearer ") {
-			tokenString = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
-		} else if strings.HasPrefix(auth, "Bearer:") {
-			tokenString = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer:"))
+		if strings.HasPrefix(header, "Bearer ") {
+			tokenString = strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		} else if strings.HasPrefix(header, "Bearer:") {
+			tokenString = strings.TrimSpace(strings.TrimPrefix(header, "Bearer:"))
 		} else {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
 			return
@@ -45,41 +45,36 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.ParseWithClaims(tokenString, jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
-			// Ensure signing method is HMAC
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		claims, err := tokens.Parse(tokenString)
+		if err != nil {
+			if errors.Is(err, auth.ErrExpired) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token expired", "details": "Log in again to get a new token"})
+				return
 			}
-			return []byte(secret), nil
-		})
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			return
-		}
-
-		// Extract sub and role
-		var sub, role string
-		if v, exists := claims["sub"]; exists && v != nil {
-			sub = fmt.Sprintf("%v", v)
-		}
-		if v, exists := claims["role"]; exists && v != nil {
-			role = fmt.Sprintf("%v", v)
-		}
-
 		// Store in Gin context
-		if sub != "" {
-			c.Set("user_id", sub)
-		}
-		if role != "" {
-			c.Set("user_role", role)
+		c.Set("user_id", claims.Subject)
+		if claims.Role != "" {
+			c.Set("user_role", claims.Role)
 		}
 
 		c.Next()
 	}
 }
+
+// OptionalAuthMiddleware authenticates like AuthMiddleware when an
+// Authorization header is sent and lets anonymous requests through otherwise,
+// so public routes can still tell admins apart.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	authenticate := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddlewareRejectsBadTokens(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_ALG", "")
+	t.Setenv("JWT_ISSUER", "")
+	t.Setenv("JWT_AUDIENCE", "")
+
+	valid := auth.ConfigFromEnv()
+	mint := func(cfg auth.Config, now time.Time) string {
+		t.Helper()
+		token, _, err := cfg.Mint("user-1", "user", now)
+		if err != nil {
+			t.Fatalf("Mint: %v", err)
+		}
+		return token
+	}
+	wrongAudience, wrongIssuer := valid, valid
+	wrongAudience.Audience = "another-api"
+	wrongIssuer.Issuer = "someone-else"
+
+	tests := []struct {
+		name      string
+		token     string
+		wantCode  int
+		wantError string
+	}{
+		{"valid", mint(valid, time.Now()), http.StatusOK, ""},
+		{"expired", mint(valid, time.Now().Add(-valid.TTL-time.Minute)), http.StatusUnauthorized, "Token expired"},
+		{"wrong audience", mint(wrongAudience, time.Now()), http.StatusUnauthorized, "Invalid token"},
+		{"wrong issuer", mint(wrongIssuer, time.Now()), http.StatusUnauthorized, "Invalid token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/me", AuthMiddleware(), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id")})
+			})
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantCode, w.Body.String())
+			}
+			var body struct {
+				Error string `json:"error"`
+			}
+			_ = json.Unmarshal(w.Body.Bytes(), &body)
+			if body.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", body.Error, tt.wantError)
+			}
+		})
+	}
+}
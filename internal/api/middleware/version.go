@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersionPrefix is the route prefix of the current API version. The same
+// routes are also served without it, deprecated, for older clients.
+const APIVersionPrefix = "/v1"
+
+// Deprecated marks responses from the unversioned aliases with a
+// Deprecation header and a Link to the same route under APIVersionPrefix.
+func Deprecated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		successor := APIVersionPrefix + c.Request.URL.Path
+		if q := c.Request.URL.RawQuery; q != "" {
+			successor += "?" + q
+		}
+		c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
+
+// unversioned strips APIVersionPrefix from a path or route pattern, so
+// settings keyed by route apply to a route and its alias alike.
+func unversioned(p string) string {
+	if rest, ok := strings.CutPrefix(p, APIVersionPrefix); ok && (rest == "" || rest[0] == '/') {
+		return rest
+	}
+	return p
+}
+
+// matchesRoute reports whether the request's path starts with p, or its
+// route pattern (e.g. "/events/:id/seats/stream") equals p, ignoring
+// APIVersionPrefix.
+func matchesRoute(c *gin.Context, p string) bool {
+	return strings.HasPrefix(unversioned(c.Request.URL.Path), p) || unversioned(c.FullPath()) == p
+}
@@ -0,0 +1,160 @@
+//go:build integration
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// race runs fn for every client at once and returns the statuses in order.
+func race(clients int, fn func(i int) int) []int {
+	statuses := make([]int, clients)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			statuses[i] = fn(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return statuses
+}
+
+func TestConcurrentHoldsOnTheSameSeats(t *testing.T) {
+	const clients = 8
+	api := newTestAPI(t)
+	admin := api.newUser("admin")
+	eventID := api.newEvent(admin, 2, "A1", "A2")
+	users := make([]user, clients)
+	for i := range users {
+		users[i] = api.newUser("user")
+	}
+
+	statuses := race(clients, func(i int) int {
+		return api.do(users[i], http.MethodPost, "/holds/", gin.H{"event_id": eventID, "seat_nos": []string{"A1", "A2"}}, nil)
+	})
+
+	held := 0
+	for i, status := range statuses {
+		switch status {
+		case http.StatusCreated:
+			held++
+		case http.StatusConflict:
+		default:
+			t.Errorf("client %d: status %d", i, status)
+		}
+	}
+	if held != 1 {
+		t.Fatalf("%d clients held the seats, want exactly 1 (statuses %v)", held, statuses)
+	}
+}
+
+func TestConcurrentBookingsOfDistinctHolds(t *testing.T) {
+	const clients = 8
+	api := newTestAPI(t)
+	admin := api.newUser("admin")
+	seatNos := make([]string, clients)
+	for i := range seatNos {
+		seatNos[i] = fmt.Sprintf("A%d", i+1)
+	}
+	eventID := api.newEvent(admin, clients, seatNos...)
+
+	// Every client holds a seat of its own, then all of them book at once:
+	// none of them should lose out to a shared connection or to each other.
+	users := make([]user, clients)
+	tokens := make([]string, clients)
+	for i := range users {
+		users[i] = api.newUser("user")
+		var hold struct {
+			HoldToken string `json:"hold_token"`
+		}
+		if status := api.do(users[i], http.MethodPost, "/holds/", gin.H{"event_id": eventID, "seat_nos": []string{seatNos[i]}}, &hold); status != http.StatusCreated {
+			t.Fatalf("hold %s: status %d", seatNos[i], status)
+		}
+		tokens[i] = hold.HoldToken
+	}
+
+	statuses := race(clients, func(i int) int {
+		return api.do(users[i], http.MethodPost, "/bookings/", gin.H{"event_id": eventID, "hold_token": tokens[i]}, nil, "Idempotency-Key", uuid.NewString())
+	})
+	for i, status := range statuses {
+		if status != http.StatusCreated {
+			t.Errorf("client %d: status %d, want 201", i, status)
+		}
+	}
+	if bookings, seats := api.activeBookings(eventID); bookings != clients || seats != clients {
+		t.Errorf("active bookings = %d with %d seats, want %d with %d", bookings, seats, clients, clients)
+	}
+	if n := api.bookedCount(eventID); n != clients {
+		t.Errorf("booked_count = %d, want %d", n, clients)
+	}
+}
+
+func TestConcurrentBookingsNeverOversellCapacity(t *testing.T) {
+	const capacity, clients = 3, 6
+	api := newTestAPI(t)
+	admin := api.newUser("admin")
+	eventID := api.newEvent(admin, capacity)
+
+	// More seats than the event may sell: the API refuses to create them, so
+	// the drift is set up in the database.
+	ctx := context.Background()
+	if _, err := api.pool.Exec(ctx, `INSERT INTO seats (event_id, seat_no) SELECT $1, 'A' || g FROM generate_series(1, $2::int) g`, eventID, clients); err != nil {
+		t.Fatalf("insert seats: %v", err)
+	}
+	users := make([]user, clients)
+	for i := range users {
+		users[i] = api.newUser("user")
+	}
+
+	codes := make([]string, clients)
+	statuses := race(clients, func(i int) int {
+		var resp struct {
+			Code string `json:"code"`
+		}
+		status := api.do(users[i], http.MethodPost, "/bookings/direct",
+			gin.H{"event_id": eventID, "seat_nos": []string{fmt.Sprintf("A%d", i+1)}}, &resp, "Idempotency-Key", uuid.NewString())
+		codes[i] = resp.Code
+		return status
+	})
+
+	booked := 0
+	for i, status := range statuses {
+		switch {
+		case status == http.StatusCreated:
+			booked++
+		case status == http.StatusConflict && codes[i] == "CAPACITY_EXCEEDED":
+		default:
+			t.Errorf("client %d: status %d %s, want 201 or 409 CAPACITY_EXCEEDED", i, status, codes[i])
+		}
+	}
+	if booked != capacity {
+		t.Errorf("%d bookings succeeded, want %d (statuses %v)", booked, capacity, statuses)
+	}
+	if bookings, seats := api.activeBookings(eventID); bookings != capacity || seats != capacity {
+		t.Errorf("active bookings = %d with %d seats, want %d with %d", bookings, seats, capacity, capacity)
+	}
+	if n := api.bookedCount(eventID); n != capacity {
+		t.Errorf("booked_count = %d, want %d", n, capacity)
+	}
+
+	// The database refuses a booked_count past capacity even without the handler.
+	_, err := api.pool.Exec(ctx, `UPDATE events SET booked_count = capacity + 1 WHERE id = $1`, eventID)
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.ConstraintName != "events_booked_count_within_capacity" {
+		t.Errorf("raising booked_count past capacity: err = %v, want events_booked_count_within_capacity violated", err)
+	}
+}
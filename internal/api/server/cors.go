@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+)
+
+// corsConfigFromEnv builds the CORS policy. ALLOWED_ORIGINS is a
+// comma-separated list of front-end origins (e.g. "https://app.example.com").
+// When it is empty, DEV_MODE=true allows any origin for local development;
+// otherwise cross-origin requests are refused.
+func corsConfigFromEnv() (cors.Config, error) {
+	cfg := cors.Config{
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replay"},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}
+
+	for _, o := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		o = strings.TrimRight(strings.TrimSpace(o), "/")
+		if o == "" {
+			continue
+		}
+		if !strings.HasPrefix(o, "http://") && !strings.HasPrefix(o, "https://") {
+			return cfg, fmt.Errorf("invalid origin %q in ALLOWED_ORIGINS: want http:// or https://", o)
+		}
+		cfg.AllowOrigins = append(cfg.AllowOrigins, o)
+	}
+	if len(cfg.AllowOrigins) > 0 {
+		return cfg, nil
+	}
+
+	if os.Getenv("DEV_MODE") == "true" {
+		log.Println("DEV_MODE: allowing CORS requests from any origin")
+		// Reflect the caller's origin; "*" can't be combined with credentials.
+		cfg.AllowOriginFunc = func(string) bool { return true }
+		return cfg, nil
+	}
+
+	log.Println("ALLOWED_ORIGINS not set: cross-origin requests will be refused")
+	cfg.AllowOriginFunc = func(string) bool { return false }
+	return cfg, nil
+}
@@ -0,0 +1,310 @@
+//go:build integration
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
+	"github.com/abhinandanwadwa/overbookr/internal/auth"
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
+	"github.com/abhinandanwadwa/overbookr/internal/testdb"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+// testAPI is the full router over a database of its own, served over HTTP
+// with the production write timeout so handlers see a real connection. Mail
+// is queued but never delivered: the queue isn't started.
+type testAPI struct {
+	t      *testing.T
+	pool   *pgxpool.Pool
+	router *gin.Engine
+	server *httptest.Server
+}
+
+// newTestAPI builds the router; opts can change its dependencies first.
+func newTestAPI(t *testing.T, opts ...func(*AppDeps)) *testAPI {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "integration-test-secret")
+	pool := testdb.New(t)
+	deps := AppDeps{
+		DB:                 pool,
+		MailQueue:          mail.NewQueue(pool, mail.NewMailer(nil), nil, mail.DefaultQueueOptions()),
+		SeatHub:            realtime.NewHub(),
+		HoldRetention:      time.Hour,
+		LoginFailureWindow: 15 * time.Minute,
+		PaymentWindow:      15 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(&deps)
+	}
+	router, err := NewRouter(deps)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 10 * time.Second
+	server.Start()
+	t.Cleanup(server.Close)
+	return &testAPI{t: t, pool: pool, router: router, server: server}
+}
+
+// user is an account created straight in the database, with a token for it.
+type user struct {
+	ID    uuid.UUID
+	Token string
+}
+
+func (a *testAPI) newUser(role string) user {
+	a.t.Helper()
+	id := uuid.New()
+	row, err := db.New(a.pool).CreateUser(context.Background(), db.CreateUserParams{
+		Name:          role + " " + id.String()[:8],
+		Email:         id.String() + "@example.com",
+		Password:      "not-a-password-hash",
+		Role:          role,
+		EmailVerified: true,
+	})
+	if err != nil {
+		a.t.Fatalf("create %s: %v", role, err)
+	}
+	token, _, err := auth.ConfigFromEnv().Mint(row.ID.String(), role, time.Now())
+	if err != nil {
+		a.t.Fatalf("mint token: %v", err)
+	}
+	return user{ID: row.ID.Bytes, Token: token}
+}
+
+// do sends a request as u (no token for the zero user) with body encoded as
+// JSON, and decodes the response into out when out is not nil.
+func (a *testAPI) do(u user, method, path string, body any, out any, header ...string) int {
+	a.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			a.t.Fatalf("encode body: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, a.server.URL+"/v1"+path, &buf)
+	if err != nil {
+		a.t.Fatalf("build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if u.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+u.Token)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	// Errorf rather than Fatalf: concurrent tests call do off the test goroutine.
+	resp, err := a.server.Client().Do(req)
+	if err != nil {
+		a.t.Errorf("%s %s: %v", method, path, err)
+		return 0
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		a.t.Errorf("%s %s: read body: %v", method, path, err)
+		return 0
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			a.t.Errorf("%s %s: decode %q: %v", method, path, data, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// newEvent creates an event with the given seats as admin and returns its id.
+func (a *testAPI) newEvent(admin user, capacity int32, seatNos ...string) string {
+	a.t.Helper()
+	var event struct {
+		ID string `json:"id"`
+	}
+	status := a.do(admin, http.MethodPost, "/events/", gin.H{
+		"name":       "Integration " + uuid.NewString()[:8],
+		"venue":      "Hall 1",
+		"start_time": time.Now().Add(30 * 24 * time.Hour).UTC(),
+		"capacity":   capacity,
+	}, &event)
+	if status != http.StatusCreated {
+		a.t.Fatalf("create event: status %d", status)
+	}
+	if len(seatNos) > 0 {
+		if status := a.do(admin, http.MethodPost, "/events/"+event.ID+"/seats", gin.H{"seat_nos": seatNos}, nil); status != http.StatusCreated {
+			a.t.Fatalf("create seats: status %d", status)
+		}
+	}
+	return event.ID
+}
+
+// bookedCount is the event's booked_count column.
+func (a *testAPI) bookedCount(eventID string) int32 {
+	a.t.Helper()
+	var n int32
+	if err := a.pool.QueryRow(context.Background(), `SELECT booked_count FROM events WHERE id = $1`, eventID).Scan(&n); err != nil {
+		a.t.Fatalf("read booked_count: %v", err)
+	}
+	return n
+}
+
+// activeBookings counts the event's active bookings and the seats they hold.
+func (a *testAPI) activeBookings(eventID string) (bookings, seats int) {
+	a.t.Helper()
+	err := a.pool.QueryRow(context.Background(),
+		`SELECT COUNT(*), COALESCE(SUM(seats), 0) FROM bookings WHERE event_id = $1 AND status = 'active'`, eventID).Scan(&bookings, &seats)
+	if err != nil {
+		a.t.Fatalf("count bookings: %v", err)
+	}
+	return bookings, seats
+}
+
+// eventually polls cond until it holds or five seconds pass.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestHoldBookCancelPromote(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.newUser("admin")
+	alice := api.newUser("user")
+	bob := api.newUser("user")
+	eventID := api.newEvent(admin, 2, "A1", "A2")
+
+	// Alice holds both seats and books them.
+	var hold struct {
+		HoldToken   string   `json:"hold_token"`
+		SeatNumbers []string `json:"seat_numbers"`
+	}
+	if status := api.do(alice, http.MethodPost, "/holds/", gin.H{"event_id": eventID, "seat_nos": []string{"A1", "A2"}}, &hold); status != http.StatusCreated {
+		t.Fatalf("hold: status %d", status)
+	}
+	// A held seat can't be held again.
+	if status := api.do(bob, http.MethodPost, "/holds/", gin.H{"event_id": eventID, "seat_nos": []string{"A2"}}, nil); status != http.StatusConflict {
+		t.Fatalf("second hold on A2: status %d, want 409", status)
+	}
+
+	var booking struct {
+		ID          string   `json:"id"`
+		Status      string   `json:"status"`
+		SeatNumbers []string `json:"seat_numbers"`
+	}
+	key := uuid.NewString()
+	if status := api.do(alice, http.MethodPost, "/bookings/", gin.H{"event_id": eventID, "hold_token": hold.HoldToken}, &booking, "Idempotency-Key", key); status != http.StatusCreated {
+		t.Fatalf("book: status %d", status)
+	}
+	if booking.Status != "active" || len(booking.SeatNumbers) != 2 {
+		t.Fatalf("booking = %+v, want active with 2 seats", booking)
+	}
+	if n := api.bookedCount(eventID); n != 2 {
+		t.Fatalf("booked_count = %d, want 2", n)
+	}
+
+	// Sold out: Bob joins the waitlist for one seat.
+	if status := api.do(bob, http.MethodPost, "/events/"+eventID+"/waitlist", gin.H{"requested_seats": 1}, nil); status != http.StatusAccepted {
+		t.Fatalf("join waitlist: status %d, want 202", status)
+	}
+
+	// Alice cancels; the freed seats go to Bob.
+	var cancelled struct {
+		Status string `json:"status"`
+	}
+	if status := api.do(alice, http.MethodDelete, "/bookings/"+booking.ID, nil, &cancelled); status != http.StatusOK || cancelled.Status != "cancelled" {
+		t.Fatalf("cancel: status %d %+v", status, cancelled)
+	}
+	eventually(t, "Bob's promotion", func() bool {
+		var status string
+		err := api.pool.QueryRow(context.Background(),
+			`SELECT status FROM waitlist WHERE event_id = $1 AND user_id = $2`, eventID, bob.ID).Scan(&status)
+		return err == nil && status == "promoted"
+	})
+
+	var bobs []struct {
+		Status      string   `json:"status"`
+		SeatNumbers []string `json:"seat_numbers"`
+	}
+	if status := api.do(bob, http.MethodGet, "/bookings/", nil, &bobs); status != http.StatusOK {
+		t.Fatalf("Bob's bookings: status %d", status)
+	}
+	if len(bobs) != 1 || bobs[0].Status != "active" || len(bobs[0].SeatNumbers) != 1 {
+		t.Fatalf("Bob's bookings = %+v, want one active booking of one seat", bobs)
+	}
+	if n := api.bookedCount(eventID); n != 1 {
+		t.Errorf("booked_count = %d, want 1", n)
+	}
+
+	var seats []struct {
+		SeatNo string `json:"seat_no"`
+		Status string `json:"status"`
+	}
+	if status := api.do(user{}, http.MethodGet, "/events/"+eventID+"/seats", nil, &seats); status != http.StatusOK {
+		t.Fatalf("seats: status %d", status)
+	}
+	byStatus := map[string]int{}
+	for _, s := range seats {
+		byStatus[s.Status]++
+	}
+	if byStatus["booked"] != 1 || byStatus["available"] != 1 {
+		t.Errorf("seats = %+v, want one booked and one available", seats)
+	}
+}
+
+func TestLastSeatGoesToExactlyOneClient(t *testing.T) {
+	const clients = 8
+	api := newTestAPI(t)
+	admin := api.newUser("admin")
+	eventID := api.newEvent(admin, 1, "A1")
+
+	users := make([]user, clients)
+	for i := range users {
+		users[i] = api.newUser("user")
+	}
+
+	statuses := race(clients, func(i int) int {
+		return api.do(users[i], http.MethodPost, "/bookings/direct",
+			gin.H{"event_id": eventID, "seat_nos": []string{"A1"}}, nil, "Idempotency-Key", uuid.NewString())
+	})
+
+	won := 0
+	for i, status := range statuses {
+		switch status {
+		case http.StatusCreated:
+			won++
+		case http.StatusConflict, http.StatusServiceUnavailable:
+			// Lost the seat, or gave up retrying the conflict.
+		default:
+			t.Errorf("client %d: status %d", i, status)
+		}
+	}
+	if won != 1 {
+		t.Fatalf("%d clients booked the last seat, want exactly 1 (statuses %v)", won, statuses)
+	}
+	if bookings, seats := api.activeBookings(eventID); bookings != 1 || seats != 1 {
+		t.Errorf("active bookings = %d with %d seats, want 1 with 1", bookings, seats)
+	}
+	if n := api.bookedCount(eventID); n != 1 {
+		t.Errorf("booked_count = %d, want 1", n)
+	}
+}
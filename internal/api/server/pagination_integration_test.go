@@ -0,0 +1,53 @@
+//go:build integration
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type envelope struct {
+	Data   []json.RawMessage `json:"data"`
+	Total  int64             `json:"total"`
+	Limit  int32             `json:"limit"`
+	Offset int32             `json:"offset"`
+}
+
+func TestListEnvelopeTotalPastTheLastPage(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.newUser("admin")
+	buyer := api.newUser("user")
+	eventID := api.newEvent(admin, 2, "A1", "A2")
+	for _, seat := range []string{"A1", "A2"} {
+		if status := api.do(buyer, http.MethodPost, "/bookings/direct", gin.H{"event_id": eventID, "seat_nos": []string{seat}}, nil, "Idempotency-Key", uuid.NewString()); status != http.StatusCreated {
+			t.Fatalf("book %s: status %d", seat, status)
+		}
+	}
+
+	tests := []struct {
+		as       user
+		path     string
+		wantData int
+		want     int64
+	}{
+		{buyer, "/bookings/?envelope=true&limit=1&offset=1", 1, 2},
+		{buyer, "/bookings/?envelope=true&limit=1&offset=5", 0, 2},
+		{admin, "/admin/bookings?envelope=true&offset=5&event_id=" + eventID, 0, 2},
+		{admin, "/events/?envelope=true&offset=5", 0, 1},
+	}
+	for _, tt := range tests {
+		var page envelope
+		if status := api.do(tt.as, http.MethodGet, tt.path, nil, &page); status != http.StatusOK {
+			t.Errorf("GET %s: status %d", tt.path, status)
+			continue
+		}
+		if len(page.Data) != tt.wantData || page.Total != tt.want {
+			t.Errorf("GET %s: %d items, total %d; want %d items, total %d", tt.path, len(page.Data), page.Total, tt.wantData, tt.want)
+		}
+	}
+}
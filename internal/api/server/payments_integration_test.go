@@ -0,0 +1,241 @@
+//go:build integration
+
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/payments"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
+	"github.com/abhinandanwadwa/overbookr/internal/workers"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const webhookSecret = "integration-webhook-secret"
+
+// recordingProvider is payments.Fake that remembers the intents it was
+// asked to cancel and refund.
+type recordingProvider struct {
+	payments.Fake
+	mu        sync.Mutex
+	cancelled []string
+	refunded  []string
+}
+
+func (p *recordingProvider) CancelIntent(ctx context.Context, intentID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cancelled = append(p.cancelled, intentID)
+	return nil
+}
+
+func (p *recordingProvider) Refund(ctx context.Context, intentID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refunded = append(p.refunded, intentID)
+	return nil
+}
+
+func (p *recordingProvider) calls() (cancelled, refunded []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.cancelled...), append([]string(nil), p.refunded...)
+}
+
+// paymentsAPI is a testAPI with the recording provider and a paid event of
+// two seats, A1 and A2.
+type paymentsAPI struct {
+	*testAPI
+	provider *recordingProvider
+	eventID  string
+}
+
+func newPaymentsAPI(t *testing.T) *paymentsAPI {
+	t.Helper()
+	provider := &recordingProvider{Fake: payments.Fake{WebhookSecret: webhookSecret}}
+	api := newTestAPI(t, func(deps *AppDeps) { deps.Payments = provider })
+	admin := api.newUser("admin")
+
+	var event struct {
+		ID string `json:"id"`
+	}
+	if status := api.do(admin, http.MethodPost, "/events/", gin.H{
+		"name":             "Paid " + uuid.NewString()[:8],
+		"venue":            "Hall 1",
+		"start_time":       time.Now().Add(30 * 24 * time.Hour).UTC(),
+		"capacity":         2,
+		"requires_payment": true,
+		"price_cents":      1500,
+		"currency":         "USD",
+	}, &event); status != http.StatusCreated {
+		t.Fatalf("create paid event: status %d", status)
+	}
+	if status := api.do(admin, http.MethodPost, "/events/"+event.ID+"/seats", gin.H{"seat_nos": []string{"A1", "A2"}}, nil); status != http.StatusCreated {
+		t.Fatalf("create seats: status %d", status)
+	}
+	return &paymentsAPI{testAPI: api, provider: provider, eventID: event.ID}
+}
+
+type pendingBooking struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	Payment *struct {
+		IntentID     string `json:"intent_id"`
+		ClientSecret string `json:"client_secret"`
+		AmountCents  int64  `json:"amount_cents"`
+		Currency     string `json:"currency"`
+	} `json:"payment"`
+}
+
+// book books seat A1 for a new user and checks it awaits payment.
+func (a *paymentsAPI) book() pendingBooking {
+	a.t.Helper()
+	var b pendingBooking
+	status := a.do(a.newUser("user"), http.MethodPost, "/bookings/direct",
+		gin.H{"event_id": a.eventID, "seat_nos": []string{"A1"}}, &b, "Idempotency-Key", uuid.NewString())
+	if status != http.StatusCreated {
+		a.t.Fatalf("book: status %d", status)
+	}
+	if b.Status != "pending_payment" || b.Payment == nil || b.Payment.IntentID == "" {
+		a.t.Fatalf("booking = %+v, want pending_payment with a payment intent", b)
+	}
+	return b
+}
+
+// webhook posts a provider notification of typ for intentID, signed the way
+// payments.Fake checks it.
+func (a *paymentsAPI) webhook(typ, intentID string) (int, map[string]any) {
+	a.t.Helper()
+	payload := fmt.Sprintf(`{"type":%q,"data":{"object":{"id":%q}}}`, typ, intentID)
+	mac := hmac.New(sha256.New, []byte(webhookSecret))
+	mac.Write([]byte(payload))
+	req := httptest.NewRequest(http.MethodPost, "/v1/payments/webhook", strings.NewReader(payload))
+	req.Header.Set("Fake-Signature", hex.EncodeToString(mac.Sum(nil)))
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		a.t.Fatalf("webhook %s: decode %q: %v", typ, w.Body.String(), err)
+	}
+	return w.Code, body
+}
+
+func (a *paymentsAPI) bookingStatus(id string) string {
+	a.t.Helper()
+	var status string
+	if err := a.pool.QueryRow(context.Background(), `SELECT status FROM bookings WHERE id = $1`, id).Scan(&status); err != nil {
+		a.t.Fatalf("load booking: %v", err)
+	}
+	return status
+}
+
+func (a *paymentsAPI) seatStatus(seatNo string) string {
+	a.t.Helper()
+	var status string
+	if err := a.pool.QueryRow(context.Background(), `SELECT status FROM seats WHERE event_id = $1 AND seat_no = $2`, a.eventID, seatNo).Scan(&status); err != nil {
+		a.t.Fatalf("load seat: %v", err)
+	}
+	return status
+}
+
+func TestPaymentWebhookConfirmsBooking(t *testing.T) {
+	api := newPaymentsAPI(t)
+	b := api.book()
+	if b.Payment.AmountCents != 1500 || !strings.EqualFold(b.Payment.Currency, "USD") {
+		t.Errorf("payment = %+v, want 1500 USD", *b.Payment)
+	}
+
+	status, body := api.webhook("payment_intent.succeeded", b.Payment.IntentID)
+	if status != http.StatusOK || body["status"] != "active" {
+		t.Fatalf("succeeded webhook: status %d %v, want 200 active", status, body)
+	}
+	if got := api.bookingStatus(b.ID); got != "active" {
+		t.Errorf("booking status = %q, want active", got)
+	}
+
+	// The provider retries: acknowledged and ignored, nothing refunded.
+	status, body = api.webhook("payment_intent.succeeded", b.Payment.IntentID)
+	if status != http.StatusOK || body["status"] != "ignored" {
+		t.Errorf("repeated webhook: status %d %v, want 200 ignored", status, body)
+	}
+	if _, refunded := api.provider.calls(); len(refunded) != 0 {
+		t.Errorf("refunded %v for a booking that was paid once", refunded)
+	}
+}
+
+func TestPaymentWebhookAbandonsFailedPayment(t *testing.T) {
+	api := newPaymentsAPI(t)
+	b := api.book()
+
+	status, body := api.webhook("payment_intent.payment_failed", b.Payment.IntentID)
+	if status != http.StatusOK || body["status"] != "abandoned" {
+		t.Fatalf("failed webhook: status %d %v, want 200 abandoned", status, body)
+	}
+	if got := api.bookingStatus(b.ID); got != "abandoned" {
+		t.Errorf("booking status = %q, want abandoned", got)
+	}
+	if got := api.seatStatus("A1"); got != "available" {
+		t.Errorf("seat A1 = %q, want available again", got)
+	}
+	if n := api.bookedCount(api.eventID); n != 0 {
+		t.Errorf("booked_count = %d, want 0", n)
+	}
+
+	// A payment that still goes through for the abandoned booking is refunded.
+	status, body = api.webhook("payment_intent.succeeded", b.Payment.IntentID)
+	if status != http.StatusOK || body["refunded"] != true {
+		t.Fatalf("late success: status %d %v, want 200 refunded", status, body)
+	}
+	if _, refunded := api.provider.calls(); len(refunded) != 1 || refunded[0] != b.Payment.IntentID {
+		t.Errorf("refunded %v, want [%s]", refunded, b.Payment.IntentID)
+	}
+	if got := api.bookingStatus(b.ID); got != "abandoned" {
+		t.Errorf("booking status after refund = %q, want abandoned", got)
+	}
+}
+
+func TestPaymentSweepAbandonsUnpaidBookings(t *testing.T) {
+	api := newPaymentsAPI(t)
+	stale := api.book()
+	if _, err := api.pool.Exec(context.Background(), `UPDATE bookings SET created_at = now() - interval '1 hour' WHERE id = $1`, stale.ID); err != nil {
+		t.Fatalf("backdate booking: %v", err)
+	}
+	var fresh pendingBooking
+	if status := api.do(api.newUser("user"), http.MethodPost, "/bookings/direct",
+		gin.H{"event_id": api.eventID, "seat_nos": []string{"A2"}}, &fresh, "Idempotency-Key", uuid.NewString()); status != http.StatusCreated {
+		t.Fatalf("book A2: status %d", status)
+	}
+
+	sweep := workers.NewPaymentSweepWorker(api.pool, nil, realtime.NewHub(), api.provider, 15*time.Minute)
+	abandoned, err := sweep.AbandonUnpaid(context.Background())
+	if err != nil {
+		t.Fatalf("AbandonUnpaid: %v", err)
+	}
+	if abandoned != 1 {
+		t.Errorf("abandoned %d bookings, want 1", abandoned)
+	}
+	if got := api.bookingStatus(stale.ID); got != "abandoned" {
+		t.Errorf("stale booking = %q, want abandoned", got)
+	}
+	if got := api.bookingStatus(fresh.ID); got != "pending_payment" {
+		t.Errorf("fresh booking = %q, want still pending_payment", got)
+	}
+	if got := api.seatStatus("A1"); got != "available" {
+		t.Errorf("seat A1 = %q, want available again", got)
+	}
+	if cancelled, _ := api.provider.calls(); len(cancelled) != 1 || cancelled[0] != stale.Payment.IntentID {
+		t.Errorf("cancelled intents %v, want [%s]", cancelled, stale.Payment.IntentID)
+	}
+}
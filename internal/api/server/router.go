@@ -1,8 +1,6 @@
 package server
 
 import (
-	"time"
-
 	"github.com/abhinandanwadwa/overbookr/internal/api/handlers"
 	"github.com/abhinandanwadwa/overbookr/internal/api/middleware"
 	"github.com/gin-contrib/cors"
@@ -14,21 +12,61 @@ type Config struct {
 	PORT   string
 }
 
-func NewRouter(deps AppDeps) *gin.Engine {
+// NewRouter builds the API. It fails if any of the settings the middleware
+// and handlers read from the environment is invalid.
+func NewRouter(deps AppDeps) (*gin.Engine, error) {
 	router := gin.New()
 	router.Use(gin.Recovery())
+	router.Use(middleware.Tracing())
 	router.Use(middleware.RequestLogger())
 
 	// Cors
-	router.Use(cors.New(cors.Config{
-		AllowAllOrigins:  true,
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Idempotency-Key"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
+	corsConfig, err := corsConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	router.Use(cors.New(corsConfig))
+
+	// Per-request DB deadline; analytics gets its own, longer one below, the
+	// seat stream stays open until the client leaves, and cancel-all and the
+	// CSV export work through an event's bookings in batches for as long as
+	// they take.
+	dbTimeout, err := middleware.TimeoutFromEnv("DB_TIMEOUT", middleware.DefaultDBTimeout)
+	if err != nil {
+		return nil, err
+	}
+	analyticsDBTimeout, err := middleware.TimeoutFromEnv("ANALYTICS_DB_TIMEOUT", middleware.DefaultAnalyticsDBTimeout)
+	if err != nil {
+		return nil, err
+	}
+	router.Use(middleware.DBTimeout(dbTimeout, "/analytics", "/events/:id/seats/stream", "/events/:id/bookings/cancel-all", "/events/:id/bookings.csv"))
+
+	// Request body caps, checked before anything is decoded: bulk endpoints
+	// get more room, the unauthenticated user endpoints very little.
+	maxBody, err := middleware.BytesFromEnv("MAX_BODY_BYTES", middleware.DefaultMaxBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	bulkBody, err := middleware.BytesFromEnv("MAX_BULK_BODY_BYTES", middleware.DefaultMaxBulkBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	router.Use(middleware.BodyLimit(maxBody, map[string]int64{
+		"/events/import":       bulkBody,
+		"/events/:id/seats":    bulkBody,
+		"/users/login":         16 << 10,
+		"/users/register":      16 << 10,
+		"/users/accept-invite": 16 << 10,
 	}))
 
+	// Maintenance mode pauses writes, e.g. during a schema migration; reads,
+	// /healthz and /admin/maintenance stay up.
+	maintenance, err := middleware.MaintenanceFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	router.Use(maintenance.Handler())
+
 	// Docs routes
 	RegisterDocsRoutes(router)
 
@@ -36,53 +74,147 @@ func NewRouter(deps AppDeps) *gin.Engine {
 	router.GET("/healthz", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
+	healthHandler := handlers.NewHealthHandler(deps.DB)
+	router.GET("/readyz", healthHandler.Ready)
 
-	// User routes
-	userHandler := handlers.NewUsersHandler(deps.DB)
-	users := router.Group("/users")
-	{
-		users.POST("/register", userHandler.Register)
-		users.POST("/login", userHandler.Login)
-	}
+	// Privileged actions are recorded by audit.Record, listed at GET /admin/audit.
+	audit := middleware.NewAuditLog(deps.DB)
 
-	// Event routes
-	eventHandler := handlers.NewEventsHandler(deps.DB)
-	events := router.Group("/events")
-	{
-		events.POST("/", middleware.AuthMiddleware(), middleware.AdminMiddleware(), eventHandler.CreateEvent)
-		events.GET("/", eventHandler.GetEvents)
-		events.GET("/:id", eventHandler.GetEventByID)
-		events.PATCH("/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), eventHandler.UpdateEvent)
-		events.DELETE("/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), eventHandler.DeleteEvent)
-
-		// Seats
-		events.GET("/:id/seats", eventHandler.GetSeats)
-		events.POST("/:id/seats", middleware.AuthMiddleware(), middleware.AdminMiddleware(), eventHandler.BulkCreateSeats)
-
-		// Waitlist
-		events.POST("/:id/waitlist", middleware.AuthMiddleware(), eventHandler.JoinWaitlist)
+	// Handlers are shared by the versioned routes and their aliases.
+	userHandler, err := handlers.NewUsersHandler(deps.DB, deps.MailQueue)
+	if err != nil {
+		return nil, err
 	}
-
-	holdsHandler := handlers.NewHoldsHandler(deps.DB)
-	holds := router.Group("/holds")
-	{
-		holds.POST("/", middleware.AuthMiddleware(), holdsHandler.CreateHold)
+	eventHandler, err := handlers.NewEventsHandler(deps.DB, deps.SeatHub)
+	if err != nil {
+		return nil, err
 	}
-
-	bookingsHandler := handlers.NewBookingsHandler(deps.DB)
-	bookings := router.Group("/bookings")
-	{
-		bookings.POST("/", middleware.AuthMiddleware(), bookingsHandler.CreateBooking)
-		bookings.GET("/", middleware.AuthMiddleware(), bookingsHandler.GetMyBookings)
-		bookings.GET("/:id", middleware.AuthMiddleware(), bookingsHandler.GetBookingByID)
-		bookings.DELETE("/:id", middleware.AuthMiddleware(), bookingsHandler.CancelBooking)
+	holdsHandler, err := handlers.NewHoldsHandler(deps.DB, deps.SeatHub)
+	if err != nil {
+		return nil, err
+	}
+	ticketsHandler := handlers.NewTicketsHandler(deps.DB)
+	bookingsHandler, err := handlers.NewBookingsHandler(deps.DB, deps.MailQueue, deps.SeatHub, deps.PaymentWindow, deps.Payments)
+	if err != nil {
+		return nil, err
 	}
-
 	analyticsHandler := handlers.NewAnalyticsHandler(deps.DB)
-	analytics := router.Group("/analytics")
-	{
-		analytics.GET("/total_bookings", middleware.AuthMiddleware(), middleware.AdminMiddleware(), analyticsHandler.GetTotalBookingsAnalytics)
+	reconcileHandler := handlers.NewReconcileHandler(deps.DB, deps.HoldRetention, deps.LoginFailureWindow)
+	mailHandler := handlers.NewMailHandler(deps.MailQueue)
+	auditHandler, err := handlers.NewAuditHandler(deps.DB)
+	if err != nil {
+		return nil, err
 	}
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenance)
+
+	// registerAPI adds every API route under api.
+	registerAPI := func(api *gin.RouterGroup) {
+		// User routes
+		users := api.Group("/users")
+		{
+			users.POST("/register", userHandler.Register)
+			users.POST("/login", userHandler.Login)
+			users.GET("/verify", userHandler.VerifyEmail)
+			users.POST("/resend-verification", middleware.AuthMiddleware(), userHandler.ResendVerification)
+			users.POST("/accept-invite", userHandler.AcceptInvite)
+		}
+
+		// Event routes
+		events := api.Group("/events")
+		{
+			events.POST("/", middleware.AuthMiddleware(), middleware.RequireRole("admin", "organizer"), audit.Record("event.create"), eventHandler.CreateEvent)
+			events.POST("/import", middleware.AuthMiddleware(), middleware.RequireRole("admin"), audit.Record("event.import"), eventHandler.ImportEvents)
+			events.GET("/", middleware.OptionalAuthMiddleware(), eventHandler.GetEvents)
+			events.GET("/:id", middleware.OptionalAuthMiddleware(), eventHandler.GetEventByID)
+			// Organizers may manage only events they own; the handlers enforce that.
+			events.PATCH("/:id", middleware.AuthMiddleware(), middleware.RequireRole("admin", "organizer"), audit.Record("event.update"), eventHandler.UpdateEvent)
+			events.DELETE("/:id", middleware.AuthMiddleware(), middleware.RequireRole("admin", "organizer"), audit.Record("event.delete"), eventHandler.DeleteEvent)
+			events.POST("/:id/restore", middleware.AuthMiddleware(), middleware.RequireRole("admin", "organizer"), audit.Record("event.restore"), eventHandler.RestoreEvent)
+
+			// Seats
+			events.GET("/:id/seats", eventHandler.GetSeats)
+			events.GET("/:id/seats/stream", eventHandler.StreamSeats)
+			events.GET("/:id/availability", eventHandler.GetAvailability)
+			events.POST("/:id/seats", middleware.AuthMiddleware(), middleware.RequireRole("admin", "organizer"), audit.Record("event.seats_create"), eventHandler.BulkCreateSeats)
+
+			// Attendees
+			events.GET("/:id/bookings", middleware.AuthMiddleware(), middleware.RequireRole("admin", "organizer"), eventHandler.ListEventBookings)
+			events.GET("/:id/bookings.csv", middleware.AuthMiddleware(), middleware.RequireRole("admin", "organizer"), eventHandler.ExportEventBookingsCSV)
+
+			// Waitlist
+			events.POST("/:id/waitlist", middleware.AuthMiddleware(), eventHandler.JoinWaitlist)
+		}
+
+		// Seat history, for disputes and debugging
+		seats := api.Group("/seats")
+		{
+			seats.GET("/:id/history", middleware.AuthMiddleware(), middleware.AdminMiddleware(), eventHandler.GetSeatHistory)
+		}
+
+		holds := api.Group("/holds")
+		{
+			holds.POST("/", middleware.AuthMiddleware(), holdsHandler.CreateHold)
+			holds.GET("/", middleware.AuthMiddleware(), holdsHandler.GetMyHolds)
+			holds.POST("/:token/transfer", middleware.AuthMiddleware(), holdsHandler.TransferHold)
+		}
+
+		tickets := api.Group("/tickets")
+		{
+			tickets.POST("/verify", middleware.AuthMiddleware(), middleware.RequireRole("admin", "gate"), ticketsHandler.VerifyTicket)
+		}
+
+		bookings := api.Group("/bookings")
+		{
+			bookings.POST("/", middleware.AuthMiddleware(), bookingsHandler.CreateBooking)
+			bookings.POST("/direct", middleware.AuthMiddleware(), bookingsHandler.CreateDirectBooking)
+			bookings.GET("/", middleware.AuthMiddleware(), bookingsHandler.GetMyBookings)
+			bookings.GET("/:id", middleware.AuthMiddleware(), bookingsHandler.GetBookingByID)
+			bookings.GET("/:id/ticket.pdf", middleware.AuthMiddleware(), bookingsHandler.GetBookingTicketPDF)
+			bookings.POST("/:id/resend-confirmation", middleware.AuthMiddleware(), bookingsHandler.ResendConfirmation)
+			bookings.POST("/:id/regenerate-ticket", middleware.AuthMiddleware(), audit.Record("booking.regenerate_ticket"), bookingsHandler.RegenerateTicket)
+			bookings.DELETE("/:id", middleware.AuthMiddleware(), bookingsHandler.CancelBooking)
+			bookings.POST("/:id/confirm", middleware.AuthMiddleware(), middleware.AdminMiddleware(), audit.Record("booking.confirm"), bookingsHandler.ConfirmBooking)
+			bookings.POST("/:id/payment", middleware.AuthMiddleware(), bookingsHandler.BookingPayment)
+			bookings.POST("/:id/abandon", middleware.AuthMiddleware(), bookingsHandler.AbandonBooking)
+			bookings.POST("/:id/checkin", middleware.AuthMiddleware(), middleware.RequireRole("admin", "gate"), audit.Record("booking.checkin"), ticketsHandler.CheckInBooking)
+			bookings.POST("/:id/transfer", middleware.AuthMiddleware(), bookingsHandler.TransferBooking)
+		}
+		// Calling off an event: cancels all of its bookings.
+		events.POST("/:id/bookings/cancel-all", middleware.AuthMiddleware(), middleware.AdminMiddleware(), audit.Record("event.cancel_all_bookings"), bookingsHandler.CancelAllEventBookings)
+
+		// Authenticated by the provider's signature rather than a bearer token.
+		paymentsGroup := api.Group("/payments")
+		{
+			paymentsGroup.POST("/webhook", bookingsHandler.PaymentWebhook)
+		}
+
+		analytics := api.Group("/analytics", middleware.DBTimeout(analyticsDBTimeout))
+		{
+			analytics.GET("/total_bookings", middleware.AuthMiddleware(), middleware.AdminMiddleware(), analyticsHandler.GetTotalBookingsAnalytics)
+			analytics.GET("/no_shows", middleware.AuthMiddleware(), middleware.AdminMiddleware(), analyticsHandler.GetNoShowReport)
+		}
+
+		admin := api.Group("/admin")
+		{
+			admin.POST("/reconcile", middleware.AuthMiddleware(), middleware.AdminMiddleware(), audit.Record("reconcile.run"), reconcileHandler.RunReconcile)
+			admin.GET("/reconcile/preview", middleware.AuthMiddleware(), middleware.AdminMiddleware(), reconcileHandler.PreviewReconcile)
+			admin.GET("/mail/stats", middleware.AuthMiddleware(), middleware.AdminMiddleware(), mailHandler.GetMailStats)
+			admin.GET("/debug/booking-retry", middleware.AuthMiddleware(), middleware.AdminMiddleware(), bookingsHandler.GetRetryPolicy)
+			admin.POST("/users", middleware.AuthMiddleware(), middleware.AdminMiddleware(), audit.Record("user.create"), userHandler.AdminCreateUser)
+			admin.POST("/users/invite", middleware.AuthMiddleware(), middleware.AdminMiddleware(), audit.Record("user.invite"), userHandler.InviteUsers)
+			admin.POST("/bookings", middleware.AuthMiddleware(), middleware.AdminMiddleware(), audit.Record("booking.admin_create"), bookingsHandler.AdminCreateBooking)
+			admin.GET("/bookings", middleware.AuthMiddleware(), middleware.AdminMiddleware(), bookingsHandler.AdminListBookings)
+			admin.GET("/audit", middleware.AuthMiddleware(), middleware.AdminMiddleware(), auditHandler.ListAuditLog)
+			admin.PUT("/events/:id/waitlist", middleware.AuthMiddleware(), middleware.AdminMiddleware(), audit.Record("event.waitlist_settings"), eventHandler.SetWaitlistSettings)
+			admin.GET("/maintenance", middleware.AuthMiddleware(), middleware.AdminMiddleware(), maintenanceHandler.GetMaintenance)
+			admin.PUT("/maintenance", middleware.AuthMiddleware(), middleware.AdminMiddleware(), audit.Record("maintenance.set"), maintenanceHandler.SetMaintenance)
+		}
+	}
+
+	// The API lives under /v1 so a future breaking change can ship as /v2.
+	// The unversioned routes are kept, marked deprecated, until clients move.
+	registerAPI(router.Group(middleware.APIVersionPrefix))
+	registerAPI(router.Group("", middleware.Deprecated()))
 
-	return router
+	return router, nil
 }
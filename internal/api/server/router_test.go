@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// testRouter builds the API without a database, which is enough for requests
+// answered by the middleware or before a handler queries anything.
+func testRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "router-test-secret")
+	r, err := NewRouter(AppDeps{})
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return r
+}
+
+func bearer(t *testing.T, role string) string {
+	t.Helper()
+	token, _, err := auth.ConfigFromEnv().Mint(uuid.NewString(), role, time.Now())
+	if err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+	return "Bearer " + token
+}
+
+func TestEventUpdateAndDeleteRoutes(t *testing.T) {
+	r := testRouter(t)
+
+	tests := []struct {
+		method string
+		role   string // "" sends no token
+		want   int
+	}{
+		// An invalid id is refused by the handler itself, so a 400 shows
+		// the route reached it.
+		{http.MethodPatch, "admin", http.StatusBadRequest},
+		{http.MethodDelete, "admin", http.StatusBadRequest},
+		{http.MethodPatch, "organizer", http.StatusBadRequest},
+		{http.MethodDelete, "organizer", http.StatusBadRequest},
+		{http.MethodPatch, "user", http.StatusForbidden},
+		{http.MethodDelete, "user", http.StatusForbidden},
+		{http.MethodPatch, "gate", http.StatusForbidden},
+		{http.MethodPatch, "", http.StatusUnauthorized},
+		{http.MethodDelete, "", http.StatusUnauthorized},
+	}
+	for _, prefix := range []string{"/v1", ""} {
+		for _, tt := range tests {
+			req := httptest.NewRequest(tt.method, prefix+"/events/not-a-uuid", nil)
+			if tt.role != "" {
+				req.Header.Set("Authorization", bearer(t, tt.role))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("%s %s as %q: status = %d, want %d (%s)", tt.method, req.URL.Path, tt.role, w.Code, tt.want, w.Body)
+				continue
+			}
+			if tt.want == http.StatusBadRequest {
+				var body struct {
+					Error string `json:"error"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error != "invalid event id" {
+					t.Errorf("%s %s as %q: body = %s, want the handler's invalid event id", tt.method, req.URL.Path, tt.role, w.Body)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+//go:build integration
+
+package server
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type seatResponse struct {
+	SeatNo    string  `json:"seat_no"`
+	Section   string  `json:"section"`
+	Status    string  `json:"status"`
+	BookingID *string `json:"booking_id"`
+}
+
+func TestGetSeats(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.newUser("admin")
+	buyer := api.newUser("user")
+	eventID := api.newEvent(admin, 4, "A1", "A2")
+	if status := api.do(admin, http.MethodPost, "/events/"+eventID+"/seats", gin.H{"seat_nos": []string{"B1", "B2"}, "section": "balcony"}, nil); status != http.StatusCreated {
+		t.Fatalf("create balcony seats: status %d", status)
+	}
+
+	var booking struct {
+		ID string `json:"id"`
+	}
+	if status := api.do(buyer, http.MethodPost, "/bookings/direct", gin.H{"event_id": eventID, "seat_nos": []string{"A1"}}, &booking, "Idempotency-Key", uuid.NewString()); status != http.StatusCreated {
+		t.Fatalf("book A1: status %d", status)
+	}
+
+	var seats []seatResponse
+	if status := api.do(user{}, http.MethodGet, "/events/"+eventID+"/seats", nil, &seats); status != http.StatusOK {
+		t.Fatalf("GET seats: status %d", status)
+	}
+	if len(seats) != 4 {
+		t.Fatalf("got %d seats, want 4: %+v", len(seats), seats)
+	}
+	for _, s := range seats {
+		switch s.SeatNo {
+		case "A1":
+			if s.Status != "booked" || s.BookingID == nil || *s.BookingID != booking.ID {
+				t.Errorf("A1 = %+v, want booked by %s", s, booking.ID)
+			}
+		default:
+			if s.Status != "available" || s.BookingID != nil {
+				t.Errorf("%s = %+v, want available with no booking", s.SeatNo, s)
+			}
+		}
+	}
+
+	var available []seatResponse
+	if status := api.do(user{}, http.MethodGet, "/events/"+eventID+"/seats?status=available", nil, &available); status != http.StatusOK {
+		t.Fatalf("GET available seats: status %d", status)
+	}
+	if len(available) != 3 {
+		t.Errorf("got %d available seats, want 3: %+v", len(available), available)
+	}
+
+	var sections []struct {
+		Section string         `json:"section"`
+		Seats   []seatResponse `json:"seats"`
+	}
+	if status := api.do(user{}, http.MethodGet, "/events/"+eventID+"/seats?group_by=section", nil, &sections); status != http.StatusOK {
+		t.Fatalf("GET seats by section: status %d", status)
+	}
+	counts := map[string]int{}
+	for _, s := range sections {
+		counts[s.Section] = len(s.Seats)
+	}
+	if len(sections) != 2 || counts["general"] != 2 || counts["balcony"] != 2 {
+		t.Errorf("sections = %v, want general and balcony with 2 seats each", counts)
+	}
+}
+
+func TestSeatNumbersAreNormalized(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.newUser("admin")
+	buyer := api.newUser("user")
+	eventID := api.newEvent(admin, 5)
+	path := "/events/" + eventID + "/seats"
+
+	type created struct {
+		Created  []seatResponse `json:"created"`
+		Existing []string       `json:"existing"`
+	}
+	seatNos := func(seats []seatResponse) []string {
+		out := make([]string, len(seats))
+		for i, s := range seats {
+			out[i] = s.SeatNo
+		}
+		slices.Sort(out)
+		return out
+	}
+
+	var first created
+	if status := api.do(admin, http.MethodPost, path, gin.H{"seat_nos": []string{"a1", " A1 ", "b2"}}, &first); status != http.StatusCreated {
+		t.Fatalf("create seats: status %d", status)
+	}
+	if got := seatNos(first.Created); !slices.Equal(got, []string{"A1", "B2"}) || len(first.Existing) != 0 {
+		t.Errorf("created %v, existing %v; want [A1 B2] and none", got, first.Existing)
+	}
+
+	var second created
+	if status := api.do(admin, http.MethodPost, path, gin.H{"seat_nos": []string{"A1", "c3"}}, &second); status != http.StatusCreated {
+		t.Fatalf("create more seats: status %d", status)
+	}
+	if got := seatNos(second.Created); !slices.Equal(got, []string{"C3"}) || !slices.Equal(second.Existing, []string{"A1"}) {
+		t.Errorf("created %v, existing %v; want [C3] and [A1]", got, second.Existing)
+	}
+
+	var none created
+	if status := api.do(admin, http.MethodPost, path, gin.H{"seat_nos": []string{" a1"}}, &none); status != http.StatusOK || len(none.Created) != 0 {
+		t.Errorf("recreate a1: status %d created %v, want 200 with nothing created", status, seatNos(none.Created))
+	}
+
+	// Lookups normalize the same way.
+	var hold struct {
+		SeatNumbers []string `json:"seat_numbers"`
+	}
+	if status := api.do(buyer, http.MethodPost, "/holds/", gin.H{"event_id": eventID, "seat_nos": []string{" b2 "}}, &hold); status != http.StatusCreated {
+		t.Fatalf("hold b2: status %d", status)
+	}
+	if !slices.Equal(hold.SeatNumbers, []string{"B2"}) {
+		t.Errorf("held %v, want [B2]", hold.SeatNumbers)
+	}
+}
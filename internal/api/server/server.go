@@ -9,6 +9,9 @@ import (
 	"syscall"
 	"time"
 
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
+	"github.com/abhinandanwadwa/overbookr/internal/payments"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,12 +21,27 @@ type Server struct {
 }
 
 type AppDeps struct {
-	DB *pgxpool.Pool
+	DB        *pgxpool.Pool
+	Mailer    *mail.Mailer
+	MailQueue *mail.Queue
+	// SeatHub carries live seat changes to the SSE seat stream.
+	SeatHub *realtime.Hub
+	// HoldRetention is how long finished holds are kept; see ReconcileWorker.
+	HoldRetention time.Duration
+	// LoginFailureWindow is LOGIN_FAILURE_WINDOW; login failure counters
+	// older than it are pruned by ReconcileWorker.
+	LoginFailureWindow time.Duration
+	// PaymentWindow is how long a booking may await payment; see PaymentSweepWorker.
+	PaymentWindow time.Duration
+	// Payments is the payment provider for paid events; nil when disabled.
+	Payments payments.Provider
 }
 
-func NewServer(cgf Config, db *pgxpool.Pool) *Server {
-	deps := AppDeps{DB: db}
-	router := NewRouter(deps)
+func NewServer(cgf Config, deps AppDeps) (*Server, error) {
+	router, err := NewRouter(deps)
+	if err != nil {
+		return nil, err
+	}
 
 	s := &http.Server{
 		Addr:           ":" + cgf.PORT,
@@ -34,7 +52,7 @@ func NewServer(cgf Config, db *pgxpool.Pool) *Server {
 		MaxHeaderBytes: 1 << 20, // 1 MB
 	}
 
-	return &Server{httpServer: s, deps: deps}
+	return &Server{httpServer: s, deps: deps}, nil
 }
 
 func (s *Server) Start() error {
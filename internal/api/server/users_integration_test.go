@@ -0,0 +1,110 @@
+//go:build integration
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRegisterNeverGrantsAnElevatedRole(t *testing.T) {
+	api := newTestAPI(t)
+
+	for _, role := range []string{"admin", "organizer", "gate", "user", ""} {
+		email := uuid.NewString() + "@example.com"
+		var created struct {
+			ID    string `json:"id"`
+			Role  string `json:"role"`
+			Token string `json:"token"`
+		}
+		status := api.do(user{}, http.MethodPost, "/users/register", gin.H{
+			"name":     "Mallory",
+			"email":    email,
+			"password": "hunter22",
+			"role":     role,
+		}, &created)
+		if status != http.StatusCreated {
+			t.Fatalf("register asking for %q: status %d, want 201", role, status)
+		}
+		if created.Role != "user" {
+			t.Errorf("register asking for %q: response role %q, want user", role, created.Role)
+		}
+
+		var stored string
+		if err := api.pool.QueryRow(context.Background(), `SELECT role FROM users WHERE email = $1`, email).Scan(&stored); err != nil {
+			t.Fatalf("load user: %v", err)
+		}
+		if stored != "user" {
+			t.Errorf("register asking for %q: stored role %q, want user", role, stored)
+		}
+
+		// The token it got back carries no admin rights either.
+		if status := api.do(user{Token: created.Token}, http.MethodPost, "/admin/users", gin.H{
+			"name":     "Accomplice",
+			"email":    uuid.NewString() + "@example.com",
+			"password": "hunter22",
+			"role":     "admin",
+		}, nil); status != http.StatusForbidden {
+			t.Errorf("registered %q creating an admin: status %d, want 403", role, status)
+		}
+	}
+}
+
+func TestAdminCreatesElevatedAccounts(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.newUser("admin")
+
+	var created struct {
+		Role string `json:"role"`
+	}
+	if status := api.do(admin, http.MethodPost, "/admin/users", gin.H{
+		"name":     "Olive Organizer",
+		"email":    uuid.NewString() + "@example.com",
+		"password": "hunter22",
+		"role":     "organizer",
+	}, &created); status != http.StatusCreated {
+		t.Fatalf("create organizer: status %d, want 201", status)
+	}
+	if created.Role != "organizer" {
+		t.Errorf("role = %q, want organizer", created.Role)
+	}
+}
+
+func TestResendVerificationCooldownHoldsWhileMailIsQueued(t *testing.T) {
+	// The test API never delivers mail, so no verification token is created.
+	api := newTestAPI(t)
+	var created struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	}
+	if status := api.do(user{}, http.MethodPost, "/users/register", gin.H{
+		"name":     "Una Verified",
+		"email":    uuid.NewString() + "@example.com",
+		"password": "hunter22",
+	}, &created); status != http.StatusCreated {
+		t.Fatalf("register: status %d, want 201", status)
+	}
+	u := user{Token: created.Token}
+
+	// Sign-up queued the first mail, so an immediate resend waits.
+	if status := api.do(u, http.MethodPost, "/users/resend-verification", nil, nil); status != http.StatusTooManyRequests {
+		t.Fatalf("resend right after sign-up: status %d, want 429", status)
+	}
+
+	if _, err := api.pool.Exec(context.Background(), `UPDATE email_verification_requests SET sent_at = now() - interval '2 minutes' WHERE user_id = $1`, created.ID); err != nil {
+		t.Fatalf("backdate request: %v", err)
+	}
+	if status := api.do(u, http.MethodPost, "/users/resend-verification", nil, nil); status != http.StatusAccepted {
+		t.Fatalf("resend after the cooldown: status %d, want 202", status)
+	}
+	var resp struct {
+		RetryAfter int `json:"retry_after"`
+	}
+	if status := api.do(u, http.MethodPost, "/users/resend-verification", nil, &resp); status != http.StatusTooManyRequests || resp.RetryAfter <= 0 {
+		t.Errorf("second resend: status %d retry_after %d, want 429 with a wait", status, resp.RetryAfter)
+	}
+}
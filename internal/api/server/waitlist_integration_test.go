@@ -0,0 +1,86 @@
+//go:build integration
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type waitlistEntry struct {
+	ID             string `json:"id"`
+	Position       int64  `json:"position"`
+	RequestedSeats int32  `json:"requested_seats"`
+	Updated        bool   `json:"updated"`
+}
+
+func TestWaitlistChangeQuantity(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.newUser("admin")
+	buyer := api.newUser("user")
+	alice := api.newUser("user")
+	bob := api.newUser("user")
+	eventID := api.newEvent(admin, 1, "A1")
+	if status := api.do(buyer, http.MethodPost, "/bookings/direct", gin.H{"event_id": eventID, "seat_nos": []string{"A1"}}, nil, "Idempotency-Key", uuid.NewString()); status != http.StatusCreated {
+		t.Fatalf("sell out: status %d", status)
+	}
+	path := "/events/" + eventID + "/waitlist"
+	join := func(u user, seats int) (waitlistEntry, int) {
+		t.Helper()
+		var entry waitlistEntry
+		status := api.do(u, http.MethodPost, path, gin.H{"requested_seats": seats}, &entry)
+		return entry, status
+	}
+
+	first, status := join(alice, 1)
+	if status != http.StatusAccepted || first.Updated {
+		t.Fatalf("Alice joins: status %d %+v, want 202 new entry", status, first)
+	}
+	bobs, status := join(bob, 1)
+	if status != http.StatusAccepted {
+		t.Fatalf("Bob joins: status %d, want 202", status)
+	}
+
+	// Asking for more seats updates the entry and moves it behind Bob.
+	more, status := join(alice, 2)
+	if status != http.StatusOK || !more.Updated {
+		t.Fatalf("Alice asks for 2: status %d %+v, want 200 updated", status, more)
+	}
+	if more.ID != first.ID || more.RequestedSeats != 2 {
+		t.Errorf("Alice asks for 2: entry %+v, want %s with 2 seats", more, first.ID)
+	}
+	if more.Position <= bobs.Position {
+		t.Errorf("Alice's position %d after asking for more, want behind Bob's %d", more.Position, bobs.Position)
+	}
+
+	// Asking for fewer keeps the place.
+	fewer, status := join(alice, 1)
+	if status != http.StatusOK || fewer.RequestedSeats != 1 || fewer.Position != more.Position {
+		t.Errorf("Alice asks for 1: status %d %+v, want 200 with 1 seat at position %d", status, fewer, more.Position)
+	}
+
+	var entries int
+	if err := api.pool.QueryRow(context.Background(), `SELECT COUNT(*) FROM waitlist WHERE event_id = $1 AND user_id = $2`, eventID, alice.ID).Scan(&entries); err != nil {
+		t.Fatalf("count entries: %v", err)
+	}
+	if entries != 1 {
+		t.Errorf("Alice has %d waitlist entries, want 1", entries)
+	}
+
+	// Entries that left the waiting state can't be changed.
+	for _, final := range []string{"promoted", "cancelled"} {
+		if _, err := api.pool.Exec(context.Background(), `UPDATE waitlist SET status = $3 WHERE event_id = $1 AND user_id = $2`, eventID, alice.ID, final); err != nil {
+			t.Fatalf("mark %s: %v", final, err)
+		}
+		var resp struct {
+			Code string `json:"code"`
+		}
+		if status := api.do(alice, http.MethodPost, path, gin.H{"requested_seats": 2}, &resp); status != http.StatusConflict || resp.Code != "ALREADY_WAITLISTED" {
+			t.Errorf("rejoin once %s: status %d %s, want 409 ALREADY_WAITLISTED", final, status, resp.Code)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gomail "gopkg.in/gomail.v2"
+)
+
+// ErrCircuitOpen is returned by a BreakerTransport while the mail server is
+// considered down. Nothing was sent, so the message can be retried as is.
+var ErrCircuitOpen = errors.New("mail circuit open: mail server unreachable")
+
+// Breaker states as reported in BreakerStats.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// BreakerOptions tunes a BreakerTransport.
+type BreakerOptions struct {
+	// Threshold is how many sends in a row must fail before the circuit
+	// opens.
+	Threshold int
+	// Cooldown is how long the circuit stays open before a single probe
+	// send is let through.
+	Cooldown time.Duration
+}
+
+// BreakerStats is a point-in-time view of a BreakerTransport.
+type BreakerStats struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Trips               int64      `json:"trips"`
+	Rejected            int64      `json:"rejected"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+}
+
+// BreakerTransport stops calling the wrapped transport after Threshold
+// failures in a row, so an SMTP outage costs one fast error per message
+// instead of a dial timeout. After Cooldown one probe send is let through:
+// success closes the circuit, failure opens it for another Cooldown.
+type BreakerTransport struct {
+	next Transport
+	opts BreakerOptions
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+
+	trips    atomic.Int64
+	rejected atomic.Int64
+}
+
+// NewBreakerTransport wraps next with a circuit breaker.
+func NewBreakerTransport(next Transport, opts BreakerOptions) *BreakerTransport {
+	return &BreakerTransport{next: next, opts: opts}
+}
+
+// BreakerOptionsFromEnv reads MAIL_BREAKER_THRESHOLD (default 5, 0 disables
+// the breaker) and MAIL_BREAKER_COOLDOWN, a Go duration (default 30s).
+func BreakerOptionsFromEnv() (BreakerOptions, error) {
+	opts := BreakerOptions{Threshold: defaultBreakerThreshold, Cooldown: defaultBreakerCooldown}
+	if raw := os.Getenv("MAIL_BREAKER_THRESHOLD"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("invalid MAIL_BREAKER_THRESHOLD %q: want a non-negative integer", raw)
+		}
+		opts.Threshold = n
+	}
+	if raw := os.Getenv("MAIL_BREAKER_COOLDOWN"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return opts, fmt.Errorf("invalid MAIL_BREAKER_COOLDOWN %q: want a positive duration", raw)
+		}
+		opts.Cooldown = d
+	}
+	return opts, nil
+}
+
+func (b *BreakerTransport) Send(ctx context.Context, msg *gomail.Message) error {
+	if !b.allow() {
+		b.rejected.Add(1)
+		return ErrCircuitOpen
+	}
+	err := b.next.Send(ctx, msg)
+	// A cancelled caller says nothing about the mail server.
+	if err != nil && ctx.Err() != nil {
+		b.release()
+		return err
+	}
+	b.record(err)
+	return err
+}
+
+// allow reports whether a send may go through, claiming the probe slot when
+// the cooldown is over.
+func (b *BreakerTransport) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// release gives the probe slot back without judging the server.
+func (b *BreakerTransport) release() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+func (b *BreakerTransport) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		if !b.openUntil.IsZero() {
+			log.Printf("mail breaker: closed, mail server reachable again")
+		}
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failures++
+	if !b.openUntil.IsZero() || b.failures >= b.opts.Threshold {
+		if b.openUntil.IsZero() {
+			b.trips.Add(1)
+			log.Printf("mail breaker: open after %d failures: %v", b.failures, err)
+		}
+		b.openUntil = time.Now().Add(b.opts.Cooldown)
+	}
+}
+
+// Open reports whether sends are currently being rejected. A circuit whose
+// cooldown is over is not, since the next send will probe.
+func (b *BreakerTransport) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openUntil.IsZero() && (b.probing || time.Now().Before(b.openUntil))
+}
+
+// HalfOpen reports whether the cooldown is over and the next send will be
+// the probe.
+func (b *BreakerTransport) HalfOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openUntil.IsZero() && !b.probing && !time.Now().Before(b.openUntil)
+}
+
+// Stats reports the breaker's state and counters.
+func (b *BreakerTransport) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := BreakerStats{
+		State:               BreakerClosed,
+		ConsecutiveFailures: b.failures,
+		Trips:               b.trips.Load(),
+		Rejected:            b.rejected.Load(),
+	}
+	if !b.openUntil.IsZero() {
+		stats.State = BreakerOpen
+		if !time.Now().Before(b.openUntil) {
+			stats.State = BreakerHalfOpen
+		}
+		until := b.openUntil
+		stats.OpenUntil = &until
+	}
+	return stats
+}
@@ -2,16 +2,19 @@ package mail
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
+	"errors"
 	"fmt"
 	"html/template"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"io"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/money"
+	"github.com/abhinandanwadwa/overbookr/internal/tickets"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/skip2/go-qrcode"
 	gomail "gopkg.in/gomail.v2"
 )
@@ -19,11 +22,58 @@ import (
 type CreateBookingResponse struct {
 	ID          string
 	EventID     string
+	UserID      string
 	SeatNumbers []string
+	Status      string
 	CreatedAt   time.Time
+	// TokenVersion is signed into the ticket QR.
+	TokenVersion int32
+	// UnitPriceCents and Currency are the seat price the booking was made
+	// at; an empty Currency means the base currency.
+	UnitPriceCents int64
+	Currency       string
 }
 
-func SendConfirmationMail(mailer *Mailer, resp CreateBookingResponse, event db.Event, toEmail string, includeQR bool) error {
+// Bill prices the booking's seats at the price it was made at, formatted
+// with branding's locale. It returns nil for free bookings.
+func (r CreateBookingResponse) Bill(branding Branding) *money.Bill {
+	currency := r.Currency
+	if currency == "" {
+		currency = branding.BaseCurrency
+	}
+	return branding.PriceLocale.PerSeat(r.SeatNumbers, r.UnitPriceCents, currency)
+}
+
+// BuildBookingConfirmation loads a committed booking and resolves its seat
+// numbers. Pass a transaction's queries to read a booking before it commits.
+// The event is not needed here: jobs look it up when they are delivered.
+func BuildBookingConfirmation(ctx context.Context, q *db.Queries, bookingID pgtype.UUID) (CreateBookingResponse, error) {
+	b, err := q.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return CreateBookingResponse{}, fmt.Errorf("get booking: %w", err)
+	}
+	seatNumbers, err := q.GetSeatNosByIds(ctx, b.SeatIds)
+	if err != nil {
+		return CreateBookingResponse{}, fmt.Errorf("get seat numbers: %w", err)
+	}
+	resp := CreateBookingResponse{
+		ID:           b.ID.String(),
+		EventID:      b.EventID.String(),
+		SeatNumbers:  seatNumbers,
+		Status:       b.Status,
+		CreatedAt:    b.CreatedAt.Time,
+		TokenVersion: b.TokenVersion,
+
+		UnitPriceCents: int64(b.UnitPriceCents),
+		Currency:       b.Currency.String,
+	}
+	if b.UserID.Valid {
+		resp.UserID = b.UserID.String()
+	}
+	return resp, nil
+}
+
+func SendConfirmationMail(ctx context.Context, mailer *Mailer, resp CreateBookingResponse, event db.Event, toEmail string, includeQR bool) error {
 	if mailer == nil {
 		return fmt.Errorf("mailer is nil")
 	}
@@ -31,11 +81,10 @@ func SendConfirmationMail(mailer *Mailer, resp CreateBookingResponse, event db.E
 		return fmt.Errorf("recipient email is empty")
 	}
 
-	const AppURL = "https://app.overbookr.com"
-
 	// prepare event pieces
 	eventName := strings.TrimSpace(event.Name)
 	venue := event.Venue.String
+	description := strings.TrimSpace(event.Description.String)
 	startStr := event.StartTime.Time.Format("Mon, 02 Jan 2006 15:04 MST")
 
 	// Subject
@@ -58,6 +107,11 @@ func SendConfirmationMail(mailer *Mailer, resp CreateBookingResponse, event db.E
             </tr></table>
           </td>
         </tr>
+        {{ if .ImageURL }}
+        <tr>
+          <td style="padding:0;"><img src="{{ .ImageURL }}" alt="{{ .EventName }}" width="680" style="display:block;width:100%;max-height:280px;object-fit:cover;"/></td>
+        </tr>
+        {{ end }}
 
         <!-- Ticket -->
         <tr>
@@ -67,6 +121,7 @@ func SendConfirmationMail(mailer *Mailer, resp CreateBookingResponse, event db.E
                 <td valign="top" width="64%" style="background:#ffffff;padding:18px;border:1px solid #eef2f7;border-right:none;">
                   <div style="font-size:11px;color:#6b7280;margin-bottom:6px;">Booking</div>
                   <div style="font-size:18px;font-weight:700;color:#0f172a;margin-bottom:12px;">{{ .EventName }}</div>
+                  {{ if .Description }}<div style="font-size:13px;color:#4b5563;line-height:1.5;margin-bottom:12px;white-space:pre-line;">{{ .Description }}</div>{{ end }}
 
                   <table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="border-collapse:collapse;">
                     <tr>
@@ -86,6 +141,22 @@ func SendConfirmationMail(mailer *Mailer, resp CreateBookingResponse, event db.E
                           {{ end }}
                         </div>
 
+                        {{ if .Price }}
+                        <div style="font-weight:600;margin-bottom:6px;">Price</div>
+                        <table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="border-collapse:collapse;font-size:13px;color:#374151;margin-bottom:10px;">
+                          {{ range .Price.Lines }}
+                          <tr>
+                            <td style="padding:2px 0;">Seat {{ .SeatNo }}</td>
+                            <td align="right" style="padding:2px 0;white-space:nowrap;">{{ .Amount }}</td>
+                          </tr>
+                          {{ end }}
+                          <tr>
+                            <td style="padding:6px 0 0 0;border-top:1px solid #eef2f7;font-weight:700;">Total</td>
+                            <td align="right" style="padding:6px 0 0 0;border-top:1px solid #eef2f7;font-weight:700;white-space:nowrap;">{{ .Price.Total }}</td>
+                          </tr>
+                        </table>
+                        {{ end }}
+
                         <div style="margin-top:8px;">
                           <a href="{{ .BookingURL }}" style="display:inline-block;padding:8px 12px;font-weight:700;font-size:13px;text-decoration:none;border-radius:8px;background:#0f3b91;color:#ffffff;">View Booking</a>
                         </div>
@@ -100,7 +171,7 @@ func SendConfirmationMail(mailer *Mailer, resp CreateBookingResponse, event db.E
 
                 <td valign="top" width="35%" style="background:#fafbff;padding:18px;border:1px solid #eef2f7;border-left:none;text-align:center;">
                   <!-- embed via cid -->
-                  <img src="cid:{{ .QRFilename }}" alt="Ticket QR" width="130" height="130" style="display:block;margin:0 auto 12px auto;border-radius:8px;"/>
+                  {{ if .QRFilename }}<img src="cid:{{ .QRFilename }}" alt="Ticket QR" width="130" height="130" style="display:block;margin:0 auto 12px auto;border-radius:8px;"/>{{ end }}
 
                   <div style="font-size:12px;color:#6b7280;margin-bottom:6px;">Reference</div>
                   <div style="font-weight:700;color:#0f172a;margin-bottom:10px;">{{ .BookingID }}</div>
@@ -124,7 +195,7 @@ func SendConfirmationMail(mailer *Mailer, resp CreateBookingResponse, event db.E
             <table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="border-collapse:collapse;">
               <tr>
                 <td style="font-size:13px;color:#6b7280;">Keep this email as proof of booking. For changes or cancellations visit your booking page.</td>
-                <td align="right" style="font-size:12px;color:#9ca3af;">Made with ❤️ — support@overbookr.com</td>
+                <td align="right" style="font-size:12px;color:#9ca3af;">Made with ❤️ — {{ .SupportEmail }}</td>
               </tr>
             </table>
           </td>
@@ -134,118 +205,122 @@ func SendConfirmationMail(mailer *Mailer, resp CreateBookingResponse, event db.E
   </body>
 </html>`
 
-	// ---- generate QR PNG to temp file if requested ----
-	qrFilename := ""    // basename used for cid
-	var tempPath string // full path for embedding
+	// ---- generate QR PNG in memory if requested ----
+	qrFilename := "" // used as the cid; empty means no QR
+	var qrPNG []byte
 	if includeQR {
-		// make a safe filename, can use booking id prefix
-		qrFilename = fmt.Sprintf("qr_%s.png", strings.ReplaceAll(resp.ID, "-", "")) // no dashes
-		// generate PNG bytes
-		png, err := qrcode.Encode(resp.ID, qrcode.Medium, 256)
+		png, err := TicketQR(resp, time.Now())
 		if err != nil {
-			// Log or fallback: don't crash — you can continue without QR or return error
-			// return fmt.Errorf("failed to generate qr: %w", err)
-			qrFilename = "" // no qr
+			log.Printf("failed to build ticket QR for booking %s, sending without it: %v", resp.ID, err)
 		} else {
-			// write to temp file (system temp dir)
-			tmpFile, err := ioutil.TempFile("", qrFilename)
-			if err != nil {
-				qrFilename = ""
-			} else {
-				tempPath = tmpFile.Name()
-				if _, err := tmpFile.Write(png); err != nil {
-					_ = tmpFile.Close()
-					_ = os.Remove(tempPath)
-					tempPath = ""
-					qrFilename = ""
-				} else {
-					_ = tmpFile.Close()
-					// Ensure basename for CID (some clients expect basename)
-					qrFilename = filepath.Base(tempPath) // use actual temp name as CID token
-				}
-			}
+			qrPNG = png
+			qrFilename = fmt.Sprintf("qr_%s.png", strings.ReplaceAll(resp.ID, "-", "")) // no dashes
 		}
 	}
 
 	// prepare data for template
 	data := struct {
-		EventName   string
-		Venue       string
-		StartTime   string
-		SeatNumbers []string
-		SeatsCount  int
-		BookingID   string
-		BookedOn    string
-		BookingURL  string
-		QRFilename  string // used in cid:...
+		EventName    string
+		Venue        string
+		Description  string
+		ImageURL     string // html/template drops it if it is not a safe URL
+		StartTime    string
+		SeatNumbers  []string
+		SeatsCount   int
+		BookingID    string
+		BookedOn     string
+		BookingURL   string
+		QRFilename   string // used in cid:...
+		SupportEmail string
+		Price        *money.Bill // nil for free bookings
 	}{
-		EventName:   eventName,
-		Venue:       venue,
-		StartTime:   startStr,
-		SeatNumbers: resp.SeatNumbers,
-		SeatsCount:  len(resp.SeatNumbers),
-		BookingID:   resp.ID,
-		BookedOn:    resp.CreatedAt.Format("Mon, 02 Jan 2006 15:04 MST"),
-		BookingURL:  fmt.Sprintf("%s/bookings/%s", AppURL, resp.ID),
-		QRFilename:  qrFilename,
+		EventName:    eventName,
+		Venue:        venue,
+		Description:  description,
+		ImageURL:     event.ImageUrl.String,
+		StartTime:    startStr,
+		SeatNumbers:  resp.SeatNumbers,
+		SeatsCount:   len(resp.SeatNumbers),
+		BookingID:    resp.ID,
+		BookedOn:     resp.CreatedAt.Format("Mon, 02 Jan 2006 15:04 MST"),
+		BookingURL:   fmt.Sprintf("%s/bookings/%s", mailer.Branding.AppURL, resp.ID),
+		QRFilename:   qrFilename,
+		SupportEmail: mailer.Branding.SupportEmail,
+		Price:        resp.Bill(mailer.Branding),
 	}
 
 	t, err := template.New("confirmation").Parse(tpl)
 	if err != nil {
-		// cleanup temp file if created
-		if tempPath != "" {
-			_ = os.Remove(tempPath)
-		}
 		return fmt.Errorf("failed to parse template: %w", err)
 	}
 	var buf bytes.Buffer
 	if err := t.Execute(&buf, data); err != nil {
-		if tempPath != "" {
-			_ = os.Remove(tempPath)
-		}
 		return fmt.Errorf("failed to execute template: %w", err)
 	}
 	htmlBody := buf.String()
 
 	// Build message with gomail directly so we can Embed
 	msg := gomail.NewMessage()
-	from := "Overbookr <noreply@overbookr.com>"
+	from, replyTo := mailer.Branding.SenderFor(event)
 	msg.SetHeader("From", from)
+	if replyTo != "" {
+		msg.SetHeader("Reply-To", replyTo)
+	}
 	msg.SetHeader("To", toEmail)
 	msg.SetHeader("Subject", subject)
 	msg.SetBody("text/html", htmlBody)
 
-	// If we have a tempPath, embed it. gomail's Embed uses the file content and sets a content-id.
-	if tempPath != "" {
-		// Use Embed which sets Content-ID based on filename; our template uses cid:<basename>
-		msg.Embed(tempPath)
+	// Embed the QR straight from memory. gomail sets Content-ID to <name>, which
+	// is what the template's cid:{{ .QRFilename }} points at.
+	if qrPNG != nil {
+		embedBytes(msg, qrFilename, qrPNG)
 	}
 
-	// send using mailer config
-	d := gomail.NewDialer(mailer.Host, mailer.Port, mailer.Username, mailer.Password)
-	if mailer.InsecureSkipVerify {
-		d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
-	}
-	if err := d.DialAndSend(msg); err != nil {
-		if tempPath != "" {
-			_ = os.Remove(tempPath)
+	// Calendar invite so the event can be added in one click
+	attachICS(msg, BuildBookingICS(resp, event, data.BookingURL, time.Now()))
+
+	// send using the mailer's transport
+	if err := mailer.Transport.Send(ctx, msg); err != nil {
+		if errors.Is(err, ErrCircuitOpen) {
+			return err
 		}
 		// try plain fallback as before
-		plain := buildPlainTextConfirmationWithEvent(resp, eventName, venue, time.Time{}, AppURL)
-		_ = mailer.Send(from, []string{toEmail}, subject, plain, false)
-		return fmt.Errorf("failed to send confirmation email: %w", err)
-	}
-
-	// cleanup the temp file (safe to remove after send)
-	if tempPath != "" {
-		_ = os.Remove(tempPath)
+		plain := gomail.NewMessage()
+		plain.SetHeader("From", from)
+		if replyTo != "" {
+			plain.SetHeader("Reply-To", replyTo)
+		}
+		plain.SetHeader("To", toEmail)
+		plain.SetHeader("Subject", subject)
+		plain.SetBody("text/plain", buildPlainTextConfirmationWithEvent(resp, eventName, venue, description, event.StartTime.Time, data.Price, mailer.Branding))
+		// The fallback delivered the confirmation, so the job must not be
+		// retried: that would send the user a second copy.
+		if perr := mailer.Transport.Send(ctx, plain); perr != nil {
+			return fmt.Errorf("failed to send confirmation email: %w (plain-text fallback: %v)", err, perr)
+		}
+		log.Printf("confirmation for booking %s sent as plain text after the html send failed: %v", resp.ID, err)
 	}
 
 	return nil
 }
 
+// TicketQR renders the booking's ticket QR code as a PNG. The QR carries a
+// signed token rather than the bare booking id, so it can be checked at the
+// gate with POST /tickets/verify.
+func TicketQR(resp CreateBookingResponse, issuedAt time.Time) ([]byte, error) {
+	token, err := tickets.Sign(resp.ID, resp.EventID, resp.UserID, resp.TokenVersion, issuedAt)
+	if err != nil {
+		return nil, fmt.Errorf("sign ticket: %w", err)
+	}
+	png, err := qrcode.Encode(token, qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("encode qr: %w", err)
+	}
+	return png, nil
+}
+
 // helper that builds a small plain-text version of the confirmation (for fallback)
-func buildPlainTextConfirmationWithEvent(resp CreateBookingResponse, eventName, venue string, start time.Time, appURL string) string {
+func buildPlainTextConfirmationWithEvent(resp CreateBookingResponse, eventName, venue, description string, start time.Time, price *money.Bill, branding Branding) string {
 	seats := "none"
 	if len(resp.SeatNumbers) > 0 {
 		seats = strings.Join(resp.SeatNumbers, ", ")
@@ -254,15 +329,181 @@ func buildPlainTextConfirmationWithEvent(resp CreateBookingResponse, eventName,
 	if !start.IsZero() {
 		startStr = start.Format("Mon, 02 Jan 2006 15:04 MST")
 	}
+	if description != "" {
+		description = "\n" + description + "\n"
+	}
+	priceStr := ""
+	if price != nil {
+		var b strings.Builder
+		b.WriteString("\nPrice:\n")
+		for _, l := range price.Lines {
+			fmt.Fprintf(&b, "  Seat %s: %s\n", l.SeatNo, l.Amount)
+		}
+		fmt.Fprintf(&b, "Total: %s\n", price.Total)
+		priceStr = b.String()
+	}
 	return fmt.Sprintf(
-		"Booking confirmed!\n\nEvent: %s\nVenue: %s\nStarts: %s\n\nBooking ID: %s\nSeats: %s\nBooked on: %s\n\nView your booking: %s/bookings/%s\n\nThanks — OverBookr",
+		"Booking confirmed!\n\nEvent: %s\nVenue: %s\nStarts: %s\n%s\nBooking ID: %s\nSeats: %s\n%sBooked on: %s\n\nView your booking: %s/bookings/%s\nQuestions? %s\n\nThanks — OverBookr",
 		eventName,
 		venue,
 		startStr,
+		description,
 		resp.ID,
 		seats,
+		priceStr,
 		resp.CreatedAt.Format("Mon, 02 Jan 2006 15:04 MST"),
-		appURL,
+		branding.AppURL,
 		resp.ID,
+		branding.SupportEmail,
+	)
+}
+
+// embedBytes embeds data inline under name without writing it to disk.
+func embedBytes(msg *gomail.Message, name string, data []byte) {
+	msg.Embed(name, gomail.SetCopyFunc(func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}))
+}
+
+// SendTransferNotice tells the previous holder that their booking now belongs
+// to someone else and that their old ticket QR no longer works.
+func SendTransferNotice(ctx context.Context, mailer *Mailer, bookingID string, event db.Event, toEmail, newHolderEmail string) error {
+	if mailer == nil {
+		return fmt.Errorf("mailer is nil")
+	}
+	if toEmail == "" {
+		return fmt.Errorf("recipient email is empty")
+	}
+
+	eventName := strings.TrimSpace(event.Name)
+	subject := fmt.Sprintf("Your booking for %s was transferred", eventName)
+	body := fmt.Sprintf(
+		"Your booking %s for %s has been transferred to %s.\n\nThe ticket QR code you received earlier is no longer valid.\n\nDidn't do this? Contact %s.\n\nThanks — OverBookr",
+		bookingID,
+		eventName,
+		newHolderEmail,
+		mailer.Branding.SupportEmail,
+	)
+	return mailer.Send(ctx, mailer.Branding.From, []string{toEmail}, subject, body, false)
+}
+
+// SendCancellationMail tells a booking holder that their booking was
+// cancelled by the organizer.
+func SendCancellationMail(ctx context.Context, mailer *Mailer, bookingID string, event db.Event, seatNos []string, toEmail string) error {
+	if mailer == nil {
+		return fmt.Errorf("mailer is nil")
+	}
+	if toEmail == "" {
+		return fmt.Errorf("recipient email is empty")
+	}
+
+	eventName := strings.TrimSpace(event.Name)
+	subject := fmt.Sprintf("Your booking for %s was cancelled", eventName)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "We're sorry — your booking for %s has been cancelled by the organizer.\n\n", eventName)
+	if event.StartTime.Valid {
+		fmt.Fprintf(&b, "Was scheduled for: %s\n", event.StartTime.Time.Format("Mon, 02 Jan 2006 15:04 MST"))
+	}
+	fmt.Fprintf(&b, "Seats: %s\n", strings.Join(seatNos, ", "))
+	fmt.Fprintf(&b, "Booking: %s\n\n", bookingID)
+	fmt.Fprintf(&b, "Your ticket is no longer valid. For refunds or questions, contact %s.\n\nThanks — OverBookr", mailer.Branding.SupportEmail)
+
+	return mailer.Send(ctx, mailer.Branding.From, []string{toEmail}, subject, b.String(), false)
+}
+
+// SendPromotionMail tells a waitlisted user they were booked automatically.
+func SendPromotionMail(ctx context.Context, mailer *Mailer, bookingID string, event db.Event, seatNos []string, toEmail string) error {
+	if mailer == nil {
+		return fmt.Errorf("mailer is nil")
+	}
+	if toEmail == "" {
+		return fmt.Errorf("recipient email is empty")
+	}
+
+	eventName := strings.TrimSpace(event.Name)
+	subject := fmt.Sprintf("You're in: seats for %s", eventName)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Good news — seats opened up for %s and you've been booked off the waitlist.\n\n", eventName)
+	if event.Venue.Valid && event.Venue.String != "" {
+		fmt.Fprintf(&b, "Venue: %s\n", event.Venue.String)
+	}
+	if event.StartTime.Valid {
+		fmt.Fprintf(&b, "When: %s\n", event.StartTime.Time.Format("Mon, 02 Jan 2006 15:04 MST"))
+	}
+	fmt.Fprintf(&b, "Seats: %s\n", strings.Join(seatNos, ", "))
+	fmt.Fprintf(&b, "Booking: %s\n\n", bookingID)
+	fmt.Fprintf(&b, "View your booking: %s/bookings/%s\n\n", mailer.Branding.AppURL, bookingID)
+	fmt.Fprintf(&b, "Can't make it? Cancel from your bookings so the seats go to the next person, or contact %s.\n\nThanks — OverBookr", mailer.Branding.SupportEmail)
+
+	return mailer.Send(ctx, mailer.Branding.From, []string{toEmail}, subject, b.String(), false)
+}
+
+// SendReminderMail reminds a booking holder that their event is coming up.
+func SendReminderMail(ctx context.Context, mailer *Mailer, bookingID string, event db.Event, seatNos []string, toEmail string) error {
+	if mailer == nil {
+		return fmt.Errorf("mailer is nil")
+	}
+	if toEmail == "" {
+		return fmt.Errorf("recipient email is empty")
+	}
+
+	eventName := strings.TrimSpace(event.Name)
+	subject := fmt.Sprintf("Reminder: %s is coming up", eventName)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Just a reminder that %s is coming up soon.\n\n", eventName)
+	if event.Venue.Valid && event.Venue.String != "" {
+		fmt.Fprintf(&b, "Venue: %s\n", event.Venue.String)
+	}
+	if event.StartTime.Valid {
+		fmt.Fprintf(&b, "When: %s\n", event.StartTime.Time.Format("Mon, 02 Jan 2006 15:04 MST"))
+	}
+	fmt.Fprintf(&b, "Seats: %s\n", strings.Join(seatNos, ", "))
+	fmt.Fprintf(&b, "Booking: %s\n\n", bookingID)
+	fmt.Fprintf(&b, "Your ticket: %s/bookings/%s\n\n", mailer.Branding.AppURL, bookingID)
+	fmt.Fprintf(&b, "Can't make it? Cancel from your bookings so the seats go to someone on the waitlist, or contact %s.\n\nThanks — OverBookr", mailer.Branding.SupportEmail)
+
+	return mailer.Send(ctx, mailer.Branding.From, []string{toEmail}, subject, b.String(), false)
+}
+
+// SendVerificationMail asks a new user to confirm their address by opening link.
+func SendVerificationMail(ctx context.Context, mailer *Mailer, name, toEmail, link string) error {
+	if mailer == nil {
+		return fmt.Errorf("mailer is nil")
+	}
+	if toEmail == "" {
+		return fmt.Errorf("recipient email is empty")
+	}
+
+	subject := "Confirm your email address"
+	body := fmt.Sprintf(
+		"Hi %s,\n\nPlease confirm your email address to start booking:\n\n%s\n\nIf you didn't create an account, you can ignore this email or contact %s.\n\nThanks — OverBookr",
+		strings.TrimSpace(name),
+		link,
+		mailer.Branding.SupportEmail,
+	)
+	return mailer.Send(ctx, mailer.Branding.From, []string{toEmail}, subject, body, false)
+}
+
+// SendInviteMail invites someone to finish setting up the account an
+// organizer created for them by opening link before expiresAt.
+func SendInviteMail(ctx context.Context, mailer *Mailer, toEmail, link string, expiresAt time.Time) error {
+	if mailer == nil {
+		return fmt.Errorf("mailer is nil")
+	}
+	if toEmail == "" {
+		return fmt.Errorf("recipient email is empty")
+	}
+
+	subject := "You're invited to OverBookr"
+	body := fmt.Sprintf(
+		"Hi,\n\nAn account has been set up for you. Choose a password to activate it:\n\n%s\n\nThis link expires on %s.\n\nIf you weren't expecting this, you can ignore this email or contact %s.\n\nThanks — OverBookr",
+		link,
+		expiresAt.Format("Mon, 02 Jan 2006 15:04 MST"),
+		mailer.Branding.SupportEmail,
 	)
+	return mailer.Send(ctx, mailer.Branding.From, []string{toEmail}, subject, body, false)
 }
@@ -0,0 +1,76 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// recordingTransport keeps every message handed to it, rendered.
+type recordingTransport struct {
+	sent []string
+}
+
+func (r *recordingTransport) Send(ctx context.Context, msg *gomail.Message) error {
+	var buf bytes.Buffer
+	if _, err := msg.WriteTo(&buf); err != nil {
+		return err
+	}
+	r.sent = append(r.sent, buf.String())
+	return nil
+}
+
+func TestSendConfirmationMailEmbedsQRFromMemory(t *testing.T) {
+	t.Setenv("TICKET_SECRET", "test-ticket-secret")
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	transport := &recordingTransport{}
+	mailer := NewMailer(transport)
+	resp := CreateBookingResponse{
+		ID:          "8c1f6a2e-0b7d-4d7a-9a53-2f3c1c0e4b11",
+		EventID:     "5d0e3c52-6b8e-4f55-9a53-0c3b7d2f1e22",
+		UserID:      "1a2b3c4d-0000-4000-8000-000000000001",
+		SeatNumbers: []string{"A1"},
+		CreatedAt:   time.Now(),
+	}
+	event := db.Event{
+		Name:      "Show",
+		StartTime: pgtype.Timestamptz{Time: time.Now().Add(24 * time.Hour), Valid: true},
+	}
+
+	if err := SendConfirmationMail(context.Background(), mailer, resp, event, "holder@example.com", true); err != nil {
+		t.Fatalf("SendConfirmationMail: %v", err)
+	}
+	if len(transport.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(transport.sent))
+	}
+	raw := transport.sent[0]
+
+	const cid = "qr_8c1f6a2e0b7d4d7a9a532f3c1c0e4b11.png"
+	if !strings.Contains(raw, "Content-ID: <"+cid+">") {
+		t.Errorf("no embedded part with Content-ID <%s>", cid)
+	}
+	// The HTML is quoted-printable, which may wrap the reference.
+	if !strings.Contains(strings.ReplaceAll(raw, "=\r\n", ""), "cid:"+cid) {
+		t.Errorf("template does not reference cid:%s", cid)
+	}
+	if !strings.Contains(raw, "Content-Type: image/png") {
+		t.Error("embedded QR is not image/png")
+	}
+
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("temp files were created: %v", entries)
+	}
+}
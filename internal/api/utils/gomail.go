@@ -1,34 +1,165 @@
 package mail
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
+	netmail "net/mail"
+	"net/url"
+	"os"
+	"strings"
 
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/money"
 	gomail "gopkg.in/gomail.v2"
 )
 
-// Mailer holds SMTP dialer configuration.
+const (
+	defaultAppURL       = "https://app.overbookr.com"
+	defaultMailFrom     = "Overbookr <noreply@overbookr.com>"
+	defaultSupportEmail = "support@overbookr.com"
+)
+
+// Branding holds the per-deployment values that end up in outgoing mail.
+type Branding struct {
+	AppURL       string // base URL for links, without a trailing slash
+	From         string
+	SupportEmail string
+	// VerifyEmailURL and AcceptInviteURL are the pages that email
+	// verification and invite links open, with the token added as the
+	// "token" query parameter. They default to /verify-email and
+	// /accept-invite under AppURL.
+	VerifyEmailURL  string
+	AcceptInviteURL string
+	// BaseCurrency prices events without a currency; PriceLocale formats
+	// prices.
+	BaseCurrency string
+	PriceLocale  money.Locale
+}
+
+// DefaultBranding returns the values used by the hosted overbookr instance.
+func DefaultBranding() Branding {
+	return Branding{
+		AppURL:          defaultAppURL,
+		From:            defaultMailFrom,
+		SupportEmail:    defaultSupportEmail,
+		VerifyEmailURL:  defaultAppURL + "/verify-email",
+		AcceptInviteURL: defaultAppURL + "/accept-invite",
+		BaseCurrency:    money.DefaultBaseCurrency,
+		PriceLocale:     money.DefaultLocale,
+	}
+}
+
+// TokenLink returns page with token added as its "token" query parameter.
+func TokenLink(page, token string) string {
+	u, err := url.Parse(page)
+	if err != nil {
+		return page + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// SenderFor returns the From and Reply-To for mail about event. The event's
+// sender_name replaces the display name of From, keeping the deployment's
+// address so SPF and DKIM still line up; replyTo is empty unless the event
+// sets one.
+func (b Branding) SenderFor(event db.Event) (from, replyTo string) {
+	from = b.From
+	if event.SenderName.Valid && event.SenderName.String != "" {
+		if addr, err := netmail.ParseAddress(b.From); err == nil {
+			from = (&netmail.Address{Name: event.SenderName.String, Address: addr.Address}).String()
+		}
+	}
+	if event.ReplyTo.Valid {
+		replyTo = event.ReplyTo.String
+	}
+	return from, replyTo
+}
+
+// BrandingFromEnv reads APP_URL, MAIL_FROM, SUPPORT_EMAIL, EMAIL_VERIFY_URL,
+// INVITE_ACCEPT_URL, BASE_CURRENCY and PRICE_LOCALE, falling back to
+// DefaultBranding. The URLs must be absolute http(s) URLs.
+func BrandingFromEnv() (Branding, error) {
+	b := DefaultBranding()
+	var err error
+	if v := strings.TrimSpace(os.Getenv("APP_URL")); v != "" {
+		if err := checkHTTPURL("APP_URL", v); err != nil {
+			return Branding{}, err
+		}
+		b.AppURL = strings.TrimRight(v, "/")
+	}
+	if b.VerifyEmailURL, err = pageURLFromEnv("EMAIL_VERIFY_URL", b.AppURL+"/verify-email"); err != nil {
+		return Branding{}, err
+	}
+	if b.AcceptInviteURL, err = pageURLFromEnv("INVITE_ACCEPT_URL", b.AppURL+"/accept-invite"); err != nil {
+		return Branding{}, err
+	}
+	if v := strings.TrimSpace(os.Getenv("MAIL_FROM")); v != "" {
+		b.From = v
+	}
+	if v := strings.TrimSpace(os.Getenv("SUPPORT_EMAIL")); v != "" {
+		b.SupportEmail = v
+	}
+	if b.BaseCurrency, err = money.BaseCurrencyFromEnv(); err != nil {
+		return Branding{}, err
+	}
+	if b.PriceLocale, err = money.LocaleFromEnv(); err != nil {
+		return Branding{}, err
+	}
+	return b, nil
+}
+
+// pageURLFromEnv reads an absolute http(s) URL from name, or returns def when
+// it is unset.
+func pageURLFromEnv(name, def string) (string, error) {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return def, nil
+	}
+	if err := checkHTTPURL(name, v); err != nil {
+		return "", err
+	}
+	return v, nil
+}
+
+func checkHTTPURL(name, v string) error {
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid %s %q: want an absolute http(s) URL", name, v)
+	}
+	return nil
+}
+
+// Mailer builds messages and hands them to a Transport.
 type Mailer struct {
-	Host     string
-	Port     int
-	Username string
-	Password string
+	Transport Transport
+	Branding  Branding
+}
 
-	// Optional: if true, Skip TLS verification (useful for self-signed dev SMTP).
-	InsecureSkipVerify bool
+// NewMailer creates a Mailer that delivers through t with the default branding.
+func NewMailer(t Transport) *Mailer {
+	return &Mailer{Transport: t, Branding: DefaultBranding()}
 }
 
-// NewMailer creates a configured Mailer.
-func NewMailer(host string, port int, username, password string) *Mailer {
-	return &Mailer{
-		Host:     host,
-		Port:     port,
-		Username: username,
-		Password: password,
+// NewMailerFromEnv creates a Mailer using the transport selected by MAIL_TRANSPORT
+// and the branding from APP_URL, MAIL_FROM and SUPPORT_EMAIL.
+func NewMailerFromEnv() (*Mailer, error) {
+	t, err := NewTransportFromEnv()
+	if err != nil {
+		return nil, err
 	}
+	b, err := BrandingFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	m := NewMailer(t)
+	m.Branding = b
+	return m, nil
 }
 
-func (m *Mailer) Send(from string, to []string, subject, body string, isHTML bool) error {
+func (m *Mailer) Send(ctx context.Context, from string, to []string, subject, body string, isHTML bool) error {
 	if len(to) == 0 {
 		return fmt.Errorf("no recipients provided")
 	}
@@ -44,16 +175,5 @@ func (m *Mailer) Send(from string, to []string, subject, body string, isHTML boo
 		msg.SetBody("text/plain", body)
 	}
 
-	d := gomail.NewDialer(m.Host, m.Port, m.Username, m.Password)
-
-	// Optional TLS config for self-signed certs / local servers.
-	if m.InsecureSkipVerify {
-		d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
-	}
-
-	// Send
-	if err := d.DialAndSend(msg); err != nil {
-		return fmt.Errorf("failed to send mail: %w", err)
-	}
-	return nil
+	return m.Transport.Send(ctx, msg)
 }
@@ -0,0 +1,112 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// defaultEventDuration is used for DTEND since events only store a start time.
+const defaultEventDuration = 2 * time.Hour
+
+const icsTimeFormat = "20060102T150405Z"
+
+// BuildBookingICS renders an RFC 5545 calendar with a single VEVENT for the
+// booking. The UID is derived from the booking id so re-sent invites update
+// the existing calendar entry instead of adding a second one.
+func BuildBookingICS(resp CreateBookingResponse, event db.Event, bookingURL string, now time.Time) []byte {
+	start := event.StartTime.Time.UTC()
+	end := start.Add(defaultEventDuration)
+
+	desc := fmt.Sprintf("Booking %s", resp.ID)
+	if len(resp.SeatNumbers) > 0 {
+		desc += fmt.Sprintf("\nSeats: %s", strings.Join(resp.SeatNumbers, ", "))
+	}
+	if bookingURL != "" {
+		desc += "\n" + bookingURL
+	}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Overbookr//Booking//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:" + resp.ID + "@overbookr",
+		"DTSTAMP:" + now.UTC().Format(icsTimeFormat),
+		"DTSTART:" + start.Format(icsTimeFormat),
+		"DTEND:" + end.Format(icsTimeFormat),
+		"SUMMARY:" + icsEscape(strings.TrimSpace(event.Name)),
+	}
+	if event.Venue.Valid && event.Venue.String != "" {
+		lines = append(lines, "LOCATION:"+icsEscape(event.Venue.String))
+	}
+	lines = append(lines,
+		"DESCRIPTION:"+icsEscape(desc),
+		"STATUS:CONFIRMED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(icsFold(l))
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
+
+// attachICS adds the calendar invite to msg without touching the filesystem.
+func attachICS(msg *gomail.Message, ics []byte) {
+	msg.Attach("invite.ics",
+		gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(ics)
+			return err
+		}),
+		gomail.SetHeader(map[string][]string{
+			"Content-Type": {`text/calendar; charset="utf-8"; method=PUBLISH`},
+		}),
+	)
+}
+
+// icsEscape escapes TEXT values per RFC 5545 section 3.3.11.
+func icsEscape(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return r.Replace(s)
+}
+
+// icsFold splits content lines longer than 75 octets, continuing each with a
+// single leading space (RFC 5545 section 3.1). It avoids splitting a UTF-8
+// sequence.
+func icsFold(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+	var b strings.Builder
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines lose one octet to the leading space
+		width = limit - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// parseICS unfolds an RFC 5545 calendar and returns its properties by name.
+// Repeated properties keep the last value.
+func parseICS(t *testing.T, ics []byte) map[string]string {
+	t.Helper()
+	raw := string(ics)
+	if !strings.HasSuffix(raw, "\r\n") {
+		t.Fatal("calendar does not end with CRLF")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(raw, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+	unfolded := strings.ReplaceAll(raw, "\r\n ", "")
+	props := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSuffix(unfolded, "\r\n"), "\r\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			t.Fatalf("malformed content line %q", line)
+		}
+		props[name] = value
+	}
+	return props
+}
+
+func TestBuildBookingICS(t *testing.T) {
+	berlin := time.FixedZone("CEST", 2*60*60)
+	event := db.Event{
+		Name:      "Jazz, Blues; and More",
+		Venue:     pgtype.Text{String: "Hall 1, Berlin", Valid: true},
+		StartTime: pgtype.Timestamptz{Time: time.Date(2026, 7, 1, 20, 30, 0, 0, berlin), Valid: true},
+	}
+	resp := CreateBookingResponse{
+		ID:          "8c1f6a2e-0b7d-4d7a-9a53-2f3c1c0e4b11",
+		SeatNumbers: []string{"A1", "A2"},
+	}
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	ics := BuildBookingICS(resp, event, "https://app.example.com/bookings/"+resp.ID, now)
+	if !strings.HasPrefix(string(ics), "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(string(ics), "END:VEVENT\r\nEND:VCALENDAR\r\n") {
+		t.Fatalf("calendar is not one VCALENDAR wrapping a VEVENT:\n%s", ics)
+	}
+	props := parseICS(t, ics)
+
+	want := map[string]string{
+		"VERSION":  "2.0",
+		"UID":      resp.ID + "@overbookr",
+		"DTSTAMP":  "20260601T090000Z",
+		"DTSTART":  "20260701T183000Z",
+		"DTEND":    "20260701T203000Z",
+		"SUMMARY":  `Jazz\, Blues\; and More`,
+		"LOCATION": `Hall 1\, Berlin`,
+	}
+	for name, v := range want {
+		if props[name] != v {
+			t.Errorf("%s = %q, want %q", name, props[name], v)
+		}
+	}
+	if desc := props["DESCRIPTION"]; !strings.Contains(desc, `Seats: A1\, A2`) || !strings.Contains(desc, resp.ID) {
+		t.Errorf("DESCRIPTION = %q, want the booking id and seats", desc)
+	}
+}
+
+func TestBuildBookingICSUIDIsStablePerBooking(t *testing.T) {
+	event := db.Event{Name: "Show", StartTime: pgtype.Timestamptz{Time: time.Now(), Valid: true}}
+	resp := CreateBookingResponse{ID: "booking-1"}
+
+	first := parseICS(t, BuildBookingICS(resp, event, "", time.Now()))
+	resent := parseICS(t, BuildBookingICS(resp, event, "", time.Now().Add(time.Hour)))
+	if first["UID"] != resent["UID"] {
+		t.Errorf("UID changed between sends: %q vs %q", first["UID"], resent["UID"])
+	}
+	if _, ok := first["LOCATION"]; ok {
+		t.Error("LOCATION set for an event without a venue")
+	}
+}
+
+func TestICSFoldKeepsUTF8Sequences(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("é", 60)
+	folded := icsFold(line)
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > 75 {
+			t.Errorf("folded line longer than 75 octets: %d", len(part))
+		}
+	}
+	if got := strings.ReplaceAll(folded, "\r\n ", ""); got != line {
+		t.Errorf("unfolding changed the line: %q", got)
+	}
+}
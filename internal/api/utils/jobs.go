@@ -0,0 +1,276 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/auth"
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ConfirmationJob is the outbox payload for KindBookingConfirmation. It only
+// carries identifiers; the user and event are looked up at send time.
+type ConfirmationJob struct {
+	BookingID   string    `json:"booking_id"`
+	EventID     string    `json:"event_id"`
+	UserID      string    `json:"user_id"`
+	SeatNumbers []string  `json:"seat_numbers"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// lookupUserAndEvent loads the recipient and event a job refers to.
+func (q *Queue) lookupUserAndEvent(ctx context.Context, userID, eventID string) (db.GetUserByIDRow, db.Event, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return db.GetUserByIDRow{}, db.Event{}, fmt.Errorf("invalid user id: %w", err)
+	}
+	eid, err := uuid.Parse(eventID)
+	if err != nil {
+		return db.GetUserByIDRow{}, db.Event{}, fmt.Errorf("invalid event id: %w", err)
+	}
+
+	user, err := q.db.GetUserByID(ctx, pgtype.UUID{Bytes: uid, Valid: true})
+	if err != nil {
+		return db.GetUserByIDRow{}, db.Event{}, fmt.Errorf("get user: %w", err)
+	}
+	event, err := q.db.GetEventByID(ctx, pgtype.UUID{Bytes: eid, Valid: true})
+	if err != nil {
+		return db.GetUserByIDRow{}, db.Event{}, fmt.Errorf("get event: %w", err)
+	}
+	return user, event, nil
+}
+
+// lookupMailRecipient is lookupUserAndEvent for email jobs. A user without an
+// address fails the job before anything is rendered, so it is parked in the
+// outbox with the reason and retried rather than sent malformed.
+func (q *Queue) lookupMailRecipient(ctx context.Context, userID, eventID string) (db.GetUserByIDRow, db.Event, error) {
+	user, event, err := q.lookupUserAndEvent(ctx, userID, eventID)
+	if err != nil {
+		return user, event, err
+	}
+	if strings.TrimSpace(user.Email) == "" {
+		return user, event, fmt.Errorf("user %s has no email address", userID)
+	}
+	return user, event, nil
+}
+
+func (q *Queue) deliverConfirmation(ctx context.Context, payload []byte) error {
+	var j ConfirmationJob
+	if err := json.Unmarshal(payload, &j); err != nil {
+		return fmt.Errorf("decode confirmation job: %w", err)
+	}
+	user, event, err := q.lookupMailRecipient(ctx, j.UserID, j.EventID)
+	if err != nil {
+		return err
+	}
+
+	resp := CreateBookingResponse{
+		ID:          j.BookingID,
+		EventID:     j.EventID,
+		UserID:      j.UserID,
+		SeatNumbers: j.SeatNumbers,
+		CreatedAt:   j.CreatedAt,
+	}
+	// The QR is signed with the booking's current token version, so a
+	// confirmation queued before the ticket was regenerated still scans.
+	bid, err := uuid.Parse(j.BookingID)
+	if err != nil {
+		return fmt.Errorf("invalid booking id: %w", err)
+	}
+	b, err := q.db.GetBookingByID(ctx, pgtype.UUID{Bytes: bid, Valid: true})
+	if err != nil {
+		return fmt.Errorf("get booking: %w", err)
+	}
+	resp.TokenVersion = b.TokenVersion
+	resp.UnitPriceCents, resp.Currency = int64(b.UnitPriceCents), b.Currency.String
+	return SendConfirmationMail(ctx, q.mailer, resp, event, user.Email, true)
+}
+
+// CancellationJob is the outbox payload for KindBookingCancelled, sent when an
+// admin cancels a booking on the holder's behalf, e.g. because the event was
+// called off.
+type CancellationJob struct {
+	BookingID   string   `json:"booking_id"`
+	EventID     string   `json:"event_id"`
+	UserID      string   `json:"user_id"`
+	SeatNumbers []string `json:"seat_numbers"`
+}
+
+func (q *Queue) deliverCancellation(ctx context.Context, payload []byte) error {
+	var j CancellationJob
+	if err := json.Unmarshal(payload, &j); err != nil {
+		return fmt.Errorf("decode cancellation job: %w", err)
+	}
+	user, event, err := q.lookupMailRecipient(ctx, j.UserID, j.EventID)
+	if err != nil {
+		return err
+	}
+
+	return SendCancellationMail(ctx, q.mailer, j.BookingID, event, j.SeatNumbers, user.Email)
+}
+
+// TransferNoticeJob is the outbox payload for KindBookingTransferred, sent to
+// the previous holder after a booking changes hands.
+type TransferNoticeJob struct {
+	BookingID  string `json:"booking_id"`
+	EventID    string `json:"event_id"`
+	FromUserID string `json:"from_user_id"`
+	ToEmail    string `json:"to_email"`
+}
+
+func (q *Queue) deliverTransferNotice(ctx context.Context, payload []byte) error {
+	var j TransferNoticeJob
+	if err := json.Unmarshal(payload, &j); err != nil {
+		return fmt.Errorf("decode transfer notice job: %w", err)
+	}
+	user, event, err := q.lookupMailRecipient(ctx, j.FromUserID, j.EventID)
+	if err != nil {
+		return err
+	}
+
+	return SendTransferNotice(ctx, q.mailer, j.BookingID, event, user.Email, j.ToEmail)
+}
+
+// PromotionJob is the outbox payload for KindWaitlistPromoted, sent when a
+// waitlisted user is booked automatically.
+type PromotionJob struct {
+	BookingID   string   `json:"booking_id"`
+	EventID     string   `json:"event_id"`
+	UserID      string   `json:"user_id"`
+	SeatNumbers []string `json:"seat_numbers"`
+}
+
+func (q *Queue) deliverPromotion(ctx context.Context, payload []byte) error {
+	var j PromotionJob
+	if err := json.Unmarshal(payload, &j); err != nil {
+		return fmt.Errorf("decode promotion job: %w", err)
+	}
+	user, event, err := q.lookupMailRecipient(ctx, j.UserID, j.EventID)
+	if err != nil {
+		return err
+	}
+
+	return SendPromotionMail(ctx, q.mailer, j.BookingID, event, j.SeatNumbers, user.Email)
+}
+
+// ReminderJob is the outbox payload for KindEventReminder, sent to booking
+// holders shortly before their event starts.
+type ReminderJob struct {
+	BookingID   string   `json:"booking_id"`
+	EventID     string   `json:"event_id"`
+	UserID      string   `json:"user_id"`
+	SeatNumbers []string `json:"seat_numbers"`
+}
+
+func (q *Queue) deliverReminder(ctx context.Context, payload []byte) error {
+	var j ReminderJob
+	if err := json.Unmarshal(payload, &j); err != nil {
+		return fmt.Errorf("decode reminder job: %w", err)
+	}
+	user, event, err := q.lookupMailRecipient(ctx, j.UserID, j.EventID)
+	if err != nil {
+		return err
+	}
+
+	return SendReminderMail(ctx, q.mailer, j.BookingID, event, j.SeatNumbers, user.Email)
+}
+
+// VerificationJob is the outbox payload for KindEmailVerification. The token
+// is generated when the mail is sent, so the raw token is never stored; the
+// link stays valid until ExpiresAt.
+type VerificationJob struct {
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queue) deliverVerification(ctx context.Context, payload []byte) error {
+	var j VerificationJob
+	if err := json.Unmarshal(payload, &j); err != nil {
+		return fmt.Errorf("decode verification job: %w", err)
+	}
+	userID, err := uuid.Parse(j.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+
+	user, err := q.db.GetUserByID(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	if !j.ExpiresAt.After(time.Now()) {
+		log.Printf("verification mail for user %s expired before it was sent", j.UserID)
+		return nil
+	}
+
+	token, hash, err := auth.NewLinkToken()
+	if err != nil {
+		return fmt.Errorf("generate verification token: %w", err)
+	}
+	if err := q.db.ReplaceEmailVerificationToken(ctx, db.ReplaceEmailVerificationTokenParams{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: pgtype.Timestamptz{Time: j.ExpiresAt, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("store verification token: %w", err)
+	}
+
+	link := TokenLink(q.mailer.Branding.VerifyEmailURL, token)
+	return SendVerificationMail(ctx, q.mailer, user.Name, user.Email, link)
+}
+
+// InviteJob is the outbox payload for KindUserInvite. Like verification
+// links, the token is generated when the mail is sent and only its hash is
+// stored; sending it expires the user's earlier invites.
+type InviteJob struct {
+	UserID    string    `json:"user_id"`
+	InvitedBy string    `json:"invited_by,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queue) deliverInvite(ctx context.Context, payload []byte) error {
+	var j InviteJob
+	if err := json.Unmarshal(payload, &j); err != nil {
+		return fmt.Errorf("decode invite job: %w", err)
+	}
+	userID, err := uuid.Parse(j.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+
+	user, err := q.db.GetUserByID(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	if !j.ExpiresAt.After(time.Now()) {
+		log.Printf("invite for user %s expired before it was sent", j.UserID)
+		return nil
+	}
+	var invitedBy pgtype.UUID
+	if id, err := uuid.Parse(j.InvitedBy); err == nil {
+		invitedBy = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	token, hash, err := auth.NewLinkToken()
+	if err != nil {
+		return fmt.Errorf("generate invite token: %w", err)
+	}
+	if err := q.db.ReplaceUserInvite(ctx, db.ReplaceUserInviteParams{
+		UserID:    user.ID,
+		TokenHash: hash,
+		InvitedBy: invitedBy,
+		ExpiresAt: pgtype.Timestamptz{Time: j.ExpiresAt, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("store invite: %w", err)
+	}
+
+	link := TokenLink(q.mailer.Branding.AcceptInviteURL, token)
+	return SendInviteMail(ctx, q.mailer, user.Email, link, j.ExpiresAt)
+}
@@ -0,0 +1,129 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SMS counterparts of the notification kinds that have one. They share the
+// payload of their email kind.
+const (
+	KindBookingCancelledSMS    = "booking_cancelled_sms"
+	KindBookingConfirmationSMS = "booking_confirmation_sms"
+	KindWaitlistPromotedSMS    = "waitlist_promoted_sms"
+)
+
+var smsKinds = map[string]string{
+	KindBookingCancelled:    KindBookingCancelledSMS,
+	KindBookingConfirmation: KindBookingConfirmationSMS,
+	KindWaitlistPromoted:    KindWaitlistPromotedSMS,
+}
+
+// Notify is the single entry point for user-facing notifications. It always
+// queues the email for kind and, when an SMS provider is configured and the
+// kind has a text form, the SMS too. Whether the user has a phone number is
+// checked at send time. Each channel is retried on its own.
+func (q *Queue) Notify(kind string, payload any) error {
+	err := q.Enqueue(kind, payload)
+	if smsKind, ok := smsKinds[kind]; ok && q.sms != nil {
+		if serr := q.Enqueue(smsKind, payload); serr != nil {
+			err = errors.Join(err, serr)
+		}
+	}
+	return err
+}
+
+// NotifyTx is Notify for notices that must not be lost. Instead of queueing
+// in memory it writes the same jobs to the mail outbox through tx, so they
+// commit or roll back with the caller's transaction and are delivered at
+// least once by the outbox poller. Call Wake after committing so delivery
+// doesn't wait for the next poll.
+func (q *Queue) NotifyTx(ctx context.Context, tx *db.Queries, kind string, payload any) error {
+	kinds := []string{kind}
+	if smsKind, ok := smsKinds[kind]; ok && q.sms != nil {
+		kinds = append(kinds, smsKind)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s payload: %w", kind, err)
+	}
+	for _, k := range kinds {
+		if _, ok := q.handlers[k]; !ok {
+			return fmt.Errorf("unknown mail job kind %q", k)
+		}
+		if err := tx.InsertMailOutbox(ctx, db.InsertMailOutboxParams{
+			Kind:          k,
+			Payload:       body,
+			Status:        outboxStatusPending,
+			NextAttemptAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		}); err != nil {
+			return fmt.Errorf("write %s to outbox: %w", k, err)
+		}
+	}
+	return nil
+}
+
+func (q *Queue) deliverCancellationSMS(ctx context.Context, payload []byte) error {
+	var j CancellationJob
+	if err := json.Unmarshal(payload, &j); err != nil {
+		return fmt.Errorf("decode cancellation job: %w", err)
+	}
+	user, event, err := q.lookupUserAndEvent(ctx, j.UserID, j.EventID)
+	if err != nil {
+		return err
+	}
+	if !user.Phone.Valid || user.Phone.String == "" {
+		return nil
+	}
+
+	body := fmt.Sprintf("Overbookr: your booking %s for %s (seats %s) was cancelled by the organizer. Check your email for details.",
+		BookingRef(j.BookingID), strings.TrimSpace(event.Name), strings.Join(j.SeatNumbers, ", "))
+	return q.sms.SendSMS(ctx, user.Phone.String, body)
+}
+
+func (q *Queue) deliverConfirmationSMS(ctx context.Context, payload []byte) error {
+	var j ConfirmationJob
+	if err := json.Unmarshal(payload, &j); err != nil {
+		return fmt.Errorf("decode confirmation job: %w", err)
+	}
+	user, event, err := q.lookupUserAndEvent(ctx, j.UserID, j.EventID)
+	if err != nil {
+		return err
+	}
+	if !user.Phone.Valid || user.Phone.String == "" {
+		return nil
+	}
+
+	body := fmt.Sprintf("Overbookr: booking %s confirmed for %s, seats %s. %s",
+		BookingRef(j.BookingID), strings.TrimSpace(event.Name), strings.Join(j.SeatNumbers, ", "), q.bookingLink(j.BookingID))
+	return q.sms.SendSMS(ctx, user.Phone.String, body)
+}
+
+func (q *Queue) deliverPromotionSMS(ctx context.Context, payload []byte) error {
+	var j PromotionJob
+	if err := json.Unmarshal(payload, &j); err != nil {
+		return fmt.Errorf("decode promotion job: %w", err)
+	}
+	user, event, err := q.lookupUserAndEvent(ctx, j.UserID, j.EventID)
+	if err != nil {
+		return err
+	}
+	if !user.Phone.Valid || user.Phone.String == "" {
+		return nil
+	}
+
+	body := fmt.Sprintf("Overbookr: you're off the waitlist for %s! Seats %s, ref %s. %s",
+		strings.TrimSpace(event.Name), strings.Join(j.SeatNumbers, ", "), BookingRef(j.BookingID), q.bookingLink(j.BookingID))
+	return q.sms.SendSMS(ctx, user.Phone.String, body)
+}
+
+func (q *Queue) bookingLink(bookingID string) string {
+	return fmt.Sprintf("%s/bookings/%s", q.mailer.Branding.AppURL, bookingID)
+}
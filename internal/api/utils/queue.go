@@ -0,0 +1,435 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/tracing"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Job kinds understood by the queue. The kind is persisted with outbox rows,
+// so existing values must not be renamed.
+const (
+	KindBookingCancelled    = "booking_cancelled"
+	KindBookingConfirmation = "booking_confirmation"
+	KindBookingTransferred  = "booking_transferred"
+	KindEmailVerification   = "email_verification"
+	KindEventReminder       = "event_reminder"
+	KindUserInvite          = "user_invite"
+	KindWaitlistPromoted    = "waitlist_promoted"
+)
+
+const (
+	outboxStatusPending = "pending"
+	outboxStatusDead    = "dead"
+
+	// outboxLease is how long a claimed outbox row stays invisible to other
+	// pollers while this process works on it.
+	outboxLease = 10 * time.Minute
+
+	persistTimeout = 5 * time.Second
+)
+
+// JobHandler delivers a single job. Returning an error schedules a retry.
+type JobHandler func(ctx context.Context, payload []byte) error
+
+// QueueOptions tunes the in-process mail queue.
+type QueueOptions struct {
+	Workers    int
+	BufferSize int
+	// MaxAttempts is how many times a job is tried in memory before it is
+	// parked in the outbox.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	// OutboxPollInterval controls how often parked messages are picked up again.
+	OutboxPollInterval time.Duration
+	// MaxOutboxAttempts is the total number of attempts after which a message
+	// is marked dead and no longer retried.
+	MaxOutboxAttempts int32
+}
+
+func DefaultQueueOptions() QueueOptions {
+	return QueueOptions{
+		Workers:            4,
+		BufferSize:         256,
+		MaxAttempts:        3,
+		InitialBackoff:     2 * time.Second,
+		OutboxPollInterval: 1 * time.Minute,
+		MaxOutboxAttempts:  10,
+	}
+}
+
+// QueueStats is a point-in-time view of mail delivery.
+type QueueStats struct {
+	Queued        int64 `json:"queued"`
+	Sent          int64 `json:"sent"`
+	Retried       int64 `json:"retried"`
+	OutboxPending int64 `json:"outbox_pending"`
+	OutboxDead    int64 `json:"outbox_dead"`
+	// Parked counts jobs sent straight to the outbox because the mail
+	// circuit was open. Breaker is omitted when the transport has none.
+	Parked  int64         `json:"parked"`
+	Breaker *BreakerStats `json:"breaker,omitempty"`
+}
+
+type job struct {
+	kind     string
+	payload  []byte
+	attempts int32
+	// outboxID is set when the job was loaded from the outbox.
+	outboxID pgtype.UUID
+}
+
+// Queue sends mail from a bounded pool of workers. Jobs that keep failing,
+// or that are still queued at shutdown, are written to the mail_outbox table
+// and retried from there, so they survive restarts.
+type Queue struct {
+	mailer   *Mailer
+	sms      SMSSender
+	db       *db.Queries
+	opts     QueueOptions
+	jobs     chan job
+	handlers map[string]JobHandler
+	// wake asks the outbox poller to run before its next tick.
+	wake chan struct{}
+
+	queued  atomic.Int64
+	sent    atomic.Int64
+	retried atomic.Int64
+	parked  atomic.Int64
+
+	wg sync.WaitGroup
+}
+
+// NewQueue creates a queue delivering through mailer and, when sms is not nil,
+// text messages through sms.
+func NewQueue(pool *pgxpool.Pool, mailer *Mailer, sms SMSSender, opts QueueOptions) *Queue {
+	q := &Queue{
+		mailer: mailer,
+		sms:    sms,
+		db:     db.New(pool),
+		opts:   opts,
+		jobs:   make(chan job, opts.BufferSize),
+		wake:   make(chan struct{}, 1),
+	}
+	q.handlers = map[string]JobHandler{
+		KindBookingCancelled:    q.deliverCancellation,
+		KindBookingConfirmation: q.deliverConfirmation,
+		KindBookingTransferred:  q.deliverTransferNotice,
+		KindEmailVerification:   q.deliverVerification,
+		KindEventReminder:       q.deliverReminder,
+		KindUserInvite:          q.deliverInvite,
+		KindWaitlistPromoted:    q.deliverPromotion,
+	}
+	if sms != nil {
+		q.handlers[KindBookingCancelledSMS] = q.deliverCancellationSMS
+		q.handlers[KindBookingConfirmationSMS] = q.deliverConfirmationSMS
+		q.handlers[KindWaitlistPromotedSMS] = q.deliverPromotionSMS
+	}
+	return q
+}
+
+// Start launches the workers and the outbox poller. They run until ctx is
+// cancelled; call Stop afterwards to wait for them to flush.
+func (q *Queue) Start(ctx context.Context) {
+	for i := 0; i < q.opts.Workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+	q.wg.Add(1)
+	go q.pollOutbox(ctx)
+}
+
+// Stop waits for the workers to exit. Anything still queued is persisted to
+// the outbox before they return.
+func (q *Queue) Stop() {
+	q.wg.Wait()
+}
+
+// Enqueue schedules payload (JSON-encoded) for delivery by the handler
+// registered for kind. It never blocks: when the buffer is full the job goes
+// straight to the outbox.
+func (q *Queue) Enqueue(kind string, payload any) error {
+	if _, ok := q.handlers[kind]; !ok {
+		return fmt.Errorf("unknown mail job kind %q", kind)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s payload: %w", kind, err)
+	}
+
+	j := job{kind: kind, payload: body}
+	q.queued.Add(1)
+	select {
+	case q.jobs <- j:
+		return nil
+	default:
+		q.queued.Add(-1)
+		ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+		defer cancel()
+		return q.persist(ctx, j, errors.New("mail queue full"))
+	}
+}
+
+// Wake makes the outbox poller look for due rows now rather than at its next
+// tick. It never blocks.
+func (q *Queue) Wake() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stats reports in-memory counters together with the outbox backlog.
+func (q *Queue) Stats(ctx context.Context) (QueueStats, error) {
+	stats := QueueStats{
+		Queued:  q.queued.Load(),
+		Sent:    q.sent.Load(),
+		Retried: q.retried.Load(),
+		Parked:  q.parked.Load(),
+	}
+	if b := q.breaker(); b != nil {
+		bs := b.Stats()
+		stats.Breaker = &bs
+	}
+	rows, err := q.db.CountMailOutboxByStatus(ctx)
+	if err != nil {
+		return stats, err
+	}
+	for _, r := range rows {
+		switch r.Status {
+		case outboxStatusPending:
+			stats.OutboxPending = r.Cnt
+		case outboxStatusDead:
+			stats.OutboxDead = r.Cnt
+		}
+	}
+	return stats, nil
+}
+
+func (q *Queue) work(ctx context.Context) {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			q.drain()
+			return
+		case j := <-q.jobs:
+			q.process(ctx, j)
+			q.queued.Add(-1)
+		}
+	}
+}
+
+// drain moves whatever is left in the buffer to the outbox.
+func (q *Queue) drain() {
+	for {
+		select {
+		case j := <-q.jobs:
+			ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+			if err := q.persist(ctx, j, errors.New("shutdown before delivery")); err != nil {
+				log.Printf("mail queue: failed to persist %s on shutdown: %v", j.kind, err)
+			}
+			cancel()
+			q.queued.Add(-1)
+		default:
+			return
+		}
+	}
+}
+
+func (q *Queue) process(ctx context.Context, j job) {
+	handler := q.handlers[j.kind]
+	backoff := q.opts.InitialBackoff
+
+	ctx, span := tracing.Tracer().Start(ctx, "mail "+j.kind)
+	var err error
+	defer func() {
+		span.SetAttributes(attribute.Int("mail.attempts", int(j.attempts)))
+		tracing.End(span, err)
+	}()
+
+	for attempt := 1; ; attempt++ {
+		err = handler(ctx, j.payload)
+		if errors.Is(err, ErrCircuitOpen) {
+			// Nothing reached the mail server: park the job for the outbox
+			// poller without spending an attempt or waiting out a backoff.
+			q.parked.Add(1)
+			pctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+			if perr := q.persist(pctx, j, err); perr != nil {
+				log.Printf("mail queue: failed to park %s in outbox: %v", j.kind, perr)
+			}
+			cancel()
+			return
+		}
+		j.attempts++
+		if err == nil {
+			q.sent.Add(1)
+			if j.outboxID.Valid {
+				if derr := q.db.DeleteMailOutbox(context.Background(), j.outboxID); derr != nil {
+					log.Printf("mail queue: failed to clear outbox row: %v", derr)
+				}
+			}
+			return
+		}
+
+		log.Printf("mail queue: %s attempt %d failed: %v", j.kind, j.attempts, err)
+
+		if attempt >= q.opts.MaxAttempts || ctx.Err() != nil {
+			pctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+			if perr := q.persist(pctx, j, err); perr != nil {
+				log.Printf("mail queue: failed to persist %s to outbox: %v", j.kind, perr)
+			}
+			cancel()
+			return
+		}
+
+		q.retried.Add(1)
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// persist writes j to the outbox, rescheduling it with a backoff or marking
+// it dead once it has used up MaxOutboxAttempts.
+func (q *Queue) persist(ctx context.Context, j job, cause error) error {
+	lastErr := pgtype.Text{String: cause.Error(), Valid: true}
+
+	if j.attempts >= q.opts.MaxOutboxAttempts {
+		log.Printf("mail queue: giving up on %s after %d attempts: %v", j.kind, j.attempts, cause)
+		if j.outboxID.Valid {
+			return q.db.MarkMailOutboxDead(ctx, db.MarkMailOutboxDeadParams{
+				ID:        j.outboxID,
+				Attempts:  j.attempts,
+				LastError: lastErr,
+			})
+		}
+		return q.db.InsertMailOutbox(ctx, db.InsertMailOutboxParams{
+			Kind:          j.kind,
+			Payload:       j.payload,
+			Attempts:      j.attempts,
+			LastError:     lastErr,
+			Status:        outboxStatusDead,
+			NextAttemptAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		})
+	}
+
+	next := pgtype.Timestamptz{Time: time.Now().Add(q.outboxBackoff(j.attempts)), Valid: true}
+	if j.outboxID.Valid {
+		return q.db.RescheduleMailOutbox(ctx, db.RescheduleMailOutboxParams{
+			ID:            j.outboxID,
+			Attempts:      j.attempts,
+			LastError:     lastErr,
+			NextAttemptAt: next,
+		})
+	}
+	return q.db.InsertMailOutbox(ctx, db.InsertMailOutboxParams{
+		Kind:          j.kind,
+		Payload:       j.payload,
+		Attempts:      j.attempts,
+		LastError:     lastErr,
+		Status:        outboxStatusPending,
+		NextAttemptAt: next,
+	})
+}
+
+// breaker returns the mail transport's circuit breaker, if it has one.
+func (q *Queue) breaker() *BreakerTransport {
+	b, _ := q.mailer.Transport.(*BreakerTransport)
+	return b
+}
+
+// outboxBackoff grows with the number of attempts so far, capped at an hour.
+func (q *Queue) outboxBackoff(attempts int32) time.Duration {
+	d := q.opts.OutboxPollInterval
+	for i := int32(1); i < attempts && d < time.Hour; i++ {
+		d *= 2
+	}
+	if d > time.Hour {
+		d = time.Hour
+	}
+	return d
+}
+
+func (q *Queue) pollOutbox(ctx context.Context) {
+	defer q.wg.Done()
+
+	q.claimOutbox(ctx)
+
+	ticker := time.NewTicker(q.opts.OutboxPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.claimOutbox(ctx)
+		case <-q.wake:
+			q.claimOutbox(ctx)
+		}
+	}
+}
+
+// claimOutbox leases due outbox rows and feeds them to the workers. Rows that
+// do not fit in the buffer stay leased and come back once the lease expires.
+func (q *Queue) claimOutbox(ctx context.Context) {
+	// While the mail server is down every claimed row would just be parked
+	// again; leave them until the breaker lets a probe through, and then
+	// claim only the probe. The rest follow once it closes the circuit.
+	free := cap(q.jobs) - len(q.jobs)
+	if b := q.breaker(); b != nil {
+		if b.Open() {
+			return
+		}
+		if b.HalfOpen() {
+			free = min(free, 1)
+		}
+	}
+	if free <= 0 {
+		return
+	}
+
+	rows, err := q.db.ClaimDueMailOutbox(ctx, db.ClaimDueMailOutboxParams{
+		NextAttemptAt: pgtype.Timestamptz{Time: time.Now().Add(outboxLease), Valid: true},
+		Limit:         int32(free),
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("mail queue: failed to claim outbox rows: %v", err)
+		}
+		return
+	}
+
+	for _, r := range rows {
+		j := job{kind: r.Kind, payload: r.Payload, attempts: r.Attempts, outboxID: r.ID}
+		if _, ok := q.handlers[j.kind]; !ok {
+			log.Printf("mail queue: outbox row has unknown kind %q", j.kind)
+			_ = q.db.MarkMailOutboxDead(ctx, db.MarkMailOutboxDeadParams{
+				ID:        r.ID,
+				Attempts:  r.Attempts,
+				LastError: pgtype.Text{String: "unknown job kind", Valid: true},
+			})
+			continue
+		}
+		q.queued.Add(1)
+		select {
+		case q.jobs <- j:
+		default:
+			q.queued.Add(-1)
+			return
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// SMSSender delivers a short text message to a phone number in E.164 form.
+type SMSSender interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+const twilioAPIBase = "https://api.twilio.com/2010-04-01"
+
+// TwilioSMS sends messages through Twilio's Messages API. Any provider with
+// the same form-encoded, basic-auth API can be used by changing BaseURL.
+type TwilioSMS struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+	BaseURL    string
+	Client     *http.Client
+}
+
+func (t *TwilioSMS) SendSMS(ctx context.Context, to, body string) error {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", t.From)
+	form.Set("Body", body)
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", strings.TrimRight(t.BaseURL, "/"), url.PathEscape(t.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// ConsoleSMS logs each message instead of sending it. Useful for local development.
+type ConsoleSMS struct{}
+
+func (ConsoleSMS) SendSMS(ctx context.Context, to, body string) error {
+	log.Printf("[sms:console] to=%s body=%q", to, body)
+	return nil
+}
+
+// NewSMSSenderFromEnv picks a provider based on SMS_PROVIDER (none|console|twilio,
+// default none). It returns nil when SMS is disabled. Twilio uses
+// TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM_NUMBER.
+func NewSMSSenderFromEnv() (SMSSender, error) {
+	kind := strings.ToLower(strings.TrimSpace(os.Getenv("SMS_PROVIDER")))
+	switch kind {
+	case "", "none", "noop":
+		return nil, nil
+	case "console":
+		return ConsoleSMS{}, nil
+	case "twilio":
+		t := &TwilioSMS{
+			AccountSID: os.Getenv("TWILIO_ACCOUNT_SID"),
+			AuthToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
+			From:       os.Getenv("TWILIO_FROM_NUMBER"),
+			BaseURL:    twilioAPIBase,
+			Client:     &http.Client{Timeout: 10 * time.Second},
+		}
+		if t.AccountSID == "" || t.AuthToken == "" || t.From == "" {
+			return nil, fmt.Errorf("SMS_PROVIDER=twilio needs TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM_NUMBER")
+		}
+		return t, nil
+	default:
+		return nil, fmt.Errorf("unknown SMS_PROVIDER %q (want none, console or twilio)", kind)
+	}
+}
+
+// BookingRef is the short, human-friendly reference shown in texts.
+func BookingRef(bookingID string) string {
+	ref := strings.ReplaceAll(bookingID, "-", "")
+	if len(ref) > 8 {
+		ref = ref[:8]
+	}
+	return strings.ToUpper(ref)
+}
@@ -0,0 +1,95 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// BuildTicketPDF renders a printable A5 ticket for the booking: event details,
+// seats, booking reference and, when qrPNG is non-nil, the same QR code the
+// confirmation email carries.
+func BuildTicketPDF(resp CreateBookingResponse, event db.Event, qrPNG []byte) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A5", "")
+	pdf.SetTitle("Ticket "+resp.ID, true)
+	pdf.SetAuthor("Overbookr", true)
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 12)
+	pdf.AddPage()
+
+	// The core fonts are cp1252; translate so accented event names survive.
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageW, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	width := pageW - left - right
+
+	// Header band
+	pdf.SetFillColor(15, 59, 145)
+	pdf.Rect(0, 0, pageW, 28, "F")
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.SetXY(left, 8)
+	pdf.CellFormat(width, 8, tr(strings.TrimSpace(event.Name)), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetX(left)
+	pdf.CellFormat(width, 6, tr(event.Venue.String), "", 1, "L", false, 0, "")
+
+	pdf.SetTextColor(15, 23, 42)
+	pdf.SetY(36)
+
+	field := func(label, value string) {
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetTextColor(107, 114, 128)
+		pdf.SetX(left)
+		pdf.CellFormat(width, 5, label, "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.SetTextColor(15, 23, 42)
+		pdf.SetX(left)
+		pdf.MultiCell(width, 6, tr(value), "", "L", false)
+		pdf.Ln(2)
+	}
+
+	when := "TBD"
+	if event.StartTime.Valid {
+		when = event.StartTime.Time.Format("Mon, 02 Jan 2006 15:04 MST")
+	}
+	seats := "none"
+	if len(resp.SeatNumbers) > 0 {
+		seats = strings.Join(resp.SeatNumbers, ", ")
+	}
+
+	field("When", when)
+	if event.Venue.Valid && event.Venue.String != "" {
+		field("Where", event.Venue.String)
+	}
+	field(fmt.Sprintf("Seats (%d)", len(resp.SeatNumbers)), seats)
+	field("Booking reference", resp.ID)
+	field("Booked on", resp.CreatedAt.Format("Mon, 02 Jan 2006 15:04 MST"))
+
+	if qrPNG != nil {
+		const qrSize = 50.0
+		name := "qr_" + strings.ReplaceAll(resp.ID, "-", "")
+		opts := gofpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(qrPNG))
+		y := pdf.GetY() + 4
+		pdf.ImageOptions(name, (pageW-qrSize)/2, y, qrSize, qrSize, false, opts, 0, "")
+		pdf.SetY(y + qrSize + 2)
+	}
+
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetTextColor(156, 163, 175)
+	pdf.SetX(left)
+	pdf.CellFormat(width, 5, "Show this ticket at the gate.", "", 1, "C", false, 0, "")
+	pdf.SetX(left)
+	pdf.CellFormat(width, 5, "Generated "+time.Now().UTC().Format("02 Jan 2006 15:04 MST"), "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("render ticket pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
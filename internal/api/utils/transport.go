@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	gomail "gopkg.in/gomail.v2"
+)
+
+// Transport delivers a fully built message.
+type Transport interface {
+	Send(ctx context.Context, msg *gomail.Message) error
+}
+
+// SMTPTransport sends mail through an SMTP server.
+type SMTPTransport struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+
+	// Optional: if true, Skip TLS verification (useful for self-signed dev SMTP).
+	InsecureSkipVerify bool
+}
+
+func (t *SMTPTransport) Send(ctx context.Context, msg *gomail.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d := gomail.NewDialer(t.Host, t.Port, t.Username, t.Password)
+
+	// Optional TLS config for self-signed certs / local servers.
+	if t.InsecureSkipVerify {
+		d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	if err := d.DialAndSend(msg); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+// ConsoleTransport logs a summary of each message instead of sending it.
+// Useful for local development.
+type ConsoleTransport struct{}
+
+func (ConsoleTransport) Send(ctx context.Context, msg *gomail.Message) error {
+	log.Printf("[mail:console] from=%v to=%v subject=%q",
+		msg.GetHeader("From"), msg.GetHeader("To"), strings.Join(msg.GetHeader("Subject"), " "))
+	return nil
+}
+
+// NoopTransport silently drops every message. Useful for tests and CI.
+type NoopTransport struct{}
+
+func (NoopTransport) Send(ctx context.Context, msg *gomail.Message) error {
+	return nil
+}
+
+// NewTransportFromEnv picks a transport based on MAIL_TRANSPORT (smtp|console|noop, default smtp).
+// SMTP uses SMTP_HOST/SMTP_PORT (default smtp.gmail.com:587) with GMAIL_USER/GMAIL_PASS credentials,
+// behind a circuit breaker configured by BreakerOptionsFromEnv.
+func NewTransportFromEnv() (Transport, error) {
+	kind := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_TRANSPORT")))
+	switch kind {
+	case "", "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			host = "smtp.gmail.com"
+		}
+		port := 587
+		if v := os.Getenv("SMTP_PORT"); v != "" {
+			p, err := strconv.Atoi(v)
+			if err != nil || p <= 0 {
+				return nil, fmt.Errorf("invalid SMTP_PORT %q", v)
+			}
+			port = p
+		}
+		var t Transport = &SMTPTransport{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("GMAIL_USER"),
+			Password: os.Getenv("GMAIL_PASS"),
+		}
+		opts, err := BreakerOptionsFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if opts.Threshold > 0 {
+			t = NewBreakerTransport(t, opts)
+		}
+		return t, nil
+	case "console":
+		return ConsoleTransport{}, nil
+	case "noop", "none":
+		return NoopTransport{}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_TRANSPORT %q (want smtp, console or noop)", kind)
+	}
+}
@@ -0,0 +1,217 @@
+// Package auth mints the login tokens handed out by the users endpoints and
+// verifies them for AuthMiddleware, so both sides agree on lifetime, issuer
+// and audience.
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	DefaultTTL      = 72 * time.Hour
+	DefaultIssuer   = "overbookr"
+	DefaultAudience = "overbookr-api"
+)
+
+// Supported JWT_ALG values.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+var (
+	// ErrNoKey is returned when the key needed to sign or verify with the
+	// configured algorithm is missing.
+	ErrNoKey = errors.New("JWT signing key not configured")
+	// ErrExpired is returned for tokens whose exp has passed.
+	ErrExpired = errors.New("token expired")
+	// ErrInvalidToken is returned for tokens that fail signature or claim checks.
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Claims is the payload of a login token. Subject holds the user id.
+type Claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Config controls how login tokens are signed and checked.
+type Config struct {
+	// Algorithm is AlgHS256 (shared Secret) or AlgRS256 (PrivateKey signs,
+	// PublicKey verifies).
+	Algorithm  string
+	Secret     []byte
+	PrivateKey *rsa.PrivateKey
+	PublicKey  *rsa.PublicKey
+	TTL        time.Duration
+	Issuer     string
+	Audience   string
+}
+
+// ConfigFromEnv is LoadConfig for callers that can't fail; errors are logged
+// and the affected keys left unset, so signing or verifying reports ErrNoKey.
+// main calls LoadConfig at startup to refuse a bad configuration outright.
+func ConfigFromEnv() Config {
+	cfg, err := LoadConfig()
+	if err != nil {
+		log.Printf("auth config: %v", err)
+	}
+	return cfg
+}
+
+// LoadConfig reads JWT_ALG (HS256 by default, or RS256), the key material for
+// it, JWT_TTL (a Go duration, e.g. "12h"), JWT_ISSUER and JWT_AUDIENCE. HS256
+// uses JWT_SECRET. RS256 signs with the PEM key at JWT_PRIVATE_KEY_FILE and
+// verifies with JWT_PUBLIC_KEY_FILE, which defaults to the private key's
+// public half; a verify-only service sets just the public key.
+func LoadConfig() (Config, error) {
+	cfg := Config{
+		Algorithm: AlgHS256,
+		TTL:       DefaultTTL,
+		Issuer:    DefaultIssuer,
+		Audience:  DefaultAudience,
+	}
+	if raw := os.Getenv("JWT_TTL"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid JWT_TTL %q: want a positive duration", raw)
+		}
+		cfg.TTL = d
+	}
+	if v := os.Getenv("JWT_ISSUER"); v != "" {
+		cfg.Issuer = v
+	}
+	if v := os.Getenv("JWT_AUDIENCE"); v != "" {
+		cfg.Audience = v
+	}
+
+	if v := os.Getenv("JWT_ALG"); v != "" {
+		cfg.Algorithm = v
+	}
+	switch cfg.Algorithm {
+	case AlgHS256:
+		cfg.Secret = []byte(os.Getenv("JWT_SECRET"))
+	case AlgRS256:
+		if path := os.Getenv("JWT_PRIVATE_KEY_FILE"); path != "" {
+			key, err := loadRSAPrivateKey(path)
+			if err != nil {
+				return cfg, err
+			}
+			cfg.PrivateKey = key
+			cfg.PublicKey = &key.PublicKey
+		}
+		if path := os.Getenv("JWT_PUBLIC_KEY_FILE"); path != "" {
+			key, err := loadRSAPublicKey(path)
+			if err != nil {
+				return cfg, err
+			}
+			cfg.PublicKey = key
+		}
+		if cfg.PublicKey == nil {
+			return cfg, errors.New("JWT_ALG=RS256 needs JWT_PUBLIC_KEY_FILE or JWT_PRIVATE_KEY_FILE")
+		}
+	default:
+		return cfg, fmt.Errorf("unsupported JWT_ALG %q: want %s or %s", cfg.Algorithm, AlgHS256, AlgRS256)
+	}
+	return cfg, nil
+}
+
+// CanSign reports whether tokens can be minted with this config.
+func (c Config) CanSign() bool {
+	_, _, err := c.signingKey()
+	return err == nil
+}
+
+// CanVerify reports whether tokens can be checked with this config.
+func (c Config) CanVerify() bool {
+	_, err := c.verificationKey()
+	return err == nil
+}
+
+func (c Config) signingKey() (jwt.SigningMethod, interface{}, error) {
+	switch c.Algorithm {
+	case AlgHS256:
+		if len(c.Secret) > 0 {
+			return jwt.SigningMethodHS256, c.Secret, nil
+		}
+	case AlgRS256:
+		if c.PrivateKey != nil {
+			return jwt.SigningMethodRS256, c.PrivateKey, nil
+		}
+	}
+	return nil, nil, ErrNoKey
+}
+
+func (c Config) verificationKey() (interface{}, error) {
+	switch c.Algorithm {
+	case AlgHS256:
+		if len(c.Secret) > 0 {
+			return c.Secret, nil
+		}
+	case AlgRS256:
+		if c.PublicKey != nil {
+			return c.PublicKey, nil
+		}
+	}
+	return nil, ErrNoKey
+}
+
+// Mint returns a signed token for the user and the time it expires.
+func (c Config) Mint(userID, role string, now time.Time) (string, time.Time, error) {
+	method, key, err := c.signingKey()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := now.Add(c.TTL)
+	claims := Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			Issuer:    c.Issuer,
+			Audience:  jwt.ClaimStrings{c.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	signed, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// Parse checks the token's algorithm, signature, expiry, issuer and audience
+// and returns its claims. Only the configured algorithm is accepted. Expired
+// tokens yield ErrExpired; everything else ErrInvalidToken.
+func (c Config) Parse(token string) (*Claims, error) {
+	key, err := c.verificationKey()
+	if err != nil {
+		return nil, err
+	}
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return key, nil
+	},
+		jwt.WithValidMethods([]string{c.Algorithm}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(c.Issuer),
+		jwt.WithAudience(c.Audience),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpired
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{
+		Algorithm: AlgHS256,
+		Secret:    []byte("test-secret"),
+		TTL:       time.Hour,
+		Issuer:    DefaultIssuer,
+		Audience:  DefaultAudience,
+	}
+}
+
+func TestParseAcceptsMintedToken(t *testing.T) {
+	cfg := testConfig()
+	token, _, err := cfg.Mint("user-1", "admin", time.Now())
+	if err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+	claims, err := cfg.Parse(token)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if claims.Subject != "user-1" || claims.Role != "admin" {
+		t.Errorf("claims = %q/%q, want user-1/admin", claims.Subject, claims.Role)
+	}
+}
+
+func TestParseRejectsExpiredToken(t *testing.T) {
+	cfg := testConfig()
+	token, _, err := cfg.Mint("user-1", "user", time.Now().Add(-2*time.Hour))
+	if err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+	if _, err := cfg.Parse(token); !errors.Is(err, ErrExpired) {
+		t.Fatalf("Parse err = %v, want ErrExpired", err)
+	}
+}
+
+func TestParseRejectsForeignClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+	}{
+		{"wrong audience", func(c *Config) { c.Audience = "another-api" }},
+		{"wrong issuer", func(c *Config) { c.Issuer = "someone-else" }},
+		{"wrong secret", func(c *Config) { c.Secret = []byte("other-secret") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minter := testConfig()
+			tt.mutate(&minter)
+			token, _, err := minter.Mint("user-1", "user", time.Now())
+			if err != nil {
+				t.Fatalf("Mint: %v", err)
+			}
+			_, err = testConfig().Parse(token)
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Parse err = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestMintUsesConfiguredTTL(t *testing.T) {
+	cfg := testConfig()
+	cfg.TTL = 12 * time.Hour
+	now := time.Now()
+	_, expiresAt, err := cfg.Mint("user-1", "user", now)
+	if err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+	if !expiresAt.Equal(now.Add(12 * time.Hour)) {
+		t.Errorf("expiresAt = %v, want %v", expiresAt, now.Add(12*time.Hour))
+	}
+}
+
+func TestLoadConfigRejectsBadTTL(t *testing.T) {
+	t.Setenv("JWT_TTL", "soon")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("LoadConfig accepted JWT_TTL=soon")
+	}
+}
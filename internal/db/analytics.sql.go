@@ -182,6 +182,170 @@ func (q *Queries) GetEventUtilizationBetween(ctx context.Context, arg GetEventUt
 	return items, nil
 }
 
+const getNoShowTotalsBetween = `-- name: GetNoShowTotalsBetween :one
+SELECT
+  COUNT(*)::bigint AS events,
+  COALESCE(SUM(t.seats_booked), 0)::bigint AS seats_booked,
+  COALESCE(SUM(t.seats_checked_in), 0)::bigint AS seats_checked_in
+FROM (
+  SELECT
+    SUM(b.seats) AS seats_booked,
+    SUM(b.seats) FILTER (WHERE b.checked_in_at IS NOT NULL) AS seats_checked_in
+  FROM events e
+  JOIN bookings b ON b.event_id = e.id AND b.status = 'active'
+  WHERE e.start_time >= $1 AND e.start_time <= $2
+    AND e.start_time < now()
+    AND e.deleted_at IS NULL
+  GROUP BY e.id
+  HAVING COUNT(b.checked_in_at) > 0
+) t
+`
+
+type GetNoShowTotalsBetweenParams struct {
+	StartTime   pgtype.Timestamptz
+	StartTime_2 pgtype.Timestamptz
+}
+
+type GetNoShowTotalsBetweenRow struct {
+	Events         int64
+	SeatsBooked    int64
+	SeatsCheckedIn int64
+}
+
+// Totals over every event GetNoShowsByEventBetween would list.
+func (q *Queries) GetNoShowTotalsBetween(ctx context.Context, arg GetNoShowTotalsBetweenParams) (GetNoShowTotalsBetweenRow, error) {
+	row := q.db.QueryRow(ctx, getNoShowTotalsBetween, arg.StartTime, arg.StartTime_2)
+	var i GetNoShowTotalsBetweenRow
+	err := row.Scan(&i.Events, &i.SeatsBooked, &i.SeatsCheckedIn)
+	return i, err
+}
+
+const getNoShowsByEventBetween = `-- name: GetNoShowsByEventBetween :many
+SELECT
+  e.id AS event_id,
+  e.name,
+  e.start_time,
+  e.capacity::int AS capacity,
+  e.oversell_percent::int AS oversell_percent,
+  SUM(b.seats)::bigint AS seats_booked,
+  COALESCE(SUM(b.seats) FILTER (WHERE b.checked_in_at IS NOT NULL), 0)::bigint AS seats_checked_in
+FROM events e
+JOIN bookings b ON b.event_id = e.id AND b.status = 'active'
+WHERE e.start_time >= $1 AND e.start_time <= $2
+  AND e.start_time < now()
+  AND e.deleted_at IS NULL
+GROUP BY e.id
+HAVING COUNT(b.checked_in_at) > 0
+ORDER BY e.start_time DESC
+LIMIT $3
+`
+
+type GetNoShowsByEventBetweenParams struct {
+	StartTime   pgtype.Timestamptz
+	StartTime_2 pgtype.Timestamptz
+	Limit       int32
+}
+
+type GetNoShowsByEventBetweenRow struct {
+	EventID         pgtype.UUID
+	Name            string
+	StartTime       pgtype.Timestamptz
+	Capacity        int32
+	OversellPercent int32
+	SeatsBooked     int64
+	SeatsCheckedIn  int64
+}
+
+// Events that started in the range and used check-in, with their active
+// booked seats split by whether the booking was scanned. Events nobody was
+// checked in at are left out: they didn't use check-in, so every seat would
+// look like a no-show.
+func (q *Queries) GetNoShowsByEventBetween(ctx context.Context, arg GetNoShowsByEventBetweenParams) ([]GetNoShowsByEventBetweenRow, error) {
+	rows, err := q.db.Query(ctx, getNoShowsByEventBetween, arg.StartTime, arg.StartTime_2, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNoShowsByEventBetweenRow
+	for rows.Next() {
+		var i GetNoShowsByEventBetweenRow
+		if err := rows.Scan(
+			&i.EventID,
+			&i.Name,
+			&i.StartTime,
+			&i.Capacity,
+			&i.OversellPercent,
+			&i.SeatsBooked,
+			&i.SeatsCheckedIn,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOverbookingExposure = `-- name: GetOverbookingExposure :many
+SELECT
+  e.id AS event_id,
+  e.name,
+  e.start_time,
+  e.capacity::int AS capacity,
+  e.oversell_percent::int AS oversell_percent,
+  e.booked_count::int AS booked_count,
+  GREATEST(e.booked_count - e.capacity, 0)::int AS overbooked_seats
+FROM events e
+WHERE e.deleted_at IS NULL
+  AND e.start_time >= now()
+  AND (e.oversell_percent > 0 OR e.booked_count > e.capacity)
+ORDER BY overbooked_seats DESC, e.start_time
+LIMIT $1
+`
+
+type GetOverbookingExposureRow struct {
+	EventID         pgtype.UUID
+	Name            string
+	StartTime       pgtype.Timestamptz
+	Capacity        int32
+	OversellPercent int32
+	BookedCount     int32
+	OverbookedSeats int32
+}
+
+// Upcoming events that oversell or are booked beyond their capacity.
+// overbooked_seats is how many booked seats have no physical seat should
+// everyone turn up.
+func (q *Queries) GetOverbookingExposure(ctx context.Context, limit int32) ([]GetOverbookingExposureRow, error) {
+	rows, err := q.db.Query(ctx, getOverbookingExposure, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOverbookingExposureRow
+	for rows.Next() {
+		var i GetOverbookingExposureRow
+		if err := rows.Scan(
+			&i.EventID,
+			&i.Name,
+			&i.StartTime,
+			&i.Capacity,
+			&i.OversellPercent,
+			&i.BookedCount,
+			&i.OverbookedSeats,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTopEventsBySeatsBetween = `-- name: GetTopEventsBySeatsBetween :many
 SELECT
   b.event_id,
@@ -22,8 +22,53 @@ func (q *Queries) ConvertSeatHoldToConverted(ctx context.Context, holdToken stri
 	return err
 }
 
+const countBookings = `-- name: CountBookings :one
+SELECT COUNT(*)
+FROM bookings b
+WHERE ($1::uuid IS NULL OR b.event_id = $1)
+  AND ($2::uuid IS NULL OR b.user_id = $2)
+  AND ($3::text IS NULL OR b.status = $3)
+  AND ($4::timestamptz IS NULL OR b.created_at >= $4)
+  AND ($5::timestamptz IS NULL OR b.created_at <= $5)
+`
+
+type CountBookingsParams struct {
+	EventID     pgtype.UUID
+	UserID      pgtype.UUID
+	Status      pgtype.Text
+	CreatedFrom pgtype.Timestamptz
+	CreatedTo   pgtype.Timestamptz
+}
+
+// Counts the bookings ListBookings pages through, with the same filters.
+func (q *Queries) CountBookings(ctx context.Context, arg CountBookingsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countBookings,
+		arg.EventID,
+		arg.UserID,
+		arg.Status,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countBookingsByUser = `-- name: CountBookingsByUser :one
+SELECT COUNT(*)
+FROM bookings
+WHERE user_id = $1
+`
+
+func (q *Queries) CountBookingsByUser(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countBookingsByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getBookingByEventAndIdempotency = `-- name: GetBookingByEventAndIdempotency :one
-SELECT id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at, updated_at
+SELECT id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at, updated_at, checked_in_at, hold_token, payment_intent_id, token_version, paid_at, unit_price_cents, currency
 FROM bookings
 WHERE event_id = $1
     AND idempotency_key = $2
@@ -47,12 +92,19 @@ func (q *Queries) GetBookingByEventAndIdempotency(ctx context.Context, arg GetBo
 		&i.IdempotencyKey,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CheckedInAt,
+		&i.HoldToken,
+		&i.PaymentIntentID,
+		&i.TokenVersion,
+		&i.PaidAt,
+		&i.UnitPriceCents,
+		&i.Currency,
 	)
 	return i, err
 }
 
 const getBookingByID = `-- name: GetBookingByID :one
-SELECT id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at, updated_at
+SELECT id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at, updated_at, checked_in_at, hold_token, payment_intent_id, token_version, paid_at, unit_price_cents, currency
 FROM bookings
 WHERE id = $1
 `
@@ -70,36 +122,139 @@ func (q *Queries) GetBookingByID(ctx context.Context, id pgtype.UUID) (Booking,
 		&i.IdempotencyKey,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CheckedInAt,
+		&i.HoldToken,
+		&i.PaymentIntentID,
+		&i.TokenVersion,
+		&i.PaidAt,
+		&i.UnitPriceCents,
+		&i.Currency,
 	)
 	return i, err
 }
 
-const getBookingsByUser = `-- name: GetBookingsByUser :many
-SELECT id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at, updated_at
-FROM bookings
-WHERE user_id = $1
-ORDER BY created_at DESC
+const getBookingsByEvent = `-- name: GetBookingsByEvent :many
+SELECT
+  b.id,
+  b.user_id,
+  b.seats,
+  b.status,
+  b.created_at,
+  b.checked_in_at,
+  u.name AS user_name,
+  u.email AS user_email,
+  ARRAY(SELECT s.seat_no FROM seats s WHERE s.id = ANY(b.seat_ids) ORDER BY s.seat_no)::text[] AS seat_nos
+FROM bookings b
+LEFT JOIN users u ON u.id = b.user_id
+WHERE b.event_id = $1
+  AND ($2::text IS NULL OR b.status = $2)
+ORDER BY b.created_at, b.id
+LIMIT $3 OFFSET $4
 `
 
-func (q *Queries) GetBookingsByUser(ctx context.Context, userID pgtype.UUID) ([]Booking, error) {
-	rows, err := q.db.Query(ctx, getBookingsByUser, userID)
+type GetBookingsByEventParams struct {
+	EventID pgtype.UUID
+	Status  pgtype.Text
+	Limit   int32
+	Offset  int32
+}
+
+type GetBookingsByEventRow struct {
+	ID          pgtype.UUID
+	UserID      pgtype.UUID
+	Seats       int32
+	Status      string
+	CreatedAt   pgtype.Timestamptz
+	CheckedInAt pgtype.Timestamptz
+	UserName    pgtype.Text
+	UserEmail   pgtype.Text
+	SeatNos     []string
+}
+
+// Attendee list for one event with holder details and seat numbers, oldest
+// booking first. A NULL status lists every booking.
+func (q *Queries) GetBookingsByEvent(ctx context.Context, arg GetBookingsByEventParams) ([]GetBookingsByEventRow, error) {
+	rows, err := q.db.Query(ctx, getBookingsByEvent,
+		arg.EventID,
+		arg.Status,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Booking
+	var items []GetBookingsByEventRow
 	for rows.Next() {
-		var i Booking
+		var i GetBookingsByEventRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.EventID,
 			&i.UserID,
 			&i.Seats,
-			&i.SeatIds,
 			&i.Status,
-			&i.IdempotencyKey,
 			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.CheckedInAt,
+			&i.UserName,
+			&i.UserEmail,
+			&i.SeatNos,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookingsByUser = `-- name: GetBookingsByUser :many
+SELECT bookings.id, bookings.event_id, bookings.user_id, bookings.seats, bookings.seat_ids, bookings.status, bookings.idempotency_key, bookings.created_at, bookings.updated_at, bookings.checked_in_at, bookings.hold_token, bookings.payment_intent_id, bookings.token_version, bookings.paid_at, bookings.unit_price_cents, bookings.currency, COUNT(*) OVER () AS total
+FROM bookings
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type GetBookingsByUserParams struct {
+	UserID pgtype.UUID
+	Limit  int32
+	Offset int32
+}
+
+type GetBookingsByUserRow struct {
+	Booking Booking
+	Total   int64
+}
+
+// total counts every booking of the user, for the list envelope.
+func (q *Queries) GetBookingsByUser(ctx context.Context, arg GetBookingsByUserParams) ([]GetBookingsByUserRow, error) {
+	rows, err := q.db.Query(ctx, getBookingsByUser, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBookingsByUserRow
+	for rows.Next() {
+		var i GetBookingsByUserRow
+		if err := rows.Scan(
+			&i.Booking.ID,
+			&i.Booking.EventID,
+			&i.Booking.UserID,
+			&i.Booking.Seats,
+			&i.Booking.SeatIds,
+			&i.Booking.Status,
+			&i.Booking.IdempotencyKey,
+			&i.Booking.CreatedAt,
+			&i.Booking.UpdatedAt,
+			&i.Booking.CheckedInAt,
+			&i.Booking.HoldToken,
+			&i.Booking.PaymentIntentID,
+			&i.Booking.TokenVersion,
+			&i.Booking.PaidAt,
+			&i.Booking.UnitPriceCents,
+			&i.Booking.Currency,
+			&i.Total,
 		); err != nil {
 			return nil, err
 		}
@@ -111,6 +266,27 @@ func (q *Queries) GetBookingsByUser(ctx context.Context, userID pgtype.UUID) ([]
 	return items, nil
 }
 
+const getEventCapacityForUpdate = `-- name: GetEventCapacityForUpdate :one
+SELECT capacity, booked_count, oversell_percent
+FROM events
+WHERE id = $1
+FOR UPDATE
+`
+
+type GetEventCapacityForUpdateRow struct {
+	Capacity        int32
+	BookedCount     int32
+	OversellPercent int32
+}
+
+// Locks the event row so concurrent bookings see each other's booked_count.
+func (q *Queries) GetEventCapacityForUpdate(ctx context.Context, id pgtype.UUID) (GetEventCapacityForUpdateRow, error) {
+	row := q.db.QueryRow(ctx, getEventCapacityForUpdate, id)
+	var i GetEventCapacityForUpdateRow
+	err := row.Scan(&i.Capacity, &i.BookedCount, &i.OversellPercent)
+	return i, err
+}
+
 const getSeatHoldForUpdateByToken = `-- name: GetSeatHoldForUpdateByToken :one
 SELECT id, hold_token, event_id, user_id, expires_at, status
 FROM seat_holds
@@ -169,7 +345,7 @@ func (q *Queries) GetSeatNosByIds(ctx context.Context, dollar_1 []pgtype.UUID) (
 }
 
 const getSeatsForBookingByIDs = `-- name: GetSeatsForBookingByIDs :many
-SELECT id, status, hold_token
+SELECT id, seat_no, status, hold_token
 FROM seats
 WHERE id = ANY($1::uuid[])
 ORDER BY id
@@ -178,6 +354,7 @@ FOR UPDATE
 
 type GetSeatsForBookingByIDsRow struct {
 	ID        pgtype.UUID
+	SeatNo    string
 	Status    string
 	HoldToken pgtype.Text
 }
@@ -191,7 +368,12 @@ func (q *Queries) GetSeatsForBookingByIDs(ctx context.Context, dollar_1 []pgtype
 	var items []GetSeatsForBookingByIDsRow
 	for rows.Next() {
 		var i GetSeatsForBookingByIDsRow
-		if err := rows.Scan(&i.ID, &i.Status, &i.HoldToken); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.SeatNo,
+			&i.Status,
+			&i.HoldToken,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -203,8 +385,10 @@ func (q *Queries) GetSeatsForBookingByIDs(ctx context.Context, dollar_1 []pgtype
 }
 
 const insertBooking = `-- name: InsertBooking :one
-INSERT INTO bookings (event_id, user_id, seats, seat_ids, status, idempotency_key)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO bookings (event_id, user_id, seats, seat_ids, status, idempotency_key, hold_token, unit_price_cents, currency)
+SELECT $1, $2, $3, $4, $5, $6, $7, e.price_cents, e.currency
+FROM events e
+WHERE e.id = $1
 RETURNING id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at
 `
 
@@ -215,6 +399,7 @@ type InsertBookingParams struct {
 	SeatIds        []pgtype.UUID
 	Status         string
 	IdempotencyKey pgtype.Text
+	HoldToken      pgtype.Text
 }
 
 type InsertBookingRow struct {
@@ -228,6 +413,7 @@ type InsertBookingRow struct {
 	CreatedAt      pgtype.Timestamptz
 }
 
+// Snapshots the event's seat price and currency onto the booking.
 func (q *Queries) InsertBooking(ctx context.Context, arg InsertBookingParams) (InsertBookingRow, error) {
 	row := q.db.QueryRow(ctx, insertBooking,
 		arg.EventID,
@@ -236,6 +422,7 @@ func (q *Queries) InsertBooking(ctx context.Context, arg InsertBookingParams) (I
 		arg.SeatIds,
 		arg.Status,
 		arg.IdempotencyKey,
+		arg.HoldToken,
 	)
 	var i InsertBookingRow
 	err := row.Scan(
@@ -251,11 +438,122 @@ func (q *Queries) InsertBooking(ctx context.Context, arg InsertBookingParams) (I
 	return i, err
 }
 
+const listBookings = `-- name: ListBookings :many
+SELECT
+  b.id,
+  b.event_id,
+  b.user_id,
+  b.seats,
+  b.status,
+  b.created_at,
+  b.updated_at,
+  u.email AS user_email,
+  e.name AS event_name,
+  COUNT(*) OVER () AS total
+FROM bookings b
+JOIN events e ON e.id = b.event_id
+LEFT JOIN users u ON u.id = b.user_id
+WHERE ($1::uuid IS NULL OR b.event_id = $1)
+  AND ($2::uuid IS NULL OR b.user_id = $2)
+  AND ($3::text IS NULL OR b.status = $3)
+  AND ($4::timestamptz IS NULL OR b.created_at >= $4)
+  AND ($5::timestamptz IS NULL OR b.created_at <= $5)
+ORDER BY b.created_at DESC, b.id
+LIMIT $6 OFFSET $7
+`
+
+type ListBookingsParams struct {
+	EventID     pgtype.UUID
+	UserID      pgtype.UUID
+	Status      pgtype.Text
+	CreatedFrom pgtype.Timestamptz
+	CreatedTo   pgtype.Timestamptz
+	Limit       int32
+	Offset      int32
+}
+
+type ListBookingsRow struct {
+	ID        pgtype.UUID
+	EventID   pgtype.UUID
+	UserID    pgtype.UUID
+	Seats     int32
+	Status    string
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+	UserEmail pgtype.Text
+	EventName string
+	Total     int64
+}
+
+// Admin listing; every filter is optional (NULL = no filter). total counts
+// every matching booking, for the list envelope.
+func (q *Queries) ListBookings(ctx context.Context, arg ListBookingsParams) ([]ListBookingsRow, error) {
+	rows, err := q.db.Query(ctx, listBookings,
+		arg.EventID,
+		arg.UserID,
+		arg.Status,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookingsRow
+	for rows.Next() {
+		var i ListBookingsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.UserID,
+			&i.Seats,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserEmail,
+			&i.EventName,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateBookingOwner = `-- name: UpdateBookingOwner :execrows
+UPDATE bookings
+SET user_id = $2,
+    token_version = token_version + 1
+WHERE id = $1
+  AND status = 'active'
+`
+
+type UpdateBookingOwnerParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+// Bumps token_version too, so tickets issued before uid was signed into them
+// stop working for the previous holder.
+func (q *Queries) UpdateBookingOwner(ctx context.Context, arg UpdateBookingOwnerParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateBookingOwner, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateEventBookedCount = `-- name: UpdateEventBookedCount :execrows
 UPDATE events
 SET booked_count = booked_count + $1
 WHERE id = $2
-  AND booked_count + $1 <= capacity
+  AND booked_count + $1 <= capacity + capacity * oversell_percent / 100
 `
 
 type UpdateEventBookedCountParams struct {
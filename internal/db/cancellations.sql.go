@@ -11,21 +11,136 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const cancelActiveBookingsByEvent = `-- name: CancelActiveBookingsByEvent :one
+WITH cancelled AS (
+  UPDATE bookings
+  SET status = 'cancelled'
+  WHERE event_id = $1 AND status IN ('active', 'pending_payment')
+  RETURNING seats
+)
+SELECT COUNT(*)::int AS bookings, COALESCE(SUM(seats), 0)::int AS seats
+FROM cancelled
+`
+
+type CancelActiveBookingsByEventRow struct {
+	Bookings int32
+	Seats    int32
+}
+
+// Cancels every active or pending_payment booking for an event, reporting how many bookings and seats were affected.
+func (q *Queries) CancelActiveBookingsByEvent(ctx context.Context, eventID pgtype.UUID) (CancelActiveBookingsByEventRow, error) {
+	row := q.db.QueryRow(ctx, cancelActiveBookingsByEvent, eventID)
+	var i CancelActiveBookingsByEventRow
+	err := row.Scan(&i.Bookings, &i.Seats)
+	return i, err
+}
+
+const cancelBookingsByIDs = `-- name: CancelBookingsByIDs :execrows
+UPDATE bookings
+SET status = 'cancelled'
+WHERE id = ANY($1::uuid[]) AND status IN ('active', 'pending_payment')
+`
+
+func (q *Queries) CancelBookingsByIDs(ctx context.Context, dollar_1 []pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelBookingsByIDs, dollar_1)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const cancelWaitlistByEvent = `-- name: CancelWaitlistByEvent :execrows
+UPDATE waitlist
+SET status = 'cancelled'
+WHERE event_id = $1 AND status = 'waiting'
+`
+
+func (q *Queries) CancelWaitlistByEvent(ctx context.Context, eventID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelWaitlistByEvent, eventID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const expireActiveHoldsByEvent = `-- name: ExpireActiveHoldsByEvent :execrows
+UPDATE seat_holds
+SET status = 'expired'
+WHERE event_id = $1 AND status = 'active'
+`
+
+func (q *Queries) ExpireActiveHoldsByEvent(ctx context.Context, eventID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, expireActiveHoldsByEvent, eventID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const expireHoldBatchByEvent = `-- name: ExpireHoldBatchByEvent :many
+UPDATE seat_holds
+SET status = 'expired', updated_at = now()
+WHERE id IN (
+  SELECT id FROM seat_holds
+  WHERE event_id = $1 AND status = 'active'
+  ORDER BY created_at, id
+  LIMIT $2
+  FOR UPDATE
+)
+RETURNING hold_token, seat_ids
+`
+
+type ExpireHoldBatchByEventParams struct {
+	EventID pgtype.UUID
+	Limit   int32
+}
+
+type ExpireHoldBatchByEventRow struct {
+	HoldToken string
+	SeatIds   []pgtype.UUID
+}
+
+// Ends up to $2 of the event's active holds, for cancel-all, and returns the
+// seats they held.
+func (q *Queries) ExpireHoldBatchByEvent(ctx context.Context, arg ExpireHoldBatchByEventParams) ([]ExpireHoldBatchByEventRow, error) {
+	rows, err := q.db.Query(ctx, expireHoldBatchByEvent, arg.EventID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExpireHoldBatchByEventRow
+	for rows.Next() {
+		var i ExpireHoldBatchByEventRow
+		if err := rows.Scan(&i.HoldToken, &i.SeatIds); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getBookingForUpdate = `-- name: GetBookingForUpdate :one
-SELECT id, event_id, user_id, seats, seat_ids, status, created_at
+SELECT id, event_id, user_id, seats, seat_ids, status, created_at, payment_intent_id, paid_at, unit_price_cents, currency
 FROM bookings
 WHERE id = $1
 FOR UPDATE
 `
 
 type GetBookingForUpdateRow struct {
-	ID        pgtype.UUID
-	EventID   pgtype.UUID
-	UserID    pgtype.UUID
-	Seats     int32
-	SeatIds   []pgtype.UUID
-	Status    string
-	CreatedAt pgtype.Timestamptz
+	ID              pgtype.UUID
+	EventID         pgtype.UUID
+	UserID          pgtype.UUID
+	Seats           int32
+	SeatIds         []pgtype.UUID
+	Status          string
+	CreatedAt       pgtype.Timestamptz
+	PaymentIntentID pgtype.Text
+	PaidAt          pgtype.Timestamptz
+	UnitPriceCents  int32
+	Currency        pgtype.Text
 }
 
 func (q *Queries) GetBookingForUpdate(ctx context.Context, id pgtype.UUID) (GetBookingForUpdateRow, error) {
@@ -39,10 +154,83 @@ func (q *Queries) GetBookingForUpdate(ctx context.Context, id pgtype.UUID) (GetB
 		&i.SeatIds,
 		&i.Status,
 		&i.CreatedAt,
+		&i.PaymentIntentID,
+		&i.PaidAt,
+		&i.UnitPriceCents,
+		&i.Currency,
 	)
 	return i, err
 }
 
+const lockActiveBookingsByEvent = `-- name: LockActiveBookingsByEvent :many
+SELECT b.id, b.user_id, b.seat_ids, b.status, b.payment_intent_id,
+       ARRAY(SELECT s.seat_no FROM seats s WHERE s.id = ANY(b.seat_ids) ORDER BY s.seat_no)::text[] AS seat_nos
+FROM bookings b
+WHERE b.event_id = $1 AND b.status IN ('active', 'pending_payment')
+ORDER BY b.created_at, b.id
+LIMIT $2
+FOR UPDATE OF b
+`
+
+type LockActiveBookingsByEventParams struct {
+	EventID pgtype.UUID
+	Limit   int32
+}
+
+type LockActiveBookingsByEventRow struct {
+	ID              pgtype.UUID
+	UserID          pgtype.UUID
+	SeatIds         []pgtype.UUID
+	Status          string
+	PaymentIntentID pgtype.Text
+	SeatNos         []string
+}
+
+// One batch of an event's live bookings, locked for cancellation, with their seat numbers.
+func (q *Queries) LockActiveBookingsByEvent(ctx context.Context, arg LockActiveBookingsByEventParams) ([]LockActiveBookingsByEventRow, error) {
+	rows, err := q.db.Query(ctx, lockActiveBookingsByEvent, arg.EventID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LockActiveBookingsByEventRow
+	for rows.Next() {
+		var i LockActiveBookingsByEventRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.SeatIds,
+			&i.Status,
+			&i.PaymentIntentID,
+			&i.SeatNos,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const releaseSeatsByEvent = `-- name: ReleaseSeatsByEvent :execrows
+UPDATE seats
+SET status = 'available',
+    booking_id = NULL,
+    hold_token = NULL,
+    hold_expires_at = NULL
+WHERE event_id = $1 AND status IN ('held', 'booked')
+`
+
+func (q *Queries) ReleaseSeatsByEvent(ctx context.Context, eventID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, releaseSeatsByEvent, eventID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateBookingToCancelled = `-- name: UpdateBookingToCancelled :exec
 UPDATE bookings
 SET status = 'cancelled'
@@ -83,3 +271,22 @@ func (q *Queries) UpdateSeatsToAvailableByIds(ctx context.Context, dollar_1 []pg
 	_, err := q.db.Exec(ctx, updateSeatsToAvailableByIds, dollar_1)
 	return err
 }
+
+const zeroEventBookedCount = `-- name: ZeroEventBookedCount :execrows
+UPDATE events
+SET booked_count = 0
+WHERE id = $1 AND booked_count <> 0
+  AND NOT EXISTS (
+    SELECT 1 FROM bookings b
+    WHERE b.event_id = $1 AND b.status IN ('active', 'pending_payment')
+  )
+`
+
+// Clears booked_count once the event has no live bookings left.
+func (q *Queries) ZeroEventBookedCount(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, zeroEventBookedCount, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -12,28 +12,47 @@ import (
 )
 
 const addEvent = `-- name: AddEvent :one
-INSERT INTO events (name, venue, start_time, capacity, metadata)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, name, venue, start_time, capacity, metadata, created_at, updated_at
+INSERT INTO events (name, venue, start_time, capacity, metadata, owner_id, description, image_url, sender_name, reply_to, requires_payment, price_cents, currency, oversell_percent)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, name, venue, start_time, capacity, metadata, created_at, updated_at, version, owner_id, description, image_url, sender_name, reply_to, requires_payment, price_cents, currency, oversell_percent
 `
 
 type AddEventParams struct {
-	Name      string
-	Venue     pgtype.Text
-	StartTime pgtype.Timestamptz
-	Capacity  int32
-	Metadata  []byte
+	Name            string
+	Venue           pgtype.Text
+	StartTime       pgtype.Timestamptz
+	Capacity        int32
+	Metadata        []byte
+	OwnerID         pgtype.UUID
+	Description     pgtype.Text
+	ImageUrl        pgtype.Text
+	SenderName      pgtype.Text
+	ReplyTo         pgtype.Text
+	RequiresPayment bool
+	PriceCents      int32
+	Currency        pgtype.Text
+	OversellPercent int32
 }
 
 type AddEventRow struct {
-	ID        pgtype.UUID
-	Name      string
-	Venue     pgtype.Text
-	StartTime pgtype.Timestamptz
-	Capacity  int32
-	Metadata  []byte
-	CreatedAt pgtype.Timestamptz
-	UpdatedAt pgtype.Timestamptz
+	ID              pgtype.UUID
+	Name            string
+	Venue           pgtype.Text
+	StartTime       pgtype.Timestamptz
+	Capacity        int32
+	Metadata        []byte
+	CreatedAt       pgtype.Timestamptz
+	UpdatedAt       pgtype.Timestamptz
+	Version         int32
+	OwnerID         pgtype.UUID
+	Description     pgtype.Text
+	ImageUrl        pgtype.Text
+	SenderName      pgtype.Text
+	ReplyTo         pgtype.Text
+	RequiresPayment bool
+	PriceCents      int32
+	Currency        pgtype.Text
+	OversellPercent int32
 }
 
 func (q *Queries) AddEvent(ctx context.Context, arg AddEventParams) (AddEventRow, error) {
@@ -43,6 +62,15 @@ func (q *Queries) AddEvent(ctx context.Context, arg AddEventParams) (AddEventRow
 		arg.StartTime,
 		arg.Capacity,
 		arg.Metadata,
+		arg.OwnerID,
+		arg.Description,
+		arg.ImageUrl,
+		arg.SenderName,
+		arg.ReplyTo,
+		arg.RequiresPayment,
+		arg.PriceCents,
+		arg.Currency,
+		arg.OversellPercent,
 	)
 	var i AddEventRow
 	err := row.Scan(
@@ -54,26 +82,98 @@ func (q *Queries) AddEvent(ctx context.Context, arg AddEventParams) (AddEventRow
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.Description,
+		&i.ImageUrl,
+		&i.SenderName,
+		&i.ReplyTo,
+		&i.RequiresPayment,
+		&i.PriceCents,
+		&i.Currency,
+		&i.OversellPercent,
+	)
+	return i, err
+}
+
+const countEventDependents = `-- name: CountEventDependents :one
+SELECT
+  (SELECT COUNT(*) FROM bookings b WHERE b.event_id = $1 AND b.status IN ('active', 'pending_payment'))::int AS active_bookings,
+  (SELECT COUNT(*) FROM seat_holds sh WHERE sh.event_id = $1 AND sh.status = 'active' AND sh.expires_at > now())::int AS active_holds,
+  (SELECT COUNT(*) FROM seats s WHERE s.event_id = $1)::int AS seats,
+  (SELECT COUNT(*) FROM waitlist w WHERE w.event_id = $1 AND w.status = 'waiting')::int AS waitlist_entries
+`
+
+type CountEventDependentsRow struct {
+	ActiveBookings  int32
+	ActiveHolds     int32
+	Seats           int32
+	WaitlistEntries int32
+}
+
+func (q *Queries) CountEventDependents(ctx context.Context, eventID pgtype.UUID) (CountEventDependentsRow, error) {
+	row := q.db.QueryRow(ctx, countEventDependents, eventID)
+	var i CountEventDependentsRow
+	err := row.Scan(
+		&i.ActiveBookings,
+		&i.ActiveHolds,
+		&i.Seats,
+		&i.WaitlistEntries,
 	)
 	return i, err
 }
 
-const deleteEvent = `-- name: DeleteEvent :one
-DELETE FROM events
-WHERE id = $1
-RETURNING id
+const countEvents = `-- name: CountEvents :one
+SELECT COUNT(*)
+FROM events
+WHERE ($1 = '' OR name ILIKE '%' || $1 || '%' OR venue ILIKE '%' || $1 || '%' OR description ILIKE '%' || $1 || '%')
+  AND ($2::boolean OR deleted_at IS NULL)
+  AND ($3::text = '' OR $3::text = (
+    SELECT CASE
+      WHEN LEAST(COUNT(*), events.capacity + events.capacity * events.oversell_percent / 100 - events.booked_count) <= 0 THEN 'sold_out'
+      WHEN LEAST(COUNT(*), events.capacity + events.capacity * events.oversell_percent / 100 - events.booked_count) * 100 <= (events.capacity + events.capacity * events.oversell_percent / 100) * $4::int THEN 'limited'
+      ELSE 'open'
+    END
+    FROM seats s
+    WHERE s.event_id = events.id AND s.status IN ('available', 'held')
+  ))
 `
 
-func (q *Queries) DeleteEvent(ctx context.Context, id pgtype.UUID) (pgtype.UUID, error) {
-	row := q.db.QueryRow(ctx, deleteEvent, id)
-	err := row.Scan(&id)
-	return id, err
+type CountEventsParams struct {
+	Column1 interface{}
+	Column2 bool
+	Column3 string
+	Column4 int32
+}
+
+// Counts the events GetAllEvents pages through, with the same filters.
+func (q *Queries) CountEvents(ctx context.Context, arg CountEventsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countEvents,
+		arg.Column1,
+		arg.Column2,
+		arg.Column3,
+		arg.Column4,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
 const getAllEvents = `-- name: GetAllEvents :many
-SELECT id, name, venue, start_time, capacity, booked_count, metadata, created_at, updated_at
+SELECT events.id, events.name, events.venue, events.start_time, events.capacity, events.booked_count, events.metadata, events.created_at, events.updated_at, events.deleted_at, events.version, events.owner_id, events.description, events.image_url, events.waitlist_cap, events.waitlist_open, events.sender_name, events.reply_to, events.requires_payment, events.price_cents, events.currency, events.oversell_percent, COUNT(*) OVER () AS total
 FROM events
-WHERE ($3 = '' OR name ILIKE '%' || $3 || '%' OR venue ILIKE '%' || $3 || '%')
+WHERE ($3 = '' OR name ILIKE '%' || $3 || '%' OR venue ILIKE '%' || $3 || '%' OR description ILIKE '%' || $3 || '%')
+  AND ($4::boolean OR deleted_at IS NULL)
+  AND ($5::text = '' OR $5::text = (
+    -- Same classification as availabilityStatus in the handlers.
+    SELECT CASE
+      WHEN LEAST(COUNT(*), events.capacity + events.capacity * events.oversell_percent / 100 - events.booked_count) <= 0 THEN 'sold_out'
+      WHEN LEAST(COUNT(*), events.capacity + events.capacity * events.oversell_percent / 100 - events.booked_count) * 100 <= (events.capacity + events.capacity * events.oversell_percent / 100) * $6::int THEN 'limited'
+      ELSE 'open'
+    END
+    FROM seats s
+    WHERE s.event_id = events.id AND s.status IN ('available', 'held')
+  ))
 ORDER BY start_time
 LIMIT $1 OFFSET $2
 `
@@ -82,27 +182,56 @@ type GetAllEventsParams struct {
 	Limit   int32
 	Offset  int32
 	Column3 interface{}
+	Column4 bool
+	Column5 string
+	Column6 int32
 }
 
-func (q *Queries) GetAllEvents(ctx context.Context, arg GetAllEventsParams) ([]Event, error) {
-	rows, err := q.db.Query(ctx, getAllEvents, arg.Limit, arg.Offset, arg.Column3)
+type GetAllEventsRow struct {
+	Event Event
+	Total int64
+}
+
+// total counts every event matching the filters, for the list envelope.
+func (q *Queries) GetAllEvents(ctx context.Context, arg GetAllEventsParams) ([]GetAllEventsRow, error) {
+	rows, err := q.db.Query(ctx, getAllEvents, arg.Limit,
+		arg.Offset,
+		arg.Column3,
+		arg.Column4,
+		arg.Column5,
+		arg.Column6,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Event
+	var items []GetAllEventsRow
 	for rows.Next() {
-		var i Event
+		var i GetAllEventsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Venue,
-			&i.StartTime,
-			&i.Capacity,
-			&i.BookedCount,
-			&i.Metadata,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.Event.ID,
+			&i.Event.Name,
+			&i.Event.Venue,
+			&i.Event.StartTime,
+			&i.Event.Capacity,
+			&i.Event.BookedCount,
+			&i.Event.Metadata,
+			&i.Event.CreatedAt,
+			&i.Event.UpdatedAt,
+			&i.Event.DeletedAt,
+			&i.Event.Version,
+			&i.Event.OwnerID,
+			&i.Event.Description,
+			&i.Event.ImageUrl,
+			&i.Event.WaitlistCap,
+			&i.Event.WaitlistOpen,
+			&i.Event.SenderName,
+			&i.Event.ReplyTo,
+			&i.Event.RequiresPayment,
+			&i.Event.PriceCents,
+			&i.Event.Currency,
+			&i.Event.OversellPercent,
+			&i.Total,
 		); err != nil {
 			return nil, err
 		}
@@ -114,8 +243,42 @@ func (q *Queries) GetAllEvents(ctx context.Context, arg GetAllEventsParams) ([]E
 	return items, nil
 }
 
+const getEventAvailability = `-- name: GetEventAvailability :one
+SELECT e.capacity,
+       e.booked_count,
+       e.oversell_percent,
+       COUNT(s.id) FILTER (WHERE s.status = 'held')::int AS held,
+       COUNT(s.id) FILTER (WHERE s.status = 'available')::int AS available_seats
+FROM events e
+LEFT JOIN seats s ON s.event_id = e.id
+WHERE e.id = $1 AND e.deleted_at IS NULL
+GROUP BY e.id
+`
+
+type GetEventAvailabilityRow struct {
+	Capacity        int32
+	BookedCount     int32
+	OversellPercent int32
+	Held            int32
+	AvailableSeats  int32
+}
+
+// Numeric availability for one live event in a single round trip.
+func (q *Queries) GetEventAvailability(ctx context.Context, id pgtype.UUID) (GetEventAvailabilityRow, error) {
+	row := q.db.QueryRow(ctx, getEventAvailability, id)
+	var i GetEventAvailabilityRow
+	err := row.Scan(
+		&i.Capacity,
+		&i.BookedCount,
+		&i.OversellPercent,
+		&i.Held,
+		&i.AvailableSeats,
+	)
+	return i, err
+}
+
 const getEventByID = `-- name: GetEventByID :one
-SELECT id, name, venue, start_time, capacity, booked_count, metadata, created_at, updated_at FROM events WHERE id = $1
+SELECT id, name, venue, start_time, capacity, booked_count, metadata, created_at, updated_at, deleted_at, version, owner_id, description, image_url, waitlist_cap, waitlist_open, sender_name, reply_to, requires_payment, price_cents, currency, oversell_percent FROM events WHERE id = $1
 `
 
 func (q *Queries) GetEventByID(ctx context.Context, id pgtype.UUID) (Event, error) {
@@ -131,10 +294,79 @@ func (q *Queries) GetEventByID(ctx context.Context, id pgtype.UUID) (Event, erro
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.Description,
+		&i.ImageUrl,
+		&i.WaitlistCap,
+		&i.WaitlistOpen,
+		&i.SenderName,
+		&i.ReplyTo,
+		&i.RequiresPayment,
+		&i.PriceCents,
+		&i.Currency,
+		&i.OversellPercent,
 	)
 	return i, err
 }
 
+const restoreEvent = `-- name: RestoreEvent :one
+UPDATE events
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, name, venue, start_time, capacity, booked_count, metadata, created_at, updated_at, deleted_at, version, owner_id, description, image_url, waitlist_cap, waitlist_open, sender_name, reply_to, requires_payment, price_cents, currency, oversell_percent
+`
+
+func (q *Queries) RestoreEvent(ctx context.Context, id pgtype.UUID) (Event, error) {
+	row := q.db.QueryRow(ctx, restoreEvent, id)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Venue,
+		&i.StartTime,
+		&i.Capacity,
+		&i.BookedCount,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.Description,
+		&i.ImageUrl,
+		&i.WaitlistCap,
+		&i.WaitlistOpen,
+		&i.SenderName,
+		&i.ReplyTo,
+		&i.RequiresPayment,
+		&i.PriceCents,
+		&i.Currency,
+		&i.OversellPercent,
+	)
+	return i, err
+}
+
+const softDeleteEvent = `-- name: SoftDeleteEvent :one
+UPDATE events
+SET deleted_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, deleted_at
+`
+
+type SoftDeleteEventRow struct {
+	ID        pgtype.UUID
+	DeletedAt pgtype.Timestamptz
+}
+
+func (q *Queries) SoftDeleteEvent(ctx context.Context, id pgtype.UUID) (SoftDeleteEventRow, error) {
+	row := q.db.QueryRow(ctx, softDeleteEvent, id)
+	var i SoftDeleteEventRow
+	err := row.Scan(&i.ID, &i.DeletedAt)
+	return i, err
+}
+
 const updateEvent = `-- name: UpdateEvent :one
 UPDATE events
 SET
@@ -142,18 +374,36 @@ SET
   venue = COALESCE($3, venue),
   start_time = COALESCE($4, start_time),
   capacity = COALESCE($5, capacity),
-  metadata = COALESCE($6, metadata)
-WHERE id = $1
-RETURNING id, name, venue, start_time, capacity, booked_count, metadata, created_at, updated_at
+  metadata = COALESCE($6, metadata),
+  description = $8,
+  image_url = $9,
+  sender_name = $10,
+  reply_to = $11,
+  requires_payment = $12,
+  price_cents = $13,
+  currency = $14,
+  oversell_percent = $15,
+  version = version + 1
+WHERE id = $1 AND version = $7
+RETURNING id, name, venue, start_time, capacity, booked_count, metadata, created_at, updated_at, deleted_at, version, owner_id, description, image_url, waitlist_cap, waitlist_open, sender_name, reply_to, requires_payment, price_cents, currency, oversell_percent
 `
 
 type UpdateEventParams struct {
-	ID        pgtype.UUID
-	Name      string
-	Venue     pgtype.Text
-	StartTime pgtype.Timestamptz
-	Capacity  int32
-	Metadata  []byte
+	ID              pgtype.UUID
+	Name            string
+	Venue           pgtype.Text
+	StartTime       pgtype.Timestamptz
+	Capacity        int32
+	Metadata        []byte
+	Version         int32
+	Description     pgtype.Text
+	ImageUrl        pgtype.Text
+	SenderName      pgtype.Text
+	ReplyTo         pgtype.Text
+	RequiresPayment bool
+	PriceCents      int32
+	Currency        pgtype.Text
+	OversellPercent int32
 }
 
 func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event, error) {
@@ -164,6 +414,15 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event
 		arg.StartTime,
 		arg.Capacity,
 		arg.Metadata,
+		arg.Version,
+		arg.Description,
+		arg.ImageUrl,
+		arg.SenderName,
+		arg.ReplyTo,
+		arg.RequiresPayment,
+		arg.PriceCents,
+		arg.Currency,
+		arg.OversellPercent,
 	)
 	var i Event
 	err := row.Scan(
@@ -176,6 +435,19 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.Description,
+		&i.ImageUrl,
+		&i.WaitlistCap,
+		&i.WaitlistOpen,
+		&i.SenderName,
+		&i.ReplyTo,
+		&i.RequiresPayment,
+		&i.PriceCents,
+		&i.Currency,
+		&i.OversellPercent,
 	)
 	return i, err
 }
@@ -11,6 +11,124 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countFinishedSeatHolds = `-- name: CountFinishedSeatHolds :one
+SELECT COUNT(*)
+FROM seat_holds
+WHERE status IN ('expired', 'converted') AND expires_at < $1
+`
+
+// Expired and converted holds whose expiry is before $1.
+func (q *Queries) CountFinishedSeatHolds(ctx context.Context, expiresAt pgtype.Timestamptz) (int64, error) {
+	row := q.db.QueryRow(ctx, countFinishedSeatHolds, expiresAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteFinishedSeatHolds = `-- name: DeleteFinishedSeatHolds :execrows
+DELETE FROM seat_holds
+WHERE id IN (
+  SELECT id FROM seat_holds
+  WHERE status IN ('expired', 'converted') AND expires_at < $1
+  LIMIT $2
+)
+`
+
+type DeleteFinishedSeatHoldsParams struct {
+	ExpiresAt pgtype.Timestamptz
+	Limit     int32
+}
+
+// Deletes up to $2 expired and converted holds whose expiry is before $1.
+func (q *Queries) DeleteFinishedSeatHolds(ctx context.Context, arg DeleteFinishedSeatHoldsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFinishedSeatHolds, arg.ExpiresAt, arg.Limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getActiveSeatHoldByToken = `-- name: GetActiveSeatHoldByToken :one
+SELECT id, hold_token, user_id, seat_ids, expires_at
+FROM seat_holds
+WHERE hold_token = $1 AND status = 'active' AND expires_at > now()
+`
+
+type GetActiveSeatHoldByTokenRow struct {
+	ID        pgtype.UUID
+	HoldToken string
+	UserID    pgtype.UUID
+	SeatIds   []pgtype.UUID
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) GetActiveSeatHoldByToken(ctx context.Context, holdToken string) (GetActiveSeatHoldByTokenRow, error) {
+	row := q.db.QueryRow(ctx, getActiveSeatHoldByToken, holdToken)
+	var i GetActiveSeatHoldByTokenRow
+	err := row.Scan(
+		&i.ID,
+		&i.HoldToken,
+		&i.UserID,
+		&i.SeatIds,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getActiveHoldsByUser = `-- name: GetActiveHoldsByUser :many
+SELECT sh.id, sh.hold_token, sh.event_id, sh.status, sh.expires_at, sh.created_at,
+  ARRAY(SELECT s.seat_no FROM seats s WHERE s.id = ANY(sh.seat_ids) ORDER BY s.seat_no)::text[] AS seat_nos
+FROM seat_holds sh
+WHERE sh.user_id = $1
+  AND ($2::boolean OR (sh.status = 'active' AND sh.expires_at > now()))
+ORDER BY sh.expires_at
+`
+
+type GetActiveHoldsByUserParams struct {
+	UserID  pgtype.UUID
+	Column2 bool
+}
+
+type GetActiveHoldsByUserRow struct {
+	ID        pgtype.UUID
+	HoldToken string
+	EventID   pgtype.UUID
+	Status    string
+	ExpiresAt pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+	SeatNos   []string
+}
+
+// The caller's live holds, soonest to expire first; $2 = true also returns
+// expired and converted ones.
+func (q *Queries) GetActiveHoldsByUser(ctx context.Context, arg GetActiveHoldsByUserParams) ([]GetActiveHoldsByUserRow, error) {
+	rows, err := q.db.Query(ctx, getActiveHoldsByUser, arg.UserID, arg.Column2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetActiveHoldsByUserRow
+	for rows.Next() {
+		var i GetActiveHoldsByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.HoldToken,
+			&i.EventID,
+			&i.Status,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.SeatNos,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getExpiredSeatHolds = `-- name: GetExpiredSeatHolds :many
 SELECT id, hold_token, event_id, seat_ids
 FROM seat_holds
@@ -50,8 +168,22 @@ func (q *Queries) GetExpiredSeatHolds(ctx context.Context) ([]GetExpiredSeatHold
 	return items, nil
 }
 
+const getSeatHoldSeatIDs = `-- name: GetSeatHoldSeatIDs :one
+SELECT seat_ids
+FROM seat_holds
+WHERE hold_token = $1
+`
+
+// The seats a hold covers, whatever its status.
+func (q *Queries) GetSeatHoldSeatIDs(ctx context.Context, holdToken string) ([]pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, getSeatHoldSeatIDs, holdToken)
+	var seat_ids []pgtype.UUID
+	err := row.Scan(&seat_ids)
+	return seat_ids, err
+}
+
 const getSeatsForEventForUpdate = `-- name: GetSeatsForEventForUpdate :many
-SELECT id, seat_no, status
+SELECT id, seat_no, status, hold_token
 FROM seats
 WHERE event_id = $1
     AND seat_no = ANY($2::text[])
@@ -65,9 +197,10 @@ type GetSeatsForEventForUpdateParams struct {
 }
 
 type GetSeatsForEventForUpdateRow struct {
-	ID     pgtype.UUID
-	SeatNo string
-	Status string
+	ID        pgtype.UUID
+	SeatNo    string
+	Status    string
+	HoldToken pgtype.Text
 }
 
 func (q *Queries) GetSeatsForEventForUpdate(ctx context.Context, arg GetSeatsForEventForUpdateParams) ([]GetSeatsForEventForUpdateRow, error) {
@@ -79,7 +212,12 @@ func (q *Queries) GetSeatsForEventForUpdate(ctx context.Context, arg GetSeatsFor
 	var items []GetSeatsForEventForUpdateRow
 	for rows.Next() {
 		var i GetSeatsForEventForUpdateRow
-		if err := rows.Scan(&i.ID, &i.SeatNo, &i.Status); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.SeatNo,
+			&i.Status,
+			&i.HoldToken,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -134,6 +272,29 @@ func (q *Queries) MarkSeatHoldExpired(ctx context.Context, id pgtype.UUID) error
 	return err
 }
 
+const updateHoldOwner = `-- name: UpdateHoldOwner :execrows
+UPDATE seat_holds
+SET user_id = $2, updated_at = now()
+WHERE hold_token = $1
+  AND status = 'active'
+  AND expires_at > now()
+`
+
+type UpdateHoldOwnerParams struct {
+	HoldToken string
+	UserID    pgtype.UUID
+}
+
+// Hands an active, unexpired hold to another user; no rows means it was
+// converted or expired meanwhile.
+func (q *Queries) UpdateHoldOwner(ctx context.Context, arg UpdateHoldOwnerParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateHoldOwner, arg.HoldToken, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateSeatsToAvailableByHold = `-- name: UpdateSeatsToAvailableByHold :exec
 UPDATE seats
 SET status = 'available',
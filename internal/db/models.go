@@ -8,28 +8,119 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AuditLog struct {
+	ID        int64
+	ActorID   pgtype.UUID
+	ActorRole pgtype.Text
+	Action    string
+	Target    pgtype.Text
+	Method    string
+	Path      string
+	Status    int32
+	CreatedAt pgtype.Timestamptz
+}
+
 type Booking struct {
-	ID             pgtype.UUID
-	EventID        pgtype.UUID
-	UserID         pgtype.UUID
-	Seats          int32
-	SeatIds        []pgtype.UUID
-	Status         string
-	IdempotencyKey pgtype.Text
-	CreatedAt      pgtype.Timestamptz
-	UpdatedAt      pgtype.Timestamptz
+	ID              pgtype.UUID
+	EventID         pgtype.UUID
+	UserID          pgtype.UUID
+	Seats           int32
+	SeatIds         []pgtype.UUID
+	Status          string
+	IdempotencyKey  pgtype.Text
+	CreatedAt       pgtype.Timestamptz
+	UpdatedAt       pgtype.Timestamptz
+	CheckedInAt     pgtype.Timestamptz
+	HoldToken       pgtype.Text
+	PaymentIntentID pgtype.Text
+	TokenVersion    int32
+	PaidAt          pgtype.Timestamptz
+	UnitPriceCents  int32
+	Currency        pgtype.Text
+}
+
+type BookingReminder struct {
+	BookingID pgtype.UUID
+	SentAt    pgtype.Timestamptz
+}
+
+type BookingSeat struct {
+	BookingID pgtype.UUID
+	SeatID    pgtype.UUID
+	Active    bool
+}
+
+type ConfirmationResend struct {
+	BookingID pgtype.UUID
+	SentAt    pgtype.Timestamptz
+}
+
+type EmailVerificationRequest struct {
+	UserID pgtype.UUID
+	SentAt pgtype.Timestamptz
+}
+
+type EmailVerificationToken struct {
+	ID        pgtype.UUID
+	UserID    pgtype.UUID
+	TokenHash string
+	ExpiresAt pgtype.Timestamptz
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
 }
 
 type Event struct {
-	ID          pgtype.UUID
-	Name        string
-	Venue       pgtype.Text
-	StartTime   pgtype.Timestamptz
-	Capacity    int32
-	BookedCount int32
-	Metadata    []byte
+	ID              pgtype.UUID
+	Name            string
+	Venue           pgtype.Text
+	StartTime       pgtype.Timestamptz
+	Capacity        int32
+	BookedCount     int32
+	Metadata        []byte
+	CreatedAt       pgtype.Timestamptz
+	UpdatedAt       pgtype.Timestamptz
+	DeletedAt       pgtype.Timestamptz
+	Version         int32
+	OwnerID         pgtype.UUID
+	Description     pgtype.Text
+	ImageUrl        pgtype.Text
+	WaitlistCap     pgtype.Int4
+	WaitlistOpen    bool
+	SenderName      pgtype.Text
+	ReplyTo         pgtype.Text
+	RequiresPayment bool
+	PriceCents      int32
+	Currency        pgtype.Text
+	OversellPercent int32
+}
+
+type IdempotencyKey struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	StatusCode  pgtype.Int4
+	Response    []byte
+	ExpiresAt   pgtype.Timestamptz
 	CreatedAt   pgtype.Timestamptz
-	UpdatedAt   pgtype.Timestamptz
+}
+
+type LoginAttempt struct {
+	Email           string
+	FailedCount     int32
+	WindowStartedAt pgtype.Timestamptz
+	LockedUntil     pgtype.Timestamptz
+}
+
+type MailOutbox struct {
+	ID            pgtype.UUID
+	Kind          string
+	Payload       []byte
+	Attempts      int32
+	LastError     pgtype.Text
+	Status        string
+	NextAttemptAt pgtype.Timestamptz
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
 }
 
 type Seat struct {
@@ -42,6 +133,23 @@ type Seat struct {
 	HoldToken     pgtype.Text
 	CreatedAt     pgtype.Timestamptz
 	UpdatedAt     pgtype.Timestamptz
+	RowLabel      pgtype.Text
+	SeatCol       pgtype.Int4
+	Section       string
+}
+
+type SeatEvent struct {
+	ID            int64
+	SeatID        pgtype.UUID
+	EventID       pgtype.UUID
+	OldStatus     pgtype.Text
+	NewStatus     string
+	BookingID     pgtype.UUID
+	HoldToken     pgtype.Text
+	ActorID       pgtype.UUID
+	Reason        pgtype.Text
+	CorrelationID pgtype.Text
+	CreatedAt     pgtype.Timestamptz
 }
 
 type SeatHold struct {
@@ -57,13 +165,25 @@ type SeatHold struct {
 }
 
 type User struct {
+	ID            pgtype.UUID
+	Name          string
+	Email         string
+	Password      string
+	Role          string
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	EmailVerified bool
+	Phone         pgtype.Text
+}
+
+type UserInvite struct {
 	ID        pgtype.UUID
-	Name      string
-	Email     string
-	Password  string
-	Role      string
+	UserID    pgtype.UUID
+	TokenHash string
+	InvitedBy pgtype.UUID
+	ExpiresAt pgtype.Timestamptz
+	UsedAt    pgtype.Timestamptz
 	CreatedAt pgtype.Timestamptz
-	UpdatedAt pgtype.Timestamptz
 }
 
 type Waitlist struct {
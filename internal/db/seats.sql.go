@@ -12,15 +12,16 @@ import (
 )
 
 const bulkInsertSeats = `-- name: BulkInsertSeats :many
-INSERT INTO seats (event_id, seat_no)
-SELECT $1, s FROM unnest($2::text[]) AS s
+INSERT INTO seats (event_id, seat_no, section)
+SELECT $1, s, $3 FROM unnest($2::text[]) AS s
 ON CONFLICT (event_id, seat_no) DO NOTHING
-RETURNING id, seat_no, status, booking_id, created_at, updated_at
+RETURNING id, seat_no, status, booking_id, created_at, updated_at, section
 `
 
 type BulkInsertSeatsParams struct {
 	EventID pgtype.UUID
 	Column2 []string
+	Section string
 }
 
 type BulkInsertSeatsRow struct {
@@ -30,11 +31,13 @@ type BulkInsertSeatsRow struct {
 	BookingID pgtype.UUID
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
+	Section   string
 }
 
-// Insert many seat_no values for an event. Do nothing on conflict (preserve existing seats).
+// Insert many seat_no values for an event into section $3. Do nothing on
+// conflict (preserve existing seats, whatever their section).
 func (q *Queries) BulkInsertSeats(ctx context.Context, arg BulkInsertSeatsParams) ([]BulkInsertSeatsRow, error) {
-	rows, err := q.db.Query(ctx, bulkInsertSeats, arg.EventID, arg.Column2)
+	rows, err := q.db.Query(ctx, bulkInsertSeats, arg.EventID, arg.Column2, arg.Section)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +52,138 @@ func (q *Queries) BulkInsertSeats(ctx context.Context, arg BulkInsertSeatsParams
 			&i.BookingID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Section,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countSeatsByEvent = `-- name: CountSeatsByEvent :one
+SELECT COUNT(*) FROM seats WHERE event_id = $1
+`
+
+func (q *Queries) CountSeatsByEvent(ctx context.Context, eventID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countSeatsByEvent, eventID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getAvailableSeatsByEvent = `-- name: GetAvailableSeatsByEvent :many
+SELECT id, seat_no, status, booking_id, created_at, updated_at, section
+FROM seats
+WHERE event_id = $1 AND status = 'available'
+  AND ($4::text = '' OR section = $4::text)
+ORDER BY section, seat_no
+LIMIT $2 OFFSET $3
+`
+
+type GetAvailableSeatsByEventParams struct {
+	EventID pgtype.UUID
+	Limit   int32
+	Offset  int32
+	Column4 string
+}
+
+type GetAvailableSeatsByEventRow struct {
+	ID        pgtype.UUID
+	SeatNo    string
+	Status    string
+	BookingID pgtype.UUID
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+	Section   string
+}
+
+// $4 limits the seats to one section; an empty string means all of them.
+func (q *Queries) GetAvailableSeatsByEvent(ctx context.Context, arg GetAvailableSeatsByEventParams) ([]GetAvailableSeatsByEventRow, error) {
+	rows, err := q.db.Query(ctx, getAvailableSeatsByEvent, arg.EventID, arg.Limit, arg.Offset, arg.Column4)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAvailableSeatsByEventRow
+	for rows.Next() {
+		var i GetAvailableSeatsByEventRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SeatNo,
+			&i.Status,
+			&i.BookingID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Section,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSeatByID = `-- name: GetSeatByID :one
+SELECT id, event_id, seat_no, status
+FROM seats
+WHERE id = $1
+`
+
+type GetSeatByIDRow struct {
+	ID      pgtype.UUID
+	EventID pgtype.UUID
+	SeatNo  string
+	Status  string
+}
+
+func (q *Queries) GetSeatByID(ctx context.Context, id pgtype.UUID) (GetSeatByIDRow, error) {
+	row := q.db.QueryRow(ctx, getSeatByID, id)
+	var i GetSeatByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.SeatNo,
+		&i.Status,
+	)
+	return i, err
+}
+
+const getSeatStatusCountsByEvent = `-- name: GetSeatStatusCountsByEvent :many
+SELECT event_id, section, status, COUNT(*)::int AS cnt
+FROM seats
+WHERE event_id = ANY($1::uuid[])
+GROUP BY event_id, section, status
+ORDER BY event_id, section
+`
+
+type GetSeatStatusCountsByEventRow struct {
+	EventID pgtype.UUID
+	Section string
+	Status  string
+	Cnt     int32
+}
+
+func (q *Queries) GetSeatStatusCountsByEvent(ctx context.Context, dollar_1 []pgtype.UUID) ([]GetSeatStatusCountsByEventRow, error) {
+	rows, err := q.db.Query(ctx, getSeatStatusCountsByEvent, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSeatStatusCountsByEventRow
+	for rows.Next() {
+		var i GetSeatStatusCountsByEventRow
+		if err := rows.Scan(
+			&i.EventID,
+			&i.Section,
+			&i.Status,
+			&i.Cnt,
 		); err != nil {
 			return nil, err
 		}
@@ -61,10 +196,10 @@ func (q *Queries) BulkInsertSeats(ctx context.Context, arg BulkInsertSeatsParams
 }
 
 const getSeatsByEvent = `-- name: GetSeatsByEvent :many
-SELECT id, seat_no, status, booking_id, created_at, updated_at
+SELECT id, seat_no, status, booking_id, created_at, updated_at, section
 FROM seats
 WHERE event_id = $1
-ORDER BY seat_no
+ORDER BY section, seat_no
 `
 
 type GetSeatsByEventRow struct {
@@ -74,6 +209,7 @@ type GetSeatsByEventRow struct {
 	BookingID pgtype.UUID
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
+	Section   string
 }
 
 func (q *Queries) GetSeatsByEvent(ctx context.Context, eventID pgtype.UUID) ([]GetSeatsByEventRow, error) {
@@ -92,6 +228,7 @@ func (q *Queries) GetSeatsByEvent(ctx context.Context, eventID pgtype.UUID) ([]G
 			&i.BookingID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Section,
 		); err != nil {
 			return nil, err
 		}
@@ -102,3 +239,41 @@ func (q *Queries) GetSeatsByEvent(ctx context.Context, eventID pgtype.UUID) ([]G
 	}
 	return items, nil
 }
+
+const listSeatNoNormalizationCandidates = `-- name: ListSeatNoNormalizationCandidates :many
+SELECT event_id, seat_no
+FROM seats
+WHERE seat_no ~ '[[:lower:][:cntrl:]]'
+   OR octet_length(seat_no) <> char_length(seat_no)
+   OR seat_no LIKE ' %'
+   OR seat_no LIKE '% '
+ORDER BY event_id, seat_no
+`
+
+type ListSeatNoNormalizationCandidatesRow struct {
+	EventID pgtype.UUID
+	SeatNo  string
+}
+
+// Seat numbers that normalizing could change, for the startup check: ones with
+// a lowercase letter, a control character, a non-ASCII character or an outer
+// space. Which of them actually change is decided in Go, as for requests.
+func (q *Queries) ListSeatNoNormalizationCandidates(ctx context.Context) ([]ListSeatNoNormalizationCandidatesRow, error) {
+	rows, err := q.db.Query(ctx, listSeatNoNormalizationCandidates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSeatNoNormalizationCandidatesRow
+	for rows.Next() {
+		var i ListSeatNoNormalizationCandidatesRow
+		if err := rows.Scan(&i.EventID, &i.SeatNo); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -11,17 +11,111 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const createUser = `-- name: CreateUser :one
-INSERT INTO users (name, email, password, role)
-VALUES ($1, $2, $3, $4)
+const acceptUserInvite = `-- name: AcceptUserInvite :one
+WITH t AS (
+  UPDATE user_invites
+  SET used_at = now()
+  WHERE token_hash = $1
+    AND used_at IS NULL
+    AND expires_at > now()
+  RETURNING user_id
+)
+UPDATE users u
+SET password = $2,
+    name = COALESCE(NULLIF($3::text, ''), u.name),
+    email_verified = true,
+    updated_at = now()
+FROM t
+WHERE u.id = t.user_id
+  AND u.password = ''
+RETURNING u.id, u.name, u.email, u.role, u.created_at, u.updated_at, u.phone
+`
+
+type AcceptUserInviteParams struct {
+	TokenHash string
+	Password  string
+	Column3   string
+}
+
+type AcceptUserInviteRow struct {
+	ID        pgtype.UUID
+	Name      string
+	Email     string
+	Role      string
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+	Phone     pgtype.Text
+}
+
+// Consumes an unused, unexpired invite token and activates its account with
+// the given password hash, and the name when one is given. Returns no row
+// when the token is invalid or the account already has a password.
+func (q *Queries) AcceptUserInvite(ctx context.Context, arg AcceptUserInviteParams) (AcceptUserInviteRow, error) {
+	row := q.db.QueryRow(ctx, acceptUserInvite, arg.TokenHash, arg.Password, arg.Column3)
+	var i AcceptUserInviteRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Phone,
+	)
+	return i, err
+}
+
+const createInvitedUser = `-- name: CreateInvitedUser :one
+INSERT INTO users (name, email, password, role, email_verified)
+VALUES ($1, $2, '', $3, false)
+ON CONFLICT (email) DO NOTHING
 RETURNING id, name, email, role, created_at, updated_at
 `
 
+type CreateInvitedUserParams struct {
+	Name  string
+	Email string
+	Role  string
+}
+
+type CreateInvitedUserRow struct {
+	ID        pgtype.UUID
+	Name      string
+	Email     string
+	Role      string
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+// Creates a placeholder account for an invite, with no usable password.
+// Returns no row when the email is already taken.
+func (q *Queries) CreateInvitedUser(ctx context.Context, arg CreateInvitedUserParams) (CreateInvitedUserRow, error) {
+	row := q.db.QueryRow(ctx, createInvitedUser, arg.Name, arg.Email, arg.Role)
+	var i CreateInvitedUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (name, email, password, role, email_verified, phone)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, name, email, role, created_at, updated_at, phone
+`
+
 type CreateUserParams struct {
-	Name     string
-	Email    string
-	Password string
-	Role     string
+	Name          string
+	Email         string
+	Password      string
+	Role          string
+	EmailVerified bool
+	Phone         pgtype.Text
 }
 
 type CreateUserRow struct {
@@ -31,6 +125,7 @@ type CreateUserRow struct {
 	Role      string
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
+	Phone     pgtype.Text
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
@@ -39,6 +134,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateU
 		arg.Email,
 		arg.Password,
 		arg.Role,
+		arg.EmailVerified,
+		arg.Phone,
 	)
 	var i CreateUserRow
 	err := row.Scan(
@@ -48,12 +145,26 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateU
 		&i.Role,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Phone,
 	)
 	return i, err
 }
 
+const expireUserInvites = `-- name: ExpireUserInvites :exec
+UPDATE user_invites
+SET expires_at = now()
+WHERE user_id = $1
+  AND used_at IS NULL
+  AND expires_at > now()
+`
+
+func (q *Queries) ExpireUserInvites(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, expireUserInvites, userID)
+	return err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, password, role, created_at, updated_at
+SELECT id, name, email, password, role, created_at, updated_at, email_verified, phone
 FROM users
 WHERE email = $1
 `
@@ -69,12 +180,14 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Role,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailVerified,
+		&i.Phone,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email
+SELECT id, name, email, phone
 FROM users
 WHERE id = $1
 `
@@ -83,11 +196,131 @@ type GetUserByIDRow struct {
 	ID    pgtype.UUID
 	Name  string
 	Email string
+	Phone pgtype.Text
 }
 
 func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (GetUserByIDRow, error) {
 	row := q.db.QueryRow(ctx, getUserByID, id)
 	var i GetUserByIDRow
-	err := row.Scan(&i.ID, &i.Name, &i.Email)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+	)
 	return i, err
 }
+
+const isUserEmailVerified = `-- name: IsUserEmailVerified :one
+SELECT email_verified
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) IsUserEmailVerified(ctx context.Context, id pgtype.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, isUserEmailVerified, id)
+	var email_verified bool
+	err := row.Scan(&email_verified)
+	return email_verified, err
+}
+
+const replaceEmailVerificationToken = `-- name: ReplaceEmailVerificationToken :exec
+WITH expired AS (
+  UPDATE email_verification_tokens
+  SET expires_at = now()
+  WHERE user_id = $1
+    AND used_at IS NULL
+    AND expires_at > now()
+)
+INSERT INTO email_verification_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+`
+
+type ReplaceEmailVerificationTokenParams struct {
+	UserID    pgtype.UUID
+	TokenHash string
+	ExpiresAt pgtype.Timestamptz
+}
+
+// Stores a new token for the user and expires the unused ones sent before
+// it, so only the latest link works.
+func (q *Queries) ReplaceEmailVerificationToken(ctx context.Context, arg ReplaceEmailVerificationTokenParams) error {
+	_, err := q.db.Exec(ctx, replaceEmailVerificationToken, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	return err
+}
+
+const replaceUserInvite = `-- name: ReplaceUserInvite :exec
+WITH expired AS (
+  UPDATE user_invites
+  SET expires_at = now()
+  WHERE user_id = $1
+    AND used_at IS NULL
+    AND expires_at > now()
+)
+INSERT INTO user_invites (user_id, token_hash, invited_by, expires_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type ReplaceUserInviteParams struct {
+	UserID    pgtype.UUID
+	TokenHash string
+	InvitedBy pgtype.UUID
+	ExpiresAt pgtype.Timestamptz
+}
+
+// Stores a new invite for the user and expires the unused ones sent before
+// it, so only the latest link works.
+func (q *Queries) ReplaceUserInvite(ctx context.Context, arg ReplaceUserInviteParams) error {
+	_, err := q.db.Exec(ctx, replaceUserInvite,
+		arg.UserID,
+		arg.TokenHash,
+		arg.InvitedBy,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const updateInvitedUserRole = `-- name: UpdateInvitedUserRole :execrows
+UPDATE users
+SET role = $2, updated_at = now()
+WHERE id = $1
+  AND password = ''
+`
+
+type UpdateInvitedUserRoleParams struct {
+	ID   pgtype.UUID
+	Role string
+}
+
+// Changes the role of an account whose invite hasn't been accepted yet.
+func (q *Queries) UpdateInvitedUserRole(ctx context.Context, arg UpdateInvitedUserRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateInvitedUserRole, arg.ID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const verifyEmailToken = `-- name: VerifyEmailToken :one
+WITH t AS (
+  UPDATE email_verification_tokens
+  SET used_at = now()
+  WHERE token_hash = $1
+    AND used_at IS NULL
+    AND expires_at > now()
+  RETURNING user_id
+)
+UPDATE users u
+SET email_verified = true, updated_at = now()
+FROM t
+WHERE u.id = t.user_id
+RETURNING u.id
+`
+
+// Consumes an unused, unexpired token and marks its user verified.
+func (q *Queries) VerifyEmailToken(ctx context.Context, tokenHash string) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, verifyEmailToken, tokenHash)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
@@ -11,28 +11,116 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countOtherWaitingByEvent = `-- name: CountOtherWaitingByEvent :one
+SELECT COUNT(*)::int
+FROM waitlist
+WHERE event_id = $1 AND status = 'waiting' AND user_id <> $2
+`
+
+type CountOtherWaitingByEventParams struct {
+	EventID pgtype.UUID
+	UserID  pgtype.UUID
+}
+
+func (q *Queries) CountOtherWaitingByEvent(ctx context.Context, arg CountOtherWaitingByEventParams) (int32, error) {
+	row := q.db.QueryRow(ctx, countOtherWaitingByEvent, arg.EventID, arg.UserID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const getAvailableSeatsForEvent = `-- name: GetAvailableSeatsForEvent :many
+SELECT id, seat_no, row_label, seat_col, section
+FROM seats
+WHERE event_id = $1
+    AND status = 'available'
+    AND ($3::text = '' OR section = $3::text)
+ORDER BY section,
+    length(substring(seat_no FROM '^[^0-9]*')),
+    substring(seat_no FROM '^[^0-9]*'),
+    lpad(coalesce(substring(seat_no FROM '[0-9]+'), ''), 20, '0'),
+    seat_no
+LIMIT $2
+`
+
+type GetAvailableSeatsForEventParams struct {
+	EventID pgtype.UUID
+	Limit   int32
+	Column3 string
+}
+
+type GetAvailableSeatsForEventRow struct {
+	ID       pgtype.UUID
+	SeatNo   string
+	RowLabel pgtype.Text
+	SeatCol  pgtype.Int4
+	Section  string
+}
+
+// GetAvailableSeatsForEventForUpdate without the locks, for looking through
+// many seats before locking the few that are picked.
+func (q *Queries) GetAvailableSeatsForEvent(ctx context.Context, arg GetAvailableSeatsForEventParams) ([]GetAvailableSeatsForEventRow, error) {
+	rows, err := q.db.Query(ctx, getAvailableSeatsForEvent, arg.EventID, arg.Limit, arg.Column3)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAvailableSeatsForEventRow
+	for rows.Next() {
+		var i GetAvailableSeatsForEventRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SeatNo,
+			&i.RowLabel,
+			&i.SeatCol,
+			&i.Section,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAvailableSeatsForEventForUpdate = `-- name: GetAvailableSeatsForEventForUpdate :many
-SELECT id, seat_no
+SELECT id, seat_no, row_label, seat_col, section
 FROM seats
 WHERE event_id = $1
     AND status = 'available'
-ORDER BY id
+    AND ($3::text = '' OR section = $3::text)
+ORDER BY section,
+    length(substring(seat_no FROM '^[^0-9]*')),
+    substring(seat_no FROM '^[^0-9]*'),
+    lpad(coalesce(substring(seat_no FROM '[0-9]+'), ''), 20, '0'),
+    seat_no
 LIMIT $2
-FOR UPDATE
+FOR UPDATE SKIP LOCKED
 `
 
 type GetAvailableSeatsForEventForUpdateParams struct {
 	EventID pgtype.UUID
 	Limit   int32
+	Column3 string
 }
 
 type GetAvailableSeatsForEventForUpdateRow struct {
-	ID     pgtype.UUID
-	SeatNo string
+	ID       pgtype.UUID
+	SeatNo   string
+	RowLabel pgtype.Text
+	SeatCol  pgtype.Int4
+	Section  string
 }
 
+// Free seats by section, then in natural order (A2 before A10, Z before
+// AA), so the first few tend to sit together. $3 limits them to one
+// section; an empty string means any. Seats other transactions have locked
+// are skipped rather than waited for: waiting on locks taken in seat order
+// while other paths lock by id could deadlock.
 func (q *Queries) GetAvailableSeatsForEventForUpdate(ctx context.Context, arg GetAvailableSeatsForEventForUpdateParams) ([]GetAvailableSeatsForEventForUpdateRow, error) {
-	rows, err := q.db.Query(ctx, getAvailableSeatsForEventForUpdate, arg.EventID, arg.Limit)
+	rows, err := q.db.Query(ctx, getAvailableSeatsForEventForUpdate, arg.EventID, arg.Limit, arg.Column3)
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +128,66 @@ func (q *Queries) GetAvailableSeatsForEventForUpdate(ctx context.Context, arg Ge
 	var items []GetAvailableSeatsForEventForUpdateRow
 	for rows.Next() {
 		var i GetAvailableSeatsForEventForUpdateRow
-		if err := rows.Scan(&i.ID, &i.SeatNo); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.SeatNo,
+			&i.RowLabel,
+			&i.SeatCol,
+			&i.Section,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventWaitlistSettingsForUpdate = `-- name: GetEventWaitlistSettingsForUpdate :one
+SELECT waitlist_cap, waitlist_open, deleted_at
+FROM events
+WHERE id = $1
+FOR UPDATE
+`
+
+type GetEventWaitlistSettingsForUpdateRow struct {
+	WaitlistCap  pgtype.Int4
+	WaitlistOpen bool
+	DeletedAt    pgtype.Timestamptz
+}
+
+// Locks the event row so concurrent joins are checked against the cap one at a time.
+func (q *Queries) GetEventWaitlistSettingsForUpdate(ctx context.Context, id pgtype.UUID) (GetEventWaitlistSettingsForUpdateRow, error) {
+	row := q.db.QueryRow(ctx, getEventWaitlistSettingsForUpdate, id)
+	var i GetEventWaitlistSettingsForUpdateRow
+	err := row.Scan(&i.WaitlistCap, &i.WaitlistOpen, &i.DeletedAt)
+	return i, err
+}
+
+const getWaitingCountsByEvent = `-- name: GetWaitingCountsByEvent :many
+SELECT event_id, COUNT(*)::int AS waiting
+FROM waitlist
+WHERE event_id = ANY($1::uuid[]) AND status = 'waiting'
+GROUP BY event_id
+`
+
+type GetWaitingCountsByEventRow struct {
+	EventID pgtype.UUID
+	Waiting int32
+}
+
+func (q *Queries) GetWaitingCountsByEvent(ctx context.Context, dollar_1 []pgtype.UUID) ([]GetWaitingCountsByEventRow, error) {
+	rows, err := q.db.Query(ctx, getWaitingCountsByEvent, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWaitingCountsByEventRow
+	for rows.Next() {
+		var i GetWaitingCountsByEventRow
+		if err := rows.Scan(&i.EventID, &i.Waiting); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -96,34 +243,61 @@ func (q *Queries) GetWaitingListByEvent(ctx context.Context, eventID pgtype.UUID
 	return items, nil
 }
 
-const insertWaitlist = `-- name: InsertWaitlist :one
-INSERT INTO waitlist (event_id, user_id, requested_seats, position, status)
-VALUES (
-    $1,
-    $2,
-    $3,
-    (SELECT COALESCE(MAX(position), 0) + 1 FROM waitlist WHERE event_id = $1),
-    'waiting'
-)
-RETURNING id, position, created_at
+const lockAvailableSeatsByIDs = `-- name: LockAvailableSeatsByIDs :many
+SELECT id
+FROM seats
+WHERE id = ANY($1::uuid[])
+    AND status = 'available'
+ORDER BY id
+FOR UPDATE SKIP LOCKED
 `
 
-type InsertWaitlistParams struct {
-	EventID        pgtype.UUID
-	UserID         pgtype.UUID
-	RequestedSeats int32
+// Locks those of the seats that are still free, in id order like the other
+// seat locks, and returns their ids. Seats locked elsewhere are skipped.
+func (q *Queries) LockAvailableSeatsByIDs(ctx context.Context, dollar_1 []pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, lockAvailableSeatsByIDs, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setEventWaitlistSettings = `-- name: SetEventWaitlistSettings :one
+UPDATE events
+SET waitlist_cap = $2,
+    waitlist_open = $3
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, waitlist_cap, waitlist_open
+`
+
+type SetEventWaitlistSettingsParams struct {
+	ID           pgtype.UUID
+	WaitlistCap  pgtype.Int4
+	WaitlistOpen bool
 }
 
-type InsertWaitlistRow struct {
-	ID        pgtype.UUID
-	Position  int64
-	CreatedAt pgtype.Timestamptz
+type SetEventWaitlistSettingsRow struct {
+	ID           pgtype.UUID
+	WaitlistCap  pgtype.Int4
+	WaitlistOpen bool
 }
 
-func (q *Queries) InsertWaitlist(ctx context.Context, arg InsertWaitlistParams) (InsertWaitlistRow, error) {
-	row := q.db.QueryRow(ctx, insertWaitlist, arg.EventID, arg.UserID, arg.RequestedSeats)
-	var i InsertWaitlistRow
-	err := row.Scan(&i.ID, &i.Position, &i.CreatedAt)
+func (q *Queries) SetEventWaitlistSettings(ctx context.Context, arg SetEventWaitlistSettingsParams) (SetEventWaitlistSettingsRow, error) {
+	row := q.db.QueryRow(ctx, setEventWaitlistSettings, arg.ID, arg.WaitlistCap, arg.WaitlistOpen)
+	var i SetEventWaitlistSettingsRow
+	err := row.Scan(&i.ID, &i.WaitlistCap, &i.WaitlistOpen)
 	return i, err
 }
 
@@ -142,3 +316,52 @@ func (q *Queries) UpdateWaitlistStatus(ctx context.Context, arg UpdateWaitlistSt
 	_, err := q.db.Exec(ctx, updateWaitlistStatus, arg.ID, arg.Status)
 	return err
 }
+
+const upsertWaitlistEntry = `-- name: UpsertWaitlistEntry :one
+INSERT INTO waitlist (event_id, user_id, requested_seats, position, status)
+VALUES (
+    $1,
+    $2,
+    $3,
+    (SELECT COALESCE(MAX(position), 0) + 1 FROM waitlist WHERE event_id = $1),
+    'waiting'
+)
+ON CONFLICT (event_id, user_id) DO UPDATE
+SET requested_seats = EXCLUDED.requested_seats,
+    position = CASE
+        WHEN EXCLUDED.requested_seats > waitlist.requested_seats THEN EXCLUDED.position
+        ELSE waitlist.position
+    END
+WHERE waitlist.status = 'waiting'
+RETURNING id, position, requested_seats, created_at, (xmax = 0) AS inserted
+`
+
+type UpsertWaitlistEntryParams struct {
+	EventID        pgtype.UUID
+	UserID         pgtype.UUID
+	RequestedSeats int32
+}
+
+type UpsertWaitlistEntryRow struct {
+	ID             pgtype.UUID
+	Position       int64
+	RequestedSeats int32
+	CreatedAt      pgtype.Timestamptz
+	Inserted       bool
+}
+
+// Joins the waitlist, or changes requested_seats on the caller's waiting entry.
+// Asking for more seats moves the entry to the back; asking for fewer keeps its
+// place. Entries no longer waiting are left alone and no row is returned.
+func (q *Queries) UpsertWaitlistEntry(ctx context.Context, arg UpsertWaitlistEntryParams) (UpsertWaitlistEntryRow, error) {
+	row := q.db.QueryRow(ctx, upsertWaitlistEntry, arg.EventID, arg.UserID, arg.RequestedSeats)
+	var i UpsertWaitlistEntryRow
+	err := row.Scan(
+		&i.ID,
+		&i.Position,
+		&i.RequestedSeats,
+		&i.CreatedAt,
+		&i.Inserted,
+	)
+	return i, err
+}
@@ -0,0 +1,131 @@
+// Package idempotency stores idempotency keys together with the response the
+// original request produced, so retried requests can be replayed safely.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultTTL is how long a key (and its stored response) is honoured.
+const DefaultTTL = 24 * time.Hour
+
+// DefaultLease is how long a claim may stay unfinished before another request
+// with the key takes it over. A handler that panics or returns without
+// committing would otherwise block the key for the whole TTL.
+const DefaultLease = 5 * time.Minute
+
+var (
+	// ErrFingerprintMismatch is returned when a key is reused with a different request body.
+	ErrFingerprintMismatch = errors.New("idempotency key reused with a different request")
+	// ErrInProgress is returned when the original request for a key has not
+	// finished yet and its lease has not run out.
+	ErrInProgress = errors.New("request with this idempotency key is still in progress")
+)
+
+// Response is a stored response to replay for a repeated key.
+type Response struct {
+	StatusCode int
+	Body       []byte
+}
+
+// CommitFunc records the final response of the request that claimed a key.
+// Server errors (5xx) release the key instead so the client can retry.
+type CommitFunc func(ctx context.Context, statusCode int, body any) error
+
+// Store is backed by the idempotency_keys table.
+type Store struct {
+	db    *db.Queries
+	TTL   time.Duration
+	Lease time.Duration
+}
+
+// NewStore creates a store with DefaultTTL and DefaultLease.
+func NewStore(dbconn *pgxpool.Pool) *Store {
+	return &Store{
+		db:    db.New(dbconn),
+		TTL:   DefaultTTL,
+		Lease: DefaultLease,
+	}
+}
+
+// Fingerprint hashes a request body for comparison across retries.
+func Fingerprint(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Ensure claims key within scope for a request whose body hashes to fingerprint.
+//
+// If an earlier request with the same key and fingerprint already completed, its
+// response is returned for replay and commit is nil. Otherwise the caller now owns
+// the key and must call commit with the final response. A different fingerprint
+// yields ErrFingerprintMismatch; a claim still in flight yields ErrInProgress,
+// until it is older than Lease and this request takes it over. The stale
+// claim's commit then no longer applies.
+func (s *Store) Ensure(ctx context.Context, key, scope, fingerprint string) (*Response, CommitFunc, error) {
+	// Two attempts: a competing claim may be released (5xx) between our claim and lookup.
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		claim, err := s.db.ClaimIdempotencyKey(ctx, db.ClaimIdempotencyKeyParams{
+			Scope:       scope,
+			Key:         key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   pgtype.Timestamptz{Time: now.Add(s.TTL), Valid: true},
+			CreatedAt:   pgtype.Timestamptz{Time: now.Add(-s.Lease), Valid: true},
+		})
+		if err == nil {
+			return nil, s.commitFunc(scope, key, claim.CreatedAt), nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, fmt.Errorf("claim idempotency key: %w", err)
+		}
+
+		existing, err := s.db.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{Scope: scope, Key: key})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("load idempotency key: %w", err)
+		}
+
+		if existing.Fingerprint != fingerprint {
+			return nil, nil, ErrFingerprintMismatch
+		}
+		if !existing.StatusCode.Valid {
+			return nil, nil, ErrInProgress
+		}
+		return &Response{StatusCode: int(existing.StatusCode.Int32), Body: existing.Response}, nil, nil
+	}
+	return nil, nil, ErrInProgress
+}
+
+func (s *Store) commitFunc(scope, key string, claimedAt pgtype.Timestamptz) CommitFunc {
+	return func(ctx context.Context, statusCode int, body any) error {
+		if statusCode >= 500 {
+			return s.db.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{Scope: scope, Key: key, CreatedAt: claimedAt})
+		}
+
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal idempotent response: %w", err)
+		}
+		return s.db.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+			Scope:      scope,
+			Key:        key,
+			StatusCode: pgtype.Int4{Int32: int32(statusCode), Valid: true},
+			Response:   payload,
+			CreatedAt:  claimedAt,
+		})
+	}
+}
@@ -0,0 +1,60 @@
+//go:build integration
+
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/abhinandanwadwa/overbookr/internal/testdb"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+func TestEnsureTakesOverAnAbandonedClaim(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	s := NewStore(pool)
+	fp := Fingerprint([]byte(`{"seat_nos":["A1"]}`))
+
+	_, abandoned, err := s.Ensure(ctx, "key-1", "scope", fp)
+	if err != nil || abandoned == nil {
+		t.Fatalf("first claim: commit %v, err %v", abandoned != nil, err)
+	}
+	if _, _, err := s.Ensure(ctx, "key-1", "scope", fp); !errors.Is(err, ErrInProgress) {
+		t.Fatalf("retry within the lease: err %v, want ErrInProgress", err)
+	}
+
+	// The first request never commits; once its lease is over a retry owns the key.
+	if _, err := pool.Exec(ctx, `UPDATE idempotency_keys SET created_at = now() - interval '1 hour' WHERE key = 'key-1'`); err != nil {
+		t.Fatalf("age claim: %v", err)
+	}
+	_, commit, err := s.Ensure(ctx, "key-1", "scope", fp)
+	if err != nil || commit == nil {
+		t.Fatalf("retry after the lease: commit %v, err %v", commit != nil, err)
+	}
+
+	// The abandoned request finishing late doesn't overwrite the new owner.
+	if err := abandoned(ctx, http.StatusConflict, map[string]string{"from": "abandoned"}); err != nil {
+		t.Fatalf("late commit: %v", err)
+	}
+	if err := commit(ctx, http.StatusCreated, map[string]string{"from": "retry"}); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	replay, _, err := s.Ensure(ctx, "key-1", "scope", fp)
+	if err != nil || replay == nil {
+		t.Fatalf("replay: %v, err %v", replay, err)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(replay.Body, &body); err != nil {
+		t.Fatalf("decode replay: %v", err)
+	}
+	if replay.StatusCode != http.StatusCreated || body["from"] != "retry" {
+		t.Errorf("replay = %d %s, want 201 from the retry", replay.StatusCode, replay.Body)
+	}
+}
@@ -0,0 +1,106 @@
+// Package money formats prices. Amounts are kept in the currency's minor
+// units (cents for USD, whole yen for JPY) as int64 and only turned into
+// strings at the edges: API responses and emails.
+package money
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultBaseCurrency is used for events without a currency when
+// BASE_CURRENCY is unset.
+const DefaultBaseCurrency = "USD"
+
+// currency describes one ISO 4217 currency.
+type currency struct {
+	// exponent is the number of minor-unit digits: 2 for USD, 0 for JPY.
+	exponent int
+	// symbol is written instead of the code when set.
+	symbol string
+}
+
+// currencies lists the supported ISO 4217 codes. Codes missing here are
+// rejected on events.
+var currencies = map[string]currency{
+	"AED": {2, ""},
+	"ARS": {2, ""},
+	"AUD": {2, "A$"},
+	"BDT": {2, "৳"},
+	"BGN": {2, ""},
+	"BHD": {3, ""},
+	"BRL": {2, "R$"},
+	"CAD": {2, "CA$"},
+	"CHF": {2, ""},
+	"CLP": {0, ""},
+	"CNY": {2, "CN¥"},
+	"COP": {2, ""},
+	"CZK": {2, ""},
+	"DKK": {2, ""},
+	"EGP": {2, ""},
+	"EUR": {2, "€"},
+	"GBP": {2, "£"},
+	"HKD": {2, "HK$"},
+	"HUF": {2, ""},
+	"IDR": {2, ""},
+	"ILS": {2, "₪"},
+	"INR": {2, "₹"},
+	"ISK": {0, ""},
+	"JOD": {3, ""},
+	"JPY": {0, "¥"},
+	"KES": {2, ""},
+	"KRW": {0, "₩"},
+	"KWD": {3, ""},
+	"LKR": {2, ""},
+	"MAD": {2, ""},
+	"MXN": {2, "MX$"},
+	"MYR": {2, ""},
+	"NGN": {2, "₦"},
+	"NOK": {2, ""},
+	"NPR": {2, ""},
+	"NZD": {2, "NZ$"},
+	"OMR": {3, ""},
+	"PEN": {2, ""},
+	"PHP": {2, "₱"},
+	"PKR": {2, ""},
+	"PLN": {2, ""},
+	"QAR": {2, ""},
+	"RON": {2, ""},
+	"SAR": {2, ""},
+	"SEK": {2, ""},
+	"SGD": {2, "S$"},
+	"THB": {2, "฿"},
+	"TND": {3, ""},
+	"TRY": {2, "₺"},
+	"TWD": {2, "NT$"},
+	"UAH": {2, "₴"},
+	"UGX": {0, ""},
+	"USD": {2, "$"},
+	"VND": {0, "₫"},
+	"ZAR": {2, ""},
+}
+
+// NormalizeCurrency upper-cases code and checks that it is a supported
+// ISO 4217 currency.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := currencies[code]; !ok {
+		return "", fmt.Errorf("unsupported currency %q: want an ISO 4217 code such as USD or EUR", code)
+	}
+	return code, nil
+}
+
+// BaseCurrencyFromEnv reads BASE_CURRENCY, the currency of events that don't
+// set one. Unset means DefaultBaseCurrency.
+func BaseCurrencyFromEnv() (string, error) {
+	raw := os.Getenv("BASE_CURRENCY")
+	if strings.TrimSpace(raw) == "" {
+		return DefaultBaseCurrency, nil
+	}
+	code, err := NormalizeCurrency(raw)
+	if err != nil {
+		return "", fmt.Errorf("BASE_CURRENCY: %w", err)
+	}
+	return code, nil
+}
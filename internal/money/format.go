@@ -0,0 +1,153 @@
+package money
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Locale holds the number conventions prices are written with.
+type Locale struct {
+	group   string // thousands separator
+	decimal string
+	// symbolAfter writes "12,50 €" rather than "€12.50".
+	symbolAfter bool
+	// space separates the symbol or code from the number.
+	space bool
+}
+
+// nbsp keeps an amount and its symbol on one line.
+const nbsp = "\u00a0"
+
+// DefaultLocale writes "$1,234.50".
+var DefaultLocale = locales["en"]
+
+// locales is keyed by language; regional tags such as "de-AT" use their
+// language's conventions.
+var locales = map[string]Locale{
+	"de": {group: ".", decimal: ",", symbolAfter: true, space: true},
+	"en": {group: ",", decimal: "."},
+	"es": {group: ".", decimal: ",", symbolAfter: true, space: true},
+	"fr": {group: nbsp, decimal: ",", symbolAfter: true, space: true},
+	"it": {group: ".", decimal: ",", symbolAfter: true, space: true},
+	"ja": {group: ",", decimal: "."},
+	"nl": {group: ".", decimal: ",", space: true},
+	"pt": {group: ".", decimal: ",", space: true},
+}
+
+// ParseLocale looks up a BCP 47 tag such as "en-US" or "fr".
+func ParseLocale(tag string) (Locale, error) {
+	lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	l, ok := locales[lang]
+	if !ok {
+		return Locale{}, fmt.Errorf("unsupported locale %q", tag)
+	}
+	return l, nil
+}
+
+// LocaleFromEnv reads PRICE_LOCALE. Unset means DefaultLocale.
+func LocaleFromEnv() (Locale, error) {
+	raw := os.Getenv("PRICE_LOCALE")
+	if strings.TrimSpace(raw) == "" {
+		return DefaultLocale, nil
+	}
+	l, err := ParseLocale(raw)
+	if err != nil {
+		return Locale{}, fmt.Errorf("PRICE_LOCALE: %w", err)
+	}
+	return l, nil
+}
+
+// Format writes amount minor units of the currency code, e.g. 123450 USD as
+// "$1,234.50" in English or "1.234,50 $" in German. Currencies without a
+// symbol are written with their code: "CHF 12.00".
+func (l Locale) Format(amount int64, code string) string {
+	cur, ok := currencies[code]
+	if !ok {
+		// Not a code we validated; write it out with two decimals.
+		cur = currency{exponent: 2}
+	}
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if len(digits) <= cur.exponent {
+		digits = strings.Repeat("0", cur.exponent-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-cur.exponent], digits[len(digits)-cur.exponent:]
+	number := groupThousands(whole, l.group)
+	if frac != "" {
+		number += l.decimal + frac
+	}
+
+	symbol, space := cur.symbol, l.space
+	if symbol == "" {
+		symbol, space = code, true
+	}
+	sep := ""
+	if space {
+		sep = nbsp
+	}
+	if l.symbolAfter {
+		return sign + number + sep + symbol
+	}
+	return sign + symbol + sep + number
+}
+
+// groupThousands inserts sep between each group of three digits.
+func groupThousands(digits, sep string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// Bill itemizes what a booking costs: one line per seat and the total, in
+// minor units of Currency and formatted.
+type Bill struct {
+	Currency   string     `json:"currency"`
+	Lines      []BillLine `json:"lines"`
+	TotalCents int64      `json:"total_cents"`
+	Total      string     `json:"total"`
+}
+
+type BillLine struct {
+	SeatNo      string `json:"seat_no"`
+	AmountCents int64  `json:"amount_cents"`
+	Amount      string `json:"amount"`
+}
+
+// PerSeat bills each of seatNos at unit minor units of currency, formatted
+// for l. It returns nil when unit is 0, i.e. the seats are free.
+func (l Locale) PerSeat(seatNos []string, unit int64, currency string) *Bill {
+	if unit == 0 {
+		return nil
+	}
+	bill := &Bill{Currency: currency, Lines: make([]BillLine, 0, len(seatNos))}
+	for _, no := range seatNos {
+		bill.Lines = append(bill.Lines, BillLine{
+			SeatNo:      no,
+			AmountCents: unit,
+			Amount:      l.Format(unit, currency),
+		})
+		bill.TotalCents += unit
+	}
+	bill.Total = l.Format(bill.TotalCents, currency)
+	return bill
+}
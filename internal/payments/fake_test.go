@@ -0,0 +1,110 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// fakeSignature is the Fake-Signature header for payload.
+func fakeSignature(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestFakeWebhookOutcomes(t *testing.T) {
+	f := &Fake{WebhookSecret: testWebhookSecret}
+	intent, err := f.CreateIntent(context.Background(), 1000, "usd", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(intent.ID, "pi_fake_") {
+		t.Fatalf("intent id = %q", intent.ID)
+	}
+
+	tests := []struct {
+		typ  string
+		want Outcome
+	}{
+		{"payment_intent.succeeded", OutcomeSucceeded},
+		{"payment_intent.payment_failed", OutcomeFailed},
+		{"payment_intent.canceled", OutcomeFailed},
+		{"payment_intent.created", OutcomeOther},
+		{"charge.refunded", OutcomeOther},
+	}
+	for _, tt := range tests {
+		payload := intentPayload(tt.typ, intent.ID)
+		h := http.Header{}
+		h.Set("Fake-Signature", fakeSignature(testWebhookSecret, payload))
+		ev, err := f.ParseWebhook(payload, h)
+		if err != nil {
+			t.Errorf("%s: %v", tt.typ, err)
+			continue
+		}
+		if ev.Outcome != tt.want || ev.Type != tt.typ || ev.IntentID != intent.ID {
+			t.Errorf("%s: event = %+v, want outcome %d", tt.typ, ev, tt.want)
+		}
+	}
+}
+
+func TestFakeWebhookSignature(t *testing.T) {
+	f := &Fake{WebhookSecret: testWebhookSecret}
+	payload := intentPayload("payment_intent.succeeded", "pi_fake_1")
+	for name, sig := range map[string]string{
+		"wrong secret": fakeSignature("other", payload),
+		"not hex":      "zz",
+		"missing":      "",
+	} {
+		h := http.Header{}
+		h.Set("Fake-Signature", sig)
+		if _, err := f.ParseWebhook(payload, h); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: err = %v, want ErrInvalidSignature", name, err)
+		}
+	}
+}
+
+func TestWebhookWithoutIntentID(t *testing.T) {
+	if _, err := decodeIntentEvent([]byte(`{"type":"payment_intent.succeeded","data":{"object":{}}}`)); err == nil {
+		t.Fatal("succeeded webhook without an intent id was accepted")
+	}
+}
+
+func TestNewProviderFromEnv(t *testing.T) {
+	tests := []struct {
+		env     map[string]string
+		want    string // provider type, "" for none
+		wantErr bool
+	}{
+		{map[string]string{}, "", false},
+		{map[string]string{"PAYMENT_PROVIDER": "none"}, "", false},
+		{map[string]string{"PAYMENT_PROVIDER": "fake", "FAKE_PAYMENT_WEBHOOK_SECRET": "s"}, "*payments.Fake", false},
+		{map[string]string{"PAYMENT_PROVIDER": "fake"}, "", true},
+		{map[string]string{"PAYMENT_PROVIDER": "Stripe", "STRIPE_SECRET_KEY": "sk", "STRIPE_WEBHOOK_SECRET": "wh"}, "*payments.Stripe", false},
+		{map[string]string{"PAYMENT_PROVIDER": "stripe", "STRIPE_SECRET_KEY": "sk"}, "", true},
+		{map[string]string{"PAYMENT_PROVIDER": "paypal"}, "", true},
+	}
+	for _, tt := range tests {
+		for _, k := range []string{"PAYMENT_PROVIDER", "FAKE_PAYMENT_WEBHOOK_SECRET", "STRIPE_SECRET_KEY", "STRIPE_WEBHOOK_SECRET"} {
+			t.Setenv(k, tt.env[k])
+		}
+		p, err := NewProviderFromEnv()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: err = %v", tt.env, err)
+			continue
+		}
+		got := ""
+		if p != nil {
+			got = fmt.Sprintf("%T", p)
+		}
+		if got != tt.want {
+			t.Errorf("%v: provider = %s, want %s", tt.env, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Package payments talks to the card payment provider for events that
+// require payment: it opens a payment intent for a pending booking and
+// verifies the webhooks that report how the payment went.
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSignature is returned for webhooks that fail signature checks.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Intent is a payment opened with the provider. The client completes it with
+// ClientSecret; the provider then reports the outcome by webhook.
+type Intent struct {
+	ID           string
+	ClientSecret string
+}
+
+// Outcome is what a webhook says happened to a payment.
+type Outcome int
+
+const (
+	// OutcomeOther covers notifications the booking flow doesn't act on.
+	OutcomeOther Outcome = iota
+	// OutcomeSucceeded means the payment went through.
+	OutcomeSucceeded
+	// OutcomeFailed means the payment failed or was cancelled for good.
+	OutcomeFailed
+)
+
+// WebhookEvent is a verified provider notification.
+type WebhookEvent struct {
+	// Type is the provider's own event type, e.g. "payment_intent.succeeded".
+	Type     string
+	IntentID string
+	Outcome  Outcome
+}
+
+// Provider creates payment intents and verifies webhooks from the payment
+// service.
+type Provider interface {
+	// CreateIntent opens a payment of amount minor units of currency (ISO 4217,
+	// e.g. "usd"). metadata is stored with the payment for reference.
+	CreateIntent(ctx context.Context, amount int64, currency string, metadata map[string]string) (Intent, error)
+	// GetIntent looks up an intent opened earlier, e.g. to hand its client
+	// secret out again.
+	GetIntent(ctx context.Context, intentID string) (Intent, error)
+	// CancelIntent cancels an intent that hasn't been paid, so the client can
+	// no longer complete it.
+	CancelIntent(ctx context.Context, intentID string) error
+	// Refund gives back the whole amount of a paid intent.
+	Refund(ctx context.Context, intentID string) error
+	// ParseWebhook verifies the signature of a webhook request and decodes it.
+	// It returns ErrInvalidSignature when the request wasn't sent by the
+	// provider.
+	ParseWebhook(payload []byte, header http.Header) (WebhookEvent, error)
+}
+
+// NewProviderFromEnv picks a provider based on PAYMENT_PROVIDER (none|fake|stripe,
+// default none). It returns nil when payments are disabled, in which case
+// admins confirm paid bookings by hand. Stripe uses STRIPE_SECRET_KEY and
+// STRIPE_WEBHOOK_SECRET; the fake provider signs webhooks with
+// FAKE_PAYMENT_WEBHOOK_SECRET.
+func NewProviderFromEnv() (Provider, error) {
+	kind := strings.ToLower(strings.TrimSpace(os.Getenv("PAYMENT_PROVIDER")))
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "fake":
+		f := &Fake{WebhookSecret: os.Getenv("FAKE_PAYMENT_WEBHOOK_SECRET")}
+		if f.WebhookSecret == "" {
+			return nil, fmt.Errorf("PAYMENT_PROVIDER=fake needs FAKE_PAYMENT_WEBHOOK_SECRET")
+		}
+		return f, nil
+	case "stripe":
+		s := &Stripe{
+			SecretKey:     os.Getenv("STRIPE_SECRET_KEY"),
+			WebhookSecret: os.Getenv("STRIPE_WEBHOOK_SECRET"),
+			BaseURL:       stripeAPIBase,
+			Client:        &http.Client{Timeout: 10 * time.Second},
+			Tolerance:     defaultWebhookTolerance,
+		}
+		if s.SecretKey == "" || s.WebhookSecret == "" {
+			return nil, fmt.Errorf("PAYMENT_PROVIDER=stripe needs STRIPE_SECRET_KEY and STRIPE_WEBHOOK_SECRET")
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_PROVIDER %q (want none, fake or stripe)", kind)
+	}
+}
+
+// intentEvent is the body of a payment intent webhook, in Stripe's shape.
+// The fake provider uses the same shape.
+type intentEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		Object struct {
+			ID string `json:"id"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+// decodeIntentEvent decodes a verified webhook body.
+func decodeIntentEvent(payload []byte) (WebhookEvent, error) {
+	var ev intentEvent
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return WebhookEvent{}, fmt.Errorf("decode webhook: %w", err)
+	}
+	out := WebhookEvent{Type: ev.Type, IntentID: ev.Data.Object.ID}
+	switch ev.Type {
+	case "payment_intent.succeeded":
+		out.Outcome = OutcomeSucceeded
+	case "payment_intent.payment_failed", "payment_intent.canceled":
+		out.Outcome = OutcomeFailed
+	}
+	if out.Outcome != OutcomeOther && out.IntentID == "" {
+		return WebhookEvent{}, fmt.Errorf("webhook %s has no payment intent id", ev.Type)
+	}
+	return out, nil
+}
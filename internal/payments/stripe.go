@@ -0,0 +1,168 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stripeAPIBase = "https://api.stripe.com"
+
+// defaultWebhookTolerance is how old a signed webhook may be before it is
+// refused as a possible replay.
+const defaultWebhookTolerance = 5 * time.Minute
+
+// Stripe creates, cancels and refunds PaymentIntents through Stripe's REST
+// API and verifies its signed webhooks.
+type Stripe struct {
+	SecretKey     string
+	WebhookSecret string
+	BaseURL       string
+	Client        *http.Client
+	// Tolerance bounds the age of a webhook's signature timestamp.
+	Tolerance time.Duration
+}
+
+func (s *Stripe) CreateIntent(ctx context.Context, amount int64, currency string, metadata map[string]string) (Intent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(amount, 10))
+	form.Set("currency", strings.ToLower(currency))
+	form.Set("automatic_payment_methods[enabled]", "true")
+	for k, v := range metadata {
+		form.Set("metadata["+k+"]", v)
+	}
+	// A retried request for the same booking must not open a second payment.
+	var idempotencyKey string
+	if id := metadata["booking_id"]; id != "" {
+		idempotencyKey = "booking-" + id
+	}
+
+	var body struct {
+		ID           string `json:"id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/v1/payment_intents", form, idempotencyKey, &body); err != nil {
+		return Intent{}, fmt.Errorf("failed to create payment intent: %w", err)
+	}
+	return Intent{ID: body.ID, ClientSecret: body.ClientSecret}, nil
+}
+
+func (s *Stripe) GetIntent(ctx context.Context, intentID string) (Intent, error) {
+	var body struct {
+		ID           string `json:"id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := s.do(ctx, http.MethodGet, "/v1/payment_intents/"+url.PathEscape(intentID), nil, "", &body); err != nil {
+		return Intent{}, fmt.Errorf("failed to fetch payment intent %s: %w", intentID, err)
+	}
+	return Intent{ID: body.ID, ClientSecret: body.ClientSecret}, nil
+}
+
+func (s *Stripe) CancelIntent(ctx context.Context, intentID string) error {
+	path := "/v1/payment_intents/" + url.PathEscape(intentID) + "/cancel"
+	if err := s.do(ctx, http.MethodPost, path, url.Values{}, "", nil); err != nil {
+		return fmt.Errorf("failed to cancel payment intent %s: %w", intentID, err)
+	}
+	return nil
+}
+
+func (s *Stripe) Refund(ctx context.Context, intentID string) error {
+	form := url.Values{}
+	form.Set("payment_intent", intentID)
+	// Webhooks are delivered at least once; refund each payment only once.
+	if err := s.do(ctx, http.MethodPost, "/v1/refunds", form, "refund-"+intentID, nil); err != nil {
+		return fmt.Errorf("failed to refund payment intent %s: %w", intentID, err)
+	}
+	return nil
+}
+
+// do sends a request to the API path, with form as its body unless form is
+// nil, and decodes the JSON reply into out, when out is not nil.
+func (s *Stripe) do(ctx context.Context, method, path string, form url.Values, idempotencyKey string, out any) error {
+	endpoint := strings.TrimRight(s.BaseURL, "/") + path
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.SecretKey)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("payment provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// ParseWebhook checks the Stripe-Signature header: an HMAC-SHA256 of
+// "<timestamp>.<payload>" keyed with the endpoint's signing secret, with a
+// timestamp no older than Tolerance.
+func (s *Stripe) ParseWebhook(payload []byte, header http.Header) (WebhookEvent, error) {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header.Get("Stripe-Signature"), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			timestamp = v
+		case "v1":
+			signatures = append(signatures, v)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return WebhookEvent{}, ErrInvalidSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return WebhookEvent{}, ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(ts, 0)); s.Tolerance > 0 && (age > s.Tolerance || age < -s.Tolerance) {
+		return WebhookEvent{}, ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.WebhookSecret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	for _, sig := range signatures {
+		got, err := hex.DecodeString(sig)
+		if err == nil && hmac.Equal(got, expected) {
+			return decodeIntentEvent(payload)
+		}
+	}
+	return WebhookEvent{}, ErrInvalidSignature
+}
@@ -0,0 +1,128 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// stripeSignature builds a Stripe-Signature header for payload signed at ts.
+func stripeSignature(secret string, ts time.Time, payload []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(payload)
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func intentPayload(typ, id string) []byte {
+	return []byte(fmt.Sprintf(`{"type":%q,"data":{"object":{"id":%q}}}`, typ, id))
+}
+
+func TestStripeParseWebhook(t *testing.T) {
+	s := &Stripe{WebhookSecret: testWebhookSecret, Tolerance: defaultWebhookTolerance}
+	payload := intentPayload("payment_intent.succeeded", "pi_123")
+	now := time.Now()
+	valid := stripeSignature(testWebhookSecret, now, payload)
+	// During secret rotation Stripe signs with both secrets: t=...,v1=old,v1=new.
+	rotated := stripeSignature("whsec_old", now, payload) + "," + strings.SplitN(valid, ",", 2)[1]
+
+	tests := []struct {
+		name    string
+		header  string
+		wantErr bool
+	}{
+		{"valid", valid, false},
+		{"signed with two secrets", rotated, false},
+		{"wrong secret", stripeSignature("whsec_other", now, payload), true},
+		{"too old", stripeSignature(testWebhookSecret, now.Add(-10*time.Minute), payload), true},
+		{"from the future", stripeSignature(testWebhookSecret, now.Add(10*time.Minute), payload), true},
+		{"no timestamp", "v1=abcd", true},
+		{"missing", "", true},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set("Stripe-Signature", tt.header)
+		ev, err := s.ParseWebhook(payload, h)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("%s: err = %v, want ErrInvalidSignature", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if ev.IntentID != "pi_123" || ev.Outcome != OutcomeSucceeded {
+			t.Errorf("%s: event = %+v", tt.name, ev)
+		}
+	}
+}
+
+func TestStripeParseWebhookRejectsTamperedBody(t *testing.T) {
+	s := &Stripe{WebhookSecret: testWebhookSecret, Tolerance: defaultWebhookTolerance}
+	h := http.Header{}
+	h.Set("Stripe-Signature", stripeSignature(testWebhookSecret, time.Now(), intentPayload("payment_intent.payment_failed", "pi_1")))
+	if _, err := s.ParseWebhook(intentPayload("payment_intent.succeeded", "pi_1"), h); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("err = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestStripeCreateIntent(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		got = r
+		fmt.Fprint(w, `{"id":"pi_42","client_secret":"pi_42_secret_x"}`)
+	}))
+	defer srv.Close()
+
+	s := &Stripe{SecretKey: "sk_test", BaseURL: srv.URL, Client: srv.Client()}
+	intent, err := s.CreateIntent(context.Background(), 2500, "EUR", map[string]string{"booking_id": "b-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intent.ID != "pi_42" || intent.ClientSecret != "pi_42_secret_x" {
+		t.Errorf("intent = %+v", intent)
+	}
+	if got.Method != http.MethodPost || got.URL.Path != "/v1/payment_intents" {
+		t.Errorf("request = %s %s", got.Method, got.URL.Path)
+	}
+	if auth := got.Header.Get("Authorization"); auth != "Bearer sk_test" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if key := got.Header.Get("Idempotency-Key"); key != "booking-b-1" {
+		t.Errorf("Idempotency-Key = %q, want booking-b-1", key)
+	}
+	for field, want := range map[string]string{"amount": "2500", "currency": "eur", "metadata[booking_id]": "b-1"} {
+		if v := got.PostForm.Get(field); v != want {
+			t.Errorf("%s = %q, want %q", field, v, want)
+		}
+	}
+}
+
+func TestStripeReportsProviderErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"No such payment_intent"}}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	s := &Stripe{SecretKey: "sk_test", BaseURL: srv.URL, Client: srv.Client()}
+	if err := s.Refund(context.Background(), "pi_missing"); err == nil {
+		t.Fatal("Refund succeeded on a 404")
+	}
+}
@@ -54,3 +54,64 @@ LEFT JOIN (
 ) b ON b.event_id = e.id
 ORDER BY e.booked_count DESC
 LIMIT $3;
+
+-- name: GetNoShowsByEventBetween :many
+-- Events that started in the range and used check-in, with their active
+-- booked seats split by whether the booking was scanned. Events nobody was
+-- checked in at are left out: they didn't use check-in, so every seat would
+-- look like a no-show.
+SELECT
+  e.id AS event_id,
+  e.name,
+  e.start_time,
+  e.capacity::int AS capacity,
+  e.oversell_percent::int AS oversell_percent,
+  SUM(b.seats)::bigint AS seats_booked,
+  COALESCE(SUM(b.seats) FILTER (WHERE b.checked_in_at IS NOT NULL), 0)::bigint AS seats_checked_in
+FROM events e
+JOIN bookings b ON b.event_id = e.id AND b.status = 'active'
+WHERE e.start_time >= $1 AND e.start_time <= $2
+  AND e.start_time < now()
+  AND e.deleted_at IS NULL
+GROUP BY e.id
+HAVING COUNT(b.checked_in_at) > 0
+ORDER BY e.start_time DESC
+LIMIT $3;
+
+-- name: GetNoShowTotalsBetween :one
+-- Totals over every event GetNoShowsByEventBetween would list.
+SELECT
+  COUNT(*)::bigint AS events,
+  COALESCE(SUM(t.seats_booked), 0)::bigint AS seats_booked,
+  COALESCE(SUM(t.seats_checked_in), 0)::bigint AS seats_checked_in
+FROM (
+  SELECT
+    SUM(b.seats) AS seats_booked,
+    SUM(b.seats) FILTER (WHERE b.checked_in_at IS NOT NULL) AS seats_checked_in
+  FROM events e
+  JOIN bookings b ON b.event_id = e.id AND b.status = 'active'
+  WHERE e.start_time >= $1 AND e.start_time <= $2
+    AND e.start_time < now()
+    AND e.deleted_at IS NULL
+  GROUP BY e.id
+  HAVING COUNT(b.checked_in_at) > 0
+) t;
+
+-- name: GetOverbookingExposure :many
+-- Upcoming events that oversell or are booked beyond their capacity.
+-- overbooked_seats is how many booked seats have no physical seat should
+-- everyone turn up.
+SELECT
+  e.id AS event_id,
+  e.name,
+  e.start_time,
+  e.capacity::int AS capacity,
+  e.oversell_percent::int AS oversell_percent,
+  e.booked_count::int AS booked_count,
+  GREATEST(e.booked_count - e.capacity, 0)::int AS overbooked_seats
+FROM events e
+WHERE e.deleted_at IS NULL
+  AND e.start_time >= now()
+  AND (e.oversell_percent > 0 OR e.booked_count > e.capacity)
+ORDER BY overbooked_seats DESC, e.start_time
+LIMIT $1;
@@ -0,0 +1,25 @@
+-- name: InsertAuditLog :exec
+INSERT INTO audit_log (actor_id, actor_role, action, target, method, path, status)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListAuditLog :many
+-- Newest first; every filter is optional (NULL = no filter). total counts
+-- every matching entry, for the list envelope.
+SELECT id, actor_id, actor_role, action, target, method, path, status, created_at,
+  COUNT(*) OVER () AS total
+FROM audit_log
+WHERE (sqlc.narg('actor_id')::uuid IS NULL OR actor_id = sqlc.narg('actor_id'))
+  AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at <= sqlc.narg('created_to'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountAuditLog :one
+-- Counts the entries ListAuditLog pages through, with the same filters.
+SELECT COUNT(*)
+FROM audit_log
+WHERE (sqlc.narg('actor_id')::uuid IS NULL OR actor_id = sqlc.narg('actor_id'))
+  AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at <= sqlc.narg('created_to'));
@@ -1,19 +1,22 @@
 -- name: GetBookingByEventAndIdempotency :one
-SELECT id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at, updated_at
+SELECT id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at, updated_at, checked_in_at, hold_token, payment_intent_id, token_version, paid_at, unit_price_cents, currency
 FROM bookings
 WHERE event_id = $1
     AND idempotency_key = $2;
 
 -- name: GetSeatsForBookingByIDs :many
-SELECT id, status, hold_token
+SELECT id, seat_no, status, hold_token
 FROM seats
 WHERE id = ANY($1::uuid[])
 ORDER BY id
 FOR UPDATE;
 
 -- name: InsertBooking :one
-INSERT INTO bookings (event_id, user_id, seats, seat_ids, status, idempotency_key)
-VALUES ($1, $2, $3, $4, $5, $6)
+-- Snapshots the event's seat price and currency onto the booking.
+INSERT INTO bookings (event_id, user_id, seats, seat_ids, status, idempotency_key, hold_token, unit_price_cents, currency)
+SELECT $1, $2, $3, $4, $5, $6, $7, e.price_cents, e.currency
+FROM events e
+WHERE e.id = $1
 RETURNING id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at;
 
 -- name: UpdateSeatsToBooked :exec
@@ -28,7 +31,14 @@ WHERE id = ANY($2::uuid[]);
 UPDATE events
 SET booked_count = booked_count + $1
 WHERE id = $2
-  AND booked_count + $1 <= capacity;
+  AND booked_count + $1 <= capacity + capacity * oversell_percent / 100;
+
+-- name: GetEventCapacityForUpdate :one
+-- Locks the event row so concurrent bookings see each other's booked_count.
+SELECT capacity, booked_count, oversell_percent
+FROM events
+WHERE id = $1
+FOR UPDATE;
 
 -- name: ConvertSeatHoldToConverted :exec
 UPDATE seat_holds
@@ -42,18 +52,89 @@ WHERE hold_token = $1
 FOR UPDATE;
 
 -- name: GetBookingsByUser :many
-SELECT id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at, updated_at
+-- total counts every booking of the user, for the list envelope.
+SELECT sqlc.embed(bookings), COUNT(*) OVER () AS total
 FROM bookings
 WHERE user_id = $1
-ORDER BY created_at DESC;
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
 
 -- name: GetBookingByID :one
-SELECT id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at, updated_at
+SELECT id, event_id, user_id, seats, seat_ids, status, idempotency_key, created_at, updated_at, checked_in_at, hold_token, payment_intent_id, token_version, paid_at, unit_price_cents, currency
 FROM bookings
 WHERE id = $1;
 
+-- name: GetBookingsByEvent :many
+-- Attendee list for one event with holder details and seat numbers, oldest
+-- booking first. A NULL status lists every booking.
+SELECT
+  b.id,
+  b.user_id,
+  b.seats,
+  b.status,
+  b.created_at,
+  b.checked_in_at,
+  u.name AS user_name,
+  u.email AS user_email,
+  ARRAY(SELECT s.seat_no FROM seats s WHERE s.id = ANY(b.seat_ids) ORDER BY s.seat_no)::text[] AS seat_nos
+FROM bookings b
+LEFT JOIN users u ON u.id = b.user_id
+WHERE b.event_id = sqlc.arg('event_id')
+  AND (sqlc.narg('status')::text IS NULL OR b.status = sqlc.narg('status'))
+ORDER BY b.created_at, b.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
 -- name: GetSeatNosByIds :many
 SELECT seat_no
 FROM seats
 WHERE id = ANY($1::uuid[])
-ORDER BY seat_no;
\ No newline at end of file
+ORDER BY seat_no;
+
+-- name: UpdateBookingOwner :execrows
+-- Bumps token_version too, so tickets issued before uid was signed into them
+-- stop working for the previous holder.
+UPDATE bookings
+SET user_id = $2,
+    token_version = token_version + 1
+WHERE id = $1
+  AND status = 'active';
+
+-- name: ListBookings :many
+-- Admin listing; every filter is optional (NULL = no filter). total counts
+-- every matching booking, for the list envelope.
+SELECT
+  b.id,
+  b.event_id,
+  b.user_id,
+  b.seats,
+  b.status,
+  b.created_at,
+  b.updated_at,
+  u.email AS user_email,
+  e.name AS event_name,
+  COUNT(*) OVER () AS total
+FROM bookings b
+JOIN events e ON e.id = b.event_id
+LEFT JOIN users u ON u.id = b.user_id
+WHERE (sqlc.narg('event_id')::uuid IS NULL OR b.event_id = sqlc.narg('event_id'))
+  AND (sqlc.narg('user_id')::uuid IS NULL OR b.user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('status')::text IS NULL OR b.status = sqlc.narg('status'))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR b.created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR b.created_at <= sqlc.narg('created_to'))
+ORDER BY b.created_at DESC, b.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountBookingsByUser :one
+SELECT COUNT(*)
+FROM bookings
+WHERE user_id = $1;
+
+-- name: CountBookings :one
+-- Counts the bookings ListBookings pages through, with the same filters.
+SELECT COUNT(*)
+FROM bookings b
+WHERE (sqlc.narg('event_id')::uuid IS NULL OR b.event_id = sqlc.narg('event_id'))
+  AND (sqlc.narg('user_id')::uuid IS NULL OR b.user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('status')::text IS NULL OR b.status = sqlc.narg('status'))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR b.created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR b.created_at <= sqlc.narg('created_to'));
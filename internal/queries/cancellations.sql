@@ -1,5 +1,5 @@
 -- name: GetBookingForUpdate :one
-SELECT id, event_id, user_id, seats, seat_ids, status, created_at
+SELECT id, event_id, user_id, seats, seat_ids, status, created_at, payment_intent_id, paid_at, unit_price_cents, currency
 FROM bookings
 WHERE id = $1
 FOR UPDATE;
@@ -20,4 +20,71 @@ WHERE id = ANY($1::uuid[]);
 -- name: UpdateEventBookedCountByDelta :exec
 UPDATE events
 SET booked_count = booked_count + $1
-WHERE id = $2;
\ No newline at end of file
+WHERE id = $2;
+
+-- name: CancelActiveBookingsByEvent :one
+-- Cancels every active or pending_payment booking for an event, reporting how many bookings and seats were affected.
+WITH cancelled AS (
+  UPDATE bookings
+  SET status = 'cancelled'
+  WHERE event_id = $1 AND status IN ('active', 'pending_payment')
+  RETURNING seats
+)
+SELECT COUNT(*)::int AS bookings, COALESCE(SUM(seats), 0)::int AS seats
+FROM cancelled;
+
+-- name: ExpireActiveHoldsByEvent :execrows
+UPDATE seat_holds
+SET status = 'expired'
+WHERE event_id = $1 AND status = 'active';
+
+-- name: ExpireHoldBatchByEvent :many
+-- Ends up to $2 of the event's active holds, for cancel-all, and returns the
+-- seats they held.
+UPDATE seat_holds
+SET status = 'expired', updated_at = now()
+WHERE id IN (
+  SELECT id FROM seat_holds
+  WHERE event_id = $1 AND status = 'active'
+  ORDER BY created_at, id
+  LIMIT $2
+  FOR UPDATE
+)
+RETURNING hold_token, seat_ids;
+
+-- name: ReleaseSeatsByEvent :execrows
+UPDATE seats
+SET status = 'available',
+    booking_id = NULL,
+    hold_token = NULL,
+    hold_expires_at = NULL
+WHERE event_id = $1 AND status IN ('held', 'booked');
+
+-- name: CancelWaitlistByEvent :execrows
+UPDATE waitlist
+SET status = 'cancelled'
+WHERE event_id = $1 AND status = 'waiting';
+-- name: LockActiveBookingsByEvent :many
+-- One batch of an event's live bookings, locked for cancellation, with their seat numbers.
+SELECT b.id, b.user_id, b.seat_ids, b.status, b.payment_intent_id,
+       ARRAY(SELECT s.seat_no FROM seats s WHERE s.id = ANY(b.seat_ids) ORDER BY s.seat_no)::text[] AS seat_nos
+FROM bookings b
+WHERE b.event_id = $1 AND b.status IN ('active', 'pending_payment')
+ORDER BY b.created_at, b.id
+LIMIT $2
+FOR UPDATE OF b;
+
+-- name: CancelBookingsByIDs :execrows
+UPDATE bookings
+SET status = 'cancelled'
+WHERE id = ANY($1::uuid[]) AND status IN ('active', 'pending_payment');
+
+-- name: ZeroEventBookedCount :execrows
+-- Clears booked_count once the event has no live bookings left.
+UPDATE events
+SET booked_count = 0
+WHERE id = $1 AND booked_count <> 0
+  AND NOT EXISTS (
+    SELECT 1 FROM bookings b
+    WHERE b.event_id = $1 AND b.status IN ('active', 'pending_payment')
+  );
@@ -0,0 +1,12 @@
+-- name: ClaimConfirmationResend :execrows
+-- Records a resend unless the last one for the booking was at or after $2.
+INSERT INTO confirmation_resends (booking_id, sent_at)
+VALUES ($1, now())
+ON CONFLICT (booking_id) DO UPDATE
+SET sent_at = now()
+WHERE confirmation_resends.sent_at < $2;
+
+-- name: GetConfirmationResentAt :one
+SELECT sent_at
+FROM confirmation_resends
+WHERE booking_id = $1;
@@ -0,0 +1,13 @@
+-- name: ClaimEmailVerificationRequest :execrows
+-- Records a verification email request unless the last one for the user was
+-- at or after $2.
+INSERT INTO email_verification_requests (user_id, sent_at)
+VALUES ($1, now())
+ON CONFLICT (user_id) DO UPDATE
+SET sent_at = now()
+WHERE email_verification_requests.sent_at < $2;
+
+-- name: GetEmailVerificationRequestedAt :one
+SELECT sent_at
+FROM email_verification_requests
+WHERE user_id = $1;
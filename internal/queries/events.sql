@@ -1,7 +1,19 @@
 -- name: GetAllEvents :many
-SELECT *
+-- total counts every event matching the filters, for the list envelope.
+SELECT sqlc.embed(events), COUNT(*) OVER () AS total
 FROM events
-WHERE ($3 = '' OR name ILIKE '%' || $3 || '%' OR venue ILIKE '%' || $3 || '%')
+WHERE ($3 = '' OR name ILIKE '%' || $3 || '%' OR venue ILIKE '%' || $3 || '%' OR description ILIKE '%' || $3 || '%')
+  AND ($4::boolean OR deleted_at IS NULL)
+  AND ($5::text = '' OR $5::text = (
+    -- Same classification as availabilityStatus in the handlers.
+    SELECT CASE
+      WHEN LEAST(COUNT(*), events.capacity + events.capacity * events.oversell_percent / 100 - events.booked_count) <= 0 THEN 'sold_out'
+      WHEN LEAST(COUNT(*), events.capacity + events.capacity * events.oversell_percent / 100 - events.booked_count) * 100 <= (events.capacity + events.capacity * events.oversell_percent / 100) * $6::int THEN 'limited'
+      ELSE 'open'
+    END
+    FROM seats s
+    WHERE s.event_id = events.id AND s.status IN ('available', 'held')
+  ))
 ORDER BY start_time
 LIMIT $1 OFFSET $2;
 
@@ -9,9 +21,9 @@ LIMIT $1 OFFSET $2;
 SELECT * FROM events WHERE id = $1;
 
 -- name: AddEvent :one
-INSERT INTO events (name, venue, start_time, capacity, metadata)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, name, venue, start_time, capacity, metadata, created_at, updated_at;
+INSERT INTO events (name, venue, start_time, capacity, metadata, owner_id, description, image_url, sender_name, reply_to, requires_payment, price_cents, currency, oversell_percent)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, name, venue, start_time, capacity, metadata, created_at, updated_at, version, owner_id, description, image_url, sender_name, reply_to, requires_payment, price_cents, currency, oversell_percent;
 
 -- name: UpdateEvent :one
 UPDATE events
@@ -20,11 +32,62 @@ SET
   venue = COALESCE($3, venue),
   start_time = COALESCE($4, start_time),
   capacity = COALESCE($5, capacity),
-  metadata = COALESCE($6, metadata)
-WHERE id = $1
-RETURNING id, name, venue, start_time, capacity, booked_count, metadata, created_at, updated_at;
-
--- name: DeleteEvent :one
-DELETE FROM events
-WHERE id = $1
-RETURNING id;
\ No newline at end of file
+  metadata = COALESCE($6, metadata),
+  description = $8,
+  image_url = $9,
+  sender_name = $10,
+  reply_to = $11,
+  requires_payment = $12,
+  price_cents = $13,
+  currency = $14,
+  oversell_percent = $15,
+  version = version + 1
+WHERE id = $1 AND version = $7
+RETURNING *;
+
+-- name: SoftDeleteEvent :one
+UPDATE events
+SET deleted_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, deleted_at;
+
+-- name: RestoreEvent :one
+UPDATE events
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: CountEventDependents :one
+SELECT
+  (SELECT COUNT(*) FROM bookings b WHERE b.event_id = $1 AND b.status IN ('active', 'pending_payment'))::int AS active_bookings,
+  (SELECT COUNT(*) FROM seat_holds sh WHERE sh.event_id = $1 AND sh.status = 'active' AND sh.expires_at > now())::int AS active_holds,
+  (SELECT COUNT(*) FROM seats s WHERE s.event_id = $1)::int AS seats,
+  (SELECT COUNT(*) FROM waitlist w WHERE w.event_id = $1 AND w.status = 'waiting')::int AS waitlist_entries;
+
+-- name: GetEventAvailability :one
+-- Numeric availability for one live event in a single round trip.
+SELECT e.capacity,
+       e.booked_count,
+       e.oversell_percent,
+       COUNT(s.id) FILTER (WHERE s.status = 'held')::int AS held,
+       COUNT(s.id) FILTER (WHERE s.status = 'available')::int AS available_seats
+FROM events e
+LEFT JOIN seats s ON s.event_id = e.id
+WHERE e.id = $1 AND e.deleted_at IS NULL
+GROUP BY e.id;
+
+-- name: CountEvents :one
+-- Counts the events GetAllEvents pages through, with the same filters.
+SELECT COUNT(*)
+FROM events
+WHERE ($1 = '' OR name ILIKE '%' || $1 || '%' OR venue ILIKE '%' || $1 || '%' OR description ILIKE '%' || $1 || '%')
+  AND ($2::boolean OR deleted_at IS NULL)
+  AND ($3::text = '' OR $3::text = (
+    SELECT CASE
+      WHEN LEAST(COUNT(*), events.capacity + events.capacity * events.oversell_percent / 100 - events.booked_count) <= 0 THEN 'sold_out'
+      WHEN LEAST(COUNT(*), events.capacity + events.capacity * events.oversell_percent / 100 - events.booked_count) * 100 <= (events.capacity + events.capacity * events.oversell_percent / 100) * $4::int THEN 'limited'
+      ELSE 'open'
+    END
+    FROM seats s
+    WHERE s.event_id = events.id AND s.status IN ('available', 'held')
+  ));
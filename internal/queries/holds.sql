@@ -1,5 +1,5 @@
 -- name: GetSeatsForEventForUpdate :many
-SELECT id, seat_no, status
+SELECT id, seat_no, status, hold_token
 FROM seats
 WHERE event_id = $1
     AND seat_no = ANY($2::text[])
@@ -36,3 +36,48 @@ WHERE hold_token = $1 AND id = ANY($2::uuid[]);
 UPDATE seat_holds
 SET status = 'expired', updated_at = now()
 WHERE id = $1;
+
+-- name: GetActiveSeatHoldByToken :one
+SELECT id, hold_token, user_id, seat_ids, expires_at
+FROM seat_holds
+WHERE hold_token = $1 AND status = 'active' AND expires_at > now();
+
+-- name: GetActiveHoldsByUser :many
+-- The caller's live holds, soonest to expire first; $2 = true also returns
+-- expired and converted ones.
+SELECT sh.id, sh.hold_token, sh.event_id, sh.status, sh.expires_at, sh.created_at,
+  ARRAY(SELECT s.seat_no FROM seats s WHERE s.id = ANY(sh.seat_ids) ORDER BY s.seat_no)::text[] AS seat_nos
+FROM seat_holds sh
+WHERE sh.user_id = $1
+  AND ($2::boolean OR (sh.status = 'active' AND sh.expires_at > now()))
+ORDER BY sh.expires_at;
+
+-- name: GetSeatHoldSeatIDs :one
+-- The seats a hold covers, whatever its status.
+SELECT seat_ids
+FROM seat_holds
+WHERE hold_token = $1;
+
+-- name: CountFinishedSeatHolds :one
+-- Expired and converted holds whose expiry is before $1.
+SELECT COUNT(*)
+FROM seat_holds
+WHERE status IN ('expired', 'converted') AND expires_at < $1;
+
+-- name: DeleteFinishedSeatHolds :execrows
+-- Deletes up to $2 expired and converted holds whose expiry is before $1.
+DELETE FROM seat_holds
+WHERE id IN (
+  SELECT id FROM seat_holds
+  WHERE status IN ('expired', 'converted') AND expires_at < $1
+  LIMIT $2
+);
+
+-- name: UpdateHoldOwner :execrows
+-- Hands an active, unexpired hold to another user; no rows means it was
+-- converted or expired meanwhile.
+UPDATE seat_holds
+SET user_id = $2, updated_at = now()
+WHERE hold_token = $1
+  AND status = 'active'
+  AND expires_at > now();
@@ -0,0 +1,46 @@
+-- name: ClaimIdempotencyKey :one
+-- Claim a key for a new request. Returns no rows if a live claim already
+-- exists: unexpired, and either finished or made at or after $5. An unfinished
+-- claim made before $5 is taken over, since its request never committed.
+INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (scope, key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint,
+    status_code = NULL,
+    response = NULL,
+    expires_at = EXCLUDED.expires_at,
+    created_at = now()
+WHERE idempotency_keys.expires_at <= now()
+   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < $5)
+RETURNING scope, key, fingerprint, status_code, response, expires_at, created_at;
+
+-- name: GetIdempotencyKey :one
+SELECT scope, key, fingerprint, status_code, response, expires_at, created_at
+FROM idempotency_keys
+WHERE scope = $1 AND key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+-- Only the claim made at $5 is completed; one taken over since is left alone.
+UPDATE idempotency_keys
+SET status_code = $3,
+    response = $4
+WHERE scope = $1 AND key = $2 AND created_at = $5;
+
+-- name: DeleteIdempotencyKey :exec
+-- Only the claim made at $3 is released; one taken over since is left alone.
+DELETE FROM idempotency_keys
+WHERE scope = $1 AND key = $2 AND created_at = $3;
+
+-- name: CountExpiredIdempotencyKeys :one
+SELECT COUNT(*)
+FROM idempotency_keys
+WHERE expires_at <= now();
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+-- Deletes up to $1 keys whose TTL has passed.
+DELETE FROM idempotency_keys
+WHERE (scope, key) IN (
+  SELECT scope, key FROM idempotency_keys
+  WHERE expires_at <= now()
+  LIMIT $1
+);
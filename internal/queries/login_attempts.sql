@@ -0,0 +1,41 @@
+-- name: GetLoginLockedUntil :one
+SELECT locked_until
+FROM login_attempts
+WHERE email = $1;
+
+-- name: RecordLoginFailure :one
+-- Counts a failure, starting a new window when the current one began before $2.
+INSERT INTO login_attempts (email, failed_count, window_started_at)
+VALUES ($1, 1, now())
+ON CONFLICT (email) DO UPDATE
+SET failed_count = CASE
+      WHEN login_attempts.window_started_at < $2 THEN 1
+      ELSE login_attempts.failed_count + 1
+    END,
+    window_started_at = CASE
+      WHEN login_attempts.window_started_at < $2 THEN now()
+      ELSE login_attempts.window_started_at
+    END
+RETURNING failed_count;
+
+-- name: LockLogin :exec
+UPDATE login_attempts
+SET locked_until = $2, failed_count = 0, window_started_at = now()
+WHERE email = $1;
+
+-- name: ClearLoginAttempts :exec
+DELETE FROM login_attempts
+WHERE email = $1;
+
+-- name: CountStaleLoginAttempts :one
+SELECT COUNT(*)
+FROM login_attempts
+WHERE window_started_at < $1
+  AND (locked_until IS NULL OR locked_until < now());
+
+-- name: DeleteStaleLoginAttempts :execrows
+-- Deletes failure counters whose window started before $1 and that hold no
+-- active lockout; the next failure for the email starts a new window anyway.
+DELETE FROM login_attempts
+WHERE window_started_at < $1
+  AND (locked_until IS NULL OR locked_until < now());
@@ -0,0 +1,39 @@
+-- name: InsertMailOutbox :exec
+INSERT INTO mail_outbox (kind, payload, attempts, last_error, status, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ClaimDueMailOutbox :many
+-- Lease due messages by pushing next_attempt_at forward; SKIP LOCKED lets replicas share the outbox.
+UPDATE mail_outbox
+SET next_attempt_at = $1
+WHERE id IN (
+  SELECT id FROM mail_outbox
+  WHERE status = 'pending' AND next_attempt_at <= now()
+  ORDER BY next_attempt_at
+  LIMIT $2
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, kind, payload, attempts;
+
+-- name: RescheduleMailOutbox :exec
+UPDATE mail_outbox
+SET attempts = $2,
+    last_error = $3,
+    next_attempt_at = $4
+WHERE id = $1;
+
+-- name: MarkMailOutboxDead :exec
+UPDATE mail_outbox
+SET status = 'dead',
+    attempts = $2,
+    last_error = $3
+WHERE id = $1;
+
+-- name: DeleteMailOutbox :exec
+DELETE FROM mail_outbox
+WHERE id = $1;
+
+-- name: CountMailOutboxByStatus :many
+SELECT status, COUNT(*)::bigint AS cnt
+FROM mail_outbox
+GROUP BY status;
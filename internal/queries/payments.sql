@@ -0,0 +1,31 @@
+-- name: AbandonPendingBooking :execrows
+UPDATE bookings
+SET status = 'abandoned'
+WHERE id = $1 AND status = 'pending_payment';
+
+-- name: ConfirmPendingBooking :execrows
+UPDATE bookings
+SET status = 'active'
+WHERE id = $1 AND status = 'pending_payment';
+
+-- name: GetBookingIDByPaymentIntent :one
+SELECT id FROM bookings WHERE payment_intent_id = $1;
+
+-- name: MarkBookingPaid :exec
+-- Records that the payment provider reported the booking's payment.
+UPDATE bookings
+SET paid_at = now()
+WHERE id = $1;
+
+-- name: GetStalePendingBookings :many
+-- Bookings still awaiting payment that were made before $1, oldest first.
+SELECT id, event_id
+FROM bookings
+WHERE status = 'pending_payment' AND created_at < $1
+ORDER BY created_at
+LIMIT $2;
+
+-- name: SetBookingPaymentIntent :exec
+UPDATE bookings
+SET payment_intent_id = $2
+WHERE id = $1;
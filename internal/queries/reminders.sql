@@ -0,0 +1,19 @@
+-- name: GetUpcomingBookingsNeedingReminder :many
+-- Active bookings for live events starting between now and $1 that have not
+-- been reminded yet, soonest event first.
+SELECT b.id, b.event_id, b.user_id,
+       ARRAY(SELECT s.seat_no FROM seats s WHERE s.id = ANY(b.seat_ids) ORDER BY s.seat_no)::text[] AS seat_nos
+FROM bookings b
+JOIN events e ON e.id = b.event_id
+WHERE b.status = 'active'
+  AND e.deleted_at IS NULL
+  AND e.start_time > now()
+  AND e.start_time <= $1
+  AND NOT EXISTS (SELECT 1 FROM booking_reminders r WHERE r.booking_id = b.id)
+ORDER BY e.start_time, b.id
+LIMIT $2;
+
+-- name: MarkBookingReminded :execrows
+INSERT INTO booking_reminders (booking_id)
+VALUES ($1)
+ON CONFLICT (booking_id) DO NOTHING;
@@ -0,0 +1,14 @@
+-- name: SetSeatAuditContext :exec
+-- Tags the seat status changes of the current transaction with who made them
+-- and why; the seat_events trigger reads these. Only takes effect inside a
+-- transaction.
+SELECT set_config('overbookr.actor_id', sqlc.arg(actor_id)::text, true),
+       set_config('overbookr.seat_reason', sqlc.arg(reason)::text, true),
+       set_config('overbookr.correlation_id', sqlc.arg(correlation_id)::text, true);
+
+-- name: GetSeatEventsBySeat :many
+SELECT id, seat_id, event_id, old_status, new_status, booking_id, hold_token, actor_id, reason, correlation_id, created_at
+FROM seat_events
+WHERE seat_id = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
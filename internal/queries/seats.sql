@@ -1,12 +1,49 @@
 -- name: GetSeatsByEvent :many
-SELECT id, seat_no, status, booking_id, created_at, updated_at
+SELECT id, seat_no, status, booking_id, created_at, updated_at, section
 FROM seats
 WHERE event_id = $1
-ORDER BY seat_no;
+ORDER BY section, seat_no;
 
 -- name: BulkInsertSeats :many
--- Insert many seat_no values for an event. Do nothing on conflict (preserve existing seats).
-INSERT INTO seats (event_id, seat_no)
-SELECT $1, s FROM unnest($2::text[]) AS s
+-- Insert many seat_no values for an event into section $3. Do nothing on
+-- conflict (preserve existing seats, whatever their section).
+INSERT INTO seats (event_id, seat_no, section)
+SELECT $1, s, $3 FROM unnest($2::text[]) AS s
 ON CONFLICT (event_id, seat_no) DO NOTHING
-RETURNING id, seat_no, status, booking_id, created_at, updated_at;
\ No newline at end of file
+RETURNING id, seat_no, status, booking_id, created_at, updated_at, section;
+
+-- name: CountSeatsByEvent :one
+SELECT COUNT(*) FROM seats WHERE event_id = $1;
+
+-- name: GetSeatStatusCountsByEvent :many
+SELECT event_id, section, status, COUNT(*)::int AS cnt
+FROM seats
+WHERE event_id = ANY($1::uuid[])
+GROUP BY event_id, section, status
+ORDER BY event_id, section;
+
+-- name: GetAvailableSeatsByEvent :many
+-- $4 limits the seats to one section; an empty string means all of them.
+SELECT id, seat_no, status, booking_id, created_at, updated_at, section
+FROM seats
+WHERE event_id = $1 AND status = 'available'
+  AND ($4::text = '' OR section = $4::text)
+ORDER BY section, seat_no
+LIMIT $2 OFFSET $3;
+
+-- name: GetSeatByID :one
+SELECT id, event_id, seat_no, status
+FROM seats
+WHERE id = $1;
+
+-- name: ListSeatNoNormalizationCandidates :many
+-- Seat numbers that normalizing could change, for the startup check: ones with
+-- a lowercase letter, a control character, a non-ASCII character or an outer
+-- space. Which of them actually change is decided in Go, as for requests.
+SELECT event_id, seat_no
+FROM seats
+WHERE seat_no ~ '[[:lower:][:cntrl:]]'
+   OR octet_length(seat_no) <> char_length(seat_no)
+   OR seat_no LIKE ' %'
+   OR seat_no LIKE '% '
+ORDER BY event_id, seat_no;
@@ -0,0 +1,17 @@
+-- name: MarkBookingCheckedIn :one
+-- Only the first scan of an active booking matches; re-scans return no rows.
+UPDATE bookings
+SET checked_in_at = now()
+WHERE id = $1
+  AND status = 'active'
+  AND checked_in_at IS NULL
+RETURNING checked_in_at;
+
+-- name: BumpBookingTokenVersion :one
+-- Invalidates the active booking's issued tickets; returns no rows for
+-- bookings that are not active.
+UPDATE bookings
+SET token_version = token_version + 1
+WHERE id = $1
+  AND status = 'active'
+RETURNING token_version;
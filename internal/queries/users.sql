@@ -1,14 +1,105 @@
 -- name: CreateUser :one
-INSERT INTO users (name, email, password, role)
-VALUES ($1, $2, $3, $4)
+INSERT INTO users (name, email, password, role, email_verified, phone)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, name, email, role, created_at, updated_at, phone;
+
+-- name: CreateInvitedUser :one
+-- Creates a placeholder account for an invite, with no usable password.
+-- Returns no row when the email is already taken.
+INSERT INTO users (name, email, password, role, email_verified)
+VALUES ($1, $2, '', $3, false)
+ON CONFLICT (email) DO NOTHING
 RETURNING id, name, email, role, created_at, updated_at;
 
 -- name: GetUserByEmail :one
-SELECT id, name, email, password, role, created_at, updated_at
+SELECT id, name, email, password, role, created_at, updated_at, email_verified, phone
 FROM users
 WHERE email = $1;
 
 -- name: GetUserByID :one
-SELECT id, name, email
+SELECT id, name, email, phone
 FROM users
 WHERE id = $1;
+
+-- name: IsUserEmailVerified :one
+SELECT email_verified
+FROM users
+WHERE id = $1;
+
+-- name: ReplaceEmailVerificationToken :exec
+-- Stores a new token for the user and expires the unused ones sent before
+-- it, so only the latest link works.
+WITH expired AS (
+  UPDATE email_verification_tokens
+  SET expires_at = now()
+  WHERE user_id = $1
+    AND used_at IS NULL
+    AND expires_at > now()
+)
+INSERT INTO email_verification_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: VerifyEmailToken :one
+-- Consumes an unused, unexpired token and marks its user verified.
+WITH t AS (
+  UPDATE email_verification_tokens
+  SET used_at = now()
+  WHERE token_hash = $1
+    AND used_at IS NULL
+    AND expires_at > now()
+  RETURNING user_id
+)
+UPDATE users u
+SET email_verified = true, updated_at = now()
+FROM t
+WHERE u.id = t.user_id
+RETURNING u.id;
+
+-- name: ReplaceUserInvite :exec
+-- Stores a new invite for the user and expires the unused ones sent before
+-- it, so only the latest link works.
+WITH expired AS (
+  UPDATE user_invites
+  SET expires_at = now()
+  WHERE user_id = $1
+    AND used_at IS NULL
+    AND expires_at > now()
+)
+INSERT INTO user_invites (user_id, token_hash, invited_by, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: ExpireUserInvites :exec
+UPDATE user_invites
+SET expires_at = now()
+WHERE user_id = $1
+  AND used_at IS NULL
+  AND expires_at > now();
+
+-- name: UpdateInvitedUserRole :execrows
+-- Changes the role of an account whose invite hasn't been accepted yet.
+UPDATE users
+SET role = $2, updated_at = now()
+WHERE id = $1
+  AND password = '';
+
+-- name: AcceptUserInvite :one
+-- Consumes an unused, unexpired invite token and activates its account with
+-- the given password hash, and the name when one is given. Returns no row
+-- when the token is invalid or the account already has a password.
+WITH t AS (
+  UPDATE user_invites
+  SET used_at = now()
+  WHERE token_hash = $1
+    AND used_at IS NULL
+    AND expires_at > now()
+  RETURNING user_id
+)
+UPDATE users u
+SET password = $2,
+    name = COALESCE(NULLIF($3::text, ''), u.name),
+    email_verified = true,
+    updated_at = now()
+FROM t
+WHERE u.id = t.user_id
+  AND u.password = ''
+RETURNING u.id, u.name, u.email, u.role, u.created_at, u.updated_at, u.phone;
@@ -1,4 +1,7 @@
--- name: InsertWaitlist :one
+-- name: UpsertWaitlistEntry :one
+-- Joins the waitlist, or changes requested_seats on the caller's waiting entry.
+-- Asking for more seats moves the entry to the back; asking for fewer keeps its
+-- place. Entries no longer waiting are left alone and no row is returned.
 INSERT INTO waitlist (event_id, user_id, requested_seats, position, status)
 VALUES (
     $1,
@@ -7,7 +10,14 @@ VALUES (
     (SELECT COALESCE(MAX(position), 0) + 1 FROM waitlist WHERE event_id = $1),
     'waiting'
 )
-RETURNING id, position, created_at;
+ON CONFLICT (event_id, user_id) DO UPDATE
+SET requested_seats = EXCLUDED.requested_seats,
+    position = CASE
+        WHEN EXCLUDED.requested_seats > waitlist.requested_seats THEN EXCLUDED.position
+        ELSE waitlist.position
+    END
+WHERE waitlist.status = 'waiting'
+RETURNING id, position, requested_seats, created_at, (xmax = 0) AS inserted;
 
 -- name: GetWaitingListByEvent :many
 SELECT id, event_id, user_id, requested_seats, position, status, created_at
@@ -21,10 +31,70 @@ SET status = $2
 WHERE id = $1;
 
 -- name: GetAvailableSeatsForEventForUpdate :many
-SELECT id, seat_no
+-- Free seats by section, then in natural order (A2 before A10, Z before
+-- AA), so the first few tend to sit together. $3 limits them to one
+-- section; an empty string means any. Seats other transactions have locked
+-- are skipped rather than waited for: waiting on locks taken in seat order
+-- while other paths lock by id could deadlock.
+SELECT id, seat_no, row_label, seat_col, section
 FROM seats
 WHERE event_id = $1
     AND status = 'available'
-ORDER BY id
+    AND ($3::text = '' OR section = $3::text)
+ORDER BY section,
+    length(substring(seat_no FROM '^[^0-9]*')),
+    substring(seat_no FROM '^[^0-9]*'),
+    lpad(coalesce(substring(seat_no FROM '[0-9]+'), ''), 20, '0'),
+    seat_no
 LIMIT $2
-FOR UPDATE;
\ No newline at end of file
+FOR UPDATE SKIP LOCKED;
+
+-- name: GetAvailableSeatsForEvent :many
+-- GetAvailableSeatsForEventForUpdate without the locks, for looking through
+-- many seats before locking the few that are picked.
+SELECT id, seat_no, row_label, seat_col, section
+FROM seats
+WHERE event_id = $1
+    AND status = 'available'
+    AND ($3::text = '' OR section = $3::text)
+ORDER BY section,
+    length(substring(seat_no FROM '^[^0-9]*')),
+    substring(seat_no FROM '^[^0-9]*'),
+    lpad(coalesce(substring(seat_no FROM '[0-9]+'), ''), 20, '0'),
+    seat_no
+LIMIT $2;
+
+-- name: LockAvailableSeatsByIDs :many
+-- Locks those of the seats that are still free, in id order like the other
+-- seat locks, and returns their ids. Seats locked elsewhere are skipped.
+SELECT id
+FROM seats
+WHERE id = ANY($1::uuid[])
+    AND status = 'available'
+ORDER BY id
+FOR UPDATE SKIP LOCKED;
+
+-- name: GetEventWaitlistSettingsForUpdate :one
+-- Locks the event row so concurrent joins are checked against the cap one at a time.
+SELECT waitlist_cap, waitlist_open, deleted_at
+FROM events
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CountOtherWaitingByEvent :one
+SELECT COUNT(*)::int
+FROM waitlist
+WHERE event_id = $1 AND status = 'waiting' AND user_id <> $2;
+
+-- name: GetWaitingCountsByEvent :many
+SELECT event_id, COUNT(*)::int AS waiting
+FROM waitlist
+WHERE event_id = ANY($1::uuid[]) AND status = 'waiting'
+GROUP BY event_id;
+
+-- name: SetEventWaitlistSettings :one
+UPDATE events
+SET waitlist_cap = $2,
+    waitlist_open = $3
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, waitlist_cap, waitlist_open;
@@ -0,0 +1,105 @@
+// Package realtime fans seat-status changes out to live subscribers such as
+// the SSE seat stream. Delivery is best-effort and in-process only: a
+// subscriber that falls behind is dropped, and clients reconcile through
+// GET /events/:id/seats when they reconnect.
+package realtime
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// subscriberBuffer is how many updates a subscriber may lag behind before it
+// is disconnected.
+const subscriberBuffer = 64
+
+// SeatUpdate reports that the listed seats of an event moved to Status
+// ("available", "held" or "booked").
+type SeatUpdate struct {
+	EventID string    `json:"event_id"`
+	Status  string    `json:"status"`
+	SeatNos []string  `json:"seat_nos"`
+	At      time.Time `json:"at"`
+}
+
+// Hub is a per-event publish/subscribe registry. The zero value is not
+// usable; call NewHub. A nil *Hub accepts Publish and ignores it.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[uuid.UUID]map[*Subscription]struct{})}
+}
+
+// Subscription receives updates for one event on C until it is closed, either
+// by Close or by the hub when the subscriber can't keep up.
+type Subscription struct {
+	C <-chan SeatUpdate
+
+	ch      chan SeatUpdate
+	hub     *Hub
+	eventID uuid.UUID
+}
+
+// Subscribe registers a new subscriber for eventID. Callers must Close it.
+func (h *Hub) Subscribe(eventID uuid.UUID) *Subscription {
+	ch := make(chan SeatUpdate, subscriberBuffer)
+	s := &Subscription{C: ch, ch: ch, hub: h, eventID: eventID}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[eventID] == nil {
+		h.subs[eventID] = make(map[*Subscription]struct{})
+	}
+	h.subs[eventID][s] = struct{}{}
+	return s
+}
+
+// Close unregisters the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// remove drops s and closes its channel; h.mu must be held.
+func (h *Hub) remove(s *Subscription) {
+	subs := h.subs[s.eventID]
+	if _, ok := subs[s]; !ok {
+		return
+	}
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(h.subs, s.eventID)
+	}
+	close(s.ch)
+}
+
+// Publish sends an update to every subscriber of eventID without blocking.
+// Call it only after the change has been committed.
+func (h *Hub) Publish(eventID uuid.UUID, status string, seatNos []string) {
+	if h == nil || len(seatNos) == 0 {
+		return
+	}
+	u := SeatUpdate{
+		EventID: eventID.String(),
+		Status:  status,
+		SeatNos: seatNos,
+		At:      time.Now().UTC(),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs[eventID] {
+		select {
+		case s.ch <- u:
+		default:
+			// Too far behind to trust; make the client resync.
+			h.remove(s)
+		}
+	}
+}
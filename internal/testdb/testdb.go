@@ -0,0 +1,132 @@
+//go:build integration
+
+// Package testdb gives integration tests a real Postgres. A test binary
+// starts one container with testcontainers-go from TestMain and migrates it
+// once; every test then gets its own database cloned from that one, so tests
+// can't see each other's rows. Run the suite with
+//
+//	go test -tags integration ./...
+//
+// which needs a Docker daemon.
+package testdb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+const (
+	image = "postgres:16-alpine"
+	// templateDB holds the migrated schema every test database is cloned from.
+	templateDB = "overbookr"
+)
+
+var (
+	container *postgres.PostgresContainer
+	// admin is connected to the postgres database, to create and drop the
+	// test databases.
+	admin *pgxpool.Pool
+	// baseConfig connects to templateDB; test databases swap the name.
+	baseConfig *pgxpool.Config
+	next       atomic.Int64
+)
+
+// Main starts and migrates the container, runs the package's tests and
+// removes the container. Call it from TestMain.
+func Main(m *testing.M) {
+	if err := start(context.Background()); err != nil {
+		log.Printf("testdb: %v", err)
+		stop()
+		os.Exit(1)
+	}
+	code := m.Run()
+	stop()
+	os.Exit(code)
+}
+
+func start(ctx context.Context) error {
+	var err error
+	container, err = postgres.Run(ctx, image,
+		postgres.WithDatabase(templateDB),
+		postgres.WithUsername("overbookr"),
+		postgres.WithPassword("overbookr"),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		return fmt.Errorf("start postgres: %w", err)
+	}
+	uri, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		return fmt.Errorf("connection string: %w", err)
+	}
+	if baseConfig, err = pgxpool.ParseConfig(uri); err != nil {
+		return err
+	}
+
+	// The template must have no open connections when it is cloned, so the
+	// migration pool is closed straight away.
+	pool, err := pgxpool.NewWithConfig(ctx, baseConfig.Copy())
+	if err != nil {
+		return err
+	}
+	_, _, err = migrations.Up(pool)
+	pool.Close()
+	if err != nil {
+		return err
+	}
+
+	adminConfig := baseConfig.Copy()
+	adminConfig.ConnConfig.Database = "postgres"
+	admin, err = pgxpool.NewWithConfig(ctx, adminConfig)
+	return err
+}
+
+func stop() {
+	if admin != nil {
+		admin.Close()
+	}
+	if container != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := container.Terminate(ctx); err != nil {
+			log.Printf("testdb: terminate container: %v", err)
+		}
+	}
+}
+
+// New returns a pool on a fresh, fully migrated database of its own. The
+// database is dropped when the test ends.
+func New(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	if admin == nil {
+		t.Fatal("testdb: no database server; call testdb.Main from TestMain")
+	}
+	ctx := context.Background()
+	name := fmt.Sprintf("test_%d", next.Add(1))
+	if _, err := admin.Exec(ctx, "CREATE DATABASE "+name+" TEMPLATE "+templateDB); err != nil {
+		t.Fatalf("testdb: create database: %v", err)
+	}
+
+	cfg := baseConfig.Copy()
+	cfg.ConnConfig.Database = name
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("testdb: connect: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Close()
+		if _, err := admin.Exec(context.Background(), "DROP DATABASE "+name+" WITH (FORCE)"); err != nil {
+			t.Logf("testdb: drop database %s: %v", name, err)
+		}
+	})
+	return pool
+}
@@ -0,0 +1,192 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/payments"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
+	"github.com/abhinandanwadwa/overbookr/internal/tracing"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// cancelAllBatchSize bounds how many bookings, and how many holds, one
+// cancel-all transaction locks and cancels.
+const cancelAllBatchSize = 100
+
+// CancelAllSummary reports what CancelAllBookings did. Skipped means the
+// reconcile lock was taken and nothing was cancelled.
+type CancelAllSummary struct {
+	EventID       string `json:"event_id"`
+	Skipped       bool   `json:"skipped"`
+	Cancelled     int    `json:"cancelled"`
+	HoldsReleased int    `json:"holds_released"`
+	SeatsFreed    int    `json:"seats_freed"`
+	Batches       int    `json:"batches"`
+}
+
+// CancelAllBookings cancels every active and pending_payment booking of an
+// event that was called off, and ends its active holds so they can't become
+// bookings. Both go in batches, each in its own transaction: their seats
+// become available and booked_count drops. Once a batch commits, the holders'
+// cancellation notices are queued through notifier.Notify like any other
+// mail, and the payment intents of bookings still awaiting payment are
+// cancelled with provider, which may be nil. Seats are not offered to the
+// waitlist. Running it again once everything is cancelled does nothing.
+//
+// It takes the reconcile worker's advisory lock, so it never runs while
+// booked_count is being repaired or alongside another cancel-all; when the
+// lock is taken the summary is marked Skipped. actorID is recorded in the
+// seat history.
+func CancelAllBookings(ctx context.Context, pool *pgxpool.Pool, notifier Notifier, seatHub *realtime.Hub, provider payments.Provider, eventID pgtype.UUID, actorID string) (summary CancelAllSummary, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "CancelAllBookings",
+		trace.WithAttributes(attribute.String("event.id", eventID.String())))
+	defer func() {
+		span.SetAttributes(
+			attribute.Bool("worker.skipped", summary.Skipped),
+			attribute.Int("bookings.cancelled", summary.Cancelled),
+			attribute.Int("seats.freed", summary.SeatsFreed),
+		)
+		tracing.End(span, err)
+	}()
+
+	summary.EventID = eventID.String()
+	ran, err := withAdvisoryLock(ctx, pool, reconcileLockKey, func(ctx context.Context) error {
+		for {
+			n, holds, seats, err := cancelBookingBatch(ctx, pool, notifier, seatHub, provider, eventID, actorID)
+			if err != nil {
+				return err
+			}
+			if n == 0 && holds == 0 {
+				break
+			}
+			summary.Batches++
+			summary.Cancelled += n
+			summary.HoldsReleased += holds
+			summary.SeatsFreed += seats
+		}
+		// Per-batch deltas leave booked_count at zero unless it had drifted;
+		// holding the reconcile lock, the repair is ours to make.
+		if _, err := db.New(pool).ZeroEventBookedCount(ctx, eventID); err != nil {
+			return fmt.Errorf("zero booked_count: %w", err)
+		}
+		return nil
+	})
+	summary.Skipped = !ran
+	return summary, err
+}
+
+// cancelBookingBatch ends up to cancelAllBatchSize of the event's active holds
+// and cancels up to cancelAllBatchSize of its live bookings in one
+// transaction, and reports how many bookings, holds and seats it released.
+// Zero bookings and holds means none are left. Holds go first: converting a
+// hold locks it before the event row, and so must this.
+func cancelBookingBatch(ctx context.Context, pool *pgxpool.Pool, notifier Notifier, seatHub *realtime.Hub, provider payments.Provider, eventID pgtype.UUID, actorID string) (int, int, int, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := db.New(tx)
+
+	if err := q.SetSeatAuditContext(ctx, db.SetSeatAuditContextParams{
+		ActorID:       actorID,
+		Reason:        "bulk_cancellation",
+		CorrelationID: tracing.CorrelationID(ctx),
+	}); err != nil {
+		return 0, 0, 0, fmt.Errorf("set seat audit context: %w", err)
+	}
+
+	holds, err := q.ExpireHoldBatchByEvent(ctx, db.ExpireHoldBatchByEventParams{
+		EventID: eventID,
+		Limit:   cancelAllBatchSize,
+	})
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("expire holds: %w", err)
+	}
+	var heldSeatIDs []pgtype.UUID
+	for _, h := range holds {
+		if err := q.UpdateSeatsToAvailableByHold(ctx, db.UpdateSeatsToAvailableByHoldParams{
+			HoldToken: pgtype.Text{String: h.HoldToken, Valid: true},
+			Column2:   h.SeatIds,
+		}); err != nil {
+			return 0, 0, 0, fmt.Errorf("release held seats: %w", err)
+		}
+		heldSeatIDs = append(heldSeatIDs, h.SeatIds...)
+	}
+
+	bookings, err := q.LockActiveBookingsByEvent(ctx, db.LockActiveBookingsByEventParams{
+		EventID: eventID,
+		Limit:   cancelAllBatchSize,
+	})
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("lock bookings: %w", err)
+	}
+	if len(bookings) == 0 && len(holds) == 0 {
+		return 0, 0, 0, nil
+	}
+
+	var seatNos []string
+	if len(heldSeatIDs) > 0 {
+		// Only needed for the live seat stream.
+		if seatNos, err = q.GetSeatNosByIds(ctx, heldSeatIDs); err != nil {
+			return 0, 0, 0, fmt.Errorf("load held seat numbers: %w", err)
+		}
+	}
+	ids := make([]pgtype.UUID, 0, len(bookings))
+	var seatIDs []pgtype.UUID
+	for _, b := range bookings {
+		ids = append(ids, b.ID)
+		seatIDs = append(seatIDs, b.SeatIds...)
+		seatNos = append(seatNos, b.SeatNos...)
+	}
+
+	if len(ids) > 0 {
+		if _, err := q.CancelBookingsByIDs(ctx, ids); err != nil {
+			return 0, 0, 0, fmt.Errorf("cancel bookings: %w", err)
+		}
+	}
+	if len(seatIDs) > 0 {
+		if err := q.UpdateSeatsToAvailableByIds(ctx, seatIDs); err != nil {
+			return 0, 0, 0, fmt.Errorf("release seats: %w", err)
+		}
+		if err := q.UpdateEventBookedCountByDelta(ctx, db.UpdateEventBookedCountByDeltaParams{
+			BookedCount: -int32(len(seatIDs)),
+			ID:          eventID,
+		}); err != nil {
+			return 0, 0, 0, fmt.Errorf("update booked_count: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, 0, fmt.Errorf("commit: %w", err)
+	}
+
+	seatHub.Publish(eventID.Bytes, "available", seatNos)
+	if notifier != nil {
+		for _, b := range bookings {
+			if !b.UserID.Valid {
+				continue
+			}
+			if err := notifier.Notify(mail.KindBookingCancelled, mail.CancellationJob{
+				BookingID:   b.ID.String(),
+				EventID:     eventID.String(),
+				UserID:      b.UserID.String(),
+				SeatNumbers: b.SeatNos,
+			}); err != nil {
+				fmt.Printf("failed to queue cancellation notice for booking %s: %v\n", b.ID.String(), err)
+			}
+		}
+	}
+	for _, b := range bookings {
+		if b.Status == "pending_payment" {
+			CancelPaymentIntent(ctx, provider, b.PaymentIntentID)
+		}
+	}
+	return len(bookings), len(holds), len(seatIDs) + len(heldSeatIDs), nil
+}
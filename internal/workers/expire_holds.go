@@ -6,25 +6,49 @@ import (
 	"sync"
 
 	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
+	"github.com/abhinandanwadwa/overbookr/internal/tracing"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // HoldExpiryWorker expires seat_holds that passed their expires_at and frees seats.
 type HoldExpiryWorker struct {
 	Pool *pgxpool.Pool
+	// Notifier is handed to the waitlist promoter for promotion notices.
+	Notifier Notifier
+	// SeatHub is told about seats released by expired holds.
+	SeatHub *realtime.Hub
 }
 
 // NewHoldExpiryWorker constructs the worker.
-func NewHoldExpiryWorker(pool *pgxpool.Pool) *HoldExpiryWorker {
-	return &HoldExpiryWorker{Pool: pool}
+func NewHoldExpiryWorker(pool *pgxpool.Pool, notifier Notifier, seatHub *realtime.Hub) *HoldExpiryWorker {
+	return &HoldExpiryWorker{Pool: pool, Notifier: notifier, SeatHub: seatHub}
 }
 
 // ExpireHolds looks for active seat_holds with expires_at <= now, expires them and frees seats.
-// It runs one short transaction per hold.
-func (w *HoldExpiryWorker) ExpireHolds(ctx context.Context) error {
+// It runs one short transaction per hold. Only one replica sweeps at a time; if another
+// instance holds the advisory lock this pass is a no-op.
+func (w *HoldExpiryWorker) ExpireHolds(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HoldExpiryWorker.ExpireHolds")
+	defer func() { tracing.End(span, err) }()
+
+	ran, err := withAdvisoryLock(ctx, w.Pool, holdExpiryLockKey, w.expireHolds)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.Bool("worker.skipped", !ran))
+	if !ran {
+		fmt.Println("HoldExpiryWorker: another instance is sweeping, skipping")
+	}
+	return nil
+}
+
+func (w *HoldExpiryWorker) expireHolds(ctx context.Context) error {
 	// simple log line for observability
 	fmt.Println("HoldExpiryWorker: checking for expired holds...")
 
@@ -95,7 +119,13 @@ func (w *HoldExpiryWorker) ExpireHolds(ctx context.Context) error {
 	return nil
 }
 
-func (w *HoldExpiryWorker) processSingleHold(ctx context.Context, holdID uuid.UUID, token string, eventID uuid.UUID, seatIDs []uuid.UUID) error {
+func (w *HoldExpiryWorker) processSingleHold(ctx context.Context, holdID uuid.UUID, token string, eventID uuid.UUID, seatIDs []uuid.UUID) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HoldExpiryWorker.expireHold", trace.WithAttributes(
+		attribute.String("hold.id", holdID.String()),
+		attribute.String("event.id", eventID.String()),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	// Begin a transaction using the pool (this acquires a connection from the pool)
 	tx, err := w.Pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
@@ -126,6 +156,13 @@ func (w *HoldExpiryWorker) processSingleHold(ctx context.Context, holdID uuid.UU
 		pgSeatIDs[i] = pgtype.UUID{Bytes: id, Valid: true}
 	}
 
+	if err := q.SetSeatAuditContext(ctx, db.SetSeatAuditContextParams{
+		Reason:        "hold_expired",
+		CorrelationID: tracing.CorrelationID(ctx),
+	}); err != nil {
+		return fmt.Errorf("set seat audit context: %w", err)
+	}
+
 	// Update seats only if hold_token matches (defensive)
 	if err := q.UpdateSeatsToAvailableByHold(ctx, db.UpdateSeatsToAvailableByHoldParams{
 		HoldToken: pgtype.Text{String: token, Valid: true},
@@ -134,6 +171,12 @@ func (w *HoldExpiryWorker) processSingleHold(ctx context.Context, holdID uuid.UU
 		return fmt.Errorf("update seats: %w", err)
 	}
 
+	// Only needed for the live seat stream, so a failure isn't fatal.
+	releasedSeatNos, err := q.GetSeatNosByIds(ctx, pgSeatIDs)
+	if err != nil {
+		fmt.Printf("failed to load seat numbers for hold %s: %v\n", holdID.String(), err)
+	}
+
 	// Mark the seat_hold as expired
 	pgHoldID := pgtype.UUID{Bytes: holdID, Valid: true}
 	if err := q.MarkSeatHoldExpired(ctx, pgHoldID); err != nil {
@@ -145,12 +188,13 @@ func (w *HoldExpiryWorker) processSingleHold(ctx context.Context, holdID uuid.UU
 	}
 
 	rolledBack = true // Mark as committed so defer won't rollback
+	w.SeatHub.Publish(eventID, "available", releasedSeatNos)
 	return nil
 }
 
 // processWaitlistForEvent handles waitlist promotion for a single event
 func (w *HoldExpiryWorker) processWaitlistForEvent(ctx context.Context, eventID uuid.UUID) error {
 	// Create a waitlist worker bound to the same pool
-	promoter := NewWaitlistWorkerFromPool(w.Pool)
+	promoter := NewWaitlistWorker(w.Pool, w.Notifier, w.SeatHub)
 	return promoter.ProcessWaitlistForEvent(ctx, eventID)
 }
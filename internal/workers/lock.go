@@ -0,0 +1,47 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Advisory lock keys, one per worker type, so different sweeps never block each other.
+const (
+	holdExpiryLockKey   int64 = 7_100_001
+	reconcileLockKey    int64 = 7_100_002
+	reminderLockKey     int64 = 7_100_003
+	paymentSweepLockKey int64 = 7_100_004
+)
+
+// withAdvisoryLock runs fn only if this process wins pg_try_advisory_lock(key).
+// The lock is session-scoped, so it is held on a dedicated pool connection for
+// the duration of fn and released afterwards. It reports whether fn ran; when
+// another replica holds the lock it returns (false, nil) immediately.
+func withAdvisoryLock(ctx context.Context, pool *pgxpool.Pool, key int64, fn func(ctx context.Context) error) (bool, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("acquire lock conn: %w", err)
+	}
+	defer conn.Release()
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&acquired); err != nil {
+		return false, fmt.Errorf("try advisory lock %d: %w", key, err)
+	}
+	if !acquired {
+		return false, nil
+	}
+
+	defer func() {
+		// Use a fresh context: if ctx was cancelled mid-sweep we still must release the lock.
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			fmt.Printf("failed to release advisory lock %d: %v\n", key, err)
+			// Closing the connection ends the session, which drops the lock with it.
+			_ = conn.Conn().Close(context.Background())
+		}
+	}()
+
+	return true, fn(ctx)
+}
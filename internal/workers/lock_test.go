@@ -0,0 +1,181 @@
+//go:build integration
+
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
+	"github.com/abhinandanwadwa/overbookr/internal/testdb"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+// holdLock takes the session lock key on a connection of its own, as another
+// replica would, and returns a func that releases it.
+func holdLock(t *testing.T, pool *pgxpool.Pool, key int64) func() {
+	t.Helper()
+	ctx := context.Background()
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, key); err != nil {
+		conn.Release()
+		t.Fatalf("lock %d: %v", key, err)
+	}
+	released := false
+	release := func() {
+		if released {
+			return
+		}
+		released = true
+		if _, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			t.Errorf("unlock %d: %v", key, err)
+		}
+		conn.Release()
+	}
+	t.Cleanup(release)
+	return release
+}
+
+func TestAdvisoryLockLetsOneWorkerRun(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	first := make(chan error, 1)
+	go func() {
+		ran, err := withAdvisoryLock(ctx, pool, holdExpiryLockKey, func(context.Context) error {
+			close(started)
+			<-finish
+			return nil
+		})
+		if err == nil && !ran {
+			err = errors.New("first worker did not run")
+		}
+		first <- err
+	}()
+	<-started
+
+	// A second worker contending for the same key gives up straight away.
+	begin := time.Now()
+	ran, err := withAdvisoryLock(ctx, pool, holdExpiryLockKey, func(context.Context) error {
+		t.Error("second worker ran while the first held the lock")
+		return nil
+	})
+	if err != nil || ran {
+		t.Fatalf("second worker: ran = %v, err = %v; want false, nil", ran, err)
+	}
+	if d := time.Since(begin); d > time.Second {
+		t.Errorf("second worker took %v to give up", d)
+	}
+
+	// Other worker types have keys of their own.
+	ran, err = withAdvisoryLock(ctx, pool, reconcileLockKey, func(context.Context) error { return nil })
+	if err != nil || !ran {
+		t.Fatalf("reconcile key: ran = %v, err = %v; want true, nil", ran, err)
+	}
+
+	close(finish)
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+
+	// Released afterwards, so the next pass runs.
+	ran, err = withAdvisoryLock(ctx, pool, holdExpiryLockKey, func(context.Context) error { return nil })
+	if err != nil || !ran {
+		t.Fatalf("after release: ran = %v, err = %v; want true, nil", ran, err)
+	}
+}
+
+func TestAdvisoryLockReleasedWhenFnFails(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+
+	boom := errors.New("boom")
+	if _, err := withAdvisoryLock(ctx, pool, holdExpiryLockKey, func(context.Context) error { return boom }); !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+	ran, err := withAdvisoryLock(ctx, pool, holdExpiryLockKey, func(context.Context) error { return nil })
+	if err != nil || !ran {
+		t.Fatalf("after a failed pass: ran = %v, err = %v; want true, nil", ran, err)
+	}
+}
+
+func TestReconcileSkipsWhileAnotherReplicaHoldsTheLock(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	w := NewReconcileWorker(pool, time.Hour, 15*time.Minute)
+
+	release := holdLock(t, pool, reconcileLockKey)
+	summary, err := w.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if !summary.Skipped {
+		t.Error("Reconcile ran while another replica held the lock")
+	}
+
+	release()
+	summary, err = w.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if summary.Skipped {
+		t.Error("Reconcile skipped with the lock free")
+	}
+}
+
+func TestExpireHoldsSkipsWhileAnotherReplicaHoldsTheLock(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+
+	var holdID string
+	err := pool.QueryRow(ctx, `
+		WITH e AS (
+			INSERT INTO events (name, capacity) VALUES ('Locked', 1) RETURNING id
+		), s AS (
+			INSERT INTO seats (event_id, seat_no, status, hold_token, hold_expires_at)
+			SELECT id, 'A1', 'held', 'tok', now() - interval '1 minute' FROM e
+			RETURNING id, event_id
+		)
+		INSERT INTO seat_holds (hold_token, event_id, seat_ids, expires_at)
+		SELECT 'tok', event_id, ARRAY[id], now() - interval '1 minute' FROM s
+		RETURNING id::text
+	`).Scan(&holdID)
+	if err != nil {
+		t.Fatalf("seed expired hold: %v", err)
+	}
+	holdStatus := func() string {
+		var status string
+		if err := pool.QueryRow(ctx, `SELECT status FROM seat_holds WHERE id = $1`, holdID).Scan(&status); err != nil {
+			t.Fatalf("load hold: %v", err)
+		}
+		return status
+	}
+
+	w := NewHoldExpiryWorker(pool, nil, realtime.NewHub())
+	release := holdLock(t, pool, holdExpiryLockKey)
+	if err := w.ExpireHolds(ctx); err != nil {
+		t.Fatalf("ExpireHolds: %v", err)
+	}
+	if got := holdStatus(); got != "active" {
+		t.Fatalf("hold status = %q while another replica held the lock, want active", got)
+	}
+
+	release()
+	if err := w.ExpireHolds(ctx); err != nil {
+		t.Fatalf("ExpireHolds: %v", err)
+	}
+	if got := holdStatus(); got != "expired" {
+		t.Errorf("hold status = %q, want expired", got)
+	}
+}
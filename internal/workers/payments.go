@@ -0,0 +1,208 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/payments"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
+	"github.com/abhinandanwadwa/overbookr/internal/tracing"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// paymentSweepBatchSize bounds how many unpaid bookings one query loads.
+const paymentSweepBatchSize = 200
+
+// ErrBookingNotPending is returned by ReleasePendingBooking when the booking
+// is no longer awaiting payment.
+var ErrBookingNotPending = errors.New("booking is not pending payment")
+
+// ReleasePendingBooking abandons a pending_payment booking on q's transaction:
+// the booking is marked abandoned, its seats become available again and the
+// event's booked_count drops accordingly. b must have been loaded with
+// GetBookingForUpdate in the same transaction. It returns the released seat
+// numbers for the live seat stream.
+func ReleasePendingBooking(ctx context.Context, q *db.Queries, b db.GetBookingForUpdateRow) ([]string, error) {
+	n, err := q.AbandonPendingBooking(ctx, b.ID)
+	if err != nil {
+		return nil, fmt.Errorf("abandon booking: %w", err)
+	}
+	if n == 0 {
+		return nil, ErrBookingNotPending
+	}
+	if len(b.SeatIds) == 0 {
+		return nil, nil
+	}
+
+	if err := q.UpdateSeatsToAvailableByIds(ctx, b.SeatIds); err != nil {
+		return nil, fmt.Errorf("release seats: %w", err)
+	}
+	// Only needed for the live seat stream, so a failure isn't fatal.
+	seatNos, err := q.GetSeatNosByIds(ctx, b.SeatIds)
+	if err != nil {
+		log.Printf("abandon booking %s: failed to load seat numbers: %v", b.ID.String(), err)
+	}
+	if err := q.UpdateEventBookedCountByDelta(ctx, db.UpdateEventBookedCountByDeltaParams{
+		BookedCount: -int32(len(b.SeatIds)),
+		ID:          b.EventID,
+	}); err != nil {
+		return nil, fmt.Errorf("update booked_count: %w", err)
+	}
+	return seatNos, nil
+}
+
+// CancelPaymentIntent cancels the provider intent of a booking that will no
+// longer be paid for, so the client can't complete it. It does nothing
+// without a provider or intent. Failures are only logged: a payment that
+// still goes through is refunded when its webhook arrives.
+func CancelPaymentIntent(ctx context.Context, provider payments.Provider, intentID pgtype.Text) {
+	if provider == nil || !intentID.Valid {
+		return
+	}
+	if err := provider.CancelIntent(ctx, intentID.String); err != nil {
+		log.Printf("payments: %v", err)
+	}
+}
+
+// PaymentSweepWorker abandons bookings that were never paid for, freeing
+// their seats for the waitlist.
+type PaymentSweepWorker struct {
+	Pool *pgxpool.Pool
+	DB   *db.Queries
+	// Notifier is handed to the waitlist promoter for promotion notices.
+	Notifier Notifier
+	// SeatHub is told about seats released by abandoned bookings.
+	SeatHub *realtime.Hub
+	// Payments cancels the intents of abandoned bookings; nil when payments
+	// are disabled.
+	Payments payments.Provider
+	// Window is how long a booking may stay pending_payment.
+	Window time.Duration
+}
+
+// NewPaymentSweepWorker constructs the worker bound to the shared pool.
+func NewPaymentSweepWorker(pool *pgxpool.Pool, notifier Notifier, seatHub *realtime.Hub, provider payments.Provider, window time.Duration) *PaymentSweepWorker {
+	return &PaymentSweepWorker{
+		Pool:     pool,
+		DB:       db.New(pool),
+		Notifier: notifier,
+		SeatHub:  seatHub,
+		Payments: provider,
+		Window:   window,
+	}
+}
+
+// AbandonUnpaid abandons every booking that has been pending_payment for
+// longer than Window, then runs waitlist promotion for the events that got
+// seats back. It returns how many bookings it abandoned. Only one replica
+// sweeps at a time; if another instance holds the advisory lock this pass is
+// a no-op.
+func (w *PaymentSweepWorker) AbandonUnpaid(ctx context.Context) (abandoned int, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "PaymentSweepWorker.AbandonUnpaid")
+	defer func() {
+		span.SetAttributes(attribute.Int("bookings.abandoned", abandoned))
+		tracing.End(span, err)
+	}()
+
+	ran, err := withAdvisoryLock(ctx, w.Pool, paymentSweepLockKey, func(ctx context.Context) error {
+		var err error
+		abandoned, err = w.abandonUnpaid(ctx)
+		return err
+	})
+	span.SetAttributes(attribute.Bool("worker.skipped", !ran))
+	return abandoned, err
+}
+
+func (w *PaymentSweepWorker) abandonUnpaid(ctx context.Context) (int, error) {
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(-w.Window), Valid: true}
+	eventsToPromote := make(map[uuid.UUID]bool)
+	abandoned := 0
+
+	defer func() {
+		for eventID := range eventsToPromote {
+			promoter := NewWaitlistWorker(w.Pool, w.Notifier, w.SeatHub)
+			if err := promoter.ProcessWaitlistForEvent(ctx, eventID); err != nil {
+				log.Printf("payment sweep: promote failed for event %s: %v", eventID.String(), err)
+			}
+		}
+	}()
+
+	for {
+		rows, err := w.DB.GetStalePendingBookings(ctx, db.GetStalePendingBookingsParams{
+			CreatedAt: cutoff,
+			Limit:     paymentSweepBatchSize,
+		})
+		if err != nil {
+			return abandoned, fmt.Errorf("failed to load unpaid bookings: %w", err)
+		}
+
+		for _, r := range rows {
+			released, err := w.abandon(ctx, r.ID)
+			if err != nil {
+				// Bookings left pending come back on the next pass.
+				log.Printf("payment sweep: booking %s: %v", r.ID.String(), err)
+				return abandoned, nil
+			}
+			if released {
+				abandoned++
+				eventsToPromote[r.EventID.Bytes] = true
+			}
+		}
+		if len(rows) < paymentSweepBatchSize {
+			return abandoned, nil
+		}
+	}
+}
+
+// abandon releases one unpaid booking in its own transaction. It reports
+// false when the booking was confirmed or abandoned in the meantime.
+func (w *PaymentSweepWorker) abandon(ctx context.Context, bookingID pgtype.UUID) (released bool, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "PaymentSweepWorker.abandon",
+		trace.WithAttributes(attribute.String("booking.id", bookingID.String())))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := w.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := db.New(tx)
+
+	b, err := q.GetBookingForUpdate(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("lock booking: %w", err)
+	}
+	if b.Status != "pending_payment" {
+		return false, nil
+	}
+
+	if err := q.SetSeatAuditContext(ctx, db.SetSeatAuditContextParams{
+		Reason:        "payment_timeout",
+		CorrelationID: tracing.CorrelationID(ctx),
+	}); err != nil {
+		return false, fmt.Errorf("set seat audit context: %w", err)
+	}
+	seatNos, err := ReleasePendingBooking(ctx, q, b)
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit: %w", err)
+	}
+
+	w.SeatHub.Publish(b.EventID.Bytes, "available", seatNos)
+	CancelPaymentIntent(ctx, w.Payments, b.PaymentIntentID)
+	return true, nil
+}
@@ -3,51 +3,60 @@ package workers
 import (
 	"context"
 	"fmt"
+	"log"
 
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
 	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/realtime"
+	"github.com/abhinandanwadwa/overbookr/internal/tracing"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type WaitlistWorker struct {
-	DBConn *pgxpool.Pool
-	Pool   *pgxpool.Pool
-	DB     *db.Queries
+// Notifier is the part of the mail queue the workers need; *mail.Queue
+// satisfies it.
+type Notifier interface {
+	// Notify queues a notice on every channel the kind has, retrying it and
+	// parking it in the outbox like any other mail.
+	Notify(kind string, payload any) error
+	// NotifyTx records a notice in the outbox as part of q's transaction.
+	NotifyTx(ctx context.Context, q *db.Queries, kind string, payload any) error
+	// Wake starts delivery of freshly committed notices.
+	Wake()
 }
 
-func NewWaitlistWorker(conn *pgxpool.Pool) *WaitlistWorker {
-	return &WaitlistWorker{
-		DBConn: conn,
-		DB:     db.New(conn),
-	}
+// WaitlistWorker promotes waiting users into bookings when seats free up.
+type WaitlistWorker struct {
+	Pool *pgxpool.Pool
+	DB   *db.Queries
+	// Notifier receives the promotion notices; nil disables them.
+	Notifier Notifier
+	// SeatHub is told about seats booked by promotions.
+	SeatHub *realtime.Hub
 }
 
-func NewWaitlistWorkerFromPool(pool *pgxpool.Pool) *WaitlistWorker {
+// NewWaitlistWorker constructs the worker bound to the shared pool.
+func NewWaitlistWorker(pool *pgxpool.Pool, notifier Notifier, seatHub *realtime.Hub) *WaitlistWorker {
 	return &WaitlistWorker{
-		Pool: pool,
-		DB:   db.New(pool),
+		Pool:     pool,
+		DB:       db.New(pool),
+		Notifier: notifier,
+		SeatHub:  seatHub,
 	}
 }
 
-func (w *WaitlistWorker) ProcessWaitlistForEvent(ctx context.Context, eventID uuid.UUID) error {
-	eventParam := pgtype.UUID{Bytes: eventID, Valid: true}
+func (w *WaitlistWorker) ProcessWaitlistForEvent(ctx context.Context, eventID uuid.UUID) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WaitlistWorker.ProcessWaitlistForEvent",
+		trace.WithAttributes(attribute.String("event.id", eventID.String())))
+	defer func() { tracing.End(span, err) }()
 
-	var waiters []db.GetWaitingListByEventRow
-	var err error
+	eventParam := pgtype.UUID{Bytes: eventID, Valid: true}
 
-	if w.Pool != nil {
-		conn, aerr := w.Pool.Acquire(ctx)
-		if aerr != nil {
-			return fmt.Errorf("acquire conn: %w", aerr)
-		}
-		defer conn.Release()
-		q := db.New(conn.Conn())
-		waiters, err = q.GetWaitingListByEvent(ctx, eventParam)
-	} else {
-		waiters, err = w.DB.GetWaitingListByEvent(ctx, eventParam)
-	}
+	waiters, err := w.DB.GetWaitingListByEvent(ctx, eventParam)
 	if err != nil {
 		return fmt.Errorf("failed to load waitlist: %w", err)
 	}
@@ -58,17 +67,9 @@ func (w *WaitlistWorker) ProcessWaitlistForEvent(ctx context.Context, eventID uu
 	for _, candidate := range waiters {
 		n := int32(candidate.RequestedSeats)
 
-		var tx pgx.Tx
-		if w.Pool != nil {
-			tx, err = w.Pool.BeginTx(ctx, pgx.TxOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to begin tx: %w", err)
-			}
-		} else {
-			tx, err = w.DBConn.Begin(ctx)
-			if err != nil {
-				return fmt.Errorf("failed to begin tx: %w", err)
-			}
+		tx, err := w.Pool.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to begin tx: %w", err)
 		}
 
 		rolledBack := false
@@ -81,6 +82,14 @@ func (w *WaitlistWorker) ProcessWaitlistForEvent(ctx context.Context, eventID uu
 
 		qtx := db.New(tx)
 
+		if err := qtx.SetSeatAuditContext(ctx, db.SetSeatAuditContextParams{
+			Reason:        "waitlist_promotion",
+			CorrelationID: tracing.CorrelationID(ctx),
+		}); err != nil {
+			rollbackIfNeeded()
+			continue
+		}
+
 		seats, err := qtx.GetAvailableSeatsForEventForUpdate(ctx, db.GetAvailableSeatsForEventForUpdateParams{EventID: eventParam, Limit: n})
 		if err != nil || int32(len(seats)) < n {
 			rollbackIfNeeded()
@@ -133,22 +142,47 @@ func (w *WaitlistWorker) ProcessWaitlistForEvent(ctx context.Context, eventID uu
 			continue
 		}
 
+		// The notice is written in the same transaction, so a promotion is
+		// never committed without it.
+		if err := w.notifyPromoted(ctx, qtx, bookingRow.ID); err != nil {
+			log.Printf("waitlist: failed to record promotion notice for booking %s: %v", bookingRow.ID.String(), err)
+			rollbackIfNeeded()
+			continue
+		}
+
 		if err := tx.Commit(ctx); err != nil {
 			_ = tx.Rollback(ctx)
 			continue
 		}
 
-		bookingId, perr := uuid.Parse(bookingRow.ID.String())
-		if perr == nil {
-			go NotifyUserPromoted(candidate.UserID, eventID, bookingId, seatNos)
+		w.SeatHub.Publish(eventID, "booked", seatNos)
+		if w.Notifier != nil {
+			w.Notifier.Wake()
 		}
 	}
 
 	return nil
 }
 
-func NotifyUserPromoted(userID pgtype.UUID, eventID, bookingID uuid.UUID, seats []string) {
-	if userID.Valid {
-		fmt.Printf("User %s promoted for event %s (booking %s), seats=%v\n", userID.String(), eventID.String(), bookingID.String(), seats)
+// notifyPromoted writes the "you're off the waitlist" email (and SMS, when
+// SMS is configured) to the mail outbox through qtx. The booking is read back
+// through the same transaction, so the notice matches what commits; the
+// outbox poller delivers it afterwards.
+func (w *WaitlistWorker) notifyPromoted(ctx context.Context, qtx *db.Queries, bookingID pgtype.UUID) error {
+	if w.Notifier == nil {
+		return nil
+	}
+	conf, err := mail.BuildBookingConfirmation(ctx, qtx, bookingID)
+	if err != nil {
+		return err
+	}
+	if conf.UserID == "" {
+		return nil
 	}
+	return w.Notifier.NotifyTx(ctx, qtx, mail.KindWaitlistPromoted, mail.PromotionJob{
+		BookingID:   conf.ID,
+		EventID:     conf.EventID,
+		UserID:      conf.UserID,
+		SeatNumbers: conf.SeatNumbers,
+	})
 }
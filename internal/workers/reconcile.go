@@ -3,42 +3,211 @@ package workers
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/tracing"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// ReconcileWorker performs periodic consistency checks and optionally fixes mismatches.
+// holdPruneBatch bounds how many finished holds one DELETE removes, so the
+// sweep never holds locks on a large slice of seat_holds at once.
+const holdPruneBatch = 1000
+
+// idempotencyPruneBatch does the same for expired idempotency keys.
+const idempotencyPruneBatch = 1000
+
+// ReconcileWorker performs periodic consistency checks and optionally fixes
+// mismatches. Each pass also prunes expired and converted holds older than
+// HoldRetention (zero keeps them forever), login failure counters whose
+// LoginWindow has passed, and idempotency keys whose TTL has.
 type ReconcileWorker struct {
-	DBConn *pgxpool.Pool
+	DBConn        *pgxpool.Pool
+	DB            *db.Queries
+	HoldRetention time.Duration
+	LoginWindow   time.Duration
 }
 
 // NewReconcileWorker constructs the worker
-func NewReconcileWorker(conn *pgxpool.Pool) *ReconcileWorker {
-	return &ReconcileWorker{DBConn: conn}
+func NewReconcileWorker(conn *pgxpool.Pool, holdRetention, loginWindow time.Duration) *ReconcileWorker {
+	return &ReconcileWorker{DBConn: conn, DB: db.New(conn), HoldRetention: holdRetention, LoginWindow: loginWindow}
 }
 
-// ReconcileEventsAndSeats runs reconciliation:
+// ReconcileSummary reports what a reconcile pass found and (unless DryRun) fixed.
+type ReconcileSummary struct {
+	DryRun                bool              `json:"dry_run"`
+	Skipped               bool              `json:"skipped"`
+	EventsFixed           int               `json:"events_fixed"`
+	SeatsFixed            int               `json:"seats_fixed"`
+	HoldsPruned           int64             `json:"holds_pruned"`            // on a dry run, how many would be
+	LoginAttemptsPruned   int64             `json:"login_attempts_pruned"`   // likewise
+	IdempotencyKeysPruned int64             `json:"idempotency_keys_pruned"` // likewise
+	EventCounts           []EventCountDrift `json:"event_counts"`
+	OrphanSeats           []OrphanSeat      `json:"orphan_seats"`
+	Errors                []string          `json:"errors"`
+}
+
+// EventCountDrift is an event whose booked_count disagrees with its active bookings.
+type EventCountDrift struct {
+	EventID     string `json:"event_id"`
+	BookedCount int32  `json:"booked_count"`
+	Actual      int64  `json:"actual"`
+}
+
+// OrphanSeat is a seat marked booked without an active booking behind it.
+type OrphanSeat struct {
+	SeatID  string `json:"seat_id"`
+	EventID string `json:"event_id"`
+}
+
+// Reconcile runs reconciliation:
 // 1) find events where events.booked_count != SUM(active bookings) and fix/log
 // 2) find seats with status='booked' but booking_id doesn't exist and fix/log
-func (r *ReconcileWorker) Reconcile(ctx context.Context) error {
-	if err := r.reconcileEventCounts(ctx); err != nil {
+// 3) delete expired and converted holds past the retention period
+// 4) delete login failure counters past their window
+// 5) delete idempotency keys past their TTL
+// Only one replica reconciles at a time; if another instance holds the advisory lock
+// this pass is a no-op and the summary is marked Skipped.
+func (r *ReconcileWorker) Reconcile(ctx context.Context) (summary ReconcileSummary, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ReconcileWorker.Reconcile")
+	defer func() {
+		span.SetAttributes(
+			attribute.Bool("worker.skipped", summary.Skipped),
+			attribute.Int("reconcile.events_fixed", summary.EventsFixed),
+			attribute.Int("reconcile.seats_fixed", summary.SeatsFixed),
+			attribute.Int64("reconcile.holds_pruned", summary.HoldsPruned),
+			attribute.Int64("reconcile.login_attempts_pruned", summary.LoginAttemptsPruned),
+			attribute.Int64("reconcile.idempotency_keys_pruned", summary.IdempotencyKeysPruned),
+		)
+		tracing.End(span, err)
+	}()
+
+	ran, err := withAdvisoryLock(ctx, r.DBConn, reconcileLockKey, func(ctx context.Context) error {
+		return r.reconcile(ctx, &summary)
+	})
+	if err != nil {
+		return summary, err
+	}
+	if !ran {
+		fmt.Println("ReconcileWorker: another instance is reconciling, skipping")
+		summary.Skipped = true
+	}
+	return summary, nil
+}
+
+// Preview reports what Reconcile would fix without writing anything.
+func (r *ReconcileWorker) Preview(ctx context.Context) (ReconcileSummary, error) {
+	summary := ReconcileSummary{DryRun: true}
+	err := r.reconcile(ctx, &summary)
+	return summary, err
+}
+
+func (r *ReconcileWorker) reconcile(ctx context.Context, summary *ReconcileSummary) error {
+	summary.EventCounts = []EventCountDrift{}
+	summary.OrphanSeats = []OrphanSeat{}
+	summary.Errors = []string{}
+
+	if err := r.reconcileEventCounts(ctx, summary); err != nil {
 		return fmt.Errorf("reconcile event counts: %w", err)
 	}
-	if err := r.reconcileOrphanBookedSeats(ctx); err != nil {
+	if err := r.reconcileOrphanBookedSeats(ctx, summary); err != nil {
 		return fmt.Errorf("reconcile orphan seats: %w", err)
 	}
+	if err := r.pruneFinishedHolds(ctx, summary); err != nil {
+		return fmt.Errorf("prune finished holds: %w", err)
+	}
+	if err := r.pruneLoginAttempts(ctx, summary); err != nil {
+		return fmt.Errorf("prune login attempts: %w", err)
+	}
+	if err := r.pruneIdempotencyKeys(ctx, summary); err != nil {
+		return fmt.Errorf("prune idempotency keys: %w", err)
+	}
 	return nil
 }
 
-func (r *ReconcileWorker) reconcileEventCounts(ctx context.Context) error {
+// pruneIdempotencyKeys deletes idempotency keys whose TTL has passed, in
+// batches of idempotencyPruneBatch. A claim replaces an expired key anyway,
+// so they only take up space.
+func (r *ReconcileWorker) pruneIdempotencyKeys(ctx context.Context, summary *ReconcileSummary) error {
+	if summary.DryRun {
+		n, err := r.DB.CountExpiredIdempotencyKeys(ctx)
+		summary.IdempotencyKeysPruned = n
+		return err
+	}
+	for {
+		n, err := r.DB.DeleteExpiredIdempotencyKeys(ctx, idempotencyPruneBatch)
+		if err != nil {
+			return err
+		}
+		summary.IdempotencyKeysPruned += n
+		if n < idempotencyPruneBatch {
+			return nil
+		}
+	}
+}
+
+// pruneLoginAttempts deletes login failure counters whose window started
+// more than LoginWindow ago and that hold no active lockout. Without it the
+// table keeps a row for every email anyone ever mistyped a password for.
+func (r *ReconcileWorker) pruneLoginAttempts(ctx context.Context, summary *ReconcileSummary) error {
+	if r.LoginWindow <= 0 {
+		return nil
+	}
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(-r.LoginWindow), Valid: true}
+
+	var err error
+	if summary.DryRun {
+		summary.LoginAttemptsPruned, err = r.DB.CountStaleLoginAttempts(ctx, cutoff)
+		return err
+	}
+	summary.LoginAttemptsPruned, err = r.DB.DeleteStaleLoginAttempts(ctx, cutoff)
+	return err
+}
+
+// pruneFinishedHolds deletes expired and converted holds that expired more
+// than HoldRetention ago, in batches of holdPruneBatch.
+func (r *ReconcileWorker) pruneFinishedHolds(ctx context.Context, summary *ReconcileSummary) error {
+	if r.HoldRetention <= 0 {
+		return nil
+	}
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(-r.HoldRetention), Valid: true}
+
+	if summary.DryRun {
+		n, err := r.DB.CountFinishedSeatHolds(ctx, cutoff)
+		if err != nil {
+			return err
+		}
+		summary.HoldsPruned = n
+		return nil
+	}
+
+	for {
+		n, err := r.DB.DeleteFinishedSeatHolds(ctx, db.DeleteFinishedSeatHoldsParams{ExpiresAt: cutoff, Limit: holdPruneBatch})
+		if err != nil {
+			return err
+		}
+		summary.HoldsPruned += n
+		if n < holdPruneBatch {
+			break
+		}
+	}
+	if summary.HoldsPruned > 0 {
+		fmt.Printf("pruned %d finished holds older than %s\n", summary.HoldsPruned, r.HoldRetention)
+	}
+	return nil
+}
+
+func (r *ReconcileWorker) reconcileEventCounts(ctx context.Context, summary *ReconcileSummary) error {
 	rows, err := r.DBConn.Query(ctx, `
 		SELECT e.id, e.booked_count, COALESCE(b.cnt,0) AS actual
 		FROM events e
 		LEFT JOIN (
 		  SELECT event_id, SUM(seats) AS cnt
 		  FROM bookings
-		  WHERE status = 'active'
+		  WHERE status IN ('active', 'pending_payment')
 		  GROUP BY event_id
 		) b ON e.id = b.event_id
 		WHERE e.booked_count IS DISTINCT FROM COALESCE(b.cnt,0)
@@ -66,28 +235,55 @@ func (r *ReconcileWorker) reconcileEventCounts(ctx context.Context) error {
 	}
 
 	for _, m := range mismatches {
-		// Decide whether to auto-fix or log. Here we fix by setting events.booked_count = actual
-		_, err := r.DBConn.Exec(ctx, `
+		summary.EventCounts = append(summary.EventCounts, EventCountDrift{
+			EventID:     m.EventID.String(),
+			BookedCount: m.BookedCount,
+			Actual:      m.Actual,
+		})
+	}
+
+	if summary.DryRun || len(mismatches) == 0 {
+		return nil
+	}
+
+	// Apply all count fixes in one transaction so a crash mid-sweep can't leave
+	// some events corrected and others not.
+	tx, err := r.DBConn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, m := range mismatches {
+		// Fix by setting events.booked_count = actual
+		if _, err := tx.Exec(ctx, `
 			UPDATE events SET booked_count = $1, updated_at = now() WHERE id = $2
-		`, m.Actual, m.EventID)
-		if err != nil {
-			// log and continue
-			fmt.Printf("failed to fix event %s: %v\n", m.EventID.String(), err)
-			continue
+		`, m.Actual, m.EventID); err != nil {
+			summary.addError("failed to fix event %s: %v", m.EventID.String(), err)
+			return nil
 		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		summary.addError("commit event count fixes failed: %v", err)
+		return nil
+	}
+
+	for _, m := range mismatches {
 		fmt.Printf("fixed event %s: booked_count %d -> %d\n", m.EventID.String(), m.BookedCount, m.Actual)
 	}
+	summary.EventsFixed = len(mismatches)
 
 	return nil
 }
 
-func (r *ReconcileWorker) reconcileOrphanBookedSeats(ctx context.Context) error {
-	// find seats that are marked 'booked' but whose booking_id doesn't exist or is not active
+func (r *ReconcileWorker) reconcileOrphanBookedSeats(ctx context.Context, summary *ReconcileSummary) error {
+	// find seats that are marked 'booked' but whose booking_id doesn't exist or no longer holds them
 	rows, err := r.DBConn.Query(ctx, `
 		SELECT s.id, s.event_id
 		FROM seats s
 		LEFT JOIN bookings b ON s.booking_id = b.id
-		WHERE s.status = 'booked' AND (b.id IS NULL OR b.status <> 'active')
+		WHERE s.status = 'booked' AND (b.id IS NULL OR b.status NOT IN ('active', 'pending_payment'))
 	`)
 	if err != nil {
 		return fmt.Errorf("query orphan seats: %w", err)
@@ -110,11 +306,22 @@ func (r *ReconcileWorker) reconcileOrphanBookedSeats(ctx context.Context) error
 		return fmt.Errorf("rows err: %w", err)
 	}
 
+	for _, o := range orphans {
+		summary.OrphanSeats = append(summary.OrphanSeats, OrphanSeat{
+			SeatID:  o.SeatID.String(),
+			EventID: o.EventID.String(),
+		})
+	}
+
+	if summary.DryRun {
+		return nil
+	}
+
 	for _, o := range orphans {
 		// fix: set seat available and clear booking_id; decrement event booked_count by 1
 		tx, err := r.DBConn.Begin(ctx)
 		if err != nil {
-			fmt.Printf("begin tx for orphan seat %s failed: %v\n", o.SeatID, err)
+			summary.addError("begin tx for orphan seat %s failed: %v", o.SeatID, err)
 			continue
 		}
 		rolledBack := false
@@ -132,7 +339,7 @@ func (r *ReconcileWorker) reconcileOrphanBookedSeats(ctx context.Context) error
 			WHERE id = $1
 		`, o.SeatID); err != nil {
 			rollback()
-			fmt.Printf("failed to fix seat %s: %v\n", o.SeatID, err)
+			summary.addError("failed to fix seat %s: %v", o.SeatID, err)
 			continue
 		}
 
@@ -143,18 +350,26 @@ func (r *ReconcileWorker) reconcileOrphanBookedSeats(ctx context.Context) error
 			WHERE id = $1
 		`, o.EventID); err != nil {
 			rollback()
-			fmt.Printf("failed to decrement event %s: %v\n", o.EventID, err)
+			summary.addError("failed to decrement event %s: %v", o.EventID, err)
 			continue
 		}
 
 		if err := tx.Commit(ctx); err != nil {
 			rollback()
-			fmt.Printf("commit failed for orphan seat %s: %v\n", o.SeatID, err)
+			summary.addError("commit failed for orphan seat %s: %v", o.SeatID, err)
 			continue
 		}
 
 		fmt.Printf("fixed orphan seat %s for event %s\n", o.SeatID, o.EventID)
+		summary.SeatsFixed++
 	}
 
 	return nil
 }
+
+// addError logs a non-fatal problem and records it on the summary.
+func (s *ReconcileSummary) addError(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	fmt.Println(msg)
+	s.Errors = append(s.Errors, msg)
+}
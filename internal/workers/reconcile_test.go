@@ -0,0 +1,57 @@
+//go:build integration
+
+package workers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abhinandanwadwa/overbookr/internal/testdb"
+)
+
+func TestReconcilePrunesExpiredIdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at)
+		VALUES ('booking', 'expired', 'fp', now() - interval '1 minute'),
+		       ('booking', 'live', 'fp', now() + interval '1 hour')`); err != nil {
+		t.Fatalf("seed keys: %v", err)
+	}
+	r := NewReconcileWorker(pool, time.Hour, 15*time.Minute)
+
+	preview, err := r.Preview(ctx)
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if preview.IdempotencyKeysPruned != 1 {
+		t.Errorf("preview would prune %d keys, want 1", preview.IdempotencyKeysPruned)
+	}
+
+	summary, err := r.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if summary.IdempotencyKeysPruned != 1 {
+		t.Errorf("pruned %d keys, want 1", summary.IdempotencyKeysPruned)
+	}
+	var left []string
+	rows, err := pool.Query(ctx, `SELECT key FROM idempotency_keys ORDER BY key`)
+	if err != nil {
+		t.Fatalf("list keys: %v", err)
+	}
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			t.Fatalf("scan key: %v", err)
+		}
+		left = append(left, k)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("list keys: %v", err)
+	}
+	if len(left) != 1 || left[0] != "live" {
+		t.Errorf("keys left = %v, want [live]", left)
+	}
+}
@@ -0,0 +1,121 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	mail "github.com/abhinandanwadwa/overbookr/internal/api/utils"
+	"github.com/abhinandanwadwa/overbookr/internal/db"
+	"github.com/abhinandanwadwa/overbookr/internal/tracing"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// reminderBatchSize bounds how many bookings one query loads.
+const reminderBatchSize = 200
+
+// ReminderWorker emails booking holders shortly before their event starts.
+type ReminderWorker struct {
+	Pool     *pgxpool.Pool
+	DB       *db.Queries
+	Notifier Notifier
+	// LeadTime is how long before the event start reminders go out.
+	LeadTime time.Duration
+}
+
+// NewReminderWorker constructs the worker bound to the shared pool.
+func NewReminderWorker(pool *pgxpool.Pool, notifier Notifier, leadTime time.Duration) *ReminderWorker {
+	return &ReminderWorker{
+		Pool:     pool,
+		DB:       db.New(pool),
+		Notifier: notifier,
+		LeadTime: leadTime,
+	}
+}
+
+// SendReminders queues a reminder for every active booking whose event starts
+// within LeadTime and that has not been reminded yet, and returns how many it
+// queued. Only one replica sends at a time; if another instance holds the
+// advisory lock this pass is a no-op.
+func (w *ReminderWorker) SendReminders(ctx context.Context) (sent int, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ReminderWorker.SendReminders")
+	defer func() {
+		span.SetAttributes(attribute.Int("reminders.sent", sent))
+		tracing.End(span, err)
+	}()
+
+	ran, err := withAdvisoryLock(ctx, w.Pool, reminderLockKey, func(ctx context.Context) error {
+		var err error
+		sent, err = w.sendReminders(ctx)
+		return err
+	})
+	span.SetAttributes(attribute.Bool("worker.skipped", !ran))
+	if sent > 0 {
+		w.Notifier.Wake()
+	}
+	return sent, err
+}
+
+func (w *ReminderWorker) sendReminders(ctx context.Context) (int, error) {
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(w.LeadTime), Valid: true}
+	sent := 0
+	for {
+		rows, err := w.DB.GetUpcomingBookingsNeedingReminder(ctx, db.GetUpcomingBookingsNeedingReminderParams{
+			StartTime: cutoff,
+			Limit:     reminderBatchSize,
+		})
+		if err != nil {
+			return sent, fmt.Errorf("failed to load bookings needing reminders: %w", err)
+		}
+
+		for _, r := range rows {
+			queued, err := w.remind(ctx, r)
+			if err != nil {
+				// Unmarked bookings come back on the next pass.
+				log.Printf("reminders: booking %s: %v", r.ID.String(), err)
+				return sent, nil
+			}
+			if queued {
+				sent++
+			}
+		}
+		if len(rows) < reminderBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// remind marks the booking reminded and queues its email in one transaction,
+// so the reminder is sent at least once and the booking is never picked again.
+// It reports false when the booking had already been reminded.
+func (w *ReminderWorker) remind(ctx context.Context, r db.GetUpcomingBookingsNeedingReminderRow) (bool, error) {
+	tx, err := w.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := db.New(tx)
+
+	marked, err := qtx.MarkBookingReminded(ctx, r.ID)
+	if err != nil {
+		return false, fmt.Errorf("mark reminded: %w", err)
+	}
+	if marked == 0 {
+		return false, nil
+	}
+	if err := w.Notifier.NotifyTx(ctx, qtx, mail.KindEventReminder, mail.ReminderJob{
+		BookingID:   r.ID.String(),
+		EventID:     r.EventID.String(),
+		UserID:      r.UserID.String(),
+		SeatNumbers: r.SeatNos,
+	}); err != nil {
+		return false, fmt.Errorf("queue reminder: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,16 @@
+-- mail_outbox (emails that could not be delivered in-process; retried by the mail queue)
+CREATE TABLE IF NOT EXISTS mail_outbox (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  kind TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending','dead')),
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  created_at TIMESTAMPTZ DEFAULT now(),
+  updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_outbox_due ON mail_outbox (status, next_attempt_at);
+
+CREATE TRIGGER trg_mail_outbox_updated_at BEFORE UPDATE ON mail_outbox FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
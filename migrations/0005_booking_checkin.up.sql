@@ -0,0 +1,2 @@
+-- Gate check-in: set once when a ticket is scanned, so it can't be reused
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS checked_in_at TIMESTAMPTZ NULL;
@@ -0,0 +1,5 @@
+-- booked_count must never exceed capacity. Drifted counts can be repaired with
+-- POST /admin/reconcile first; genuinely oversold events need their capacity
+-- raised before this migration will apply.
+ALTER TABLE events
+  ADD CONSTRAINT events_booked_count_within_capacity CHECK (booked_count <= capacity);
@@ -0,0 +1,4 @@
+-- Archive events instead of deleting them so bookings and analytics keep their history.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS idx_events_live_start_time ON events(start_time) WHERE deleted_at IS NULL;
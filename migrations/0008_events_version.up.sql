@@ -0,0 +1,2 @@
+-- Bumped on every edit so concurrent admin updates can detect stale writes.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,8 @@
+-- organizer: manages only the events it owns; gate: check-in only
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user','admin','organizer','gate'));
+
+-- The account that created the event; organizers may only manage their own.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS owner_id UUID NULL REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_events_owner ON events(owner_id);
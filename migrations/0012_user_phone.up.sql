@@ -0,0 +1,2 @@
+-- Optional E.164 phone number for SMS notifications.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone TEXT;
@@ -0,0 +1,13 @@
+-- First-class description and cover image, previously stuffed into metadata.
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS description TEXT,
+  ADD COLUMN IF NOT EXISTS image_url TEXT;
+
+-- Carry over values clients already kept in metadata; metadata itself is left alone.
+UPDATE events
+SET description = metadata->>'description'
+WHERE description IS NULL AND jsonb_typeof(metadata->'description') = 'string';
+
+UPDATE events
+SET image_url = metadata->>'image_url'
+WHERE image_url IS NULL AND metadata->>'image_url' ~* '^https?://';
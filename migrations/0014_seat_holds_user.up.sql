@@ -0,0 +1,2 @@
+-- Supports GET /holds, which lists a user's holds.
+CREATE INDEX IF NOT EXISTS idx_seat_holds_user ON seat_holds (user_id, expires_at);
@@ -0,0 +1,48 @@
+-- Database-level guard against double-booking. bookings keeps its seat_ids
+-- array; booking_seats mirrors it one row per seat, maintained by trigger, so
+-- a unique partial index can refuse a second active booking for a seat no
+-- matter which code path inserts it.
+CREATE TABLE IF NOT EXISTS booking_seats (
+  booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+  seat_id UUID NOT NULL REFERENCES seats(id) ON DELETE CASCADE,
+  active BOOLEAN NOT NULL,
+  PRIMARY KEY (booking_id, seat_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_booking_seats_active_seat
+  ON booking_seats (seat_id) WHERE active;
+
+CREATE OR REPLACE FUNCTION sync_booking_seats()
+RETURNS TRIGGER LANGUAGE plpgsql AS $$
+BEGIN
+  IF TG_OP = 'UPDATE' THEN
+    UPDATE booking_seats SET active = false WHERE booking_id = OLD.id AND active;
+  END IF;
+  INSERT INTO booking_seats (booking_id, seat_id, active)
+  SELECT NEW.id, s, NEW.status = 'active' FROM unnest(NEW.seat_ids) AS s
+  ON CONFLICT (booking_id, seat_id) DO UPDATE SET active = EXCLUDED.active;
+  RETURN NEW;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS trg_bookings_sync_seats ON bookings;
+CREATE TRIGGER trg_bookings_sync_seats
+AFTER INSERT OR UPDATE OF status, seat_ids ON bookings
+FOR EACH ROW EXECUTE FUNCTION sync_booking_seats();
+
+-- Backfill. Should legacy data already hold a seat in two active bookings,
+-- the later one is recorded inactive rather than failing the migration.
+INSERT INTO booking_seats (booking_id, seat_id, active)
+SELECT b.id, s, false FROM bookings b, unnest(b.seat_ids) AS s
+WHERE EXISTS (SELECT 1 FROM seats WHERE seats.id = s)
+ON CONFLICT DO NOTHING;
+
+UPDATE booking_seats bs
+SET active = true
+FROM (
+  SELECT DISTINCT ON (s) b.id, s AS seat_id
+  FROM bookings b, unnest(b.seat_ids) AS s
+  WHERE b.status = 'active'
+  ORDER BY s, b.created_at
+) first
+WHERE bs.booking_id = first.id AND bs.seat_id = first.seat_id;
@@ -0,0 +1,8 @@
+-- Per-event waitlist controls. A NULL cap means unlimited; closing the
+-- waitlist stops new joins without touching existing entries.
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS waitlist_cap INT CHECK (waitlist_cap IS NULL OR waitlist_cap >= 0),
+  ADD COLUMN IF NOT EXISTS waitlist_open BOOLEAN NOT NULL DEFAULT true;
+
+-- Supports counting an event's waiting entries.
+CREATE INDEX IF NOT EXISTS idx_waitlist_event_waiting ON waitlist (event_id) WHERE status = 'waiting';
@@ -0,0 +1,7 @@
+-- One row per booking that has been sent its event reminder. The reminder
+-- worker inserts the row in the same transaction that queues the email, so a
+-- booking is reminded at most once.
+CREATE TABLE IF NOT EXISTS booking_reminders (
+  booking_id UUID PRIMARY KEY REFERENCES bookings(id) ON DELETE CASCADE,
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,5 @@
+-- Per-event sender overrides for booking emails. NULL falls back to the
+-- global MAIL_FROM display name and no Reply-To.
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS sender_name TEXT,
+  ADD COLUMN IF NOT EXISTS reply_to TEXT;
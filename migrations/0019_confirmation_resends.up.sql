@@ -0,0 +1,7 @@
+-- When each booking's confirmation was last resent on request. POST
+-- /bookings/:id/resend-confirmation claims the row atomically, which keeps
+-- the per-booking cooldown consistent across replicas.
+CREATE TABLE IF NOT EXISTS confirmation_resends (
+  booking_id UUID PRIMARY KEY REFERENCES bookings(id) ON DELETE CASCADE,
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
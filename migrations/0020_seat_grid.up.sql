@@ -0,0 +1,8 @@
+-- Row and position of grid-style seats ("C12" is row C, seat 12), derived
+-- from seat_no so every insert path fills them. Other seat numbers leave
+-- both NULL. Best-available holds use them to find adjacent seats.
+ALTER TABLE seats
+  ADD COLUMN IF NOT EXISTS row_label TEXT
+    GENERATED ALWAYS AS (substring(seat_no FROM '^([A-Za-z]+)[0-9]{1,9}$')) STORED,
+  ADD COLUMN IF NOT EXISTS seat_col INT
+    GENERATED ALWAYS AS (CASE WHEN seat_no ~ '^[A-Za-z]+[0-9]{1,9}$' THEN substring(seat_no FROM '[0-9]+$')::int END) STORED;
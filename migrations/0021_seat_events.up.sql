@@ -0,0 +1,53 @@
+-- History of every seat status change, for disputes and debugging. Rows are
+-- written by trigger in the transaction that changes the seat, so the log
+-- can't drift from the seats table. Who did it and why come from
+-- transaction-local settings the app sets before touching seats
+-- (SetSeatAuditContext); changes made without them are still logged, with
+-- those columns NULL.
+CREATE TABLE IF NOT EXISTS seat_events (
+  id BIGSERIAL PRIMARY KEY,
+  seat_id UUID NOT NULL REFERENCES seats(id) ON DELETE CASCADE,
+  event_id UUID NOT NULL,
+  old_status TEXT NULL, -- NULL when the seat was created
+  new_status TEXT NOT NULL,
+  booking_id UUID NULL,
+  hold_token TEXT NULL,
+  actor_id UUID NULL,
+  reason TEXT NULL,
+  correlation_id TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_seat_events_seat ON seat_events(seat_id, id);
+
+CREATE OR REPLACE FUNCTION log_seat_event()
+RETURNS TRIGGER LANGUAGE plpgsql AS $$
+BEGIN
+  INSERT INTO seat_events (seat_id, event_id, old_status, new_status, booking_id, hold_token, actor_id, reason, correlation_id)
+  VALUES (
+    NEW.id,
+    NEW.event_id,
+    CASE WHEN TG_OP = 'UPDATE' THEN OLD.status END,
+    NEW.status,
+    -- A released seat has already dropped its booking and hold; keep the
+    -- ones it was released from.
+    CASE WHEN TG_OP = 'UPDATE' THEN COALESCE(NEW.booking_id, OLD.booking_id) ELSE NEW.booking_id END,
+    CASE WHEN TG_OP = 'UPDATE' THEN COALESCE(NEW.hold_token, OLD.hold_token) ELSE NEW.hold_token END,
+    NULLIF(current_setting('overbookr.actor_id', true), '')::uuid,
+    NULLIF(current_setting('overbookr.seat_reason', true), ''),
+    NULLIF(current_setting('overbookr.correlation_id', true), '')
+  );
+  RETURN NEW;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS trg_seats_log_insert ON seats;
+CREATE TRIGGER trg_seats_log_insert
+AFTER INSERT ON seats
+FOR EACH ROW EXECUTE FUNCTION log_seat_event();
+
+DROP TRIGGER IF EXISTS trg_seats_log_status ON seats;
+CREATE TRIGGER trg_seats_log_status
+AFTER UPDATE OF status ON seats
+FOR EACH ROW WHEN (OLD.status IS DISTINCT FROM NEW.status)
+EXECUTE FUNCTION log_seat_event();
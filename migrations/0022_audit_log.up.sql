@@ -0,0 +1,17 @@
+-- Who did what through the privileged routes (event changes, admin
+-- bookings, check-ins, ...). actor_id has no foreign key so entries outlive
+-- the accounts that made them.
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  actor_id UUID NULL,
+  actor_role TEXT NULL,
+  action TEXT NOT NULL,
+  target TEXT NULL,
+  method TEXT NOT NULL,
+  path TEXT NOT NULL,
+  status INTEGER NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_created ON audit_log(actor_id, created_at);
@@ -0,0 +1,5 @@
+-- The expiry worker only looks at active holds, which stay a small slice of
+-- the table, so index just those. Finished holds are pruned by the
+-- reconcile worker after HOLD_RETENTION.
+CREATE INDEX IF NOT EXISTS idx_seat_holds_active_expires_at
+  ON seat_holds (expires_at) WHERE status = 'active';
@@ -0,0 +1,5 @@
+-- The hold a booking was made from, for tracing a booking back through the
+-- hold and seat history. A token rather than a foreign key, so the link
+-- survives the hold being pruned. NULL for bookings made without a hold
+-- (direct, admin and waitlist bookings) and for older ones.
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS hold_token TEXT NULL;
@@ -0,0 +1,27 @@
+-- Two-phase booking for paid events. Bookings for an event that requires
+-- payment start out pending_payment: their seats are booked and count toward
+-- booked_count, but the booking only becomes active once payment is
+-- confirmed. Unpaid ones are abandoned, which gives the seats back.
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS requires_payment BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+  CHECK (status IN ('active','pending_payment','cancelled','expired','failed','abandoned'));
+
+-- A pending booking holds its seats as firmly as an active one.
+CREATE OR REPLACE FUNCTION sync_booking_seats()
+RETURNS TRIGGER LANGUAGE plpgsql AS $$
+BEGIN
+  IF TG_OP = 'UPDATE' THEN
+    UPDATE booking_seats SET active = false WHERE booking_id = OLD.id AND active;
+  END IF;
+  INSERT INTO booking_seats (booking_id, seat_id, active)
+  SELECT NEW.id, s, NEW.status IN ('active','pending_payment') FROM unnest(NEW.seat_ids) AS s
+  ON CONFLICT (booking_id, seat_id) DO UPDATE SET active = EXCLUDED.active;
+  RETURN NEW;
+END;
+$$;
+
+-- Supports the sweep for unpaid bookings.
+CREATE INDEX IF NOT EXISTS idx_bookings_pending_payment ON bookings (created_at) WHERE status = 'pending_payment';
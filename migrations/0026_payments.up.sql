@@ -0,0 +1,11 @@
+-- Card payments for events that require payment. price_cents is the price of
+-- one seat in the smallest currency unit; a booking is charged for each of
+-- its seats. payment_intent_id links a booking to the provider's payment so
+-- webhooks can find it.
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS price_cents INTEGER NOT NULL DEFAULT 0 CHECK (price_cents >= 0);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS payment_intent_id TEXT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_bookings_payment_intent ON bookings (payment_intent_id)
+  WHERE payment_intent_id IS NOT NULL;
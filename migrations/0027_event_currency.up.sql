@@ -0,0 +1,4 @@
+-- ISO 4217 code an event is priced in. NULL means the deployment's base
+-- currency (BASE_CURRENCY).
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS currency TEXT NULL CHECK (currency ~ '^[A-Z]{3}$');
@@ -0,0 +1,12 @@
+-- Percentage of capacity an event may be sold beyond, to make up for
+-- expected no-shows. 0 keeps bookings within capacity.
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS oversell_percent INTEGER NOT NULL DEFAULT 0
+    CHECK (oversell_percent BETWEEN 0 AND 100);
+
+-- booked_count may now reach the sellable capacity. The integer division
+-- must match sellableCapacity in the handlers.
+ALTER TABLE events
+  DROP CONSTRAINT IF EXISTS events_booked_count_within_capacity,
+  ADD CONSTRAINT events_booked_count_within_capacity
+    CHECK (booked_count <= capacity + capacity * oversell_percent / 100);
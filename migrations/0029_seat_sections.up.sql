@@ -0,0 +1,9 @@
+-- Sections (floor, balcony, ...) split an event's seats into areas with
+-- their own availability. Seats of events that don't use sections are all
+-- in 'general'. Seat numbers stay unique per event, across sections.
+ALTER TABLE seats
+  ADD COLUMN IF NOT EXISTS section TEXT NOT NULL DEFAULT 'general'
+    CHECK (section <> '' AND length(section) <= 50);
+
+CREATE INDEX IF NOT EXISTS seats_event_section_status_idx
+  ON seats (event_id, section, status);
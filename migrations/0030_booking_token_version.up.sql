@@ -0,0 +1,5 @@
+-- Ticket QR tokens carry the booking's token_version. Bumping it (after a
+-- resale or a leaked QR) invalidates every ticket issued before. Existing
+-- tickets were signed without a version, which reads as 0.
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
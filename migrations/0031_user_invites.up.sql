@@ -0,0 +1,15 @@
+-- Invited accounts are created before their owner picks a password. Until
+-- the invite is accepted users.password is an empty string, which never
+-- matches a bcrypt hash, so the account can't be logged into.
+CREATE TABLE IF NOT EXISTS user_invites (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  -- Only the sha256 of each token is stored; the raw token lives in the email.
+  token_hash TEXT UNIQUE NOT NULL,
+  invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_invites_user ON user_invites(user_id);
@@ -0,0 +1,5 @@
+-- paid_at is when the payment provider reported a booking's payment as
+-- succeeded. A payment reported for a booking without one (abandoned,
+-- cancelled before it was paid, or confirmed by hand) is refunded.
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS paid_at TIMESTAMPTZ;
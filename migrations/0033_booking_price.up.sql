@@ -0,0 +1,13 @@
+-- Bookings keep the seat price and currency they were made at, so a later
+-- change to the event's price alters neither what they show nor what they
+-- are charged. A NULL currency means the base currency, as for events.
+-- Existing bookings take their event's current price.
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS unit_price_cents INTEGER NOT NULL DEFAULT 0 CHECK (unit_price_cents >= 0),
+  ADD COLUMN IF NOT EXISTS currency TEXT NULL CHECK (currency ~ '^[A-Z]{3}$');
+
+UPDATE bookings b
+SET unit_price_cents = e.price_cents,
+    currency = e.currency
+FROM events e
+WHERE e.id = b.event_id;
@@ -0,0 +1,7 @@
+-- When a verification email was last requested for each user. Tokens are only
+-- created once the queued mail goes out, so POST /users/resend-verification
+-- claims this row instead, which holds the cooldown while mail is queued.
+CREATE TABLE IF NOT EXISTS email_verification_requests (
+  user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,95 @@
+// Package migrations embeds the SQL schema migrations and applies them with
+// golang-migrate. Files follow golang-migrate's NNNN_name.up.sql naming, and
+// the applied version is tracked in schema_migrations, so databases migrated
+// earlier with the migrate CLI pick up where they left off.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// newMigrator builds a migrator that runs over pool. Closing it leaves the
+// pool open.
+func newMigrator(pool *pgxpool.Pool) (*migrate.Migrate, error) {
+	src, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+	driver, err := pgxmigrate.WithInstance(stdlib.OpenDBFromPool(pool), &pgxmigrate.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("open migration driver: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "pgx5", driver)
+	if err != nil {
+		return nil, fmt.Errorf("create migrator: %w", err)
+	}
+	return m, nil
+}
+
+// Up applies every pending migration and returns the schema version before
+// and after. A database already at the latest version is not an error.
+func Up(pool *pgxpool.Pool) (from, to uint, err error) {
+	m, err := newMigrator(pool)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer m.Close()
+
+	from, _, err = version(m)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return from, 0, fmt.Errorf("apply migrations: %w", err)
+	}
+	to, _, err = version(m)
+	return from, to, err
+}
+
+// Version reports the applied schema version (0 when none) and whether a
+// failed migration left the schema dirty.
+func Version(pool *pgxpool.Pool) (uint, bool, error) {
+	m, err := newMigrator(pool)
+	if err != nil {
+		return 0, false, err
+	}
+	defer m.Close()
+	return version(m)
+}
+
+// Force records v as the applied version and clears the dirty flag without
+// running anything, for recovering after a migration failed halfway and was
+// fixed by hand.
+func Force(pool *pgxpool.Pool, v int) error {
+	m, err := newMigrator(pool)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	if err := m.Force(v); err != nil {
+		return fmt.Errorf("force version %d: %w", v, err)
+	}
+	return nil
+}
+
+func version(m *migrate.Migrate) (uint, bool, error) {
+	v, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("read schema version: %w", err)
+	}
+	return v, dirty, nil
+}
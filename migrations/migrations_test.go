@@ -0,0 +1,59 @@
+//go:build integration
+
+package migrations_test
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/abhinandanwadwa/overbookr/internal/testdb"
+	"github.com/abhinandanwadwa/overbookr/migrations"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+// latestVersion is the number of the newest NNNN_name.up.sql file.
+func latestVersion(t *testing.T) uint {
+	t.Helper()
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var latest uint
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".up.sql") {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.SplitN(e.Name(), "_", 2)[0], 10, 32)
+		if err != nil {
+			t.Fatalf("%s: %v", e.Name(), err)
+		}
+		latest = max(latest, uint(n))
+	}
+	return latest
+}
+
+func TestFreshDatabaseMigratesToLatest(t *testing.T) {
+	pool := testdb.New(t)
+	want := latestVersion(t)
+
+	v, dirty, err := migrations.Version(pool)
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if v != want || dirty {
+		t.Fatalf("version = %d (dirty %v), want %d", v, dirty, want)
+	}
+
+	from, to, err := migrations.Up(pool)
+	if err != nil {
+		t.Fatalf("Up on a migrated database: %v", err)
+	}
+	if from != want || to != want {
+		t.Errorf("Up moved the version from %d to %d, want it left at %d", from, to, want)
+	}
+}